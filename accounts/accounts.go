@@ -40,6 +40,7 @@ const (
 	MimetypeTypedData         = "data/typed"
 	MimetypeClique            = "application/x-clique-header"
 	MimetypePoseidon          = "application/x-poseidon-header"
+	MimetypePoseidonVote      = "application/x-poseidon-vote"
 	MimetypeTextPlain         = "text/plain"
 )
 
@@ -77,9 +78,17 @@ type Wallet interface {
 	// rather only contain the accounts explicitly pinned during account derivation.
 	Accounts() []Account
 
-	VrfProve(alpha []byte) (beta, pi []byte, err error)
+	// VrfProve requests the wallet to prove the vrf output of alpha with the given
+	// account. It looks up the account specified either solely via its address
+	// contained within, or optionally with the aid of any location metadata from
+	// the embedded URL field.
+	VrfProve(account Account, alpha []byte) (beta, pi []byte, err error)
 	VrfVerify(alpha, pi []byte) (beta []byte, err error)
 
+	// VrfProveWithPassphrase is identical to VrfProve, but proves with the given
+	// account, using the password to decrypt it
+	VrfProveWithPassphrase(account Account, passphrase string, alpha []byte) (beta, pi []byte, err error)
+
 	// Contains returns whether an account is part of this particular wallet or not.
 	Contains(account Account) bool
 
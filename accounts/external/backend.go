@@ -17,6 +17,7 @@
 package external
 
 import (
+	"bytes"
 	"fmt"
 	"math/big"
 	"sync"
@@ -26,6 +27,8 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/vrf"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -167,7 +170,7 @@ func (api *ExternalSigner) SignData(account accounts.Account, mimeType string, d
 		return nil, err
 	}
 	// If V is on 27/28-form, convert to 0/1 for Clique
-	if (mimeType == accounts.MimetypeClique || mimeType == accounts.MimetypePoseidon) && (res[64] == 27 || res[64] == 28) {
+	if (mimeType == accounts.MimetypeClique || mimeType == accounts.MimetypePoseidon || mimeType == accounts.MimetypePoseidonVote) && (res[64] == 27 || res[64] == 28) {
 		res[64] -= 27 // Transform V from 27/28 to 0/1 for Clique use
 	}
 	return res, nil
@@ -272,10 +275,45 @@ func (api *ExternalSigner) pingVersion() (string, error) {
 	return v, nil
 }
 
-func (api *ExternalSigner) VrfProve(alpha []byte) (beta, pi []byte, err error) {
-	return nil, nil, fmt.Errorf("VRF is not supported")
+// vrfProveResult represents the vrf proof returned by clef.
+type vrfProveResult struct {
+	Beta      hexutil.Bytes `json:"beta"`
+	Proof     hexutil.Bytes `json:"proof"`
+	PublicKey hexutil.Bytes `json:"publicKey"`
+}
+
+// VrfProve requests the external signer to prove the vrf output of alpha with
+// the given account. The proof is verified against the public key of the
+// account, so that a faulty signer can't make the validator seal invalid blocks.
+func (api *ExternalSigner) VrfProve(account accounts.Account, alpha []byte) (beta, pi []byte, err error) {
+	var res vrfProveResult
+	var signAddress = common.NewMixedcaseAddress(account.Address)
+	if err := api.client.Call(&res, "account_vrfProve",
+		&signAddress, // Need to use the pointer here, because of how MarshalJSON is defined
+		hexutil.Encode(alpha)); err != nil {
+		return nil, nil, err
+	}
+	pubkey, err := crypto.UnmarshalPubkey(res.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	if signer := crypto.PubkeyToAddress(*pubkey); signer != account.Address {
+		return nil, nil, fmt.Errorf("vrf public key mismatch: have %x, want %x", signer, account.Address)
+	}
+	beta, err = vrf.Verify(pubkey, alpha, res.Proof)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !bytes.Equal(beta, res.Beta) {
+		return nil, nil, fmt.Errorf("vrf output mismatch: have %x, want %x", res.Beta, beta)
+	}
+	return beta, res.Proof, nil
+}
+
+func (api *ExternalSigner) VrfProveWithPassphrase(account accounts.Account, passphrase string, alpha []byte) (beta, pi []byte, err error) {
+	return nil, nil, fmt.Errorf("password-operations not supported on external signers")
 }
 
 func (api *ExternalSigner) VrfVerify(alpha, pi []byte) (beta []byte, err error) {
 	return nil, fmt.Errorf("VRF is not supported")
-}
\ No newline at end of file
+}
@@ -36,6 +36,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/vrf"
 	"github.com/ethereum/go-ethereum/event"
 )
 
@@ -300,6 +301,17 @@ func (ks *KeyStore) SignHashWithPassphrase(a accounts.Account, passphrase string
 	return crypto.Sign(hash, key.PrivateKey)
 }
 
+// VrfProveWithPassphrase proves the vrf output of alpha if the private key
+// matching the given address can be decrypted with the given passphrase.
+func (ks *KeyStore) VrfProveWithPassphrase(a accounts.Account, passphrase string, alpha []byte) (beta, pi []byte, err error) {
+	_, key, err := ks.getDecryptedKey(a, passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer zeroKey(key.PrivateKey)
+	return vrf.Prove(key.PrivateKey, alpha)
+}
+
 // SignTxWithPassphrase signs the transaction if the private key matching the
 // given address can be decrypted with the given passphrase.
 func (ks *KeyStore) SignTxWithPassphrase(a accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
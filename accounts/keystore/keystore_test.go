@@ -17,6 +17,7 @@
 package keystore
 
 import (
+	"bytes"
 	"io/ioutil"
 	"math/rand"
 	"os"
@@ -116,6 +117,46 @@ func TestSignWithPassphrase(t *testing.T) {
 	}
 }
 
+func TestVrfProveWithPassphrase(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	pass := "passwd"
+	acc, err := ks.NewAccount(pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	beta, pi, err := ks.VrfProveWithPassphrase(acc, pass, testSigData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, unlocked := ks.unlocked[acc.Address]; unlocked {
+		t.Fatal("expected account to be locked")
+	}
+	if _, _, err = ks.VrfProveWithPassphrase(acc, "invalid passwd", testSigData); err == nil {
+		t.Fatal("expected VrfProveWithPassphrase to fail with invalid password")
+	}
+	// The proof must verify against the key of the account
+	if err := ks.Unlock(acc, pass); err != nil {
+		t.Fatal(err)
+	}
+	wallet := ks.Wallets()[0]
+	verified, err := wallet.VrfVerify(testSigData, pi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(verified, beta) {
+		t.Fatalf("vrf output mismatch: have %x, want %x", verified, beta)
+	}
+	// The unlocked wallet must prove with the requested account only
+	if proved, _, err := wallet.VrfProve(acc, testSigData); err != nil || !bytes.Equal(proved, beta) {
+		t.Fatalf("unlocked vrf output mismatch: have %x (%v), want %x", proved, err, beta)
+	}
+	if _, _, err := wallet.VrfProve(accounts.Account{Address: common.Address{0x01}}, testSigData); err != accounts.ErrUnknownAccount {
+		t.Fatalf("foreign account error mismatch: have %v, want %v", err, accounts.ErrUnknownAccount)
+	}
+}
+
 func TestTimedUnlock(t *testing.T) {
 	dir, ks := tmpKeyStore(t, true)
 	defer os.RemoveAll(dir)
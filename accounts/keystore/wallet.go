@@ -161,8 +161,14 @@ func (w *keystoreWallet) getPrivateKey(account accounts.Account) (*ecdsa.Private
 	return nil, ErrLocked
 }
 
-func (w *keystoreWallet) VrfProve(alpha []byte) (beta, pi []byte, err error) {
-	privateKey, err := w.getPrivateKey(w.account)
+// VrfProve implements accounts.Wallet, attempting to prove the vrf output of
+// alpha with the given account.
+func (w *keystoreWallet) VrfProve(account accounts.Account, alpha []byte) (beta, pi []byte, err error) {
+	// Make sure the requested account is contained within
+	if !w.Contains(account) {
+		return nil, nil, accounts.ErrUnknownAccount
+	}
+	privateKey, err := w.getPrivateKey(account)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -181,3 +187,15 @@ func (w *keystoreWallet) VrfVerify(alpha, pi []byte) (beta []byte, err error) {
 	}
 	return vrf.Verify(publicKeyECDSA, alpha, pi)
 }
+
+// VrfProveWithPassphrase implements accounts.Wallet, attempting to prove the
+// vrf output of alpha with the given account using passphrase as extra
+// authentication.
+func (w *keystoreWallet) VrfProveWithPassphrase(account accounts.Account, passphrase string, alpha []byte) (beta, pi []byte, err error) {
+	// Make sure the requested account is contained within
+	if !w.Contains(account) {
+		return nil, nil, accounts.ErrUnknownAccount
+	}
+	// Account seems valid, request the keystore to prove
+	return w.keystore.VrfProveWithPassphrase(account, passphrase, alpha)
+}
@@ -1084,10 +1084,14 @@ func makeRecoverableSignature(hash, sig, expectedPubkey []byte) ([]byte, error)
 	return nil, ErrPubkeyMismatch
 }
 
-func (w *Wallet) VrfProve(alpha []byte) (beta, pi []byte, err error) {
+func (w *Wallet) VrfProve(account accounts.Account, alpha []byte) (beta, pi []byte, err error) {
 	return nil, nil, fmt.Errorf("VRF is not supported")
 }
 
 func (w *Wallet) VrfVerify(alpha, pi []byte) (beta []byte, err error) {
 	return nil, fmt.Errorf("VRF is not supported")
-}
\ No newline at end of file
+}
+
+func (w *Wallet) VrfProveWithPassphrase(account accounts.Account, passphrase string, alpha []byte) (beta, pi []byte, err error) {
+	return nil, nil, fmt.Errorf("VRF is not supported")
+}
@@ -639,10 +639,14 @@ func (w *wallet) SignTxWithPassphrase(account accounts.Account, passphrase strin
 	return w.SignTx(account, tx, chainID)
 }
 
-func (w *wallet) VrfProve(alpha []byte) (beta, pi []byte, err error) {
+func (w *wallet) VrfProve(account accounts.Account, alpha []byte) (beta, pi []byte, err error) {
 	return nil, nil, fmt.Errorf("VRF is not supported")
 }
 
 func (w *wallet) VrfVerify(alpha, pi []byte) (beta []byte, err error) {
 	return nil, fmt.Errorf("VRF is not supported")
 }
+
+func (w *wallet) VrfProveWithPassphrase(account accounts.Account, passphrase string, alpha []byte) (beta, pi []byte, err error) {
+	return nil, nil, fmt.Errorf("VRF is not supported")
+}
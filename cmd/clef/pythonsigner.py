@@ -96,6 +96,13 @@ class StdIOHandler():
         """
         return {"approved": False, "password" : None}
 
+    @public
+    def ApproveVrfProve(self, req):
+        """ Example request
+
+        """
+        return {"approved": False}
+
     @public
     def ApproveExport(self, req):
         """ Example request
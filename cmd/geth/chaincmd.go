@@ -65,9 +65,8 @@ It expects the genesis file as argument.`,
 		ArgsUsage: "",
 		Flags: []cli.Flag{
 			utils.MainnetFlag,
-			utils.RopstenFlag,
-			utils.RinkebyFlag,
-			utils.GoerliFlag,
+			utils.PhoenixFlag,
+			utils.PhoenixTestnetFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
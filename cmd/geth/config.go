@@ -28,6 +28,7 @@ import (
 	"gopkg.in/urfave/cli.v1"
 
 	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/eth/catalyst"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
@@ -147,6 +148,16 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, gethConfig) {
 // makeFullNode loads geth configuration and creates the Ethereum backend.
 func makeFullNode(ctx *cli.Context) (*node.Node, ethapi.Backend) {
 	stack, cfg := makeConfigNode(ctx)
+	if ctx.GlobalBool(utils.PhoenixTestnetFlag.Name) {
+		// The test network genesis isn't bundled, refuse to start a fresh
+		// datadir on the main network one instead.
+		chaindb := utils.MakeChainDatabase(ctx, stack, false)
+		stored := rawdb.ReadCanonicalHash(chaindb, 0)
+		chaindb.Close()
+		if stored != params.PhoenixTestGenesisHash {
+			utils.Fatalf("Phoenix test network not initialised, run `geth init --phoenix.testnet` with its genesis file first")
+		}
+	}
 	if ctx.GlobalIsSet(utils.OverrideLondonFlag.Name) {
 		cfg.Eth.OverrideLondon = new(big.Int).SetUint64(ctx.GlobalUint64(utils.OverrideLondonFlag.Name))
 	}
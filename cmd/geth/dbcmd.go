@@ -72,9 +72,8 @@ Remove blockchain and state databases`,
 			utils.DataDirFlag,
 			utils.SyncModeFlag,
 			utils.MainnetFlag,
-			utils.RopstenFlag,
-			utils.RinkebyFlag,
-			utils.GoerliFlag,
+			utils.PhoenixFlag,
+			utils.PhoenixTestnetFlag,
 		},
 		Usage:       "Inspect the storage size for each type of data in the database",
 		Description: `This commands iterates the entire database. If the optional 'prefix' and 'start' arguments are provided, then the iteration is limited to the given subset of data.`,
@@ -87,9 +86,8 @@ Remove blockchain and state databases`,
 			utils.DataDirFlag,
 			utils.SyncModeFlag,
 			utils.MainnetFlag,
-			utils.RopstenFlag,
-			utils.RinkebyFlag,
-			utils.GoerliFlag,
+			utils.PhoenixFlag,
+			utils.PhoenixTestnetFlag,
 		},
 	}
 	dbCompactCmd = cli.Command{
@@ -100,9 +98,8 @@ Remove blockchain and state databases`,
 			utils.DataDirFlag,
 			utils.SyncModeFlag,
 			utils.MainnetFlag,
-			utils.RopstenFlag,
-			utils.RinkebyFlag,
-			utils.GoerliFlag,
+			utils.PhoenixFlag,
+			utils.PhoenixTestnetFlag,
 			utils.CacheFlag,
 			utils.CacheDatabaseFlag,
 		},
@@ -119,9 +116,8 @@ corruption if it is aborted during execution'!`,
 			utils.DataDirFlag,
 			utils.SyncModeFlag,
 			utils.MainnetFlag,
-			utils.RopstenFlag,
-			utils.RinkebyFlag,
-			utils.GoerliFlag,
+			utils.PhoenixFlag,
+			utils.PhoenixTestnetFlag,
 		},
 		Description: "This command looks up the specified database key from the database.",
 	}
@@ -134,9 +130,8 @@ corruption if it is aborted during execution'!`,
 			utils.DataDirFlag,
 			utils.SyncModeFlag,
 			utils.MainnetFlag,
-			utils.RopstenFlag,
-			utils.RinkebyFlag,
-			utils.GoerliFlag,
+			utils.PhoenixFlag,
+			utils.PhoenixTestnetFlag,
 		},
 		Description: `This command deletes the specified database key from the database. 
 WARNING: This is a low-level operation which may cause database corruption!`,
@@ -150,9 +145,8 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 			utils.DataDirFlag,
 			utils.SyncModeFlag,
 			utils.MainnetFlag,
-			utils.RopstenFlag,
-			utils.RinkebyFlag,
-			utils.GoerliFlag,
+			utils.PhoenixFlag,
+			utils.PhoenixTestnetFlag,
 		},
 		Description: `This command sets a given database key to the given value. 
 WARNING: This is a low-level operation which may cause database corruption!`,
@@ -166,9 +160,8 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 			utils.DataDirFlag,
 			utils.SyncModeFlag,
 			utils.MainnetFlag,
-			utils.RopstenFlag,
-			utils.RinkebyFlag,
-			utils.GoerliFlag,
+			utils.PhoenixFlag,
+			utils.PhoenixTestnetFlag,
 		},
 		Description: "This command looks up the specified database key from the database.",
 	}
@@ -181,9 +174,8 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 			utils.DataDirFlag,
 			utils.SyncModeFlag,
 			utils.MainnetFlag,
-			utils.RopstenFlag,
-			utils.RinkebyFlag,
-			utils.GoerliFlag,
+			utils.PhoenixFlag,
+			utils.PhoenixTestnetFlag,
 		},
 		Description: "This command displays information about the freezer index.",
 	}
@@ -60,9 +60,8 @@ var (
 				Flags: []cli.Flag{
 					utils.DataDirFlag,
 					utils.AncientFlag,
-					utils.RopstenFlag,
-					utils.RinkebyFlag,
-					utils.GoerliFlag,
+					utils.PhoenixFlag,
+					utils.PhoenixTestnetFlag,
 					utils.CacheTrieJournalFlag,
 					utils.BloomFilterSizeFlag,
 				},
@@ -90,9 +89,8 @@ the trie clean cache with default directory will be deleted.
 				Flags: []cli.Flag{
 					utils.DataDirFlag,
 					utils.AncientFlag,
-					utils.RopstenFlag,
-					utils.RinkebyFlag,
-					utils.GoerliFlag,
+					utils.PhoenixFlag,
+					utils.PhoenixTestnetFlag,
 				},
 				Description: `
 geth snapshot verify-state <state-root>
@@ -110,9 +108,8 @@ In other words, this command does the snapshot to trie conversion.
 				Flags: []cli.Flag{
 					utils.DataDirFlag,
 					utils.AncientFlag,
-					utils.RopstenFlag,
-					utils.RinkebyFlag,
-					utils.GoerliFlag,
+					utils.PhoenixFlag,
+					utils.PhoenixTestnetFlag,
 				},
 				Description: `
 geth snapshot traverse-state <state-root>
@@ -132,9 +129,8 @@ It's also usable without snapshot enabled.
 				Flags: []cli.Flag{
 					utils.DataDirFlag,
 					utils.AncientFlag,
-					utils.RopstenFlag,
-					utils.RinkebyFlag,
-					utils.GoerliFlag,
+					utils.PhoenixFlag,
+					utils.PhoenixTestnetFlag,
 				},
 				Description: `
 geth snapshot traverse-rawstate <state-root>
@@ -155,9 +151,8 @@ It's also usable without snapshot enabled.
 				Flags: []cli.Flag{
 					utils.DataDirFlag,
 					utils.AncientFlag,
-					utils.RopstenFlag,
-					utils.RinkebyFlag,
-					utils.GoerliFlag,
+					utils.PhoenixFlag,
+					utils.PhoenixTestnetFlag,
 					utils.ExcludeCodeFlag,
 					utils.ExcludeStorageFlag,
 					utils.StartKeyFlag,
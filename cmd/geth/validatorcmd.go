@@ -0,0 +1,568 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/poseidon"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	validatorRPCFlag = cli.StringFlag{
+		Name:  "rpc.url",
+		Usage: "RPC endpoint of the node to use instead of starting one in-process",
+	}
+	validatorHubFlag = cli.StringFlag{
+		Name:  "hub",
+		Usage: "Address of the ValidatorHub contract (default = the one of the chain config)",
+	}
+	validatorFromFlag = cli.StringFlag{
+		Name:  "from",
+		Usage: "Account to send the transaction from (address or keystore index)",
+	}
+	validatorNameFlag = cli.StringFlag{
+		Name:  "name",
+		Usage: "Name to register the validator with",
+	}
+	validatorDuesFlag = cli.StringFlag{
+		Name:  "dues",
+		Usage: "Registration dues to pay in wei (default = the dues the hub requires)",
+	}
+	validatorDryRunFlag = cli.BoolFlag{
+		Name:  "dryrun",
+		Usage: "Only simulate the transaction with eth_call, without submitting it",
+	}
+
+	validatorFlags   = []cli.Flag{validatorRPCFlag, validatorHubFlag}
+	validatorTxFlags = []cli.Flag{validatorFromFlag, validatorDryRunFlag}
+
+	validatorCommand = cli.Command{
+		Name:     "validator",
+		Usage:    "Manage Poseidon validators",
+		Category: "VALIDATOR COMMANDS",
+		Description: `
+Register and inspect the validators of the ValidatorHub contract of a Poseidon
+chain.
+
+The commands run against an in-process node started from the usual node flags,
+or against the node given with --rpc.url. The hub is the one the chain config
+of the node, or with --rpc.url the one of the selected network, schedules at the
+chain head, unless given with --hub. Transactions are signed with the accounts
+of the keystore, or with Clef if --signer is set, and are simulated with
+eth_call before being submitted.`,
+		Subcommands: []cli.Command{
+			{
+				Name:   "register",
+				Usage:  "Register an account as validator",
+				Action: utils.MigrateFlags(validatorRegister),
+				Flags:  append(append(append(nodeFlags, validatorFlags...), validatorTxFlags...), validatorNameFlag, validatorDuesFlag),
+				Description: `
+    geth validator register --from <address> --name <name>
+
+Registers the sending account as validator, paying the registration dues. The
+account joins the committee once its reward contract holds enough stake.`,
+			},
+			{
+				Name:      "status",
+				Usage:     "Print the registration and stake of a validator",
+				ArgsUsage: "<address>",
+				Action:    utils.MigrateFlags(validatorStatus),
+				Flags:     append(nodeFlags, validatorFlags...),
+				Description: `
+    geth validator status <address>
+
+Prints whether the account is a registered validator and proposer, along with
+its stake and the last block it sealed.`,
+			},
+			{
+				Name:      "rewards",
+				Usage:     "Print the reward contract of a validator",
+				ArgsUsage: "<address>",
+				Action:    utils.MigrateFlags(validatorRewards),
+				Flags:     append(nodeFlags, validatorFlags...),
+				Description: `
+    geth validator rewards <address>
+
+Prints the reward contract of the validator and the ether it holds.`,
+			},
+			{
+				Name:      "slash-state",
+				Usage:     "Print the slash state of a validator",
+				ArgsUsage: "<address>",
+				Action:    utils.MigrateFlags(validatorSlashState),
+				Flags:     append(nodeFlags, validatorFlags...),
+				Description: `
+    geth validator slash-state <address>
+
+Prints the last block the hub recorded for the validator, either sealed or
+slashed, the blocks passed since and whether the validator is slashable in the
+next block.`,
+			},
+		},
+	}
+)
+
+// validatorSession is a connection to a node along with the ValidatorHub ABI
+// to interact with it.
+type validatorSession struct {
+	stack     *node.Node
+	rpc       *rpc.Client
+	client    *ethclient.Client
+	config    *params.ChainConfig
+	hub       common.Address
+	abi       abi.ABI
+	passwords []string
+}
+
+// newValidatorSession connects to the node of --rpc.url, or starts a node
+// in-process if none is given.
+func newValidatorSession(ctx *cli.Context) (*validatorSession, error) {
+	hubABI, err := abi.JSON(strings.NewReader(poseidon.ValidatorHubABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the ValidatorHub ABI: %v", err)
+	}
+	s := &validatorSession{
+		abi:       hubABI,
+		passwords: utils.MakePasswordList(ctx),
+	}
+	var client *rpc.Client
+	if endpoint := ctx.String(validatorRPCFlag.Name); endpoint != "" {
+		s.stack, _ = makeConfigNode(ctx)
+		if client, err = rpc.Dial(endpoint); err != nil {
+			s.stack.Close()
+			return nil, fmt.Errorf("unable to attach to remote geth: %v", err)
+		}
+		s.config = validatorChainConfig(ctx)
+	} else {
+		prepare(ctx)
+		stack, backend := makeFullNode(ctx)
+		utils.StartNode(ctx, stack)
+		if client, err = stack.Attach(); err != nil {
+			stack.Close()
+			return nil, fmt.Errorf("failed to attach to the inproc geth: %v", err)
+		}
+		s.stack, s.config = stack, backend.ChainConfig()
+	}
+	s.rpc, s.client = client, ethclient.NewClient(client)
+
+	if s.hub, err = s.validatorHub(ctx); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// validatorChainConfig returns the chain config of the network selected by the
+// flags, defaulting to the main network.
+func validatorChainConfig(ctx *cli.Context) *params.ChainConfig {
+	if ctx.GlobalBool(utils.DeveloperFlag.Name) {
+		return nil
+	}
+	genesis := utils.MakeGenesis(ctx)
+	if genesis == nil {
+		genesis = core.DefaultGenesisBlock()
+	}
+	return genesis.Config
+}
+
+// validatorHub returns the ValidatorHub of --hub, or the one the chain config
+// schedules at the chain head.
+func (s *validatorSession) validatorHub(ctx *cli.Context) (common.Address, error) {
+	if ctx.IsSet(validatorHubFlag.Name) {
+		hub := ctx.String(validatorHubFlag.Name)
+		if !common.IsHexAddress(hub) {
+			return common.Address{}, fmt.Errorf("invalid ValidatorHub address: %s", hub)
+		}
+		return common.HexToAddress(hub), nil
+	}
+	head, err := s.client.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to retrieve the chain head: %v", err)
+	}
+	return systemcontracts.ValidatorHub(s.config, head.Number), nil
+}
+
+// Close disconnects from the node and stops it if it runs in-process.
+func (s *validatorSession) Close() {
+	s.client.Close()
+	s.stack.Close()
+}
+
+// call runs a ValidatorHub view method against the head state and unpacks its
+// results.
+func (s *validatorSession) call(method string, args ...interface{}) ([]interface{}, error) {
+	result, err := s.callRaw(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	out, err := s.abi.Unpack(method, result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack %s: %v", method, err)
+	}
+	return out, nil
+}
+
+// callRaw runs a ValidatorHub view method against the head state, returning its
+// packed results.
+func (s *validatorSession) callRaw(method string, args ...interface{}) ([]byte, error) {
+	data, err := s.abi.Pack(method, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack %s: %v", method, err)
+	}
+	result, err := s.client.CallContract(context.Background(), ethereum.CallMsg{To: &s.hub, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s: %v", method, err)
+	}
+	return result, nil
+}
+
+// validatorInfo retrieves the registration of the validator from the hub.
+func (s *validatorSession) validatorInfo(validator common.Address) (*poseidon.ValidatorInfo, error) {
+	result, err := s.callRaw("getValidatorInfo", validator)
+	if err != nil {
+		return nil, err
+	}
+	info := new(poseidon.ValidatorInfo)
+	if err := s.abi.UnpackIntoInterface(info, "getValidatorInfo", result); err != nil {
+		return nil, fmt.Errorf("failed to unpack getValidatorInfo: %v", err)
+	}
+	return info, nil
+}
+
+// isValidator reports whether the account is registered with the hub.
+func (s *validatorSession) isValidator(validator common.Address) (bool, error) {
+	out, err := s.call("isValidator", validator)
+	if err != nil {
+		return false, err
+	}
+	return out[0].(bool), nil
+}
+
+// transact dry-runs a ValidatorHub method with eth_call, then signs it with the
+// --from account and submits it unless only a dry-run was requested.
+func (s *validatorSession) transact(ctx *cli.Context, account accounts.Account, wallet accounts.Wallet, value *big.Int, method string, args ...interface{}) error {
+	data, err := s.abi.Pack(method, args...)
+	if err != nil {
+		return fmt.Errorf("failed to pack %s: %v", method, err)
+	}
+	msg := ethereum.CallMsg{From: account.Address, To: &s.hub, Value: value, Data: data}
+	if _, err := s.client.CallContract(context.Background(), msg, nil); err != nil {
+		return fmt.Errorf("dry-run of %s failed: %v", method, err)
+	}
+	fmt.Printf("Dry-run of %s succeeded\n", method)
+	if ctx.Bool(validatorDryRunFlag.Name) {
+		return nil
+	}
+	tx, err := s.newTransaction(msg)
+	if err != nil {
+		return err
+	}
+	chainID, err := s.client.ChainID(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to retrieve the chain id: %v", err)
+	}
+	// Keystore accounts need their password, Clef asks for confirmation itself
+	var signed *types.Transaction
+	if wallet.URL().Scheme == keystore.KeyStoreScheme {
+		prompt := fmt.Sprintf("Unlocking account %s", account.Address.Hex())
+		password := utils.GetPassPhraseWithList(prompt, false, 0, s.passwords)
+		signed, err = wallet.SignTxWithPassphrase(account, password, tx, chainID)
+	} else {
+		signed, err = wallet.SignTx(account, tx, chainID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sign transaction: %v", err)
+	}
+	if err := s.client.SendTransaction(context.Background(), signed); err != nil {
+		return fmt.Errorf("failed to submit transaction: %v", err)
+	}
+	fmt.Printf("Submitted transaction %s\n", signed.Hash().Hex())
+	return nil
+}
+
+// account resolves the --from account and the wallet holding it.
+func (s *validatorSession) account(ctx *cli.Context) (accounts.Account, accounts.Wallet, error) {
+	from := ctx.String(validatorFromFlag.Name)
+	if from == "" {
+		return accounts.Account{}, nil, fmt.Errorf("no account given with --%s", validatorFromFlag.Name)
+	}
+	account := accounts.Account{Address: common.HexToAddress(from)}
+	if !common.IsHexAddress(from) {
+		ks := s.stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+		var err error
+		if account, err = utils.MakeAddress(ks, from); err != nil {
+			return accounts.Account{}, nil, fmt.Errorf("invalid account %s: %v", from, err)
+		}
+	}
+	wallet, err := s.stack.AccountManager().Find(account)
+	if err != nil {
+		return accounts.Account{}, nil, fmt.Errorf("account %s unavailable: %v", account.Address.Hex(), err)
+	}
+	return account, wallet, nil
+}
+
+// newTransaction assembles the unsigned transaction of the message, with the
+// pending nonce of the sender and the gas and fees suggested by the node.
+func (s *validatorSession) newTransaction(msg ethereum.CallMsg) (*types.Transaction, error) {
+	background := context.Background()
+
+	nonce, err := s.client.PendingNonceAt(background, msg.From)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the account nonce: %v", err)
+	}
+	gas, err := s.estimateGas(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate gas: %v", err)
+	}
+	head, err := s.client.HeaderByNumber(background, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the chain head: %v", err)
+	}
+	if head.BaseFee == nil {
+		gasPrice, err := s.client.SuggestGasPrice(background)
+		if err != nil {
+			return nil, fmt.Errorf("failed to suggest gas price: %v", err)
+		}
+		return types.NewTx(&types.LegacyTx{Nonce: nonce, To: msg.To, Value: msg.Value, Gas: gas, GasPrice: gasPrice, Data: msg.Data}), nil
+	}
+	tip, err := s.client.SuggestGasTipCap(background)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest gas tip cap: %v", err)
+	}
+	feeCap := new(big.Int).Add(tip, new(big.Int).Mul(head.BaseFee, common.Big2))
+	return types.NewTx(&types.DynamicFeeTx{Nonce: nonce, To: msg.To, Value: msg.Value, Gas: gas, GasTipCap: tip, GasFeeCap: feeCap, Data: msg.Data}), nil
+}
+
+// estimateGas estimates the gas of the message against the head state, like the
+// dry-run. The pending block isn't available on nodes that don't seal blocks.
+func (s *validatorSession) estimateGas(msg ethereum.CallMsg) (uint64, error) {
+	arg := map[string]interface{}{
+		"from": msg.From,
+		"to":   msg.To,
+		"data": hexutil.Bytes(msg.Data),
+	}
+	if msg.Value != nil {
+		arg["value"] = (*hexutil.Big)(msg.Value)
+	}
+	var gas hexutil.Uint64
+	if err := s.rpc.CallContext(context.Background(), &gas, "eth_estimateGas", arg, "latest"); err != nil {
+		return 0, err
+	}
+	return uint64(gas), nil
+}
+
+// validatorAddress parses the validator address passed as the only argument.
+func validatorAddress(ctx *cli.Context) (common.Address, error) {
+	if ctx.NArg() != 1 || !common.IsHexAddress(ctx.Args().First()) {
+		return common.Address{}, errors.New("this command requires a validator address as argument")
+	}
+	return common.HexToAddress(ctx.Args().First()), nil
+}
+
+func validatorRegister(ctx *cli.Context) error {
+	name := ctx.String(validatorNameFlag.Name)
+	if name == "" {
+		return fmt.Errorf("no validator name given with --%s", validatorNameFlag.Name)
+	}
+	var dues *big.Int
+	if ctx.IsSet(validatorDuesFlag.Name) {
+		var ok bool
+		if dues, ok = new(big.Int).SetString(ctx.String(validatorDuesFlag.Name), 0); !ok {
+			return fmt.Errorf("invalid dues: %s", ctx.String(validatorDuesFlag.Name))
+		}
+	}
+	s, err := newValidatorSession(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	if dues == nil {
+		out, err := s.call("INIT_REQUIRED_REGISTER")
+		if err != nil {
+			return err
+		}
+		dues = out[0].(*big.Int)
+	}
+	account, wallet, err := s.account(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Registering %s as %q, paying %s\n", account.Address.Hex(), name, formatEther(dues))
+	return s.transact(ctx, account, wallet, dues, "register", account.Address, name)
+}
+
+func validatorStatus(ctx *cli.Context) error {
+	validator, err := validatorAddress(ctx)
+	if err != nil {
+		return err
+	}
+	s, err := newValidatorSession(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	fmt.Printf("Validator:         %s\n", validator.Hex())
+	registered, err := s.isValidator(validator)
+	if err != nil {
+		return err
+	}
+	if !registered {
+		fmt.Println("Registered:        no")
+		return nil
+	}
+	info, err := s.validatorInfo(validator)
+	if err != nil {
+		return err
+	}
+	proposer, err := s.call("isProposer", validator)
+	if err != nil {
+		return err
+	}
+	supply, err := s.call("getCommitteeSupply")
+	if err != nil {
+		return err
+	}
+	minimum, err := s.call("MINSUPPLY")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Registered:        yes\n")
+	fmt.Printf("Name:              %s\n", info.Name)
+	fmt.Printf("Proposer:          %s\n", yesNo(proposer[0].(bool)))
+	fmt.Printf("Stake:             %s (minimum %s)\n", formatEther(info.TotalSupply), formatEther(minimum[0].(*big.Int)))
+	fmt.Printf("Committee stake:   %s\n", formatEther(supply[0].(*big.Int)))
+	fmt.Printf("Reward contract:   %s\n", info.RewardAddr.Hex())
+	fmt.Printf("Last sealed block: #%v\n", info.LastBlockHeight)
+	return nil
+}
+
+func validatorRewards(ctx *cli.Context) error {
+	validator, err := validatorAddress(ctx)
+	if err != nil {
+		return err
+	}
+	s, err := newValidatorSession(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	fmt.Printf("Validator:         %s\n", validator.Hex())
+	registered, err := s.isValidator(validator)
+	if err != nil {
+		return err
+	}
+	if !registered {
+		fmt.Println("Registered:        no")
+		return nil
+	}
+	info, err := s.validatorInfo(validator)
+	if err != nil {
+		return err
+	}
+	balance, err := s.client.BalanceAt(context.Background(), info.RewardAddr, nil)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve the reward contract balance: %v", err)
+	}
+	fmt.Printf("Reward contract:   %s\n", info.RewardAddr.Hex())
+	fmt.Printf("Balance:           %s\n", formatEther(balance))
+	return nil
+}
+
+func validatorSlashState(ctx *cli.Context) error {
+	validator, err := validatorAddress(ctx)
+	if err != nil {
+		return err
+	}
+	s, err := newValidatorSession(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	fmt.Printf("Validator:         %s\n", validator.Hex())
+	registered, err := s.isValidator(validator)
+	if err != nil {
+		return err
+	}
+	if !registered {
+		fmt.Println("Registered:        no")
+		return nil
+	}
+	info, err := s.validatorInfo(validator)
+	if err != nil {
+		return err
+	}
+	out, err := s.call("getSlashHeight", validator)
+	if err != nil {
+		return err
+	}
+	head, err := s.client.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve the chain head: %v", err)
+	}
+	fmt.Printf("Last slash:        #%v\n", out[0].(*big.Int))
+	fmt.Printf("Last sealed block: #%v\n", info.LastBlockHeight)
+
+	// Validators are slashable once they missed the heart rate, see VerifySlash
+	next := new(big.Int).Add(head.Number, common.Big1)
+	if next.Cmp(info.LastBlockHeight) >= 0 {
+		idle := new(big.Int).Sub(next, info.LastBlockHeight)
+		fmt.Printf("Blocks since seal: %v\n", new(big.Int).Sub(idle, common.Big1))
+		if s.config != nil && s.config.Poseidon != nil {
+			heartRate := s.config.Poseidon.ParamsAt(next).HeartRate
+			fmt.Printf("Slashable:         %s (after %d blocks)\n", yesNo(idle.Uint64() >= heartRate), heartRate)
+		}
+	}
+	return nil
+}
+
+// formatEther renders a wei amount in ether, dropping the trailing zeros.
+func formatEther(wei *big.Int) string {
+	amount := new(big.Rat).SetFrac(wei, big.NewInt(params.Ether)).FloatString(18)
+	amount = strings.TrimRight(strings.TrimRight(amount, "0"), ".")
+	return amount + " ether"
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
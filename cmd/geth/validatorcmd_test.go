@@ -0,0 +1,178 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/poseidon"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// These tests are 'smoke tests' for the validator related subcommands, run
+// against an in-process node of a developer chain. The first account of the
+// test keystore is the developer validator, the second one is funded but not
+// registered and the third one holds nothing.
+
+var (
+	validatorDeveloper = common.HexToAddress("7ef5a6135f1fd6a02593eedc869c6d41d934aef8")
+	validatorFunded    = common.HexToAddress("f466859ead1932d743d622cb74fc058882e8648a")
+	validatorUnfunded  = common.HexToAddress("289d485d9771714cce91d3393d764e1311907acc")
+)
+
+// tmpValidatorDatadir creates a datadir holding the test keystore, initialized
+// with a developer chain sealed by the first account.
+func tmpValidatorDatadir(t *testing.T) string {
+	datadir := tmpDatadirWithKeystore(t)
+
+	genesis := poseidon.DeveloperGenesisBlock(15, validatorDeveloper)
+	genesis.Alloc[validatorFunded] = core.GenesisAccount{Balance: new(big.Int).Mul(big.NewInt(10000), big.NewInt(params.Ether))}
+	blob, err := json.Marshal(genesis)
+	if err != nil {
+		t.Fatalf("failed to encode genesis: %v", err)
+	}
+	path := filepath.Join(datadir, "genesis.json")
+	if err := ioutil.WriteFile(path, blob, 0600); err != nil {
+		t.Fatalf("failed to write genesis file: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(datadir, "password.txt"), []byte("foobar"), 0600); err != nil {
+		t.Fatalf("failed to write password file: %v", err)
+	}
+	runGeth(t, "--datadir", datadir, "init", path).WaitExit()
+	return datadir
+}
+
+// runValidator runs a validator subcommand on an in-process node of the datadir,
+// with the given flags followed by the arguments.
+func runValidator(t *testing.T, datadir string, command string, flags []string, args ...string) *testgeth {
+	cmd := []string{"validator", command,
+		"--datadir", datadir, "--networkid", "1337", "--password", filepath.Join(datadir, "password.txt"),
+		"--maxpeers", "0", "--port", "0", "--nodiscover", "--nat", "none",
+	}
+	cmd = append(append(cmd, flags...), args...)
+	return runGeth(t, cmd...)
+}
+
+func TestValidatorRegisterDryRun(t *testing.T) {
+	datadir := tmpValidatorDatadir(t)
+	geth := runValidator(t, datadir, "register", []string{"--from", validatorFunded.Hex(), "--name", "tester", "--dryrun"})
+	defer geth.ExpectExit()
+	geth.Expect(fmt.Sprintf(`
+Registering %s as "tester", paying 5000 ether
+Dry-run of register succeeded
+`, validatorFunded.Hex()))
+}
+
+func TestValidatorRegister(t *testing.T) {
+	datadir := tmpValidatorDatadir(t)
+	geth := runValidator(t, datadir, "register", []string{"--from", "1", "--name", "tester"})
+	defer geth.ExpectExit()
+	geth.ExpectRegexp(fmt.Sprintf(`
+Registering %s as "tester", paying 5000 ether
+Dry-run of register succeeded
+Submitted transaction 0x[0-9a-f]{64}
+`, validatorFunded.Hex()))
+}
+
+func TestValidatorRegisterFailures(t *testing.T) {
+	datadir := tmpValidatorDatadir(t)
+
+	// Registrations without a name are refused before starting the node
+	geth := runValidator(t, datadir, "register", []string{"--from", validatorFunded.Hex()})
+	geth.ExpectExit()
+	if want := "no validator name given with --name"; !strings.Contains(geth.StderrText(), want) {
+		t.Errorf("stderr text does not contain %q", want)
+	}
+	// Registrations the hub rejects fail the dry-run, without being submitted
+	geth = runValidator(t, datadir, "register", []string{"--from", validatorUnfunded.Hex(), "--name", "tester"})
+	geth.Expect(fmt.Sprintf(`
+Registering %s as "tester", paying 5000 ether
+`, validatorUnfunded.Hex()))
+	geth.ExpectExit()
+	if geth.ExitStatus() == 0 {
+		t.Errorf("failed registration exited successfully")
+	}
+	if want := "dry-run of register failed"; !strings.Contains(geth.StderrText(), want) {
+		t.Errorf("stderr text does not contain %q", want)
+	}
+}
+
+func TestValidatorStatus(t *testing.T) {
+	datadir := tmpValidatorDatadir(t)
+
+	geth := runValidator(t, datadir, "status", nil, validatorDeveloper.Hex())
+	geth.ExpectRegexp(fmt.Sprintf(`
+Validator:         %s
+Registered:        yes
+Name:              developer
+Proposer:          (yes|no)
+Stake:             [0-9.]+ ether \(minimum [0-9.]+ ether\)
+Committee stake:   [0-9.]+ ether
+Reward contract:   0x[0-9a-fA-F]{40}
+Last sealed block: #\d+
+`, validatorDeveloper.Hex()))
+	geth.ExpectExit()
+
+	geth = runValidator(t, datadir, "status", nil, validatorFunded.Hex())
+	geth.Expect(fmt.Sprintf(`
+Validator:         %s
+Registered:        no
+`, validatorFunded.Hex()))
+	geth.ExpectExit()
+}
+
+func TestValidatorRewards(t *testing.T) {
+	datadir := tmpValidatorDatadir(t)
+
+	geth := runValidator(t, datadir, "rewards", nil, validatorDeveloper.Hex())
+	defer geth.ExpectExit()
+	geth.ExpectRegexp(fmt.Sprintf(`
+Validator:         %s
+Reward contract:   0x[0-9a-fA-F]{40}
+Balance:           [0-9.]+ ether
+`, validatorDeveloper.Hex()))
+}
+
+func TestValidatorSlashState(t *testing.T) {
+	datadir := tmpValidatorDatadir(t)
+
+	geth := runValidator(t, datadir, "slash-state", nil, validatorDeveloper.Hex())
+	defer geth.ExpectExit()
+	geth.ExpectRegexp(fmt.Sprintf(`
+Validator:         %s
+Last slash:        #\d+
+Last sealed block: #\d+
+Blocks since seal: \d+
+Slashable:         (yes|no) \(after %d blocks\)
+`, validatorDeveloper.Hex(), params.DefaultPoseidonHeartRate))
+}
+
+func TestValidatorInvalidArgs(t *testing.T) {
+	geth := runGeth(t, "validator", "status", "not-an-address")
+	geth.ExpectExit()
+	if want := "this command requires a validator address as argument"; !strings.Contains(geth.StderrText(), want) {
+		t.Errorf("stderr text does not contain %q", want)
+	}
+}
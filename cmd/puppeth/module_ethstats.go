@@ -34,6 +34,144 @@ var ethstatsDockerfile = `
 FROM puppeth/ethstats:latest
 
 RUN echo 'module.exports = {trusted: [{{.Trusted}}], banned: [{{.Banned}}], reserved: ["yournode"]};' > lib/utils/config.js
+
+ADD poseidon.js lib/poseidon.js
+ADD validators.html dist/validators.html
+RUN echo "require('./lib/poseidon')(api, client);" >> app.js
+`
+
+// ethstatsPoseidonScript is the ethstats server extension relaying the Poseidon
+// validator sets and block sortitions reported by the nodes to the browsers.
+var ethstatsPoseidonScript = `
+var secrets = (process.env.WS_SECRET || '').split('|');
+
+module.exports = function (api, client) {
+	var validators = null;
+
+	api.on('connection', function (spark) {
+		var authorized = false;
+
+		spark.on('hello', function (data) {
+			authorized = !!data && secrets.indexOf(data.secret) > -1;
+		});
+		spark.on('validators', function (data) {
+			if (!authorized || !data || !data.validators) {
+				return;
+			}
+			if (validators && validators.number > data.validators.number) {
+				return;
+			}
+			validators = data.validators;
+			client.write({action: 'validators', data: validators});
+		});
+		spark.on('block', function (data) {
+			if (!authorized || !data || !data.block || !data.block.sortition) {
+				return;
+			}
+			client.write({action: 'sortition', data: {
+				id:        data.id,
+				number:    data.block.number,
+				hash:      data.block.hash,
+				sortition: data.block.sortition
+			}});
+		});
+	});
+	client.on('connection', function (spark) {
+		if (validators) {
+			spark.write({action: 'validators', data: validators});
+		}
+	});
+};
+`
+
+// ethstatsValidatorsPage is the monitoring page displaying the latest Poseidon
+// validator set and the sortition data of the freshly sealed blocks.
+var ethstatsValidatorsPage = `<!DOCTYPE html>
+<html lang="en">
+	<head>
+		<meta charset="utf-8">
+		<title>Validators</title>
+		<style>
+			body   { background: #111; color: #ccc; font-family: monospace; }
+			h2     { color: #fff; }
+			table  { border-collapse: collapse; margin-bottom: 2em; }
+			th, td { padding: 2px 12px; text-align: left; }
+			.active   { color: #7bcc3a; }
+			.slashed  { color: #f74b4b; }
+			.inactive { color: #888; }
+		</style>
+	</head>
+	<body>
+		<h2>Validators at block <span id="number">-</span></h2>
+		<table>
+			<thead><tr><th>Status</th><th>Name</th><th>Address</th><th>Stake</th><th>Last block</th><th>Slash height</th></tr></thead>
+			<tbody id="validators"></tbody>
+		</table>
+		<h2>Latest sortitions</h2>
+		<table>
+			<thead><tr><th>Block</th><th>Reported by</th><th>Validator</th><th>Retries</th><th>Beta</th><th>Committee supply</th></tr></thead>
+			<tbody id="sortitions"></tbody>
+		</table>
+		<script src="/primus/primus.js"></script>
+		<script>
+			function cell(row, text, style) {
+				var td = document.createElement('td');
+				td.textContent = text;
+				if (style) {
+					td.className = style;
+				}
+				row.appendChild(td);
+			}
+			function validators(set) {
+				document.getElementById('number').textContent = set.number;
+
+				var body = document.getElementById('validators');
+				body.innerHTML = '';
+				[['active', set.active], ['slashed', set.slashed], ['inactive', set.inactive]].forEach(function (group) {
+					(group[1] || []).forEach(function (validator) {
+						var row = document.createElement('tr');
+						cell(row, group[0], group[0]);
+						cell(row, validator.name);
+						cell(row, validator.address);
+						cell(row, validator.stake);
+						cell(row, validator.lastBlockHeight);
+						cell(row, validator.slashHeight);
+						body.appendChild(row);
+					});
+				});
+			}
+			function sortition(block) {
+				if (document.getElementById(block.hash)) {
+					return;
+				}
+				var row = document.createElement('tr');
+				row.id = block.hash;
+				cell(row, block.number);
+				cell(row, block.id);
+				cell(row, block.sortition.validatorName);
+				cell(row, block.sortition.retries);
+				cell(row, block.sortition.beta);
+				cell(row, block.sortition.committeeSupply);
+
+				var body = document.getElementById('sortitions');
+				body.insertBefore(row, body.firstChild);
+				while (body.children.length > 32) {
+					body.removeChild(body.lastChild);
+				}
+			}
+			new Primus().on('data', function (message) {
+				switch (message.action) {
+				case 'validators':
+					validators(message.data);
+					break;
+				case 'sortition':
+					sortition(message.data);
+					break;
+				}
+			});
+		</script>
+	</body>
+</html>
 `
 
 // ethstatsComposefile is the docker-compose.yml file required to deploy and
@@ -82,6 +220,8 @@ func deployEthstats(client *sshClient, network string, port int, secret string,
 		"Banned":  strings.Join(bannedLabels, ", "),
 	})
 	files[filepath.Join(workdir, "Dockerfile")] = dockerfile.Bytes()
+	files[filepath.Join(workdir, "poseidon.js")] = []byte(ethstatsPoseidonScript)
+	files[filepath.Join(workdir, "validators.html")] = []byte(ethstatsValidatorsPage)
 
 	composefile := new(bytes.Buffer)
 	template.Must(template.New("").Parse(ethstatsComposefile)).Execute(composefile, map[string]interface{}{
@@ -119,9 +259,14 @@ type ethstatsInfos struct {
 // Report converts the typed struct into a plain string->string map, containing
 // most - but not all - fields for reporting to the user.
 func (info *ethstatsInfos) Report() map[string]string {
+	validators := info.host
+	if info.port != 80 && info.port != 443 {
+		validators += fmt.Sprintf(":%d", info.port)
+	}
 	return map[string]string{
 		"Website address":       info.host,
 		"Website listener port": strconv.Itoa(info.port),
+		"Validators page":       validators + "/validators.html",
 		"Login secret":          info.secret,
 		"Banned addresses":      strings.Join(info.banned, "\n"),
 	}
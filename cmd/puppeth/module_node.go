@@ -191,7 +191,7 @@ func (info *nodeInfos) Report() map[string]string {
 			report["Miner account"] = info.etherbase
 		}
 		if info.keyJSON != "" {
-			// Clique proof-of-authority signer
+			// Clique proof-of-authority signer or Poseidon proof-of-stake validator
 			var key struct {
 				Address string `json:"address"`
 			}
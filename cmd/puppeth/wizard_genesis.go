@@ -30,6 +30,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/poseidon"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
@@ -59,7 +60,12 @@ func (w *wizard) makeGenesis() {
 	fmt.Println("Which consensus engine to use? (default = clique)")
 	fmt.Println(" 1. Ethash - proof-of-work")
 	fmt.Println(" 2. Clique - proof-of-authority")
+	fmt.Println(" 3. Poseidon - proof-of-stake")
 
+	var (
+		validators []poseidon.GenesisValidator
+		minter     common.Address
+	)
 	choice := w.read()
 	switch {
 	case choice == "1":
@@ -105,6 +111,73 @@ func (w *wizard) makeGenesis() {
 			copy(genesis.ExtraData[32+i*common.AddressLength:], signer[:])
 		}
 
+	case choice == "3":
+		// In the case of poseidon, configure the consensus parameters and forks
+		genesis.Difficulty = big.NewInt(1)
+		genesis.ExtraData = make([]byte, 32)
+		genesis.Config.BerlinBlock = big.NewInt(0)
+		genesis.Config.LondonBlock = big.NewInt(0)
+		genesis.Config.Poseidon = &params.PoseidonConfig{
+			Period:          15,
+			ForkChoiceBlock: big.NewInt(0), // The Evidence fork waits for the evidence accepting hub
+
+			FeeDistributionBlock: big.NewInt(0),
+			SystemTxBlock:        big.NewInt(0),
+			SlotBlock:            big.NewInt(0),
+			SortitionBlock:       big.NewInt(0),
+		}
+		fmt.Println()
+		fmt.Println("How many seconds should blocks take? (default = 15)")
+		genesis.Config.Poseidon.Period = uint64(w.readDefaultInt(15))
+
+		// We also need the initial validators along with their stake
+		fmt.Println()
+		fmt.Println("Which accounts are allowed to validate? (mandatory at least one)")
+
+		var validatorAddrs []common.Address
+		for {
+			if address := w.readAddress(); address != nil {
+				validatorAddrs = append(validatorAddrs, *address)
+				continue
+			}
+			if len(validatorAddrs) > 0 {
+				break
+			}
+		}
+		for i, address := range validatorAddrs {
+			validator := poseidon.GenesisValidator{Address: address}
+
+			fmt.Println()
+			fmt.Printf("What should validator %s be called? (default = validator%d)\n", address.Hex(), i+1)
+			validator.Name = w.readDefaultString(fmt.Sprintf("validator%d", i+1))
+
+			fmt.Println()
+			fmt.Printf("Which account should receive the rewards of %s? (default = %s)\n", validator.Name, address.Hex())
+			validator.Owner = w.readDefaultAddress(address)
+
+			fmt.Println()
+			fmt.Printf("How many ethers should %s stake? (default = 20000)\n", validator.Name)
+			validator.Stake = new(big.Int).Mul(big.NewInt(int64(w.readDefaultInt(20000))), big.NewInt(params.Ether))
+
+			validators = append(validators, validator)
+		}
+		fmt.Println()
+		fmt.Printf("Which account should seal blocks until the validators take over? (default = %s)\n", validatorAddrs[0].Hex())
+		minter = w.readDefaultAddress(validatorAddrs[0])
+
+		// Schedule the Phoenix hard forks
+		fmt.Println()
+		fmt.Println("Which block should BigBen come into effect? (default = 0)")
+		genesis.Config.BigBenBlock = w.readDefaultBigInt(big.NewInt(0))
+
+		fmt.Println()
+		fmt.Println("Which block should Thames come into effect? (default = 0)")
+		genesis.Config.ThamesBlock = w.readDefaultBigInt(big.NewInt(0))
+
+		fmt.Println()
+		fmt.Println("Which block should Trident come into effect? (default = 0)")
+		genesis.Config.TridentBlock = w.readDefaultBigInt(big.NewInt(0))
+
 	default:
 		log.Crit("Invalid consensus engine choice", "choice", choice)
 	}
@@ -134,6 +207,14 @@ func (w *wizard) makeGenesis() {
 	fmt.Println("Specify your chain/network ID if you want an explicit one (default = random)")
 	genesis.Config.ChainID = new(big.Int).SetUint64(uint64(w.readDefaultInt(rand.Intn(65536))))
 
+	// Deploy the system contracts and register the validators on top of the funds
+	if genesis.Config.Poseidon != nil {
+		if err := poseidon.SetupGenesis(genesis, minter, validators); err != nil {
+			log.Error("Failed to register genesis validators", "err", err)
+			return
+		}
+	}
+
 	// All done, store the genesis and flush to disk
 	log.Info("Configured new genesis block")
 
@@ -243,6 +324,20 @@ func (w *wizard) manageGenesis() {
 		fmt.Printf("Which block should London come into effect? (default = %v)\n", w.conf.Genesis.Config.LondonBlock)
 		w.conf.Genesis.Config.LondonBlock = w.readDefaultBigInt(w.conf.Genesis.Config.LondonBlock)
 
+		if w.conf.Genesis.Config.Poseidon != nil {
+			fmt.Println()
+			fmt.Printf("Which block should BigBen come into effect? (default = %v)\n", w.conf.Genesis.Config.BigBenBlock)
+			w.conf.Genesis.Config.BigBenBlock = w.readDefaultBigInt(w.conf.Genesis.Config.BigBenBlock)
+
+			fmt.Println()
+			fmt.Printf("Which block should Thames come into effect? (default = %v)\n", w.conf.Genesis.Config.ThamesBlock)
+			w.conf.Genesis.Config.ThamesBlock = w.readDefaultBigInt(w.conf.Genesis.Config.ThamesBlock)
+
+			fmt.Println()
+			fmt.Printf("Which block should Trident come into effect? (default = %v)\n", w.conf.Genesis.Config.TridentBlock)
+			w.conf.Genesis.Config.TridentBlock = w.readDefaultBigInt(w.conf.Genesis.Config.TridentBlock)
+		}
+
 		out, _ := json.MarshalIndent(w.conf.Genesis.Config, "", "  ")
 		fmt.Printf("Chain configuration updated:\n\n%s\n", out)
 
@@ -118,7 +118,12 @@ func (w *wizard) deployNode(boot bool) {
 				fmt.Printf("What address should the miner use? (default = %s)\n", infos.etherbase)
 				infos.etherbase = w.readDefaultAddress(common.HexToAddress(infos.etherbase)).Hex()
 			}
-		} else if w.conf.Genesis.Config.Clique != nil {
+		} else if w.conf.Genesis.Config.Clique != nil || w.conf.Genesis.Config.Poseidon != nil {
+			// Poseidon validators seal with the same keystore, which also proves the vrf
+			role := "signer"
+			if w.conf.Genesis.Config.Poseidon != nil {
+				role = "validator"
+			}
 			// If a previous signer was already set, offer to reuse it
 			if infos.keyJSON != "" {
 				if key, err := keystore.DecryptKey([]byte(infos.keyJSON), infos.keyPass); err != nil {
@@ -131,10 +136,10 @@ func (w *wizard) deployNode(boot bool) {
 					}
 				}
 			}
-			// Clique based signers need a keyfile and unlock password, ask if unavailable
+			// Clique signers and Poseidon validators need a keyfile and unlock password, ask if unavailable
 			if infos.keyJSON == "" {
 				fmt.Println()
-				fmt.Println("Please paste the signer's key JSON:")
+				fmt.Printf("Please paste the %s's key JSON:\n", role)
 				infos.keyJSON = w.readJSON()
 
 				fmt.Println()
@@ -82,12 +82,12 @@ type Engine interface {
 	Prepare(chain ChainHeaderReader, header *types.Header) error
 
 	// Finalize runs any post-transaction state modifications (e.g. block rewards)
-	// but does not assemble the block.
+	// but does not assemble the block. An error rejects the block.
 	//
 	// Note: The block header and state database might be updated to reflect any
 	// consensus rules that happen at finalization (e.g. block rewards).
 	Finalize(chain ChainHeaderReader, header *types.Header, state *state.StateDB, txs []*types.Transaction,
-		uncles []*types.Header)
+		uncles []*types.Header, receipts []*types.Receipt) error
 
 	// FinalizeAndAssemble runs any post-transaction state modifications (e.g. block
 	// rewards) and assembles the final block.
@@ -131,5 +131,46 @@ type PoSA interface {
 
 	Heartbeat(number *big.Int) error
 
-	GetSystemTransaction(signer types.Signer, state *state.StateDB, baseFee *big.Int, totalFee *big.Int) (*types.TransactionsByPriceAndNonce,error)
+	// SubmitEvidence reports the double-sign evidence detected by the engine to
+	// the chain, addressed to the system contracts of the given block.
+	SubmitEvidence(number *big.Int) error
+
+	GetSystemTransaction(signer types.Signer, state *state.StateDB, header *types.Header, totalFee *big.Int) (*types.TransactionsByPriceAndNonce,error)
+
+	// VerifySystemTransactions checks the system transactions of a block. The
+	// receipts may be nil if the block wasn't executed yet, skipping the checks
+	// depending on the execution results.
+	VerifySystemTransactions(header *types.Header, txs []*types.Transaction, receipts []*types.Receipt) error
+
+	// VerifySlash checks whether a slash transaction may be included in the block
+	// of the given header after the given transactions.
+	VerifySlash(header *types.Header, txs []*types.Transaction, tx *types.Transaction) error
+
+	// VerifyEvidence checks whether a double-sign evidence transaction may be
+	// included in the block of the given header after the given transactions.
+	VerifyEvidence(header *types.Header, txs []*types.Transaction, tx *types.Transaction) error
+
+	// VerifyCheckpoint checks the committee carried by a checkpoint header against
+	// the state of its parent, the header verification deferring the check if the
+	// state wasn't available yet.
+	VerifyCheckpoint(chain ChainHeaderReader, header *types.Header, statedb *state.StateDB) error
+
+	// ReorgNeeded is the fork choice rule of the engine. It reports whether the
+	// chain should switch from the current head to the extern header, given the
+	// latest finalized header (nil if none) and the total difficulties of both
+	// heads for engines falling back to them.
+	ReorgNeeded(chain ChainHeaderReader, current, extern, finalized *types.Header, localTd, externTd *big.Int) bool
+
+	// SignVote attests the given canonical header with the local validator key.
+	// It returns nil if the local node is not allowed to vote on the header.
+	SignVote(chain ChainHeaderReader, header *types.Header) (*types.Vote, error)
+
+	// AddVote verifies and tallies a finality vote. It reports whether the vote
+	// made the attested block final, which happens at most once per block, so
+	// the caller must keep finalizing it until the chain accepts.
+	AddVote(chain ChainHeaderReader, vote *types.Vote) (bool, error)
+
+	// QueuedVotes returns the finality votes that arrived before the blocks they
+	// attest, once the blocks got imported.
+	QueuedVotes(chain ChainHeaderReader) []*types.Vote
 }
@@ -34,4 +34,8 @@ var (
 	// ErrInvalidNumber is returned if a block's number doesn't equal its parent's
 	// plus one.
 	ErrInvalidNumber = errors.New("invalid block number")
+
+	// ErrInvalidVote is returned when a finality vote can never be valid, as it
+	// isn't properly signed or its signer can't vote on the attested block.
+	ErrInvalidVote = errors.New("invalid vote")
 )
@@ -22,18 +22,84 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/params"
 )
 
+// BaseFeeParams are the EIP-1559 parameters the base fee of a block derives from.
+type BaseFeeParams struct {
+	MinBaseFee               *big.Int // Floor the base fee can't decrease below
+	ElasticityMultiplier     uint64   // Divisor of the gas limit yielding the gas target
+	BaseFeeChangeDenominator uint64   // Bound of the base fee change between blocks
+}
+
+// BaseFeeGovernance is implemented by the consensus engines sourcing the base fee
+// parameters from on-chain governance.
+type BaseFeeGovernance interface {
+	// BaseFeeParams retrieves the base fee parameters in effect on top of the given
+	// parent block, reading the governance state from statedb if set or looking the
+	// parent state up otherwise.
+	BaseFeeParams(chain consensus.ChainHeaderReader, parent *types.Header, statedb *state.StateDB) (*BaseFeeParams, error)
+}
+
+// ConfigBaseFeeParams returns the base fee parameters the chain config schedules
+// on top of the given parent block: the floor of the active fork, overridden by
+// the Poseidon parameters if set.
+func ConfigBaseFeeParams(config *params.ChainConfig, number *big.Int) *BaseFeeParams {
+	p := &BaseFeeParams{
+		MinBaseFee:               new(big.Int).SetUint64(params.MinBaseFee),
+		ElasticityMultiplier:     params.ElasticityMultiplier,
+		BaseFeeChangeDenominator: params.BaseFeeChangeDenominator,
+	}
+	if config.IsThames(number) {
+		p.MinBaseFee.SetUint64(params.ThamesBaseFee)
+	} else if config.IsBigBen(number) {
+		p.MinBaseFee.SetUint64(params.BigBenBaseFee)
+	}
+	if config.Poseidon != nil {
+		scheduled := config.Poseidon.ParamsAt(number)
+		if scheduled.MinBaseFee != 0 {
+			p.MinBaseFee.SetUint64(scheduled.MinBaseFee)
+		}
+		if scheduled.ElasticityMultiplier != 0 {
+			p.ElasticityMultiplier = scheduled.ElasticityMultiplier
+		}
+		if scheduled.BaseFeeChangeDenominator != 0 {
+			p.BaseFeeChangeDenominator = scheduled.BaseFeeChangeDenominator
+		}
+	}
+	return p
+}
+
+// GetBaseFeeParams returns the base fee parameters in effect on top of the given
+// parent block, sourced from the consensus engine if it governs them on-chain or
+// from the chain config otherwise. The statedb is the optional state of the parent
+// block, the chain is only needed along with it.
+func GetBaseFeeParams(config *params.ChainConfig, engine consensus.Engine, chain consensus.ChainHeaderReader, parent *types.Header, statedb *state.StateDB) (*BaseFeeParams, error) {
+	if governance, ok := engine.(BaseFeeGovernance); ok {
+		return governance.BaseFeeParams(chain, parent, statedb)
+	}
+	return ConfigBaseFeeParams(config, parent.Number), nil
+}
+
 // VerifyEip1559Header verifies some header attributes which were changed in EIP-1559,
 // - gas limit check
 // - basefee check
+//
+// The base fee parameters are the ones the chain config schedules.
 func VerifyEip1559Header(config *params.ChainConfig, parent, header *types.Header) error {
+	return VerifyEip1559HeaderWithParams(config, ConfigBaseFeeParams(config, parent.Number), parent, header)
+}
+
+// VerifyEip1559HeaderWithParams verifies the EIP-1559 header attributes using the
+// given base fee parameters in effect on top of the parent block.
+func VerifyEip1559HeaderWithParams(config *params.ChainConfig, feeParams *BaseFeeParams, parent, header *types.Header) error {
 	// Verify that the gas limit remains within allowed bounds
 	parentGasLimit := parent.GasLimit
 	if !config.IsLondon(parent.Number) {
-		parentGasLimit = parent.GasLimit * params.ElasticityMultiplier
+		parentGasLimit = parent.GasLimit * feeParams.ElasticityMultiplier
 	}
 	if err := VerifyGaslimit(parentGasLimit, header.GasLimit); err != nil {
 		return err
@@ -43,7 +109,7 @@ func VerifyEip1559Header(config *params.ChainConfig, parent, header *types.Heade
 		return fmt.Errorf("header is missing baseFee")
 	}
 	// Verify the baseFee is correct based on the parent header.
-	expectedBaseFee := CalcBaseFee(config, parent)
+	expectedBaseFee := CalcBaseFeeWithParams(config, feeParams, parent)
 	if header.BaseFee.Cmp(expectedBaseFee) != 0 {
 		return fmt.Errorf("invalid baseFee: have %s, want %s, parentBaseFee %s, parentGasUsed %d",
 			expectedBaseFee, header.BaseFee, parent.BaseFee, parent.GasUsed)
@@ -51,17 +117,24 @@ func VerifyEip1559Header(config *params.ChainConfig, parent, header *types.Heade
 	return nil
 }
 
-// CalcBaseFee calculates the basefee of the header.
+// CalcBaseFee calculates the basefee of the header using the base fee parameters
+// the chain config schedules.
 func CalcBaseFee(config *params.ChainConfig, parent *types.Header) *big.Int {
+	return CalcBaseFeeWithParams(config, ConfigBaseFeeParams(config, parent.Number), parent)
+}
+
+// CalcBaseFeeWithParams calculates the basefee of the header using the given base
+// fee parameters in effect on top of the parent block.
+func CalcBaseFeeWithParams(config *params.ChainConfig, feeParams *BaseFeeParams, parent *types.Header) *big.Int {
 	// If the current block is the first EIP-1559 block, return the InitialBaseFee.
 	if !config.IsLondon(parent.Number) {
 		return new(big.Int).SetUint64(params.InitialBaseFee)
 	}
 
 	var (
-		parentGasTarget          = parent.GasLimit / params.ElasticityMultiplier
+		parentGasTarget          = parent.GasLimit / feeParams.ElasticityMultiplier
 		parentGasTargetBig       = new(big.Int).SetUint64(parentGasTarget)
-		baseFeeChangeDenominator = new(big.Int).SetUint64(params.BaseFeeChangeDenominator)
+		baseFeeChangeDenominator = new(big.Int).SetUint64(feeParams.BaseFeeChangeDenominator)
 	)
 	// If the parent gasUsed is the same as the target, the baseFee remains unchanged.
 	if parent.GasUsed == parentGasTarget {
@@ -85,16 +158,9 @@ func CalcBaseFee(config *params.ChainConfig, parent *types.Header) *big.Int {
 		y := x.Div(x, parentGasTargetBig)
 		baseFeeDelta := x.Div(y, baseFeeChangeDenominator)
 
-		minBaseFee := params.MinBaseFee
-		if config.IsThames(parent.Number) {
-			minBaseFee = params.ThamesBaseFee
-		} else if config.IsBigBen(parent.Number) {
-			minBaseFee = params.BigBenBaseFee
-		}
-
 		return math.BigMax(
 			x.Sub(parent.BaseFee, baseFeeDelta),
-			new(big.Int).SetUint64(uint64(minBaseFee)),
+			new(big.Int).Set(feeParams.MinBaseFee),
 		)
 	}
 }
@@ -130,3 +130,49 @@ func TestCalcBaseFee(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the base fee follows the parameters the Poseidon config schedules
+// instead of the fork floors.
+func TestCalcBaseFeeSchedule(t *testing.T) {
+	var (
+		minBaseFee  = uint64(1)
+		elasticity  = uint64(4)
+		denominator = uint64(4)
+	)
+	config := config()
+	config.Poseidon = &params.PoseidonConfig{
+		MinBaseFee: 1000,
+		ForkSchedule: []*params.PoseidonFork{{
+			Block: big.NewInt(40),
+			Overrides: params.PoseidonOverrides{
+				MinBaseFee:               &minBaseFee,
+				ElasticityMultiplier:     &elasticity,
+				BaseFeeChangeDenominator: &denominator,
+			},
+		}},
+	}
+	tests := []struct {
+		parentNumber    int64
+		parentBaseFee   int64
+		parentGasUsed   uint64
+		expectedBaseFee int64
+	}{
+		{32, 1000000000, 10000000, 1000000000}, // usage == target
+		{32, 1000000000, 0, 875000000},         // usage below target
+		{32, 1000, 0, 1000},                    // floored base fee
+		{40, 1000000000, 5000000, 1000000000},  // usage == scheduled target
+		{40, 1000000000, 0, 750000000},         // scheduled change bound
+		{40, 1, 0, 1},                          // scheduled floor
+	}
+	for i, test := range tests {
+		parent := &types.Header{
+			Number:   big.NewInt(test.parentNumber),
+			GasLimit: 20000000,
+			GasUsed:  test.parentGasUsed,
+			BaseFee:  big.NewInt(test.parentBaseFee),
+		}
+		if have, want := CalcBaseFee(config, parent), big.NewInt(test.expectedBaseFee); have.Cmp(want) != 0 {
+			t.Errorf("test %d: have %d  want %d, ", i, have, want)
+		}
+	}
+}
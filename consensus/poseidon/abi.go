@@ -1,6 +1,7 @@
 package poseidon
 
-const validatorSetABI = `
+// ValidatorHubABI is the JSON ABI of the ValidatorHub system contract.
+const ValidatorHubABI = `
 [
 	{
 		"anonymous": false,
@@ -445,6 +446,29 @@ const validatorSetABI = `
 		"stateMutability": "nonpayable",
 		"type": "function"
 	},
+	{
+		"inputs": [
+			{
+				"internalType": "address",
+				"name": "_validator",
+				"type": "address"
+			},
+			{
+				"internalType": "bytes",
+				"name": "_headerA",
+				"type": "bytes"
+			},
+			{
+				"internalType": "bytes",
+				"name": "_headerB",
+				"type": "bytes"
+			}
+		],
+		"name": "submitDoubleSignEvidence",
+		"outputs": [],
+		"stateMutability": "nonpayable",
+		"type": "function"
+	},
 	{
 		"inputs": [
 			{
@@ -593,3 +617,33 @@ const validatorSetABI = `
 	}
 ]
 `
+
+// FeeGovernanceABI is the JSON ABI of the contract governing the EIP-1559 base
+// fee parameters, zero values keeping the ones scheduled by the chain config.
+const FeeGovernanceABI = `
+[
+	{
+		"inputs": [],
+		"name": "getBaseFeeParams",
+		"outputs": [
+			{
+				"internalType": "uint256",
+				"name": "minBaseFee",
+				"type": "uint256"
+			},
+			{
+				"internalType": "uint256",
+				"name": "elasticityMultiplier",
+				"type": "uint256"
+			},
+			{
+				"internalType": "uint256",
+				"name": "baseFeeChangeDenominator",
+				"type": "uint256"
+			}
+		],
+		"stateMutability": "view",
+		"type": "function"
+	}
+]
+`
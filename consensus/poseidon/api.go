@@ -1,12 +1,20 @@
 package poseidon
 
 import (
+	"fmt"
+	"math/big"
+
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rpc"
-	"math/big"
 )
 
+// maxProposerStatsRange is the maximum number of blocks poseidon_getProposerStats
+// is allowed to walk in a single request.
+const maxProposerStatsRange = 10000
+
 // API is a user facing RPC API to allow controlling the signer and voting
 // mechanisms of the proof-of-authority scheme.
 type API struct {
@@ -14,10 +22,346 @@ type API struct {
 	poseidon *Poseidon
 }
 
-// GetSigners retrieves the list of authorized signers at the specified block.
+// CommitteeMember is the sortition relevant data of a single committee member.
+type CommitteeMember struct {
+	Address         common.Address `json:"address"`
+	Name            string         `json:"name"`
+	RewardAddr      common.Address `json:"rewardAddr"`
+	Stake           *hexutil.Big   `json:"stake"`
+	LastBlockHeight *hexutil.Big   `json:"lastBlockHeight"`
+}
+
+// Committee is the set of validators allowed to propose a given block.
+type Committee struct {
+	Number  hexutil.Uint64     `json:"number"`
+	Hash    common.Hash        `json:"hash"`
+	Supply  *hexutil.Big       `json:"supply"`
+	Members []*CommitteeMember `json:"members"`
+}
+
+// ProposerStats is the number of blocks each signer proposed in a block range.
+type ProposerStats struct {
+	From      hexutil.Uint64                    `json:"from"`
+	To        hexutil.Uint64                    `json:"to"`
+	NumBlocks hexutil.Uint64                    `json:"numBlocks"`
+	Proposed  map[common.Address]hexutil.Uint64 `json:"proposed"`
+	Retries   map[common.Address]hexutil.Uint64 `json:"retries"`
+}
+
+// BlockProof is the decoded sortition proof of a sealed block.
+type BlockProof struct {
+	Number          hexutil.Uint64 `json:"number"`
+	Hash            common.Hash    `json:"hash"`
+	Signer          common.Address `json:"signer"`
+	Nonce           hexutil.Uint64 `json:"nonce"`
+	Alpha           hexutil.Bytes  `json:"alpha"`
+	Proof           hexutil.Bytes  `json:"proof"`
+	Beta            hexutil.Bytes  `json:"beta"`
+	Stake           *hexutil.Big   `json:"stake"`
+	CommitteeSupply *hexutil.Big   `json:"committeeSupply"`
+	Weight          hexutil.Uint64 `json:"weight"`
+}
+
+// SealingWork is the sealing work package of a block for a remote signer. The
+// block is sealed by the vrf proof of Alpha and the signature of SealHash, both
+// made with the key of Signer, which must win the sortition of the block's nonce.
+//
+// Alphas are the vrf inputs of every nonce of the retry window. If Signer loses
+// the sortition of the block's nonce, it submits the first nonce it wins over
+// poseidon_submitNonce and seals the block reassembled in the slot of that nonce.
+type SealingWork struct {
+	SealHash   common.Hash     `json:"sealHash"`
+	ParentHash common.Hash     `json:"parentHash"`
+	Number     hexutil.Uint64  `json:"number"`
+	Nonce      hexutil.Uint64  `json:"nonce"`
+	Time       hexutil.Uint64  `json:"timestamp"`
+	Signer     common.Address  `json:"signer"`
+	Alpha      hexutil.Bytes   `json:"alpha"`
+	VrfLength  hexutil.Uint64  `json:"vrfLength"`
+	Alphas     []hexutil.Bytes `json:"alphas"`
+}
+
+// ValidatorLiveness is the liveness of a single validator over the tracked blocks.
+type ValidatorLiveness struct {
+	Proposed     hexutil.Uint64  `json:"proposed"`     // Blocks proposed
+	Expected     float64         `json:"expected"`     // Blocks expected to be proposed by the stake share
+	Missed       hexutil.Uint64  `json:"missed"`       // Expected blocks not proposed
+	LastProposed *hexutil.Uint64 `json:"lastProposed"` // Last block proposed, nil if none was tracked
+	IdleBlocks   hexutil.Uint64  `json:"idleBlocks"`   // Blocks since the last proposal
+	IdleTime     hexutil.Uint64  `json:"idleTime"`     // Seconds since the last proposal
+	Overdue      bool            `json:"overdue"`      // Whether the validator is overdue on its heartbeat
+}
+
+// Liveness is the liveness of the validators over the recent canonical blocks.
+type Liveness struct {
+	Number     hexutil.Uint64                        `json:"number"`
+	Hash       common.Hash                           `json:"hash"`
+	From       hexutil.Uint64                        `json:"from"`
+	Validators map[common.Address]*ValidatorLiveness `json:"validators"`
+}
+
+// finalizedReader is implemented by the chains tracking the blocks finalized by
+// the committee votes.
+type finalizedReader interface {
+	CurrentFinalizedHeader() *types.Header
+}
+
+// header retrieves the header for the given block number, defaulting to the
+// current head if none is requested.
+func (api *API) header(number *rpc.BlockNumber) (*types.Header, error) {
+	var header *types.Header
+	switch {
+	case number == nil || *number == rpc.LatestBlockNumber || *number == rpc.PendingBlockNumber:
+		header = api.chain.CurrentHeader()
+	case *number == rpc.FinalizedBlockNumber:
+		if chain, ok := api.chain.(finalizedReader); ok {
+			header = chain.CurrentFinalizedHeader()
+		}
+	case *number < 0:
+		return nil, fmt.Errorf("unsupported block number %d", number.Int64())
+	default:
+		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return header, nil
+}
+
+// GetSnapshot retrieves the committee snapshot at a given block. It is only
+// available if the engine checkpoints the committee.
+func (api *API) GetSnapshot(number *rpc.BlockNumber) (*Snapshot, error) {
+	header, err := api.header(number)
+	if err != nil {
+		return nil, err
+	}
+	return api.poseidon.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
+}
+
+// GetSigners retrieves the list of validators that were eligible to propose the
+// specified block.
 func (api *API) GetSigners(number *rpc.BlockNumber) ([]common.Address, error) {
-	//TODO:
-	return nil, nil
+	header, err := api.header(number)
+	if err != nil {
+		return nil, err
+	}
+	return api.signers(header)
+}
+
+// signers retrieves the validators that passed the proposer check of the given
+// block.
+func (api *API) signers(header *types.Header) ([]common.Address, error) {
+	if header.Number.Sign() == 0 {
+		return []common.Address{}, nil
+	}
+	if api.poseidon.snapshotCommittee(header.Number) {
+		snap, err := api.poseidon.snapshot(api.chain, header.Number.Uint64()-1, header.ParentHash, nil)
+		if err != nil {
+			return nil, err
+		}
+		return snap.validators(), nil
+	}
+	proposers, err := api.poseidon.proposers(api.poseidon.hubCallerAt(header.Number, rpc.BlockNumberOrHashWithHash(header.ParentHash, false)))
+	if err != nil {
+		return nil, err
+	}
+	signers := make([]common.Address, 0, len(proposers))
+	for _, proposer := range proposers {
+		signers = append(signers, proposer.Address)
+	}
+	return signers, nil
+}
+
+// GetCommittee retrieves the committee members and their stake that were used to
+// verify the specified block.
+func (api *API) GetCommittee(number *rpc.BlockNumber) (*Committee, error) {
+	header, err := api.header(number)
+	if err != nil {
+		return nil, err
+	}
+	signers, err := api.signers(header)
+	if err != nil {
+		return nil, err
+	}
+	committee := &Committee{
+		Number:  hexutil.Uint64(header.Number.Uint64()),
+		Hash:    header.Hash(),
+		Supply:  (*hexutil.Big)(new(big.Int)),
+		Members: make([]*CommitteeMember, 0, len(signers)),
+	}
+	if header.Number.Sign() == 0 {
+		return committee, nil
+	}
+	for _, signer := range signers {
+		info, supply, err := api.poseidon.proposerInfo(api.chain, header, nil, signer)
+		if err != nil {
+			return nil, err
+		}
+		committee.Supply = (*hexutil.Big)(supply)
+
+		member := &CommitteeMember{
+			Address:         signer,
+			Name:            info.Name,
+			RewardAddr:      info.RewardAddr,
+			Stake:           (*hexutil.Big)(info.TotalSupply),
+			LastBlockHeight: (*hexutil.Big)(info.LastBlockHeight),
+		}
+		// Checkpointed committees carry no metadata, fill it in if the state is around
+		if member.Name == "" {
+			if info, err := api.poseidon.GetValidatorInfo(signer, header.Number); err == nil {
+				member.Name, member.RewardAddr = info.Name, info.RewardAddr
+			}
+		}
+		committee.Members = append(committee.Members, member)
+	}
+	return committee, nil
+}
+
+// GetProposerStats counts the blocks proposed by each signer in the inclusive
+// range [from, to], along with the total number of nonce retries they needed.
+func (api *API) GetProposerStats(from, to *rpc.BlockNumber) (*ProposerStats, error) {
+	last, err := api.header(to)
+	if err != nil {
+		return nil, err
+	}
+	end := last.Number.Uint64()
+	start := uint64(1)
+	if from != nil && *from < 0 {
+		first, err := api.header(from)
+		if err != nil {
+			return nil, err
+		}
+		start = first.Number.Uint64()
+	} else if from != nil && *from > 0 {
+		start = uint64(from.Int64())
+	}
+	if start > end {
+		return nil, fmt.Errorf("invalid block range: from %d > to %d", start, end)
+	}
+	if end-start+1 > maxProposerStatsRange {
+		return nil, fmt.Errorf("block range too large: %d > %d", end-start+1, maxProposerStatsRange)
+	}
+	stats := &ProposerStats{
+		From:      hexutil.Uint64(start),
+		To:        hexutil.Uint64(end),
+		NumBlocks: hexutil.Uint64(end - start + 1),
+		Proposed:  make(map[common.Address]hexutil.Uint64),
+		Retries:   make(map[common.Address]hexutil.Uint64),
+	}
+	for n := start; n <= end; n++ {
+		header := api.chain.GetHeaderByNumber(n)
+		if header == nil {
+			return nil, fmt.Errorf("missing block %d", n)
+		}
+		signer, err := api.poseidon.Author(header)
+		if err != nil {
+			return nil, err
+		}
+		stats.Proposed[signer]++
+		stats.Retries[signer] += hexutil.Uint64(header.Nonce.Uint64())
+	}
+	return stats, nil
+}
+
+// GetBlockProof decodes and verifies the vrf proof of the specified block and
+// returns the sortition data it was accepted with.
+func (api *API) GetBlockProof(number *rpc.BlockNumber) (*BlockProof, error) {
+	header, err := api.header(number)
+	if err != nil {
+		return nil, err
+	}
+	if header.Number.Sign() == 0 {
+		return nil, errUnknownBlock
+	}
+	pubkey, signer, err := ecrecover(header, api.poseidon.signatures, api.poseidon.chainConfig.ChainID, api.poseidon.vrfLength(header.Number))
+	if err != nil {
+		return nil, err
+	}
+	pi, err := vrfProof(header, api.poseidon.vrfLength(header.Number))
+	if err != nil {
+		return nil, err
+	}
+	beta, err := api.poseidon.verifyVrf(header, pubkey)
+	if err != nil {
+		return nil, err
+	}
+	info, supply, err := api.poseidon.proposerInfo(api.chain, header, nil, signer)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockProof{
+		Number:          hexutil.Uint64(header.Number.Uint64()),
+		Hash:            header.Hash(),
+		Signer:          signer,
+		Nonce:           hexutil.Uint64(header.Nonce.Uint64()),
+		Alpha:           api.poseidon.GetVrfAlpha(header.ParentHash, header.Nonce),
+		Proof:           pi,
+		Beta:            beta,
+		Stake:           (*hexutil.Big)(info.TotalSupply),
+		CommitteeSupply: (*hexutil.Big)(supply),
+		Weight:          hexutil.Uint64(api.poseidon.sortitionWeight(info.TotalSupply, supply, header.Number, beta)),
+	}, nil
+}
+
+// GetEvidence retrieves the double-sign evidences detected in the verified
+// headers, along with the transactions they were reported in, if any.
+func (api *API) GetEvidence() []*Evidence {
+	return api.poseidon.pendingEvidence()
+}
+
+// GetLiveness retrieves the liveness of the committee members over the recent
+// canonical blocks, up to the current head.
+func (api *API) GetLiveness() (*Liveness, error) {
+	return api.poseidon.getLiveness()
+}
+
+// GetSealingWork returns the sealing work package of the latest block assembled
+// for a remote signer.
+func (api *API) GetSealingWork() (*SealingWork, error) {
+	var (
+		workCh = make(chan *SealingWork, 1)
+		errc   = make(chan error, 1)
+	)
+	select {
+	case api.poseidon.sealer.fetchWorkCh <- &sealWork{errc: errc, res: workCh}:
+	case <-api.poseidon.quit:
+		return nil, errPoseidonStopped
+	}
+	select {
+	case work := <-workCh:
+		return work, nil
+	case err := <-errc:
+		return nil, err
+	}
+}
+
+// SubmitSeal can be used by a remote signer to submit the vrf proof and the
+// signature sealing the block of the given seal hash. The seal is verified the
+// same way as in an imported block before the block is published, and the
+// reason of the rejection is returned otherwise.
+func (api *API) SubmitSeal(sealHash common.Hash, proof hexutil.Bytes, signature hexutil.Bytes) error {
+	errc := make(chan error, 1)
+	select {
+	case api.poseidon.sealer.submitSealCh <- &sealResult{sealHash: sealHash, proof: proof, signature: signature, errc: errc}:
+	case <-api.poseidon.quit:
+		return errPoseidonStopped
+	}
+	return <-errc
+}
+
+// SubmitNonce can be used by a remote signer to select the nonce it seals the
+// block on top of the given parent with, proving that it wins the sortition of
+// the nonce with the vrf proof of its alpha and the public key of the signer.
+// The block is reassembled in the slot of the nonce and handed out as the next
+// sealing work.
+func (api *API) SubmitNonce(parentHash common.Hash, nonce hexutil.Uint64, pubkey hexutil.Bytes, proof hexutil.Bytes) error {
+	errc := make(chan error, 1)
+	select {
+	case api.poseidon.sealer.submitNonceCh <- &nonceResult{parentHash: parentHash, nonce: uint64(nonce), pubkey: pubkey, proof: proof, errc: errc}:
+	case <-api.poseidon.quit:
+		return errPoseidonStopped
+	}
+	return <-errc
 }
 
 func (api *API) IsValidator(validatorAddr common.Address, blockNumber *big.Int) (bool, error) {
@@ -28,8 +372,8 @@ func (api *API) GetValidatorInfo(validatorAddr common.Address, blockNumber *big.
 	return api.poseidon.GetValidatorInfo(validatorAddr, blockNumber)
 }
 
-func (api *API) GetCommitteeSupply( blockNumber *big.Int) (*big.Int, error) {
-	return api.poseidon.GetCommitteeSupply(blockNumber,common.Address{})
+func (api *API) GetCommitteeSupply(blockNumber *big.Int) (*big.Int, error) {
+	return api.poseidon.GetCommitteeSupply(blockNumber, common.Address{})
 }
 
 func (api *API) IsProposer(validatorAddr common.Address, blockNumber *big.Int) (bool, error) {
@@ -0,0 +1,225 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poseidon
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// evidenceGas is the gas allowance of a double-sign evidence submission. Verified
+// evidence is refunded in full, so it only needs to cover the hub checks.
+const evidenceGas = 1000000
+
+// sealKey identifies the slot a validator may seal at most one block for. Blocks
+// of the same height on different parents aren't conflicting, the validator may
+// seal the height again after a reorg of its parent.
+type sealKey struct {
+	signer common.Address
+	number uint64
+	parent common.Hash
+}
+
+// Evidence is the proof that a validator sealed two different blocks of the same
+// height on the same parent. Both headers carry the seal signature and the vrf
+// proof of the signer in their extra-data, so anyone can verify the pair.
+type Evidence struct {
+	Signer     common.Address `json:"signer"`
+	Number     hexutil.Uint64 `json:"number"`
+	HeaderA    *types.Header  `json:"headerA"`
+	HeaderB    *types.Header  `json:"headerB"`
+	SignatureA hexutil.Bytes  `json:"signatureA"`
+	SignatureB hexutil.Bytes  `json:"signatureB"`
+	Submitted  *common.Hash   `json:"submitted"` // Hash of the reporting transaction, nil until submitted
+}
+
+// newEvidence assembles the evidence of two conflicting headers, ordered by hash
+// so that every node reports the same pair.
+func newEvidence(signer common.Address, a, b *types.Header) *Evidence {
+	if bytes.Compare(a.Hash().Bytes(), b.Hash().Bytes()) > 0 {
+		a, b = b, a
+	}
+	return &Evidence{
+		Signer:     signer,
+		Number:     hexutil.Uint64(a.Number.Uint64()),
+		HeaderA:    types.CopyHeader(a),
+		HeaderB:    types.CopyHeader(b),
+		SignatureA: common.CopyBytes(a.Extra[len(a.Extra)-extraSeal:]),
+		SignatureB: common.CopyBytes(b.Extra[len(b.Extra)-extraSeal:]),
+	}
+}
+
+// recordSeal remembers a header with a verified seal and records evidence if
+// its signer already sealed a different block of the same height and parent.
+func (c *Poseidon) recordSeal(header *types.Header, signer common.Address) {
+	key := sealKey{signer: signer, number: header.Number.Uint64(), parent: header.ParentHash}
+
+	c.evidenceLock.Lock()
+	defer c.evidenceLock.Unlock()
+
+	seen, known := c.seals.Get(key)
+	if !known {
+		c.seals.Add(key, header)
+		return
+	}
+	// Headers only differing in their unsigned fields aren't evidence
+	prev := seen.(*types.Header)
+	if c.SealHash(prev) == c.SealHash(header) || c.evidence.Contains(key) {
+		return
+	}
+	c.evidence.Add(key, newEvidence(signer, prev, header))
+	log.Warn("Detected double-signing validator", "signer", signer, "number", key.number, "hashA", prev.Hash(), "hashB", header.Hash())
+}
+
+// pendingEvidence returns a copy of the double-sign evidences detected so far.
+func (c *Poseidon) pendingEvidence() []*Evidence {
+	c.evidenceLock.RLock()
+	defer c.evidenceLock.RUnlock()
+
+	evidences := make([]*Evidence, 0, c.evidence.Len())
+	for _, key := range c.evidence.Keys() {
+		if value, ok := c.evidence.Peek(key); ok {
+			cpy := *value.(*Evidence)
+			evidences = append(evidences, &cpy)
+		}
+	}
+	return evidences
+}
+
+// SubmitEvidence implements consensus.PoSA, reporting the double-sign evidences
+// that weren't submitted yet to the ValidatorHub of the given block as system
+// transactions of the local validator. Evidence against the local validator
+// itself is skipped, as is all evidence before the hub accepts it.
+func (c *Poseidon) SubmitEvidence(number *big.Int) error {
+	if c.txPoolAPI == nil || !c.config.IsEvidence(number) {
+		return nil
+	}
+	c.evidenceLock.Lock()
+	defer c.evidenceLock.Unlock()
+
+	for _, key := range c.evidence.Keys() {
+		value, ok := c.evidence.Peek(key)
+		if !ok {
+			continue
+		}
+		evidence := value.(*Evidence)
+		if evidence.Submitted != nil || evidence.Signer == c.val {
+			continue
+		}
+		headerA, err := rlp.EncodeToBytes(evidence.HeaderA)
+		if err != nil {
+			return err
+		}
+		headerB, err := rlp.EncodeToBytes(evidence.HeaderB)
+		if err != nil {
+			return err
+		}
+		data, err := c.validatorSetABI.Pack("submitDoubleSignEvidence", evidence.Signer, headerA, headerB)
+		if err != nil {
+			log.Error("Unable to pack tx for double-sign evidence", "error", err)
+			return err
+		}
+		var (
+			msgData   = (hexutil.Bytes)(data)
+			toAddress = c.hubAt(number)
+			gas       = (hexutil.Uint64)(uint64(evidenceGas))
+		)
+		hash, err := c.txPoolAPI.SendTransaction(context.Background(), ethapi.TransactionArgs{From: &c.val, To: &toAddress, Data: &msgData, Gas: &gas})
+		if err != nil {
+			return err
+		}
+		evidence.Submitted = &hash
+		log.Info("Submitted double-sign evidence", "signer", evidence.Signer, "number", evidence.Number, "tx", hash)
+	}
+	return nil
+}
+
+// VerifyEvidence implements consensus.PoSA, checking that a double-sign evidence
+// transaction may be included in the block of the given header after the given
+// transactions. Both reported headers must be sealed by the reported validator
+// at the same height below the block and on the same parent, over different
+// contents, and none of the preceding transactions may report the same validator.
+func (c *Poseidon) VerifyEvidence(header *types.Header, txs []*types.Transaction, tx *types.Transaction) error {
+	hub := c.hubAt(header.Number)
+	validator, headerA, headerB, err := c.decodeEvidence(hub, tx)
+	if err != nil {
+		return err
+	}
+	for _, prev := range txs {
+		if !systemcontracts.IsDoubleSignTransition(hub, prev.To(), prev.Data()) {
+			continue
+		}
+		if target, _, _, err := c.decodeEvidence(hub, prev); err == nil && target == validator {
+			return fmt.Errorf("%w: %x", errDuplicateEvidence, validator)
+		}
+	}
+	if headerA.Number == nil || headerB.Number == nil || headerA.Number.Cmp(headerB.Number) != 0 {
+		return fmt.Errorf("%w: height mismatch", errInvalidEvidence)
+	}
+	if headerA.Number.Sign() <= 0 || headerA.Number.Cmp(header.Number) >= 0 {
+		return fmt.Errorf("%w: height #%v out of range", errInvalidEvidence, headerA.Number)
+	}
+	if headerA.ParentHash != headerB.ParentHash {
+		return fmt.Errorf("%w: parent mismatch", errInvalidEvidence)
+	}
+	for _, reported := range []*types.Header{headerA, headerB} {
+		if len(reported.Extra) < extraVanity+extraSeal+c.vrfLength(reported.Number) {
+			return fmt.Errorf("%w: %v", errInvalidEvidence, errMissingSignature)
+		}
+		proof, err := c.verifySealProof(reported)
+		if err != nil {
+			return fmt.Errorf("%w: %v", errInvalidEvidence, err)
+		}
+		if proof.signer != validator {
+			return fmt.Errorf("%w: sealed by %x, not %x", errInvalidEvidence, proof.signer, validator)
+		}
+	}
+	if c.SealHash(headerA) == c.SealHash(headerB) {
+		return fmt.Errorf("%w: same sealed content", errInvalidEvidence)
+	}
+	return nil
+}
+
+// decodeEvidence decodes the reported validator and the two headers of a
+// double-sign evidence transaction of the given ValidatorHub.
+func (c *Poseidon) decodeEvidence(hub common.Address, tx *types.Transaction) (common.Address, *types.Header, *types.Header, error) {
+	if !systemcontracts.IsDoubleSignTransition(hub, tx.To(), tx.Data()) {
+		return common.Address{}, nil, nil, fmt.Errorf("not a double-sign evidence transaction: %x", tx.Hash())
+	}
+	args, err := c.validatorSetABI.Methods["submitDoubleSignEvidence"].Inputs.Unpack(tx.Data()[4:])
+	if err != nil {
+		return common.Address{}, nil, nil, fmt.Errorf("%w: %v", errInvalidEvidence, err)
+	}
+	headerA, headerB := new(types.Header), new(types.Header)
+	if err := rlp.DecodeBytes(args[1].([]byte), headerA); err != nil {
+		return common.Address{}, nil, nil, fmt.Errorf("%w: %v", errInvalidEvidence, err)
+	}
+	if err := rlp.DecodeBytes(args[2].([]byte), headerB); err != nil {
+		return common.Address{}, nil, nil, fmt.Errorf("%w: %v", errInvalidEvidence, err)
+	}
+	return args[0].(common.Address), headerA, headerB, nil
+}
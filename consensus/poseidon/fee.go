@@ -0,0 +1,141 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poseidon
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// errInvalidFeeParams is returned if the fee governance contract reports base fee
+// parameters the base fee can't be calculated with.
+var errInvalidFeeParams = errors.New("invalid governed base fee parameters")
+
+// BaseFeeParams implements misc.BaseFeeGovernance, returning the base fee
+// parameters the chain config schedules for the parent block, overridden by the
+// non-zero values the fee governance contract reports on top of its state.
+func (p *Poseidon) BaseFeeParams(chain consensus.ChainHeaderReader, parent *types.Header, statedb *state.StateDB) (*misc.BaseFeeParams, error) {
+	feeParams := misc.ConfigBaseFeeParams(p.chainConfig, parent.Number)
+
+	governance := p.config.ParamsAt(parent.Number).FeeGovernance
+	if governance == (common.Address{}) {
+		return feeParams, nil
+	}
+	method := "getBaseFeeParams"
+	data, err := p.feeABI.Pack(method)
+	if err != nil {
+		return nil, err
+	}
+	// The state of the parent never changes, so the results can be cached
+	key := hubCallKey{block: parent.Hash(), hub: governance, input: string(data)}
+	result, ok := p.hubCache.get(key)
+	if !ok {
+		if statedb != nil {
+			result, err = p.callFeeGovernance(chain, parent, statedb.Copy(), governance, data)
+		} else {
+			result, err = p.callFeeGovernanceAPI(parent, governance, data)
+			if isStateUnavailable(err) && p.stateFn != nil && chain != nil {
+				result, err = p.callFeeGovernanceOnDemand(chain, parent, governance, data)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		p.hubCache.add(key, result)
+	}
+	var out struct {
+		MinBaseFee               *big.Int
+		ElasticityMultiplier     *big.Int
+		BaseFeeChangeDenominator *big.Int
+	}
+	if err := p.feeABI.UnpackIntoInterface(&out, method, result); err != nil {
+		return nil, err
+	}
+	if out.MinBaseFee.Sign() > 0 {
+		feeParams.MinBaseFee = out.MinBaseFee
+	}
+	if out.ElasticityMultiplier.Sign() > 0 {
+		if !out.ElasticityMultiplier.IsUint64() {
+			return nil, errInvalidFeeParams
+		}
+		feeParams.ElasticityMultiplier = out.ElasticityMultiplier.Uint64()
+	}
+	if out.BaseFeeChangeDenominator.Sign() > 0 {
+		if !out.BaseFeeChangeDenominator.IsUint64() {
+			return nil, errInvalidFeeParams
+		}
+		feeParams.BaseFeeChangeDenominator = out.BaseFeeChangeDenominator.Uint64()
+	}
+	return feeParams, nil
+}
+
+// callFeeGovernance executes a read-only fee governance call as an implicit call
+// on top of the given parent state.
+func (p *Poseidon) callFeeGovernance(chain consensus.ChainHeaderReader, parent *types.Header, statedb *state.StateDB, governance common.Address, data []byte) (hexutil.Bytes, error) {
+	var (
+		context = core.NewEVMBlockContext(parent, chainContext{Chain: chain, poseidon: p}, &parent.Coinbase)
+		evm     = vm.NewEVM(context, vm.TxContext{GasPrice: common.Big0}, statedb, p.chainConfig, vm.Config{})
+	)
+	result, _, err := evm.Call(vm.AccountRef(common.Address{}), governance, data, p.config.ParamsAt(parent.Number).GasCap, common.Big0)
+	return result, err
+}
+
+// callFeeGovernanceOnDemand executes a read-only fee governance call against the
+// state of the parent block retrieved on demand, for nodes without local state.
+func (p *Poseidon) callFeeGovernanceOnDemand(chain consensus.ChainHeaderReader, parent *types.Header, governance common.Address, data []byte) (hexutil.Bytes, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	statedb := p.stateFn(ctx, parent)
+	result, err := p.callFeeGovernance(chain, parent, statedb, governance, data)
+	if err := statedb.Error(); err != nil {
+		return nil, err
+	}
+	return result, err
+}
+
+// callFeeGovernanceAPI executes a read-only fee governance call against the state
+// of the parent block looked up through the blockchain API.
+func (p *Poseidon) callFeeGovernanceAPI(parent *types.Header, governance common.Address, data []byte) (hexutil.Bytes, error) {
+	if p.ethAPI == nil {
+		return nil, errNoContractAccess
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		gas   = hexutil.Uint64(p.config.ParamsAt(parent.Number).GasCap)
+		input = hexutil.Bytes(data)
+	)
+	return p.ethAPI.Call(ctx, ethapi.TransactionArgs{
+		Gas:  &gas,
+		To:   &governance,
+		Data: &input,
+	}, rpc.BlockNumberOrHashWithHash(parent.Hash(), false), nil)
+}
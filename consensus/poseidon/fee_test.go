@@ -0,0 +1,117 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poseidon
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the non-zero base fee parameters reported by the fee governance
+// contract override the ones the chain config schedules.
+func TestBaseFeeGovernance(t *testing.T) {
+	engine, chain := newTesterChain(newTesterValidators(1), 1024)
+	defer engine.Close()
+
+	governance := common.HexToAddress("0x0000000000000000000000000000000000001010")
+	engine.config.MinBaseFee = 1000
+	engine.config.BaseFeeChangeDenominator = 16
+	engine.config.FeeGovernance = &governance
+
+	// Report a floor of 7 wei and a change denominator of 4, keeping the elasticity
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetCode(governance, common.FromHex("0x6007600052600460405260606000f3"))
+
+	parent := chain.CurrentHeader()
+	feeParams, err := engine.BaseFeeParams(chain, parent, statedb)
+	if err != nil {
+		t.Fatalf("failed to retrieve base fee parameters: %v", err)
+	}
+	if feeParams.MinBaseFee.Uint64() != 7 || feeParams.ElasticityMultiplier != params.ElasticityMultiplier || feeParams.BaseFeeChangeDenominator != 4 {
+		t.Fatalf("base fee parameters mismatch: have %+v", feeParams)
+	}
+	// The parameters of the same parent are served from the cache without state
+	if cached, err := engine.BaseFeeParams(chain, parent, nil); err != nil || cached.MinBaseFee.Uint64() != 7 {
+		t.Fatalf("cached base fee parameters mismatch: have %+v, %v", cached, err)
+	}
+	// Unknown parents need the state or the blockchain API
+	other := types.CopyHeader(parent)
+	other.Time++
+	if _, err := engine.BaseFeeParams(chain, other, nil); !errors.Is(err, errNoContractAccess) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errNoContractAccess)
+	}
+	// Nodes without local state retrieve it on demand
+	engine.SetStateFn(func(ctx context.Context, header *types.Header) *state.StateDB {
+		return statedb
+	})
+	if feeParams, err := engine.BaseFeeParams(chain, other, nil); err != nil || feeParams.MinBaseFee.Uint64() != 7 {
+		t.Fatalf("on-demand base fee parameters mismatch: have %+v, %v", feeParams, err)
+	}
+	// Without governance, the chain config schedule is used
+	engine.config.FeeGovernance = nil
+	feeParams, err = engine.BaseFeeParams(chain, other, nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve base fee parameters: %v", err)
+	}
+	if feeParams.MinBaseFee.Cmp(big.NewInt(1000)) != 0 || feeParams.BaseFeeChangeDenominator != 16 {
+		t.Fatalf("base fee parameters mismatch: have %+v", feeParams)
+	}
+}
+
+// Tests that headers are only accepted without verifying the governed base fee
+// if the parent state is unavailable, and rejected if the governance contract
+// reports invalid parameters.
+func TestBaseFeeVerificationErrors(t *testing.T) {
+	engine, chain := newTesterChain(newTesterValidators(1), 1024)
+	defer engine.Close()
+
+	governance := common.HexToAddress("0x0000000000000000000000000000000000001010")
+	engine.config.FeeGovernance = &governance
+	chain.config.LondonBlock = common.Big0
+
+	// Report an elasticity multiplier overflowing 64 bits
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetCode(governance, common.FromHex("0x600760005268010000000000000000602052600460405260606000f3"))
+
+	parent := chain.CurrentHeader()
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     common.Big1,
+		Time:       parent.Time + engine.config.Period,
+		GasLimit:   parent.GasLimit,
+		BaseFee:    big.NewInt(params.InitialBaseFee),
+	}
+	// Without the parent state, the check is deferred to the block processing
+	if err := engine.verifyCascadingFields(chain, header, nil); err != nil {
+		t.Fatalf("deferred verification failed: %v", err)
+	}
+	// With the parent state, the invalid parameters are reported
+	engine.SetStateFn(func(ctx context.Context, header *types.Header) *state.StateDB {
+		return statedb
+	})
+	if err := engine.verifyCascadingFields(chain, header, nil); !errors.Is(err, errInvalidFeeParams) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errInvalidFeeParams)
+	}
+}
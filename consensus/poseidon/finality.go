@@ -0,0 +1,272 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poseidon
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// tally is the accumulated stake of the committee members that voted on a
+// single block.
+type tally struct {
+	supply *big.Int                    // Committee supply of the attested block
+	stake  *big.Int                    // Stake of the voters so far
+	voters map[common.Address]struct{} // Committee members that voted already
+	final  bool                        // Whether the votes exceeded 2/3 of the supply
+}
+
+// queuedVote is a finality vote on a block that isn't known yet, along with the
+// validator that signed it.
+type queuedVote struct {
+	vote  *types.Vote
+	voter common.Address
+}
+
+// voteSlot is a block height a validator has a queued finality vote on. Honest
+// validators vote at most once per height, so a single vote is queued per slot.
+type voteSlot struct {
+	voter  common.Address
+	number uint64
+}
+
+// VoteRLP returns the rlp bytes which need to be signed for a finality vote on
+// the given block. The chain id is included to prevent replays across chains.
+func VoteRLP(number uint64, hash common.Hash, chainId *big.Int) []byte {
+	enc, err := rlp.EncodeToBytes([]interface{}{chainId, number, hash})
+	if err != nil {
+		panic("can't encode: " + err.Error())
+	}
+	return enc
+}
+
+// lastVoteKey returns the database key of the highest block number the given
+// validator voted on.
+func lastVoteKey(voter common.Address) []byte {
+	return append([]byte("poseidon-vote-"), voter.Bytes()...)
+}
+
+// readLastVote retrieves the highest block number the given validator voted on,
+// zero if it never voted.
+func (c *Poseidon) readLastVote(voter common.Address) uint64 {
+	blob, err := c.db.Get(lastVoteKey(voter))
+	if err != nil || len(blob) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(blob)
+}
+
+// writeLastVote stores the highest block number the given validator voted on.
+func (c *Poseidon) writeLastVote(voter common.Address, number uint64) error {
+	blob := make([]byte, 8)
+	binary.BigEndian.PutUint64(blob, number)
+	return c.db.Put(lastVoteKey(voter), blob)
+}
+
+// voteSigner recovers the address of the validator that signed a finality vote.
+func (c *Poseidon) voteSigner(vote *types.Vote) (common.Address, error) {
+	if len(vote.Signature) != crypto.SignatureLength {
+		return common.Address{}, errMissingSignature
+	}
+	hash := crypto.Keccak256(VoteRLP(vote.Number, vote.Hash, c.chainConfig.ChainID))
+	pubkey, err := crypto.SigToPub(hash, vote.Signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pubkey), nil
+}
+
+// SignVote implements consensus.PoSA, attesting the given canonical header with
+// the local validator key. It returns nil if the local validator is not part of
+// the committee of the header, or if it already voted on the same or a higher
+// block, so that it never attests two blocks of the same height. The height of
+// the last vote is persisted before signing, surviving restarts.
+func (c *Poseidon) SignVote(chain consensus.ChainHeaderReader, header *types.Header) (*types.Vote, error) {
+	number := header.Number.Uint64()
+	if number == 0 {
+		return nil, nil
+	}
+	c.lock.RLock()
+	signer, signFn := c.val, c.signFn
+	c.lock.RUnlock()
+
+	if signFn == nil {
+		return nil, nil
+	}
+	if _, _, err := c.proposerInfo(chain, header, nil, signer); err != nil {
+		if err == errUnauthorizedProposer {
+			return nil, nil
+		}
+		return nil, err
+	}
+	c.voteLock.Lock()
+	defer c.voteLock.Unlock()
+
+	if number <= c.readLastVote(signer) {
+		return nil, nil
+	}
+	if err := c.writeLastVote(signer, number); err != nil {
+		return nil, err
+	}
+	sig, err := signFn(accounts.Account{Address: signer}, accounts.MimetypePoseidonVote, VoteRLP(number, header.Hash(), c.chainConfig.ChainID))
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Vote{Number: number, Hash: header.Hash(), Signature: sig}, nil
+}
+
+// AddVote implements consensus.PoSA, verifying a finality vote and adding the
+// stake of its voter to the tally of the attested block. Only members of the
+// committee that verified the block may vote on it. It reports whether the vote
+// made the votes cover more than 2/3 of the committee supply, which happens at
+// most once per block. Votes on blocks that aren't known yet are buffered until
+// they are returned by QueuedVotes.
+func (c *Poseidon) AddVote(chain consensus.ChainHeaderReader, vote *types.Vote) (bool, error) {
+	if vote.Number == 0 {
+		return false, errUnknownBlock
+	}
+	voter, err := c.voteSigner(vote)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", consensus.ErrInvalidVote, err)
+	}
+	header := chain.GetHeader(vote.Hash, vote.Number)
+	if header == nil {
+		return false, c.queueVote(chain, vote, voter)
+	}
+	if c.knownVote(vote.Hash, voter) {
+		return false, errKnownVote
+	}
+	info, supply, err := c.proposerInfo(chain, header, nil, voter)
+	if err != nil {
+		if err == errUnauthorizedProposer {
+			return false, errUnauthorizedVoter
+		}
+		return false, err
+	}
+	c.voteLock.Lock()
+	defer c.voteLock.Unlock()
+
+	var t *tally
+	if cached, ok := c.tallies.Get(vote.Hash); ok {
+		t = cached.(*tally)
+	} else {
+		t = &tally{
+			supply: new(big.Int).Set(supply),
+			stake:  new(big.Int),
+			voters: make(map[common.Address]struct{}),
+		}
+		c.tallies.Add(vote.Hash, t)
+	}
+	if _, ok := t.voters[voter]; ok {
+		return false, errKnownVote
+	}
+	t.voters[voter] = struct{}{}
+	t.stake.Add(t.stake, info.TotalSupply)
+
+	if t.final || new(big.Int).Mul(t.stake, big.NewInt(3)).Cmp(new(big.Int).Mul(t.supply, big.NewInt(2))) <= 0 {
+		return false, nil
+	}
+	t.final = true
+	return true, nil
+}
+
+// knownVote reports whether the voter was already tallied for the given block.
+func (c *Poseidon) knownVote(hash common.Hash, voter common.Address) bool {
+	c.voteLock.Lock()
+	defer c.voteLock.Unlock()
+
+	if cached, ok := c.tallies.Get(hash); ok {
+		_, known := cached.(*tally).voters[voter]
+		return known
+	}
+	return false
+}
+
+// queueVote buffers a finality vote on a block that isn't known yet, as votes
+// may overtake the block they attest. Only votes close to the local head by
+// members of the committee at the head are kept, one per voter and height, up
+// to a global limit.
+func (c *Poseidon) queueVote(chain consensus.ChainHeaderReader, vote *types.Vote, voter common.Address) error {
+	head := chain.CurrentHeader()
+	if number := head.Number.Uint64(); vote.Number > number+maxFutureVotes || vote.Number+maxFutureVotes < number {
+		return errUnknownBlock
+	}
+	// The committee rarely changes between the head and the attested block, check
+	// the voter against the committee proposing on top of the head
+	next := &types.Header{ParentHash: head.Hash(), Number: new(big.Int).Add(head.Number, common.Big1)}
+	if _, _, err := c.proposerInfo(chain, next, nil, voter); err != nil {
+		if err == errUnauthorizedProposer {
+			return errUnexpectedVoter
+		}
+		return err
+	}
+	c.voteLock.Lock()
+	defer c.voteLock.Unlock()
+
+	slot := voteSlot{voter: voter, number: vote.Number}
+	if _, ok := c.slots[slot]; ok {
+		return errKnownVote
+	}
+	if c.nqueued >= maxQueuedVotes {
+		return errUnknownBlock
+	}
+	c.queued[vote.Hash] = append(c.queued[vote.Hash], &queuedVote{vote: vote, voter: voter})
+	c.slots[slot] = struct{}{}
+	c.nqueued++
+
+	return errQueuedVote
+}
+
+// QueuedVotes implements consensus.PoSA, returning the buffered finality votes
+// whose blocks got imported since, and dropping the ones too far behind the
+// local head for their blocks to still be expected.
+func (c *Poseidon) QueuedVotes(chain consensus.ChainHeaderReader) []*types.Vote {
+	head := chain.CurrentHeader().Number.Uint64()
+
+	c.voteLock.Lock()
+	defer c.voteLock.Unlock()
+
+	var votes []*types.Vote
+	for hash, queued := range c.queued {
+		number := queued[0].vote.Number
+		if chain.GetHeader(hash, number) != nil {
+			for _, q := range queued {
+				votes = append(votes, q.vote)
+			}
+		} else if number+maxFutureVotes >= head {
+			continue
+		} else {
+			log.Trace("Dropped stale finality votes", "number", number, "hash", hash, "votes", len(queued))
+		}
+		for _, q := range queued {
+			delete(c.slots, voteSlot{voter: q.voter, number: number})
+		}
+		delete(c.queued, hash)
+		c.nqueued -= len(queued)
+	}
+	return votes
+}
@@ -0,0 +1,108 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poseidon
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ReorgNeeded implements consensus.PoSA, deciding whether the chain should switch
+// from the current head to the extern one. From the fork choice fork on, Poseidon
+// chooses between forks by:
+//
+//   - Never switching to a head that doesn't descend from the finalized block.
+//   - Preferring the higher head, as every block is sealed by a committee member
+//     and the difficulty only reflects how long the signer waited.
+//   - Preferring, at equal height, the head sealed with fewer nonce retries, as
+//     the retries could otherwise be used to grind for a better vrf output.
+//   - Preferring, at equal height and retries, the head with the lowest vrf
+//     output.
+//   - Falling back to the total difficulty if the rules above can't decide,
+//     keeping the current head on ties.
+//
+// Before the fork, forks are chosen by total difficulty alone.
+func (c *Poseidon) ReorgNeeded(chain consensus.ChainHeaderReader, current, extern, finalized *types.Header, localTd, externTd *big.Int) bool {
+	if c.config.IsForkChoice(extern.Number) {
+		if finalized != nil && !descends(chain, extern, finalized) {
+			return false
+		}
+		if cmp := current.Number.Cmp(extern.Number); cmp != 0 {
+			return cmp < 0
+		}
+		if current.Number.Sign() > 0 {
+			if cmp := c.compareSeals(current, extern); cmp != 0 {
+				return cmp > 0
+			}
+		}
+	}
+	return externTd.Cmp(localTd) > 0
+}
+
+// descends reports whether the header is the given ancestor or one of its
+// descendants. The ancestor is expected to be canonical, so the walk stops at
+// the first canonical block it meets.
+func descends(chain consensus.ChainHeaderReader, header, ancestor *types.Header) bool {
+	number := ancestor.Number.Uint64()
+	for header != nil && header.Number.Uint64() >= number {
+		if header.Number.Uint64() == number {
+			return header.Hash() == ancestor.Hash()
+		}
+		if canon := chain.GetHeaderByNumber(header.Number.Uint64()); canon != nil && canon.Hash() == header.Hash() {
+			return true
+		}
+		header = chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	}
+	return false
+}
+
+// compareSeals orders two sealed headers of equal height by the number of nonce
+// retries and then by the vrf output, lowest first. It returns 0 if the headers
+// can't be told apart.
+func (c *Poseidon) compareSeals(a, b *types.Header) int {
+	if a.Nonce.Uint64() != b.Nonce.Uint64() {
+		if a.Nonce.Uint64() < b.Nonce.Uint64() {
+			return -1
+		}
+		return 1
+	}
+	betaA, err := c.vrfOutput(a)
+	if err != nil {
+		log.Debug("Failed to retrieve vrf output for fork choice", "number", a.Number, "hash", a.Hash(), "err", err)
+		return 0
+	}
+	betaB, err := c.vrfOutput(b)
+	if err != nil {
+		log.Debug("Failed to retrieve vrf output for fork choice", "number", b.Number, "hash", b.Hash(), "err", err)
+		return 0
+	}
+	return bytes.Compare(betaA, betaB)
+}
+
+// vrfOutput recovers the signer of a sealed header and returns the output of
+// its vrf proof.
+func (c *Poseidon) vrfOutput(header *types.Header) ([]byte, error) {
+	pubkey, _, err := ecrecover(header, c.signatures, c.chainConfig.ChainID, c.vrfLength(header.Number))
+	if err != nil {
+		return nil, err
+	}
+	return c.verifyVrf(header, pubkey)
+}
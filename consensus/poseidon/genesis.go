@@ -0,0 +1,228 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poseidon
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	// registrationDues is the registration fee the ValidatorHub charges.
+	registrationDues = new(big.Int).Mul(big.NewInt(5000), ether)
+
+	// developerStake is deposited into the developer's reward contract, enough
+	// for the hub's minimum supply.
+	developerStake = new(big.Int).Mul(big.NewInt(20000), ether)
+
+	// rewardDepositSelector is the selector of deposit() on the reward contracts
+	// created by the ValidatorFactory.
+	rewardDepositSelector = common.FromHex("0xd0e30db0")
+)
+
+// GenesisValidator is a validator registered with the ValidatorHub in the genesis
+// state of a chain.
+type GenesisValidator struct {
+	Address common.Address // Account sealing the blocks
+	Name    string         // Name the validator is registered with
+	Owner   common.Address // Account registering the validator, owning its reward contract
+	Stake   *big.Int       // Amount the owner deposits into the reward contract
+}
+
+// DeveloperGenesisBlock returns the 'geth --dev --dev.poseidon' genesis block,
+// with the system contracts deployed and the developer account registered as
+// the only validator.
+//
+// The hub only lets registered validators propose from the next hundred block
+// boundary on, so the developer is also compiled into the hub as the genesis
+// minter to seal the blocks before that.
+func DeveloperGenesisBlock(period uint64, developer common.Address) *core.Genesis {
+	config := *params.AllCliqueProtocolChanges
+	config.Clique = nil
+	config.Poseidon = &params.PoseidonConfig{
+		Period:          period,
+		ForkChoiceBlock: common.Big0, // The Evidence fork waits for the evidence accepting hub
+
+		FeeDistributionBlock: common.Big0,
+		SystemTxBlock:        common.Big0,
+		SlotBlock:            common.Big0,
+		SortitionBlock:       common.Big0,
+	}
+
+	genesis := &core.Genesis{
+		Config:     &config,
+		ExtraData:  make([]byte, extraVanity),
+		GasLimit:   11500000,
+		BaseFee:    big.NewInt(params.InitialBaseFee),
+		Difficulty: big.NewInt(1),
+		Alloc: map[common.Address]core.GenesisAccount{
+			common.BytesToAddress([]byte{1}): {Balance: big.NewInt(1)}, // ECRecover
+			common.BytesToAddress([]byte{2}): {Balance: big.NewInt(1)}, // SHA256
+			common.BytesToAddress([]byte{3}): {Balance: big.NewInt(1)}, // RIPEMD
+			common.BytesToAddress([]byte{4}): {Balance: big.NewInt(1)}, // Identity
+			common.BytesToAddress([]byte{5}): {Balance: big.NewInt(1)}, // ModExp
+			common.BytesToAddress([]byte{6}): {Balance: big.NewInt(1)}, // ECAdd
+			common.BytesToAddress([]byte{7}): {Balance: big.NewInt(1)}, // ECScalarMul
+			common.BytesToAddress([]byte{8}): {Balance: big.NewInt(1)}, // ECPairing
+			common.BytesToAddress([]byte{9}): {Balance: big.NewInt(1)}, // BLAKE2b
+			developer:                        {Balance: new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(9))},
+		},
+	}
+	validator := GenesisValidator{Address: developer, Name: "developer", Owner: developer, Stake: developerStake}
+	if err := SetupGenesis(genesis, developer, []GenesisValidator{validator}); err != nil {
+		panic(fmt.Sprintf("failed to register developer validator: %v", err))
+	}
+	return genesis
+}
+
+// SetupGenesis deploys the system contracts into the genesis allocation, with the
+// minter compiled into the ValidatorHub as the genesis minter, and registers the
+// given validators on top of it.
+//
+// The owners pay the registration dues and deposit the stakes from their genesis
+// balance, any shortfall is credited to them beforehand.
+func SetupGenesis(genesis *core.Genesis, minter common.Address, validators []GenesisValidator) error {
+	if genesis.Alloc == nil {
+		genesis.Alloc = make(core.GenesisAlloc)
+	}
+	hubCode := bytes.ReplaceAll(common.FromHex(systemcontracts.ValidatorHubCode), common.HexToAddress(systemcontracts.GenesisMinter).Bytes(), minter.Bytes())
+	genesis.Alloc[common.HexToAddress(systemcontracts.ValidatorHubContract)] = core.GenesisAccount{Balance: new(big.Int), Code: hubCode}
+	genesis.Alloc[common.HexToAddress(systemcontracts.ValidatorFactoryContract)] = core.GenesisAccount{Balance: new(big.Int), Code: common.FromHex(systemcontracts.ValidatorFactoryCode)}
+
+	alloc, err := registerValidators(genesis, minter, validators)
+	if err != nil {
+		return err
+	}
+	genesis.Alloc = alloc
+	return nil
+}
+
+// registerValidators runs the hub initialisation by the minter and the validator
+// registrations on top of the genesis allocation, returning the resulting state as a new
+// allocation.
+func registerValidators(genesis *core.Genesis, minter common.Address, validators []GenesisValidator) (core.GenesisAlloc, error) {
+	hubABI, err := abi.JSON(strings.NewReader(ValidatorHubABI))
+	if err != nil {
+		return nil, err
+	}
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	for addr, account := range genesis.Alloc {
+		statedb.AddBalance(addr, account.Balance)
+		statedb.SetCode(addr, account.Code)
+		statedb.SetNonce(addr, account.Nonce)
+		for key, value := range account.Storage {
+			statedb.SetState(addr, key, value)
+		}
+	}
+	baseFee := new(big.Int)
+	if genesis.BaseFee != nil {
+		baseFee.Set(genesis.BaseFee)
+	}
+	evm := vm.NewEVM(vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		GetHash:     func(uint64) common.Hash { return common.Hash{} },
+		GasLimit:    genesis.GasLimit,
+		BlockNumber: new(big.Int),
+		Time:        new(big.Int),
+		Difficulty:  new(big.Int).Set(genesis.Difficulty),
+		BaseFee:     baseFee,
+	}, vm.TxContext{GasPrice: new(big.Int)}, statedb, genesis.Config, vm.Config{})
+
+	call := func(from, to common.Address, value *big.Int, input []byte) ([]byte, error) {
+		evm.Reset(vm.TxContext{Origin: from, GasPrice: new(big.Int)}, statedb)
+		ret, _, err := evm.Call(vm.AccountRef(from), to, input, math.MaxUint64/2, value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, hexutil.Encode(ret))
+		}
+		return ret, nil
+	}
+	hub := common.HexToAddress(systemcontracts.ValidatorHubContract)
+	input, err := hubABI.Pack("init")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := call(minter, hub, new(big.Int), input); err != nil {
+		return nil, fmt.Errorf("init: %w", err)
+	}
+	for _, validator := range validators {
+		// Credit the owner whatever it lacks for the dues and the stake
+		cost := new(big.Int).Add(registrationDues, validator.Stake)
+		if balance := statedb.GetBalance(validator.Owner); balance.Cmp(cost) < 0 {
+			statedb.AddBalance(validator.Owner, new(big.Int).Sub(cost, balance))
+		}
+		// The factory creates the reward contract of the registration, which the
+		// hub doesn't report yet during the genesis minter's blocks
+		factory := common.HexToAddress(systemcontracts.ValidatorFactoryContract)
+		reward := crypto.CreateAddress(factory, statedb.GetNonce(factory))
+
+		input, err := hubABI.Pack("register", validator.Address, validator.Name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := call(validator.Owner, hub, registrationDues, input); err != nil {
+			return nil, fmt.Errorf("register %x: %w", validator.Address, err)
+		}
+		if _, err := call(validator.Owner, reward, validator.Stake, rewardDepositSelector); err != nil {
+			return nil, fmt.Errorf("deposit %x: %w", validator.Address, err)
+		}
+	}
+	// Commit the state for the trie key preimages and collect it back
+	root, err := statedb.Commit(genesis.Config.IsEIP158(common.Big0))
+	if err != nil {
+		return nil, err
+	}
+	if statedb, err = state.New(root, statedb.Database(), nil); err != nil {
+		return nil, err
+	}
+	dump := statedb.RawDump(&state.DumpConfig{OnlyWithAddresses: true})
+
+	alloc := make(core.GenesisAlloc, len(dump.Accounts))
+	for addr, account := range dump.Accounts {
+		balance, ok := new(big.Int).SetString(account.Balance, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid balance %q of %x", account.Balance, addr)
+		}
+		genesisAccount := core.GenesisAccount{
+			Balance: balance,
+			Nonce:   account.Nonce,
+			Code:    account.Code,
+		}
+		if len(account.Storage) > 0 {
+			genesisAccount.Storage = make(map[common.Hash]common.Hash, len(account.Storage))
+			for key, value := range account.Storage {
+				genesisAccount.Storage[key] = common.HexToHash(value)
+			}
+		}
+		alloc[addr] = genesisAccount
+	}
+	return alloc, nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poseidon
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the developer genesis registers the developer as the only validator,
+// sealing on demand past the hundred blocks the genesis minter is limited to.
+func TestDeveloperGenesis(t *testing.T) {
+	ks := keystore.NewKeyStore(t.TempDir(), keystore.LightScryptN, keystore.LightScryptP)
+	key, _ := crypto.GenerateKey()
+	account, err := ks.ImportECDSA(key, "")
+	if err != nil {
+		t.Fatalf("failed to import key: %v", err)
+	}
+	if err := ks.Unlock(account, ""); err != nil {
+		t.Fatalf("failed to unlock key: %v", err)
+	}
+	genesis := DeveloperGenesisBlock(0, account.Address)
+	genesis.Timestamp = uint64(time.Now().Add(-time.Hour).Unix())
+	if !genesis.Config.IsTrident(common.Big0) {
+		t.Fatalf("developer chain doesn't run the trident rules")
+	}
+	h := bootTesterHub(t, genesis, ks, key, nil)
+	dev := h.minter
+
+	next := func() *big.Int { return new(big.Int).Add(h.chain.CurrentBlock().Number(), common.Big1) }
+	validators, err := h.engine.GetValidators(next())
+	if err != nil {
+		t.Fatalf("failed to retrieve validators: %v", err)
+	}
+	if len(validators) != 1 || validators[0] != dev.addr {
+		t.Fatalf("validators mismatch: have %x, want [%x]", validators, dev.addr)
+	}
+	// Blocks with only the header sync aren't sealed without a period
+	results := make(chan *types.Block, 1)
+	if err := dev.engine.Seal(h.chain, h.propose(t, dev, nil), results, nil); err != nil {
+		t.Fatalf("failed to seal empty block: %v", err)
+	}
+	select {
+	case <-results:
+		t.Fatalf("empty block sealed")
+	default:
+	}
+	// Blocks with transactions are sealed right away, also after the minter's
+	// blocks run out
+	recipient := common.HexToAddress("0xdeadbeef")
+	for h.chain.CurrentBlock().NumberU64() < 105 {
+		tx := h.transact(t, dev.key, recipient, common.Big1, nil, nil)
+		h.seal(t, []*testerNode{dev}, []*types.Transaction{tx})
+	}
+	if ok, err := h.engine.IsProposer(dev.addr, next()); err != nil || !ok {
+		t.Fatalf("developer not a proposer: %v, %v", ok, err)
+	}
+	info, err := h.engine.GetValidatorInfo(dev.addr, next())
+	if err != nil {
+		t.Fatalf("failed to retrieve validator info: %v", err)
+	}
+	if info.TotalSupply.Cmp(developerStake) != 0 {
+		t.Fatalf("stake mismatch: have %v, want %v", info.TotalSupply, developerStake)
+	}
+}
+
+// Tests that the genesis setup registers every validator, with the stake of the
+// owner deposited into its reward contract even if the owner wasn't funded.
+func TestSetupGenesis(t *testing.T) {
+	ks := keystore.NewKeyStore(t.TempDir(), keystore.LightScryptN, keystore.LightScryptP)
+	minterKey, _ := crypto.GenerateKey()
+	account, err := ks.ImportECDSA(minterKey, "")
+	if err != nil {
+		t.Fatalf("failed to import key: %v", err)
+	}
+	if err := ks.Unlock(account, ""); err != nil {
+		t.Fatalf("failed to unlock key: %v", err)
+	}
+	config := *params.AllCliqueProtocolChanges
+	config.Clique = nil
+	config.Poseidon = &params.PoseidonConfig{Period: 1}
+
+	genesis := &core.Genesis{
+		Config:     &config,
+		Timestamp:  uint64(time.Now().Add(-time.Hour).Unix()),
+		GasLimit:   11500000,
+		Difficulty: big.NewInt(1),
+		Alloc:      core.GenesisAlloc{account.Address: {Balance: new(big.Int).Mul(big.NewInt(100000), ether)}},
+	}
+	validators := []GenesisValidator{
+		{Address: common.HexToAddress("0x01"), Name: "alice", Owner: account.Address, Stake: new(big.Int).Mul(big.NewInt(20000), ether)},
+		{Address: common.HexToAddress("0x02"), Name: "bob", Owner: common.HexToAddress("0xb0b"), Stake: new(big.Int).Mul(big.NewInt(30000), ether)},
+	}
+	if err := SetupGenesis(genesis, account.Address, validators); err != nil {
+		t.Fatalf("failed to set up genesis: %v", err)
+	}
+	h := bootTesterHub(t, genesis, ks, minterKey, nil)
+
+	if minter, err := h.engine.GetGenesisMinter(common.Big1); err != nil || minter != account.Address {
+		t.Fatalf("genesis minter mismatch: have %x, %v, want %x", minter, err, account.Address)
+	}
+	registered, err := h.engine.GetValidators(common.Big1)
+	if err != nil {
+		t.Fatalf("failed to retrieve validators: %v", err)
+	}
+	if len(registered) != len(validators) {
+		t.Fatalf("validator count mismatch: have %d, want %d", len(registered), len(validators))
+	}
+	for i, validator := range validators {
+		if registered[i] != validator.Address {
+			t.Errorf("validator %d: address mismatch: have %x, want %x", i, registered[i], validator.Address)
+		}
+		info, err := h.engine.GetValidatorInfo(validator.Address, common.Big1)
+		if err != nil {
+			t.Fatalf("validator %d: failed to retrieve info: %v", i, err)
+		}
+		if info.Name != validator.Name || info.TotalSupply.Cmp(validator.Stake) != 0 {
+			t.Errorf("validator %d: info mismatch: have %s/%v, want %s/%v", i, info.Name, info.TotalSupply, validator.Name, validator.Stake)
+		}
+	}
+}
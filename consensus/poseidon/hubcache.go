@@ -0,0 +1,134 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poseidon
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+var (
+	hubCacheHitMeter  = metrics.NewRegisteredMeter("consensus/poseidon/hubcache/hit", nil)
+	hubCacheMissMeter = metrics.NewRegisteredMeter("consensus/poseidon/hubcache/miss", nil)
+)
+
+// hubCallKey identifies a read-only ValidatorHub call run against the state of
+// a block.
+type hubCallKey struct {
+	block common.Hash    // Hash of the block whose state the call runs against
+	from  common.Address // Sender of the call
+	hub   common.Address // ValidatorHub contract called
+	input string         // Packed method and arguments
+}
+
+// hubCache caches the results of the read-only ValidatorHub calls. The state of
+// a block never changes, so the results only go stale for blocks reorged out of
+// the canonical chain, which are evicted as the chain reports them.
+type hubCache struct {
+	results *lru.Cache // Call results by hubCallKey
+}
+
+func newHubCache() *hubCache {
+	results, _ := lru.New(inmemoryHubCalls)
+	return &hubCache{results: results}
+}
+
+// get retrieves the result of a call, if cached.
+func (c *hubCache) get(key hubCallKey) (hexutil.Bytes, bool) {
+	if result, ok := c.results.Get(key); ok {
+		hubCacheHitMeter.Mark(1)
+		return common.CopyBytes(result.(hexutil.Bytes)), true
+	}
+	hubCacheMissMeter.Mark(1)
+	return nil, false
+}
+
+// add caches the result of a call.
+func (c *hubCache) add(key hubCallKey, result hexutil.Bytes) {
+	c.results.Add(key, hexutil.Bytes(common.CopyBytes(result)))
+}
+
+// evict drops the results of all the calls run against the given block.
+func (c *hubCache) evict(block common.Hash) {
+	for _, key := range c.results.Keys() {
+		if key.(hubCallKey).block == block {
+			c.results.Remove(key)
+		}
+	}
+}
+
+// purge drops all the cached results.
+func (c *hubCache) purge() {
+	c.results.Purge()
+}
+
+// chainEventSource is the subset of the blockchain reporting head changes and
+// reorged out blocks.
+type chainEventSource interface {
+	consensus.ChainHeaderReader
+
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+	SubscribeChainSideEvent(ch chan<- core.ChainSideEvent) event.Subscription
+}
+
+// SubscribeChainEvents keeps the cached ValidatorHub results in sync with the
+// given chain, evicting the results of blocks turned into side blocks and all
+// of them when the new head doesn't extend the previous one. The new heads are
+// tracked for the validator liveness too, and start the search of the winning
+// nonce of the next block.
+func (p *Poseidon) SubscribeChainEvents(chain chainEventSource) {
+	var (
+		headCh  = make(chan core.ChainHeadEvent, 16)
+		sideCh  = make(chan core.ChainSideEvent, 16)
+		headSub = chain.SubscribeChainHeadEvent(headCh)
+		sideSub = chain.SubscribeChainSideEvent(sideCh)
+	)
+	go func() {
+		defer headSub.Unsubscribe()
+		defer sideSub.Unsubscribe()
+
+		var head common.Hash
+		for {
+			select {
+			case ev := <-headCh:
+				if head != (common.Hash{}) && ev.Block.ParentHash() != head {
+					log.Debug("Purging hub call cache on reorg", "number", ev.Block.Number(), "hash", ev.Block.Hash())
+					p.hubCache.purge()
+				}
+				head = ev.Block.Hash()
+				p.trackLiveness(chain, ev.Block.Header())
+				p.precomputeNonce(chain, ev.Block.Header())
+
+			case ev := <-sideCh:
+				p.hubCache.evict(ev.Block.Hash())
+
+			case <-headSub.Err():
+				return
+			case <-sideSub.Err():
+				return
+			case <-p.quit:
+				return
+			}
+		}
+	}()
+}
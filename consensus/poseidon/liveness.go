@@ -0,0 +1,347 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poseidon
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	livenessWindow  = 1024 // Number of recent canonical blocks the validator liveness is tracked over
+	livenessRefresh = 16   // Number of blocks the committee stake shares are reused for
+)
+
+// errNoLiveness is returned if the validator liveness is requested before any
+// canonical head was tracked.
+var errNoLiveness = errors.New("validator liveness not tracked yet")
+
+// livenessCommittee is the share of the committee supply each committee member
+// staked, which is the expected number of blocks it proposes per block.
+type livenessCommittee struct {
+	number uint64                     // Block the shares were retrieved for
+	shares map[common.Address]float64 // Stake shares by committee member
+}
+
+// livenessRecord is a canonical block tracked for the validator liveness.
+type livenessRecord struct {
+	number    uint64
+	hash      common.Hash
+	time      uint64
+	signer    common.Address
+	committee *livenessCommittee // Committee the block was proposed by
+}
+
+// livenessGauges are the metrics of the liveness of a single validator.
+type livenessGauges struct {
+	names    []string
+	proposed metrics.Gauge // Blocks proposed in the window
+	missed   metrics.Gauge // Expected blocks not proposed in the window
+	idle     metrics.Gauge // Seconds since the last proposal
+}
+
+func newLivenessGauges(validator common.Address) *livenessGauges {
+	prefix := fmt.Sprintf("consensus/poseidon/liveness/%x/", validator)
+	g := &livenessGauges{
+		names: []string{prefix + "proposed", prefix + "missed", prefix + "idle"},
+	}
+	g.proposed = metrics.GetOrRegisterGauge(g.names[0], nil)
+	g.missed = metrics.GetOrRegisterGauge(g.names[1], nil)
+	g.idle = metrics.GetOrRegisterGauge(g.names[2], nil)
+	return g
+}
+
+func (g *livenessGauges) unregister() {
+	for _, name := range g.names {
+		metrics.DefaultRegistry.Unregister(name)
+	}
+}
+
+// livenessTracker tracks the liveness of the committee members over the recent
+// canonical blocks.
+type livenessTracker struct {
+	records []*livenessRecord                  // Canonical blocks of the window in ascending order
+	gauges  map[common.Address]*livenessGauges // Metrics of the validators in the window
+	slashed map[common.Address]uint64          // Block the overdue peers were last slashed at
+	lock    sync.RWMutex                       // Protects the records and the slashes
+}
+
+func newLivenessTracker() *livenessTracker {
+	return &livenessTracker{
+		gauges:  make(map[common.Address]*livenessGauges),
+		slashed: make(map[common.Address]uint64),
+	}
+}
+
+// index returns the position of the block with the given hash in the window, or
+// -1 if it's not tracked.
+func (t *livenessTracker) index(hash common.Hash) int {
+	for i := len(t.records) - 1; i >= 0; i-- {
+		if t.records[i].hash == hash {
+			return i
+		}
+	}
+	return -1
+}
+
+// trackLiveness updates the validator liveness with a new canonical head, dropping
+// the tracked blocks it reorged out.
+func (c *Poseidon) trackLiveness(chain consensus.ChainHeaderReader, head *types.Header) {
+	t := c.liveness
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	// Gather the new canonical blocks down to the last one already tracked
+	var (
+		headers []*types.Header
+		keep    int
+	)
+	for header := head; header != nil && header.Number.Sign() > 0 && len(headers) < livenessWindow; header = chain.GetHeader(header.ParentHash, header.Number.Uint64()-1) {
+		if i := t.index(header.Hash()); i >= 0 {
+			keep = i + 1
+			break
+		}
+		headers = append(headers, header)
+	}
+	t.records = t.records[:keep]
+
+	for i := len(headers) - 1; i >= 0; i-- {
+		header := headers[i]
+		signer, err := c.Author(header)
+		if err != nil {
+			log.Debug("Failed to recover signer for liveness", "number", header.Number, "hash", header.Hash(), "err", err)
+			continue
+		}
+		number := header.Number.Uint64()
+
+		// Reuse the committee of the previous block while it's recent enough
+		var committee *livenessCommittee
+		if len(t.records) > 0 {
+			committee = t.records[len(t.records)-1].committee
+		}
+		if committee == nil || number < committee.number || number-committee.number >= livenessRefresh {
+			fresh, err := c.livenessCommittee(chain, header)
+			switch {
+			case err == nil:
+				committee = fresh
+			case committee != nil:
+				// Keep the stale committee instead of retrying every block
+				log.Debug("Failed to retrieve liveness committee", "number", number, "err", err)
+				committee = &livenessCommittee{number: number, shares: committee.shares}
+			default:
+				log.Debug("Failed to retrieve liveness committee", "number", number, "err", err)
+				committee = &livenessCommittee{number: number, shares: make(map[common.Address]float64)}
+			}
+		}
+		t.records = append(t.records, &livenessRecord{
+			number:    number,
+			hash:      header.Hash(),
+			time:      header.Time,
+			signer:    signer,
+			committee: committee,
+		})
+	}
+	if len(t.records) > livenessWindow {
+		t.records = append([]*livenessRecord(nil), t.records[len(t.records)-livenessWindow:]...)
+	}
+	// Report the liveness of the tracked validators, dropping the metrics of the
+	// ones no longer in the window
+	if len(t.records) == 0 {
+		return
+	}
+	stats := t.stats(c.config.ParamsAt(head.Number).HeartRate)
+	for validator, gauges := range t.gauges {
+		if _, ok := stats[validator]; !ok {
+			gauges.unregister()
+			delete(t.gauges, validator)
+		}
+	}
+	for validator, liveness := range stats {
+		gauges, ok := t.gauges[validator]
+		if !ok {
+			gauges = newLivenessGauges(validator)
+			t.gauges[validator] = gauges
+		}
+		gauges.proposed.Update(int64(liveness.Proposed))
+		gauges.missed.Update(int64(liveness.Missed))
+		gauges.idle.Update(int64(liveness.IdleTime))
+	}
+}
+
+// livenessCommittee retrieves the stake shares of the committee allowed to
+// propose the given block.
+func (c *Poseidon) livenessCommittee(chain consensus.ChainHeaderReader, header *types.Header) (*livenessCommittee, error) {
+	var (
+		members []checkpointValidator
+		supply  *big.Int
+	)
+	if c.snapshotCommittee(header.Number) {
+		snap, err := c.snapshot(chain, header.Number.Uint64()-1, header.ParentHash, nil)
+		if err != nil {
+			return nil, err
+		}
+		for validator, info := range snap.Validators {
+			members = append(members, checkpointValidator{Address: validator, Stake: info.Stake})
+		}
+		supply = snap.committeeSupply()
+	} else {
+		proposers, err := c.GetProposers(header.Number)
+		if err != nil {
+			return nil, err
+		}
+		if supply, err = c.GetCommitteeSupply(header.Number, common.Address{}); err != nil {
+			return nil, err
+		}
+		members = proposers
+	}
+	committee := &livenessCommittee{
+		number: header.Number.Uint64(),
+		shares: make(map[common.Address]float64, len(members)),
+	}
+	for _, member := range members {
+		share := 1.0
+		if member.Stake.Cmp(supply) < 0 {
+			share, _ = new(big.Float).Quo(new(big.Float).SetInt(member.Stake), new(big.Float).SetInt(supply)).Float64()
+		}
+		committee.shares[member.Address] = share
+	}
+	return committee, nil
+}
+
+// stats aggregates the liveness of every validator that was a committee member
+// or proposed a block within the window. A member of the head committee is
+// overdue once it didn't propose for the given number of blocks.
+func (t *livenessTracker) stats(heartRate uint64) map[common.Address]*ValidatorLiveness {
+	type tally struct {
+		proposed    uint64
+		expected    float64
+		first, last *livenessRecord
+	}
+	tallies := make(map[common.Address]*tally)
+	get := func(validator common.Address, record *livenessRecord) *tally {
+		v, ok := tallies[validator]
+		if !ok {
+			v = &tally{first: record}
+			tallies[validator] = v
+		}
+		return v
+	}
+	for _, record := range t.records {
+		for member, share := range record.committee.shares {
+			get(member, record).expected += share
+		}
+		v := get(record.signer, record)
+		v.proposed++
+		v.last = record
+	}
+	head := t.records[len(t.records)-1]
+
+	stats := make(map[common.Address]*ValidatorLiveness, len(tallies))
+	for validator, v := range tallies {
+		liveness := &ValidatorLiveness{
+			Proposed: hexutil.Uint64(v.proposed),
+			Expected: v.expected,
+		}
+		if expected := uint64(math.Floor(v.expected)); expected > v.proposed {
+			liveness.Missed = hexutil.Uint64(expected - v.proposed)
+		}
+		if v.last != nil {
+			number := hexutil.Uint64(v.last.number)
+			liveness.LastProposed = &number
+			liveness.IdleBlocks = hexutil.Uint64(head.number - v.last.number)
+			liveness.IdleTime = hexutil.Uint64(head.time - v.last.time)
+		} else {
+			liveness.IdleBlocks = hexutil.Uint64(head.number - v.first.number + 1)
+			liveness.IdleTime = hexutil.Uint64(head.time - v.first.time)
+		}
+		_, member := head.committee.shares[validator]
+		liveness.Overdue = member && uint64(liveness.IdleBlocks) >= heartRate
+		stats[validator] = liveness
+	}
+	return stats
+}
+
+// getLiveness returns the liveness of the validators as of the last tracked head.
+func (c *Poseidon) getLiveness() (*Liveness, error) {
+	t := c.liveness
+
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if len(t.records) == 0 {
+		return nil, errNoLiveness
+	}
+	head := t.records[len(t.records)-1]
+	return &Liveness{
+		Number:     hexutil.Uint64(head.number),
+		Hash:       head.hash,
+		From:       hexutil.Uint64(t.records[0].number),
+		Validators: t.stats(c.config.ParamsAt(new(big.Int).SetUint64(head.number)).HeartRate),
+	}, nil
+}
+
+// slashOverduePeers sends slash transactions for the committee members other
+// than the local validator that are overdue on their heartbeat, as confirmed by
+// the ValidatorHub state the given block is built upon. A validator is slashed
+// at most once per heart rate.
+func (c *Poseidon) slashOverduePeers(number *big.Int) error {
+	if c.txPoolAPI == nil {
+		return nil
+	}
+	t := c.liveness
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if len(t.records) == 0 {
+		return nil
+	}
+	heartRate := c.config.ParamsAt(number).HeartRate
+	for validator, liveness := range t.stats(heartRate) {
+		if !liveness.Overdue || validator == c.val {
+			continue
+		}
+		if last, ok := t.slashed[validator]; ok && number.Uint64() < last+heartRate {
+			continue
+		}
+		info, err := c.GetValidatorInfo(validator, number)
+		if err != nil {
+			return err
+		}
+		lastBlockHeight := info.LastBlockHeight.Uint64()
+		if number.Uint64() < lastBlockHeight || number.Uint64()-lastBlockHeight < heartRate {
+			continue
+		}
+		if err := c.sendSlash(number, validator); err != nil {
+			return err
+		}
+		t.slashed[validator] = number.Uint64()
+		log.Info("Slashed overdue validator", "validator", validator, "number", number, "lastBlock", lastBlockHeight)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poseidon
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Tests that the liveness of the committee members is tracked from the canonical
+// headers, rewinding the ones reorged out.
+func TestLiveness(t *testing.T) {
+	validators := newTesterValidators(3)
+	engine, chain := newTesterChain(validators, 1024)
+	engine.config.HeartRate = 10
+
+	api := &API{chain: chain, poseidon: engine}
+	if _, err := api.GetLiveness(); err != errNoLiveness {
+		t.Fatalf("liveness error mismatch: have %v, want %v", err, errNoLiveness)
+	}
+	// Let the last validator go idle, tracking the heads both one by one and in
+	// batches
+	for i := 0; i < 30; i++ {
+		sealNext(t, engine, chain, validators[:2])
+		if i < 10 || i%5 == 4 {
+			engine.trackLiveness(chain, chain.CurrentHeader())
+		}
+	}
+	liveness, err := api.GetLiveness()
+	if err != nil {
+		t.Fatalf("failed to retrieve liveness: %v", err)
+	}
+	if liveness.Number != 30 || liveness.From != 1 || liveness.Hash != chain.CurrentHeader().Hash() {
+		t.Fatalf("tracked range mismatch: have #%d-#%d %x", liveness.From, liveness.Number, liveness.Hash)
+	}
+	var proposed uint64
+	for _, validator := range liveness.Validators {
+		proposed += uint64(validator.Proposed)
+	}
+	if proposed != 30 {
+		t.Fatalf("proposed block count mismatch: have %d, want 30", proposed)
+	}
+	idle := liveness.Validators[validators[2].addr]
+	if idle == nil {
+		t.Fatalf("idle validator not tracked")
+	}
+	if idle.Proposed != 0 || idle.LastProposed != nil || idle.IdleBlocks != 30 || !idle.Overdue {
+		t.Fatalf("idle validator liveness mismatch: %+v", idle)
+	}
+	if idle.Expected < 9.9 || idle.Expected > 10.1 || idle.Missed < 9 {
+		t.Fatalf("idle validator expectation mismatch: expected %v, missed %d", idle.Expected, idle.Missed)
+	}
+	if active := liveness.Validators[validators[0].addr]; active == nil || active.Overdue || active.LastProposed == nil {
+		t.Fatalf("active validator liveness mismatch: %+v", active)
+	}
+	// Rewind the head and ensure the reorged out blocks are dropped
+	engine.trackLiveness(chain, chain.GetHeaderByNumber(20))
+	if liveness, err = api.GetLiveness(); err != nil {
+		t.Fatalf("failed to retrieve liveness: %v", err)
+	}
+	if liveness.Number != 20 || liveness.Validators[validators[2].addr].IdleBlocks != 20 {
+		t.Fatalf("rewound liveness mismatch: head #%d, idle %+v", liveness.Number, liveness.Validators[validators[2].addr])
+	}
+}
+
+// Tests that the heartbeat slashes the overdue peers instead of the local
+// validator if requested, at most once per heart rate.
+func TestSlashPeers(t *testing.T) {
+	h := newTesterHub(t, 3)
+	h.config.Poseidon.HeartRate = 10
+	h.formCommittee(t)
+
+	// Let a single validator seal until its peers are overdue
+	node, peers := h.nodes[0], h.nodes[1:]
+	node.engine.SetSlashPeers(true)
+	node.engine.trackLiveness(h.chain, h.chain.CurrentHeader())
+
+	overdue := func() bool {
+		liveness, err := node.engine.getLiveness()
+		if err != nil {
+			t.Fatalf("failed to retrieve liveness: %v", err)
+		}
+		for _, peer := range peers {
+			if validator := liveness.Validators[peer.addr]; validator == nil || !validator.Overdue {
+				return false
+			}
+		}
+		return true
+	}
+	for !overdue() {
+		if h.chain.CurrentBlock().NumberU64() > 50 {
+			t.Fatalf("peers never overdue")
+		}
+		h.seal(t, []*testerNode{node}, nil)
+		node.engine.trackLiveness(h.chain, h.chain.CurrentHeader())
+	}
+	head := h.chain.CurrentHeader()
+	next := new(big.Int).Add(head.Number, common.Big1)
+	if err := node.engine.Heartbeat(next); err != nil {
+		t.Fatalf("failed to beat: %v", err)
+	}
+	pending := h.backend.pending()
+	if len(pending) != len(peers) {
+		t.Fatalf("slash transaction count mismatch: have %d, want %d", len(pending), len(peers))
+	}
+	slashed := make(map[common.Address]bool)
+	for _, tx := range pending {
+		target, err := slashTarget(hubAddress, tx)
+		if err != nil {
+			t.Fatalf("invalid slash: %v", err)
+		}
+		if err := node.engine.VerifySlash(&types.Header{Number: next, ParentHash: head.Hash()}, nil, tx); err != nil {
+			t.Fatalf("slash of %x rejected: %v", target, err)
+		}
+		slashed[target] = true
+	}
+	for _, peer := range peers {
+		if !slashed[peer.addr] {
+			t.Fatalf("overdue peer %x not slashed", peer.addr)
+		}
+	}
+	// Beats within the heart rate must not slash again
+	for i := int64(0); i < 3; i++ {
+		if err := node.engine.Heartbeat(new(big.Int).Add(next, big.NewInt(i))); err != nil {
+			t.Fatalf("failed to beat: %v", err)
+		}
+	}
+	if pending := h.backend.pending(); len(pending) != len(peers) {
+		t.Fatalf("slash transaction count mismatch: have %d, want %d", len(pending), len(peers))
+	}
+}
@@ -28,7 +28,9 @@ import (
 	"github.com/ethereum/go-ethereum/crypto/vrf"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"io"
+	"math"
 	"math/big"
+	"runtime"
 	"strings"
 	"sync"
 	"time"
@@ -41,6 +43,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -51,23 +54,25 @@ import (
 )
 
 const (
+	checkpointInterval = 1024 // Number of blocks after which to save the committee snapshot to the database
+	inmemorySnapshots  = 128  // Number of recent committee snapshots to keep in memory
 	inmemorySignatures = 4096 // Number of recent block signatures to keep in memory
-
-	vrfExpectedSize = 3.0
-
-	validatorBytesLength = common.AddressLength
-
-	nonceSignSize = 255
-	heartRate     = 100
-
-	gasCap = 50000000
+	inmemoryTallies    = 1024 // Number of recent block finality tallies to keep in memory
+	maxQueuedVotes     = 4096 // Maximum number of finality votes to buffer for unknown blocks
+	maxFutureVotes     = 64   // Maximum distance of a buffered vote from the local head
+	inmemorySeals      = 4096 // Number of recent sealed headers to keep in memory for double-sign detection
+	inmemoryEvidence   = 128  // Number of recent double-sign evidences to keep in memory
+	inmemoryHubCalls   = 1024 // Number of recent ValidatorHub call results to keep in memory
+	inmemoryNonces     = 16   // Number of recent winning nonces of the local validator to keep in memory
+
+	validatorBytesLength = common.AddressLength + common.HashLength + 8 // Address, stake and last sealed block of a checkpoint entry
 )
 
 // Spos proof-of-authority protocol constants.
 var (
-	extraVanity = 32                     // Fixed number of extra-data prefix bytes reserved for signer vanity
-	extraSeal   = crypto.SignatureLength // Fixed number of extra-data suffix bytes reserved for signer seal
-	extraVrf    = 81
+	extraVanity = 32                              // Fixed number of extra-data prefix bytes reserved for signer vanity
+	extraSeal   = crypto.SignatureLength          // Fixed number of extra-data suffix bytes reserved for signer seal
+	extraVrf    = params.DefaultPoseidonVrfLength // Number of extra-data bytes reserved for the vrf proof, unless configured otherwise
 
 	uncleHash = types.CalcUncleHash(nil) // Always Keccak256(RLP([])) as uncles are meaningless outside of PoW.
 
@@ -103,7 +108,15 @@ var (
 	// to contain a 65 byte secp256k1 signature.
 	errMissingSignature = errors.New("extra-data 65 byte signature suffix missing")
 
-	errMissingVrf = errors.New("extra-data 81 byte vrf suffix missing")
+	errMissingVrf = errors.New("extra-data vrf suffix missing")
+
+	// errNoContractAccess is returned if the ValidatorHub state is queried by an
+	// engine that has no access to the chain state (e.g. a light client).
+	errNoContractAccess = errors.New("validator contract not accessible")
+
+	// errNoSnapshots is returned if a committee snapshot is requested from an
+	// engine that doesn't checkpoint the committee.
+	errNoSnapshots = errors.New("committee snapshots disabled")
 
 	// errExtraSigners is returned if non-checkpoint block contain signer data in
 	// their extra-data fields.
@@ -160,6 +173,52 @@ var (
 	// errUnauthorizedValidator is returned if a header is signed by a non-authorized entity.
 	errUnauthorizedValidator = errors.New("unauthorized validator")
 
+	// errUnauthorizedVoter is returned if a finality vote is signed by an entity
+	// outside of the committee of the attested block.
+	errUnauthorizedVoter = fmt.Errorf("%w: unauthorized voter", consensus.ErrInvalidVote)
+
+	// errUnexpectedVoter is returned if a finality vote on a block that isn't
+	// known yet is signed by an entity outside of the committee at the local head.
+	errUnexpectedVoter = errors.New("vote on unknown block by non-member")
+
+	// errKnownVote is returned if a finality vote of the same voter on the same
+	// block was already tallied.
+	errKnownVote = errors.New("known vote")
+
+	// errQueuedVote is returned if a finality vote attests a block that isn't
+	// known yet, buffering the vote until the block is imported.
+	errQueuedVote = errors.New("vote on unknown block queued")
+
+	// errMissingSyncHeader is returned if a block doesn't end with the header sync
+	// transaction of its signer.
+	errMissingSyncHeader = errors.New("missing header sync transaction")
+
+	// errMisplacedSyncHeader is returned if a header sync transaction is found
+	// anywhere but at the end of a block.
+	errMisplacedSyncHeader = errors.New("misplaced header sync transaction")
+
+	// errInvalidSyncHeaderSender is returned if the header sync transaction isn't
+	// sent by the signer of the block.
+	errInvalidSyncHeaderSender = errors.New("header sync transaction not sent by block signer")
+
+	// errInvalidSyncHeaderFee is returned if the fee reported by the header sync
+	// transaction doesn't match the fees paid in the block.
+	errInvalidSyncHeaderFee = errors.New("invalid header sync fee")
+
+	// errInvalidSlash is returned if a block slashes a validator which isn't
+	// overdue on its heartbeat.
+	errInvalidSlash = errors.New("slashed validator not overdue")
+
+	// errDuplicateSlash is returned if a block slashes the same validator twice.
+	errDuplicateSlash = errors.New("duplicate slash")
+
+	// errInvalidEvidence is returned if a double-sign evidence doesn't prove that
+	// the reported validator sealed two different blocks of the same height.
+	errInvalidEvidence = errors.New("invalid double-sign evidence")
+
+	// errDuplicateEvidence is returned if a block reports the same validator twice.
+	errDuplicateEvidence = errors.New("duplicate double-sign evidence")
+
 	// errCoinBaseMisMatch is returned if a header's coinbase do not match with signature
 	//errCoinBaseMisMatch = errors.New("coinbase do not match with signature")
 
@@ -171,10 +230,11 @@ var (
 // SignerFn hashes and signs the data to be signed by a backing account.
 type SignerFn func(signer accounts.Account, mimeType string, message []byte) ([]byte, error)
 type SignerTxFn func(accounts.Account, *types.Transaction, *big.Int) (*types.Transaction, error)
-type VrfProveFn func(alpha []byte) (beta, pi []byte, err error)
+type VrfProveFn func(signer accounts.Account, alpha []byte) (beta, pi []byte, err error)
 
-// ecrecover extracts the Ethereum account address from a signed header.
-func ecrecover(header *types.Header, sigcache *lru.ARCCache, chainId *big.Int) ([]byte, common.Address, error) {
+// ecrecover extracts the Ethereum account address from a signed header, whose
+// extra-data carries a vrf proof of the given length.
+func ecrecover(header *types.Header, sigcache *lru.ARCCache, chainId *big.Int, vrfLength int) ([]byte, common.Address, error) {
 	// If the signature's already cached, return that
 	hash := header.Hash()
 	if data, known := sigcache.Get(hash); known {
@@ -190,7 +250,7 @@ func ecrecover(header *types.Header, sigcache *lru.ARCCache, chainId *big.Int) (
 	signature := header.Extra[len(header.Extra)-extraSeal:]
 
 	// Recover the public key and the Ethereum address
-	pubkey, err := crypto.Ecrecover(SealHash(header, chainId).Bytes(), signature)
+	pubkey, err := crypto.Ecrecover(sealHash(header, chainId, vrfLength).Bytes(), signature)
 	if err != nil {
 		return nil, common.Address{}, err
 	}
@@ -210,19 +270,48 @@ type Poseidon struct {
 	db          ethdb.Database // Database to store and retrieve snapshot checkpoints
 
 	beatcache  *lru.Cache
+	recents    *lru.ARCCache // Snapshots for recent block to speed up reorgs
 	signatures *lru.ARCCache // Signatures of recent blocks to speed up mining
 
+	tallies  *lru.Cache                    // Finality vote tallies of recent blocks
+	queued   map[common.Hash][]*queuedVote // Finality votes on blocks not known yet
+	slots    map[voteSlot]struct{}         // Voters and heights of the queued votes
+	nqueued  int                           // Number of finality votes in the queue
+	voteLock sync.Mutex                    // Protects the tallies and the queued votes
+
+	seals        *lru.Cache   // Recently verified sealed headers by signer and number
+	evidence     *lru.Cache   // Double-sign evidences detected in the verified headers
+	evidenceLock sync.RWMutex // Protects the recorded seals and the evidence submission state
+
+	hubCache *hubCache        // Results of the read-only ValidatorHub calls of recent blocks
+	liveness *livenessTracker // Liveness of the committee members over the recent blocks
+
+	slashPeers bool // Whether the heartbeat slashes overdue peers instead of the local validator
+
+	nonces     *lru.Cache                // First winning nonces of the local validator by parent, to skip re-proving on recommits
+	searches   map[nonceKey]*nonceSearch // Winning nonce searches in flight by parent
+	searchLock sync.Mutex                // Protects the winning nonces and their searches
+
 	vrfFn    VrfProveFn
 	signer   types.Signer
 	val      common.Address // Ethereum address of the signing key
 	signFn   SignerFn       // Signer function to authorize hashes with
 	signTxFn SignerTxFn
+	remote   bool         // Whether the blocks are sealed by a remote signer
 	lock     sync.RWMutex // Protects the signer fields
 
+	sealer    *remoteSealer // Sealing work handed over to the remote signer
+	nonceFeed event.Feed    // Nonces selected by the remote signer, to reassemble the block in their slot
+
 	ethAPI    *ethapi.PublicBlockChainAPI
 	txPoolAPI *ethapi.PublicTransactionPoolAPI
+	stateFn   StateFn // On-demand state retrieval of nodes without local state
 
 	validatorSetABI abi.ABI
+	feeABI          abi.ABI
+
+	quit      chan struct{} // Terminates the chain event loop
+	closeOnce sync.Once
 }
 
 // New creates a Spos proof-of-authority consensus engine with the initial
@@ -237,9 +326,14 @@ func New(
 	poseidonConfig := chainConfig.Poseidon
 
 	// Allocate the snapshot caches and create the engine
+	recents, _ := lru.NewARC(inmemorySnapshots)
 	signatures, _ := lru.NewARC(inmemorySignatures)
 
-	vABI, err := abi.JSON(strings.NewReader(validatorSetABI))
+	vABI, err := abi.JSON(strings.NewReader(ValidatorHubABI))
+	if err != nil {
+		panic(err)
+	}
+	feeABI, err := abi.JSON(strings.NewReader(FeeGovernanceABI))
 	if err != nil {
 		panic(err)
 	}
@@ -247,28 +341,66 @@ func New(
 	if err != nil {
 		panic(err)
 	}
+	tallies, _ := lru.New(inmemoryTallies)
+	seals, _ := lru.New(inmemorySeals)
+	evidence, _ := lru.New(inmemoryEvidence)
+	nonces, _ := lru.New(inmemoryNonces)
 
-	return &Poseidon{
+	p := &Poseidon{
 		chainConfig:     chainConfig,
 		config:          poseidonConfig,
 		genesisHash:     genesisHash,
 		db:              db,
 		ethAPI:          ethAPI,
+		recents:         recents,
 		signatures:      signatures,
+		tallies:         tallies,
+		queued:          make(map[common.Hash][]*queuedVote),
+		slots:           make(map[voteSlot]struct{}),
+		seals:           seals,
+		evidence:        evidence,
+		nonces:          nonces,
+		searches:        make(map[nonceKey]*nonceSearch),
 		validatorSetABI: vABI,
+		feeABI:          feeABI,
 		signer:          types.NewEIP155Signer(chainConfig.ChainID),
 		beatcache:       beatCache,
+		hubCache:        newHubCache(),
+		liveness:        newLivenessTracker(),
+		quit:            make(chan struct{}),
 	}
+	p.sealer = startRemoteSealer(p)
+	return p
 }
 
 func (p *Poseidon) SetTxPoolAPI(txPoolAPI *ethapi.PublicTransactionPoolAPI) {
 	p.txPoolAPI = txPoolAPI
 }
 
+// StateFn retrieves the state of a block on demand, e.g. from the network.
+type StateFn func(ctx context.Context, header *types.Header) *state.StateDB
+
+// SetStateFn sets the on-demand state retrieval used by nodes without local
+// state, like light clients, to verify the governed base fee of the headers.
+func (p *Poseidon) SetStateFn(stateFn StateFn) {
+	p.stateFn = stateFn
+}
+
+// vrfLength returns the length of the vrf proof in the extra-data of the block
+// with the given number.
+func (c *Poseidon) vrfLength(number *big.Int) int {
+	return int(c.config.ParamsAt(number).VrfLength)
+}
+
+// hubAt returns the address of the ValidatorHub contract at the given block.
+func (c *Poseidon) hubAt(number *big.Int) common.Address {
+	return systemcontracts.ValidatorHub(c.chainConfig, number)
+}
+
 // Author implements consensus.Engine, returning the Ethereum address recovered
 // from the signature in the header's extra-data section.
 func (c *Poseidon) Author(header *types.Header) (common.Address, error) {
-	_, signer, err := ecrecover(header, c.signatures, c.chainConfig.ChainID)
+	_, signer, err := ecrecover(header, c.signatures, c.chainConfig.ChainID, c.vrfLength(header.Number))
 	return signer, err
 }
 
@@ -277,87 +409,166 @@ func (c *Poseidon) VerifyHeader(chain consensus.ChainHeaderReader, header *types
 	return c.verifyHeader(chain, header, nil, seal)
 }
 
-// VerifyHeaders is similar to VerifyHeader, but verifies a batch of headers. The
-// method returns a quit channel to abort the operations and a results channel to
-// retrieve the async verifications (the order is that of the input slice).
+// VerifyHeaders is similar to VerifyHeader, but verifies a batch of headers
+// concurrently. The method returns a quit channel to abort the operations and
+// a results channel to retrieve the async verifications (the order is that of
+// the input slice).
+//
+// The structural fields and the seal signatures and vrf proofs don't depend on
+// the committee, so they are checked by a pool of workers. The committee rules
+// need the snapshot of the parent, so they are checked in order afterwards.
 func (c *Poseidon) VerifyHeaders(chain consensus.ChainHeaderReader, headers []*types.Header, seals []bool) (chan<- struct{}, <-chan error) {
-	abort := make(chan struct{})
-	results := make(chan error, len(headers))
-
+	abort, results := make(chan struct{}), make(chan error, len(headers))
+	if len(headers) == 0 {
+		return abort, results
+	}
+	// Spawn as many workers as allowed threads
+	workers := runtime.GOMAXPROCS(0)
+	if len(headers) < workers {
+		workers = len(headers)
+	}
+	// Create a task channel and spawn the verifiers
+	var (
+		inputs = make(chan int)
+		done   = make(chan int, workers)
+		errs   = make([]error, len(headers))
+		proofs = make([]*sealProof, len(headers))
+	)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for index := range inputs {
+				proofs[index], errs[index] = c.verifyHeaderWorker(chain, headers, seals, index)
+				done <- index
+			}
+		}()
+	}
 	go func() {
-		for i, header := range headers {
-			err := c.verifyHeader(chain, header, headers[:i], seals[i])
-
+		defer close(inputs)
+		var (
+			in, out = 0, 0
+			checked = make([]bool, len(headers))
+			inputs  = inputs
+		)
+		for {
 			select {
+			case inputs <- in:
+				if in++; in == len(headers) {
+					// Reached end of headers. Stop sending to workers.
+					inputs = nil
+				}
+			case index := <-done:
+				for checked[index] = true; checked[out]; out++ {
+					err := errs[out]
+					if err == nil {
+						err = c.verifyCommittee(chain, headers[out], headers[:out], proofs[out])
+					}
+					results <- err
+					if out == len(headers)-1 {
+						return
+					}
+				}
 			case <-abort:
 				return
-			case results <- err:
 			}
 		}
 	}()
 	return abort, results
 }
 
-// verifyHeader checks whether a header conforms to the consensus rules.The
+// verifyHeaderWorker runs the committee independent checks of a single header
+// in a batch.
+func (c *Poseidon) verifyHeaderWorker(chain consensus.ChainHeaderReader, headers []*types.Header, seals []bool, index int) (*sealProof, error) {
+	return c.verifyStructure(chain, headers[index], headers[:index], seals[index])
+}
+
+// verifyHeader checks whether a header conforms to the consensus rules. The
 // caller may optionally pass in a batch of parents (ascending order) to avoid
-// looking those up from the database. This is useful for concurrently verifying
-// a batch of new headers.
+// looking those up from the database.
 func (c *Poseidon) verifyHeader(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header, seal bool) error {
+	proof, err := c.verifyStructure(chain, header, parents, seal)
+	if err != nil {
+		return err
+	}
+	return c.verifyCommittee(chain, header, parents, proof)
+}
+
+// verifyStructure checks the header fields that don't depend on the committee,
+// and if requested the seal signature and vrf proof. The caller may optionally
+// pass in a batch of parents (ascending order) to avoid looking those up from
+// the database. This is useful for concurrently verifying a batch of new headers.
+func (c *Poseidon) verifyStructure(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header, seal bool) (*sealProof, error) {
 	if header.Number == nil {
-		return errUnknownBlock
+		return nil, errUnknownBlock
 	}
 	number := header.Number.Uint64()
 
 	// Don't waste time checking blocks from the future
 	if header.Time > uint64(time.Now().Unix()) {
-		return consensus.ErrFutureBlock
+		return nil, consensus.ErrFutureBlock
 	}
 
 	// Check that the extra-data contains both the vanity and signature
 	if len(header.Extra) < extraVanity {
-		return errMissingVanity
+		return nil, errMissingVanity
 	}
 	if len(header.Extra) < extraVanity+extraSeal {
-		return errMissingSignature
-	}
-	if len(header.Extra) < extraVanity+extraSeal+extraVrf {
-		return errMissingVrf
+		return nil, errMissingSignature
+	}
+	vrfLength := c.vrfLength(header.Number)
+	if len(header.Extra) < extraVanity+extraSeal+vrfLength {
+		return nil, errMissingVrf
+	}
+	// Ensure that the extra-data contains a committee on checkpoint, but none otherwise
+	if c.config.IsCheckpointing(header.Number) {
+		validatorsBytes := len(header.Extra) - extraVanity - vrfLength - extraSeal
+		checkpoint := c.config.IsCheckpoint(header.Number)
+		if !checkpoint && validatorsBytes != 0 {
+			return nil, errExtraSigners
+		}
+		if checkpoint && validatorsBytes%validatorBytesLength != 0 {
+			return nil, errInvalidCheckpointSigners
+		}
 	}
-	// Ensure that the extra-data contains a signer list on checkpoint, but none otherwise
-	//signersBytes := len(header.Extra) - extraVanity - extraSeal
 
 	// Ensure that the mix digest is zero as we don't have fork protection currently
 	if header.MixDigest != (common.Hash{}) {
-		return errInvalidMixDigest
+		return nil, errInvalidMixDigest
 	}
 	// Ensure that the block doesn't contain any uncles which are meaningless in PoA
 	if header.UncleHash != uncleHash {
-		return errInvalidUncleHash
+		return nil, errInvalidUncleHash
 	}
 	// Ensure that the block's difficulty is meaningful (may not be correct at this point)
 	if number > 0 {
 		if header.Difficulty == nil {
-			return errInvalidDifficulty
+			return nil, errInvalidDifficulty
 		}
 	}
 	// Verify that the gas limit is <= 2^63-1
 	cap := uint64(0x7fffffffffffffff)
 	if header.GasLimit > cap {
-		return fmt.Errorf("invalid gasLimit: have %v, max %v", header.GasLimit, cap)
+		return nil, fmt.Errorf("invalid gasLimit: have %v, max %v", header.GasLimit, cap)
 	}
 	// If all checks passed, validate any special fields for hard forks
 	if err := misc.VerifyForkHashes(chain.Config(), header, false); err != nil {
-		return err
+		return nil, err
 	}
 	// All basic checks passed, verify cascading fields
-	return c.verifyCascadingFields(chain, header, parents, seal)
+	if err := c.verifyCascadingFields(chain, header, parents); err != nil {
+		return nil, err
+	}
+	// If requested, verify the seal signature and vrf proof
+	if !seal || number == 0 {
+		return nil, nil
+	}
+	return c.verifySealProof(header)
 }
 
 // verifyCascadingFields verifies all the header fields that are not standalone,
 // rather depend on a batch of previous headers. The caller may optionally pass
 // in a batch of parents (ascending order) to avoid looking those up from the
 // database. This is useful for concurrently verifying a batch of new headers.
-func (c *Poseidon) verifyCascadingFields(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header, seal bool) error {
+func (c *Poseidon) verifyCascadingFields(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header) error {
 	// The genesis block is the always valid dead-end
 	number := header.Number.Uint64()
 	if number == 0 {
@@ -373,7 +584,14 @@ func (c *Poseidon) verifyCascadingFields(chain consensus.ChainHeaderReader, head
 	if parent == nil || parent.Number.Uint64() != number-1 || parent.Hash() != header.ParentHash {
 		return consensus.ErrUnknownAncestor
 	}
-	if parent.Time+c.config.Period > header.Time {
+	// Ensure that the block's timestamp isn't before the slot of its nonce, or
+	// before the end of the period ahead of the slot fork
+	period := c.config.ParamsAt(header.Number).Period
+	if c.config.IsSlot(header.Number) {
+		if start, ok := slotStart(parent.Time, period, header.Nonce.Uint64()); !ok || start > header.Time {
+			return errInvalidTimestamp
+		}
+	} else if parent.Time+period > header.Time {
 		return errInvalidTimestamp
 	}
 	// Verify that the gasUsed is <= gasLimit
@@ -388,21 +606,266 @@ func (c *Poseidon) verifyCascadingFields(chain consensus.ChainHeaderReader, head
 		if err := misc.VerifyGaslimit(parent.GasLimit, header.GasLimit); err != nil {
 			return err
 		}
-	} else if err := misc.VerifyEip1559Header(chain.Config(), parent, header); err != nil {
-		// Verify the header's EIP-1559 attributes.
-		return err
+	} else {
+		// Verify the header's EIP-1559 attributes against the governed base fee
+		// parameters. If the parent state isn't available yet (batch imports), the
+		// check is deferred to the block processing.
+		feeParams, err := c.BaseFeeParams(chain, parent, nil)
+		if isStateUnavailable(err) {
+			log.Debug("Deferring base fee verification", "number", number, "err", err)
+			if header.BaseFee == nil {
+				return fmt.Errorf("header is missing baseFee")
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := misc.VerifyEip1559HeaderWithParams(chain.Config(), feeParams, parent, header); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
+// verifyCommittee verifies the header fields that depend on the committee of the
+// parent block: the checkpoint committee list and, if the seal was checked, the
+// proposer's eligibility, difficulty and sortition. The caller may optionally pass
+// in a batch of parents (ascending order) to avoid looking those up from the
+// database.
+func (c *Poseidon) verifyCommittee(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header, proof *sealProof) error {
+	// The genesis block is the always valid dead-end
+	number := header.Number.Uint64()
+	if number == 0 {
+		return nil
+	}
+	// If the block is a checkpoint block, verify the committee list against the
+	// ValidatorHub state, if we have it.
+	if c.config.IsCheckpoint(header.Number) {
+		if err := c.verifyCheckpoint(chain, header, parents); err != nil {
+			return err
+		}
+	}
 	// All basic checks passed, verify the seal and return
-	if seal == true {
-		if err := c.verifySeal(chain, header, parents); err != nil {
+	if proof != nil {
+		if err := c.verifySealCommittee(chain, header, parents, proof); err != nil {
 			log.Warn("Poseidon verifySeal fail", "number", header.Number, "err", err)
 			return err
 		}
+		c.recordSeal(header, proof.signer)
 	}
 	return nil
 }
 
+// verifyCheckpoint checks that the committee carried by a checkpoint header is
+// the one the ValidatorHub contract elected at its parent. If the parent state
+// isn't available yet (batch imports), the check is deferred to the block
+// processing, see VerifyCheckpoint. Nodes never processing the block (light
+// clients, snap sync) trust the signed checkpoint instead.
+func (c *Poseidon) verifyCheckpoint(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header) error {
+	validators, err := decodeCheckpoint(header, c.vrfLength(header.Number))
+	if err != nil {
+		return err
+	}
+	if chain.GetHeader(header.ParentHash, header.Number.Uint64()-1) == nil {
+		log.Debug("Deferring checkpoint committee check", "number", header.Number, "err", consensus.ErrUnknownAncestor)
+		return nil
+	}
+	proposers, err := c.proposers(c.hubCallerAt(header.Number, rpc.BlockNumberOrHashWithHash(header.ParentHash, false)))
+	if isStateUnavailable(err) {
+		log.Debug("Deferring checkpoint committee check", "number", header.Number, "err", err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return c.compareCheckpoint(chain, header, parents, validators, proposers)
+}
+
+// VerifyCheckpoint implements consensus.PoSA, checking the committee carried by
+// a checkpoint header against the ValidatorHub state of its parent, which the
+// block is about to be processed on top of.
+func (c *Poseidon) VerifyCheckpoint(chain consensus.ChainHeaderReader, header *types.Header, statedb *state.StateDB) error {
+	if !c.config.IsCheckpoint(header.Number) || header.Number.Sign() == 0 {
+		return nil
+	}
+	validators, err := decodeCheckpoint(header, c.vrfLength(header.Number))
+	if err != nil {
+		return err
+	}
+	proposers, err := c.proposers(c.hubCallerState(chain, header, statedb.Copy()))
+	if err != nil {
+		return err
+	}
+	return c.compareCheckpoint(chain, header, nil, validators, proposers)
+}
+
+// compareCheckpoint checks the committee carried by a checkpoint header against
+// the proposers elected by the ValidatorHub at its parent.
+func (c *Poseidon) compareCheckpoint(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header, validators []checkpointValidator, proposers []checkpointValidator) error {
+	expected, err := c.trackLastBlocks(chain, header, parents, proposers)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(encodeCheckpoint(validators), encodeCheckpoint(expected)) {
+		return errMismatchingCheckpointSigners
+	}
+	return nil
+}
+
+// checkpointValidators assembles the committee a checkpoint header has to carry:
+// the proposers elected by the ValidatorHub at the parent state along with the
+// last sealed block tracked by the parent snapshot.
+func (c *Poseidon) checkpointValidators(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header) ([]checkpointValidator, error) {
+	validators, err := c.GetProposers(header.Number)
+	if err != nil {
+		return nil, err
+	}
+	return c.trackLastBlocks(chain, header, parents, validators)
+}
+
+// trackLastBlocks replaces the last sealed blocks of the given proposers with the
+// ones tracked by the parent snapshot of the header. The first checkpoint has no
+// parent snapshot, so the ValidatorHub heights are kept.
+func (c *Poseidon) trackLastBlocks(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header, validators []checkpointValidator) ([]checkpointValidator, error) {
+	if !c.snapshotCommittee(header.Number) {
+		return validators, nil
+	}
+	number := header.Number.Uint64()
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, parents)
+	if err != nil {
+		return nil, err
+	}
+	for i, validator := range validators {
+		if tracked, ok := snap.Validators[validator.Address]; ok {
+			validators[i].LastBlockHeight = tracked.LastBlockHeight
+		}
+	}
+	return validators, nil
+}
+
+// snapshotCommittee reports whether the committee allowed to propose the block
+// with the given number is tracked by the snapshot of its parent, rather than
+// read from the ValidatorHub state.
+func (c *Poseidon) snapshotCommittee(number *big.Int) bool {
+	return number.Sign() > 0 && c.config.IsCheckpointing(new(big.Int).Sub(number, common.Big1))
+}
+
+// snapshot retrieves the committee snapshot at a given point in time.
+func (c *Poseidon) snapshot(chain consensus.ChainHeaderReader, number uint64, hash common.Hash, parents []*types.Header) (*Snapshot, error) {
+	if !c.config.IsCheckpointing(new(big.Int).SetUint64(number)) {
+		return nil, errNoSnapshots
+	}
+	// Search for a snapshot in memory or on disk for checkpoints
+	var (
+		headers []*types.Header
+		snap    *Snapshot
+		start   = c.config.CheckpointStart()
+	)
+	for snap == nil {
+		// If an in-memory snapshot was found, use that
+		if s, ok := c.recents.Get(hash); ok {
+			snap = s.(*Snapshot)
+			break
+		}
+		// If an on-disk checkpoint snapshot can be found, use that
+		if number%checkpointInterval == 0 {
+			if s, err := loadSnapshot(c.config, c.signatures, c.chainConfig.ChainID, c.db, hash); err == nil {
+				log.Trace("Loaded committee snapshot from disk", "number", number, "hash", hash)
+				snap = s
+				break
+			}
+		}
+		// If we're at the first checkpoint, snapshot the initial state. Alternatively
+		// if we're at a checkpoint block without a parent (light client CHT), or we
+		// have piled up more headers than allowed to be reorged (chain reinit from a
+		// freezer), consider the checkpoint trusted and snapshot it.
+		if number == start || (c.config.IsCheckpoint(new(big.Int).SetUint64(number)) && (len(headers) > params.FullImmutabilityThreshold || chain.GetHeaderByNumber(number-1) == nil)) {
+			checkpoint := chain.GetHeaderByNumber(number)
+			if checkpoint != nil {
+				hash := checkpoint.Hash()
+
+				validators, err := decodeCheckpoint(checkpoint, c.vrfLength(checkpoint.Number))
+				if err != nil {
+					return nil, err
+				}
+				snap = newSnapshot(c.config, c.signatures, c.chainConfig.ChainID, number, hash, validators)
+				if err := snap.store(c.db); err != nil {
+					return nil, err
+				}
+				log.Info("Stored checkpoint snapshot to disk", "number", number, "hash", hash)
+				break
+			}
+		}
+		// No snapshot for this header, gather the header and move backward
+		var header *types.Header
+		if len(parents) > 0 {
+			// If we have explicit parents, pick from there (enforced)
+			header = parents[len(parents)-1]
+			if header.Hash() != hash || header.Number.Uint64() != number {
+				return nil, consensus.ErrUnknownAncestor
+			}
+			parents = parents[:len(parents)-1]
+		} else {
+			// No explicit parents (or no more left), reach out to the database
+			header = chain.GetHeader(hash, number)
+			if header == nil {
+				return nil, consensus.ErrUnknownAncestor
+			}
+		}
+		headers = append(headers, header)
+		number, hash = number-1, header.ParentHash
+	}
+	// Previous snapshot found, apply any pending headers on top of it
+	for i := 0; i < len(headers)/2; i++ {
+		headers[i], headers[len(headers)-1-i] = headers[len(headers)-1-i], headers[i]
+	}
+	snap, err := snap.apply(headers)
+	if err != nil {
+		return nil, err
+	}
+	c.recents.Add(snap.Hash, snap)
+
+	// If we've generated a new checkpoint snapshot, save to disk
+	if snap.Number%checkpointInterval == 0 && len(headers) > 0 {
+		if err = snap.store(c.db); err != nil {
+			return nil, err
+		}
+		log.Trace("Stored committee snapshot to disk", "number", snap.Number, "hash", snap.Hash)
+	}
+	return snap, err
+}
+
+// proposerInfo retrieves the sortition inputs of the signer for the given header,
+// its stake and last sealed block along with the committee supply. If the engine
+// checkpoints the committee, they are derived from the parent snapshot, otherwise
+// the ValidatorHub state of the parent block is queried.
+func (c *Poseidon) proposerInfo(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header, signer common.Address) (*ValidatorInfo, *big.Int, error) {
+	if c.snapshotCommittee(header.Number) {
+		snap, err := c.snapshot(chain, header.Number.Uint64()-1, header.ParentHash, parents)
+		if err != nil {
+			return nil, nil, err
+		}
+		info := snap.validatorInfo(signer)
+		if info == nil {
+			return nil, nil, errUnauthorizedProposer
+		}
+		return info, snap.committeeSupply(), nil
+	}
+	if isProposer, err := c.IsProposer(signer, header.Number); err != nil || isProposer == false {
+		return nil, nil, errUnauthorizedProposer
+	}
+	info, err := c.GetValidatorInfo(signer, header.Number)
+	if err != nil {
+		return nil, nil, err
+	}
+	committeeSupply, err := c.GetCommitteeSupply(header.Number, signer)
+	if err != nil {
+		return nil, nil, err
+	}
+	return info, committeeSupply, nil
+}
+
 // VerifyUncles implements consensus.Engine, always returning an error for any
 // uncles as this consensus mechanism doesn't permit uncles.
 func (c *Poseidon) VerifyUncles(chain consensus.ChainReader, block *types.Block) error {
@@ -417,44 +880,50 @@ func (c *Poseidon) VerifyUncles(chain consensus.ChainReader, block *types.Block)
 // headers that aren't yet part of the local blockchain to generate the snapshots
 // from.
 func (c *Poseidon) verifySeal(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header) error {
-	// Verifying the genesis block is not supported
-	number := header.Number.Uint64()
-	if number == 0 {
-		return errUnknownBlock
-	}
-
-	// Resolve the authorization key and check against signers
-	pubkey, signer, err := ecrecover(header, c.signatures, c.chainConfig.ChainID)
+	proof, err := c.verifySealProof(header)
 	if err != nil {
 		return err
 	}
-	if isProposer, err := c.IsProposer(signer, header.Number); err != nil || isProposer == false {
-		return errUnauthorizedProposer
+	return c.verifySealCommittee(chain, header, parents, proof)
+}
+
+// sealProof is the committee independent part of a verified seal.
+type sealProof struct {
+	signer common.Address // Address recovered from the seal signature
+	beta   []byte         // Output of the verified vrf proof
+}
+
+// verifySealProof recovers the signer of the header and verifies its vrf proof.
+// Neither depends on the committee, so it's safe to run concurrently.
+func (c *Poseidon) verifySealProof(header *types.Header) (*sealProof, error) {
+	// Verifying the genesis block is not supported
+	number := header.Number.Uint64()
+	if number == 0 {
+		return nil, errUnknownBlock
 	}
-	info, err := c.GetValidatorInfo(signer, header.Number)
+	// Resolve the authorization key and verify the vrf proof with it
+	pubkey, signer, err := ecrecover(header, c.signatures, c.chainConfig.ChainID, c.vrfLength(header.Number))
 	if err != nil {
-		return err
+		return nil, err
 	}
-	committeeSupply, err := c.GetCommitteeSupply(header.Number, signer)
+	beta, err := c.verifyVrf(header, pubkey)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	pi := make([]byte, extraVrf)
-	copy(pi, header.Extra[len(header.Extra)-extraSeal-extraVrf:len(header.Extra)-extraSeal])
+	return &sealProof{signer: signer, beta: beta}, nil
+}
 
-	alpha := c.GetVrfAlpha(header.ParentHash, header.Nonce)
-	publicKey, err := crypto.UnmarshalPubkey(pubkey)
-	if err != nil {
-		return err
-	}
-	beta, err := vrf.Verify(publicKey, alpha, pi)
+// verifySealCommittee checks that the signer of a verified seal was a proposer
+// of the parent committee, won the sortition and set the right difficulty.
+func (c *Poseidon) verifySealCommittee(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header, proof *sealProof) error {
+	info, committeeSupply, err := c.proposerInfo(chain, header, parents, proof.signer)
 	if err != nil {
 		return err
 	}
-	if err := c.checkDifficulty(chain, header, info, beta); err != nil {
+	if err := c.checkDifficulty(chain, header, info, proof.beta); err != nil {
 		return err
 	}
-	if c.verifySort(info.TotalSupply, committeeSupply, header.Number, beta) == false {
+	if c.verifySort(info.TotalSupply, committeeSupply, header.Number, proof.beta) == false {
 		return errUnauthorizedSigner
 	}
 	return nil
@@ -483,36 +952,243 @@ func (c *Poseidon) Prepare(chain consensus.ChainHeaderReader, header *types.Head
 	if parent == nil {
 		return consensus.ErrUnknownAncestor
 	}
-	header.Time = parent.Time + c.config.Period
-	if header.Time < uint64(time.Now().Unix()) {
-		header.Time = uint64(time.Now().Unix())
-	}
-
 	info, err := c.GetValidatorInfo(c.val, header.Number)
 	if err != nil {
 		return err
 	}
 	header.Coinbase = info.RewardAddr
 
-	header.Extra = append(header.Extra, make([]byte, extraVrf+extraSeal)...)
+	// From the slot fork on, schedule the block in the first nonce slot the local
+	// validator wins, the sortition doesn't depend on the block contents. Before
+	// it, the nonce is picked when sealing.
+	period := c.config.ParamsAt(header.Number).Period
+	if c.config.IsSlot(header.Number) {
+		nonce, err := c.firstWinningNonce(chain, header)
+		if err != nil {
+			return err
+		}
+		header.Nonce = types.EncodeNonce(nonce)
+		header.Time, _ = slotStart(parent.Time, period, nonce)
+	} else {
+		header.Time = parent.Time + period
+	}
+	if header.Time < uint64(time.Now().Unix()) {
+		header.Time = uint64(time.Now().Unix())
+	}
+
+	// Announce the committee on checkpoint blocks
+	if c.config.IsCheckpoint(header.Number) {
+		validators, err := c.checkpointValidators(chain, header, nil)
+		if err != nil {
+			return err
+		}
+		header.Extra = append(header.Extra, encodeCheckpoint(validators)...)
+	}
+	header.Extra = append(header.Extra, make([]byte, c.vrfLength(header.Number)+extraSeal)...)
 	header.Difficulty = common.Big0
 	return nil
 }
 
+// nonceKey identifies the sortition of the local validator on top of a parent.
+type nonceKey struct {
+	parent common.Hash
+	signer common.Address
+}
+
+// nonceSearch is a search of the first winning nonce on top of a parent, which
+// may still be in flight.
+type nonceSearch struct {
+	done  chan struct{} // Closed once the search finished
+	nonce uint64
+	err   error
+}
+
+// firstWinningNonce returns the first nonce of the retry window the local
+// validator wins the sortition of the header with, or 0 if it can't seal it at
+// all, in which case the seal is refused later on. The result only depends on
+// the parent, so it's searched for in the background as soon as the parent
+// becomes the head, and cached for the recommits of the same block.
+//
+// Remote signers select the nonce themselves over poseidon_submitNonce, until
+// then the block is prepared for the first nonce slot.
+func (c *Poseidon) firstWinningNonce(chain consensus.ChainHeaderReader, header *types.Header) (uint64, error) {
+	c.lock.RLock()
+	signer, vrfFn := c.val, c.vrfFn
+	c.lock.RUnlock()
+
+	if vrfFn == nil {
+		if nonce, ok := c.nonces.Get(nonceKey{parent: header.ParentHash, signer: signer}); ok {
+			return nonce.(uint64), nil
+		}
+		return 0, nil
+	}
+	search := c.searchNonce(chain, header, signer, vrfFn)
+	select {
+	case <-search.done:
+		return search.nonce, search.err
+	case <-c.quit:
+		return 0, errPoseidonStopped
+	}
+}
+
+// precomputeNonce starts searching the first winning nonce of the local
+// validator on top of the new head, so that preparing the next block doesn't
+// prove the retry window on the commit path.
+func (c *Poseidon) precomputeNonce(chain consensus.ChainHeaderReader, head *types.Header) {
+	c.lock.RLock()
+	signer, vrfFn, remote := c.val, c.vrfFn, c.remote
+	c.lock.RUnlock()
+
+	if vrfFn == nil || remote {
+		return
+	}
+	// Before the slot fork the nonce is retried when sealing, nothing to search
+	next := &types.Header{ParentHash: head.Hash(), Number: new(big.Int).Add(head.Number, common.Big1)}
+	if !c.config.IsSlot(next.Number) {
+		return
+	}
+	c.searchNonce(chain, next, signer, vrfFn)
+}
+
+// searchNonce returns the search of the first winning nonce of the signer on
+// top of the parent of the header, starting it if it's neither cached nor in
+// flight.
+func (c *Poseidon) searchNonce(chain consensus.ChainHeaderReader, header *types.Header, signer common.Address, vrfFn VrfProveFn) *nonceSearch {
+	key := nonceKey{parent: header.ParentHash, signer: signer}
+
+	c.searchLock.Lock()
+	defer c.searchLock.Unlock()
+
+	if search, ok := c.searches[key]; ok {
+		return search
+	}
+	search := &nonceSearch{done: make(chan struct{})}
+	if nonce, ok := c.nonces.Get(key); ok {
+		search.nonce = nonce.(uint64)
+		close(search.done)
+		return search
+	}
+	c.searches[key] = search
+
+	go func() {
+		nonce, err := c.searchWinningNonce(chain, header, signer, vrfFn)
+
+		c.searchLock.Lock()
+		if err == nil {
+			c.nonces.Add(key, nonce)
+		}
+		delete(c.searches, key)
+		c.searchLock.Unlock()
+
+		search.nonce, search.err = nonce, err
+		close(search.done)
+	}()
+	return search
+}
+
+// searchWinningNonce proves the nonces of the retry window in order until the
+// signer wins the sortition of the header, returning 0 if it wins none.
+func (c *Poseidon) searchWinningNonce(chain consensus.ChainHeaderReader, header *types.Header, signer common.Address, vrfFn VrfProveFn) (uint64, error) {
+	info, committeeSupply, err := c.proposerInfo(chain, header, nil, signer)
+	if err == errUnauthorizedProposer {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	window := c.config.ParamsAt(header.Number).NonceSignSize
+	for nonce := uint64(0); nonce < window; nonce++ {
+		beta, _, err := vrfFn(accounts.Account{Address: signer}, c.GetVrfAlpha(header.ParentHash, types.EncodeNonce(nonce)))
+		if err != nil {
+			return 0, err
+		}
+		if c.verifySort(info.TotalSupply, committeeSupply, header.Number, beta) {
+			return nonce, nil
+		}
+	}
+	return 0, nil
+}
+
+// slotLength returns the length in seconds of the time window of each nonce
+// retry on a chain with the given period. 0-period chains don't wait between
+// retries.
+func slotLength(period uint64) uint64 {
+	return (period + 1) / 2
+}
+
+// slotStart returns the earliest timestamp of a block sealed with the given nonce
+// on top of a parent with the given timestamp: the first slot starts one period
+// after the parent, and every retry takes one more slot. It reports false if the
+// timestamp overflows.
+func slotStart(parentTime uint64, period uint64, nonce uint64) (uint64, bool) {
+	start, slot := parentTime+period, slotLength(period)
+	if slot > 0 && nonce > (math.MaxUint64-start)/slot {
+		return 0, false
+	}
+	return start + nonce*slot, true
+}
+
 func (c *Poseidon) verifySort(money *big.Int, totalMoney *big.Int, blockNumber *big.Int, vrfOutput []byte) bool {
-	expectedSize := vrfExpectedSize
+	return c.sortitionWeight(money, totalMoney, blockNumber, vrfOutput) > 0
+}
+
+// sortitionWeight returns the number of times a validator holding money out of
+// totalMoney was selected by the sortition of the given block for the vrf output.
+// Blocks before the sortition fork keep being verified with the floating point
+// sortition they were sealed with.
+func (c *Poseidon) sortitionWeight(money *big.Int, totalMoney *big.Int, blockNumber *big.Int, vrfOutput []byte) uint64 {
+	expectedSize := c.config.ParamsAt(blockNumber).ExpectedSize
 	if money.Cmp(totalMoney) >= 0 {
 		expectedSize = 1
 	}
-	return vrf.VerifySort(new(big.Int).Div(money, ether).Uint64(), new(big.Int).Div(totalMoney, ether).Uint64(), expectedSize, vrfOutput)
+	selectSort := vrf.SelectSortFloat
+	if c.config.IsSortition(blockNumber) {
+		selectSort = vrf.SelectSort
+	}
+	return selectSort(new(big.Int).Div(money, ether).Uint64(), new(big.Int).Div(totalMoney, ether).Uint64(), expectedSize, vrfOutput)
+}
+
+// vrfProof retrieves the vrf proof of the given length from the header extra-data.
+func vrfProof(header *types.Header, vrfLength int) ([]byte, error) {
+	if len(header.Extra) < extraVanity+extraSeal+vrfLength {
+		return nil, errMissingVrf
+	}
+	pi := make([]byte, vrfLength)
+	copy(pi, header.Extra[len(header.Extra)-extraSeal-vrfLength:len(header.Extra)-extraSeal])
+	return pi, nil
+}
+
+// verifyVrf checks the vrf proof of the header against the signer's public key
+// and returns the vrf output.
+func (c *Poseidon) verifyVrf(header *types.Header, pubkey []byte) ([]byte, error) {
+	pi, err := vrfProof(header, c.vrfLength(header.Number))
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := crypto.UnmarshalPubkey(pubkey)
+	if err != nil {
+		return nil, err
+	}
+	return vrf.Verify(publicKey, c.GetVrfAlpha(header.ParentHash, header.Nonce), pi)
 }
 
 // Finalize implements consensus.Engine, ensuring no uncles are set, nor block
-// rewards given.
+// rewards given. From the fee distribution fork on, the fees are credited to the
+// signer's reward contract, rejecting the block if that fails.
 func (c *Poseidon) Finalize(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, txs []*types.Transaction,
-	uncles []*types.Header) {
+	uncles []*types.Header, receipts []*types.Receipt) error {
+	if header.Number.Sign() > 0 && c.config.IsFeeDistribution(header.Number) {
+		signer, err := c.Author(header)
+		if err != nil {
+			return err
+		}
+		if err := c.distributeFees(chain, header, state, signer, txs, receipts); err != nil {
+			return err
+		}
+	}
 	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
 	header.UncleHash = types.CalcUncleHash(nil)
+	return nil
 }
 
 // FinalizeAndAssemble implements consensus.Engine, ensuring no uncles are set,
@@ -530,6 +1206,16 @@ func (c *Poseidon) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header
 	if header.GasLimit < header.GasUsed {
 		panic("Gas consumption of system txs exceed the gas limit")
 	}
+	// The block isn't sealed yet, the local validator is going to sign it
+	c.lock.RLock()
+	signer := c.val
+	c.lock.RUnlock()
+
+	if c.config.IsFeeDistribution(header.Number) {
+		if err := c.distributeFees(chain, header, state, signer, txs, receipts); err != nil {
+			return nil, err
+		}
+	}
 	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
 	header.UncleHash = types.CalcUncleHash(nil)
 
@@ -547,6 +1233,27 @@ func (c *Poseidon) Authorize(val common.Address, signFn SignerFn, signTxFn Signe
 	c.signFn = signFn
 	c.vrfFn = vrfFn
 	c.signTxFn = signTxFn
+	c.remote = false
+}
+
+// AuthorizeRemote sets the validator to mint new blocks for, leaving their vrf
+// proof and seal to a remote signer holding the key, which fetches the sealing
+// work over poseidon_getSealingWork and submits it over poseidon_submitSeal.
+//
+// The header sync transaction closing every block must be sent by the signer
+// too, so it's still signed locally with signTxFn, e.g. through an external
+// signer. The blocks are prepared for the first nonce slot until the remote
+// signer selects the nonce it wins over poseidon_submitNonce, after which they
+// are reassembled in the slot of that nonce.
+func (c *Poseidon) AuthorizeRemote(val common.Address, signTxFn SignerTxFn) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.val = val
+	c.signFn = nil
+	c.vrfFn = nil
+	c.signTxFn = signTxFn
+	c.remote = true
 }
 
 func (c *Poseidon) GetVrfAlpha(parentHash common.Hash, nonce types.BlockNonce) []byte {
@@ -558,20 +1265,20 @@ func (c *Poseidon) GetVrfAlpha(parentHash common.Hash, nonce types.BlockNonce) [
 
 func (c *Poseidon) sortition(chain consensus.ChainHeaderReader, header *types.Header, info *ValidatorInfo, committeeSupply *big.Int, signer common.Address, signFn SignerFn) (bool, error) {
 	alpha := c.GetVrfAlpha(header.ParentHash, header.Nonce)
-	beta, pi, err := c.vrfFn(alpha)
+	beta, pi, err := c.vrfFn(accounts.Account{Address: signer}, alpha)
 	if err != nil {
 		return false, err
 	}
-	copy(header.Extra[extraVanity:], pi)
+	copy(header.Extra[len(header.Extra)-extraSeal-c.vrfLength(header.Number):], pi)
 
 	if c.verifySort(info.TotalSupply, committeeSupply, header.Number, beta) == false {
 		return false, nil
 	}
 	// Set the correct difficulty
-	header.Difficulty = calcDifficulty(header.Nonce, header.Number, info.TotalSupply, info.LastBlockHeight, beta)
+	header.Difficulty = c.calcDifficulty(header.Nonce, header.Number, info.TotalSupply, info.LastBlockHeight, beta)
 
 	// Sign all the things!
-	sighash, err := signFn(accounts.Account{Address: signer}, accounts.MimetypePoseidon, PoseidonRLP(header, c.chainConfig.ChainID))
+	sighash, err := signFn(accounts.Account{Address: signer}, accounts.MimetypePoseidon, poseidonRLP(header, c.chainConfig.ChainID, c.vrfLength(header.Number)))
 	if err != nil {
 		return false, err
 	}
@@ -580,40 +1287,70 @@ func (c *Poseidon) sortition(chain consensus.ChainHeaderReader, header *types.He
 }
 
 // Seal implements consensus.Engine, attempting to create a sealed block using
-// the local signing credentials.
+// the local signing credentials, or handing it over to the remote signer if the
+// engine is authorized for remote sealing.
 func (c *Poseidon) Seal(chain consensus.ChainHeaderReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) error {
-	if c.vrfFn == nil {
+	// Don't hold the signer fields for the entire sealing procedure
+	c.lock.RLock()
+	signer, signFn, vrfFn, remote := c.val, c.signFn, c.vrfFn, c.remote
+	c.lock.RUnlock()
+
+	if vrfFn == nil && !remote {
 		return errInvalidVrfFn
 	}
 	header := block.Header()
-	if isProposer, err := c.IsProposer(c.val, header.Number); err != nil || isProposer == false {
-		return errUnauthorizedProposer
-	}
-
-	info, err := c.GetValidatorInfo(c.val, header.Number)
-	if err != nil {
-		return err
-	}
-	committeeSupply, err := c.GetCommitteeSupply(header.Number, c.val)
-	if err != nil {
-		return err
-	}
 
 	// Sealing the genesis block is not supported
 	number := header.Number.Uint64()
 	if number == 0 {
 		return errUnknownBlock
 	}
-	// For 0-period chains, refuse to seal empty blocks (no reward but would spin sealing)
-	if c.config.Period == 0 && len(block.Transactions()) == 0 {
+	info, committeeSupply, err := c.proposerInfo(chain, header, nil, signer)
+	if err != nil {
+		return err
+	}
+	// For 0-period chains, refuse to seal empty blocks (no reward but would spin sealing).
+	// Every block carries the system transactions, only count the others.
+	period := c.config.ParamsAt(header.Number).Period
+	if period == 0 && !hasUserTransactions(c.chainConfig, header.Number, block.Transactions()) {
 		log.Info("Sealing paused, waiting for transactions")
 		return nil
 	}
+	// Leave the sortition and the signature to the remote signer if requested
+	if remote {
+		task := &sealTask{chain: chain, block: block, signer: signer, results: results, stop: stop}
+		select {
+		case c.sealer.workCh <- task:
+			return nil
+		case <-c.quit:
+			return errPoseidonStopped
+		}
+	}
+	// Before the slot fork, the nonce is retried while waiting for the block time
+	if !c.config.IsSlot(header.Number) {
+		return c.sealRetrying(chain, block, info, committeeSupply, signer, signFn, results, stop)
+	}
+	// From the slot fork on, the nonce slot was picked when preparing the header,
+	// give up if we don't win its sortition
+	isSeal, err := c.sortition(chain, header, info, committeeSupply, signer, signFn)
+	if err != nil {
+		return err
+	}
+	if !isSeal {
+		log.Debug("Not selected to seal the block", "number", number, "nonce", header.Nonce.Uint64())
+		return nil
+	}
+	// Sweet, the protocol permits us to sign the block, wait for our time
+	c.publish(block.WithSeal(header), results, stop, "local")
+	return nil
+}
 
-	// Don't hold the signer fields for the entire sealing procedure
-	c.lock.RLock()
-	signer, signFn := c.val, c.signFn
-	c.lock.RUnlock()
+// sealRetrying seals a block ahead of the slot fork, bumping the nonce every half
+// period until the local validator wins the sortition. The block is published
+// once its time has come and the sortition is won, polling every second.
+func (c *Poseidon) sealRetrying(chain consensus.ChainHeaderReader, block *types.Block, info *ValidatorInfo, committeeSupply *big.Int, signer common.Address, signFn SignerFn, results chan<- *types.Block, stop <-chan struct{}) error {
+	header := block.Header()
+	period := c.config.ParamsAt(header.Number).Period
 
 	// Sweet, the protocol permits us to sign the block, wait for our time
 	delay := time.Unix(int64(header.Time), 0).Sub(time.Now()) // nolint: gosimple
@@ -622,10 +1359,22 @@ func (c *Poseidon) Seal(chain consensus.ChainHeaderReader, block *types.Block, r
 	if err != nil {
 		return err
 	}
+	// Without a period there's no slot to wait for, retry the nonces of the
+	// retry window right away and only fall back to the timer beyond it
+	for window := c.config.ParamsAt(header.Number).NonceSignSize; period == 0 && !isSeal && header.Nonce.Uint64() < window; {
+		header.Nonce = types.EncodeNonce(header.Nonce.Uint64() + 1)
+		if isSeal, err = c.sortition(chain, header, info, committeeSupply, signer, signFn); err != nil {
+			return err
+		}
+	}
+	retry := time.Duration(period) * time.Second / 2
+	if retry == 0 {
+		retry = time.Second
+	}
 	// Wait until sealing is terminated or delay timeout.
 	log.Trace("Waiting for slot to sign and propagate", "delay", common.PrettyDuration(delay))
 	go func() {
-		vrfTimer := time.NewTicker(time.Duration(c.config.Period/2) * time.Second)
+		vrfTimer := time.NewTicker(retry)
 		defer vrfTimer.Stop()
 		afterTimer := time.NewTimer(delay)
 		defer afterTimer.Stop()
@@ -655,13 +1404,24 @@ func (c *Poseidon) Seal(chain consensus.ChainHeaderReader, block *types.Block, r
 		select {
 		case results <- block.WithSeal(header):
 		default:
-			log.Warn("Sealing result is not read by miner", "sealhash", SealHash(header, c.chainConfig.ChainID))
+			log.Warn("Sealing result is not read by miner", "sealhash", c.SealHash(header))
 		}
 	}()
 
 	return nil
 }
 
+// hasUserTransactions reports whether any of the transactions of the given block
+// isn't a system transaction.
+func hasUserTransactions(config *params.ChainConfig, number *big.Int, txs types.Transactions) bool {
+	for _, tx := range txs {
+		if !systemcontracts.IsSystemTransitionAt(config, number, tx.To(), tx.Data()) {
+			return true
+		}
+	}
+	return false
+}
+
 // CalcDifficulty is the difficulty adjustment algorithm. It returns the difficulty
 // that a new block should have:
 // * DIFF_NOTURN(2) if BLOCK_NUMBER % SIGNER_COUNT != SIGNER_INDEX
@@ -679,23 +1439,30 @@ func (c *Poseidon) CalcDifficulty(chain consensus.ChainHeaderReader, time uint64
 			TotalSupply:     big.NewInt(0),
 		}
 	}
-	return calcDifficulty(nonce, header.Number, info.TotalSupply, info.LastBlockHeight, make([]byte, 32))
+	return c.calcDifficulty(nonce, header.Number, info.TotalSupply, info.LastBlockHeight, make([]byte, 32))
 }
 
 func (c *Poseidon) checkDifficulty(chain consensus.ChainHeaderReader, header *types.Header, info *ValidatorInfo, beta []byte) error {
-	diff := calcDifficulty(header.Nonce, header.Number, info.TotalSupply, info.LastBlockHeight, beta)
+	diff := c.calcDifficulty(header.Nonce, header.Number, info.TotalSupply, info.LastBlockHeight, beta)
 	if diff.Cmp(header.Difficulty) != 0 {
 		return errInvalidDifficulty
 	}
 	return nil
 }
 
+// calcDifficulty computes the difficulty of a block with the nonce retry window
+// in effect at its number.
+func (c *Poseidon) calcDifficulty(blockNonce types.BlockNonce, blockNumber *big.Int, totalSupply *big.Int, lastBlockHeight *big.Int, beta []byte) *big.Int {
+	return calcDifficulty(blockNonce, blockNumber, totalSupply, lastBlockHeight, beta, c.config.ParamsAt(blockNumber).NonceSignSize)
+}
+
 func calcDifficulty(
 	blockNonce types.BlockNonce,
 	blockNumber *big.Int,
 	totalSupply *big.Int,
 	lastBlockHeight *big.Int,
 	beta []byte,
+	nonceSignSize uint64,
 ) *big.Int {
 	nonce := big.NewInt(0) //uint8
 	if blockNonce.Uint64() < nonceSignSize {
@@ -720,11 +1487,12 @@ func calcDifficulty(
 
 // SealHash returns the hash of a block prior to it being sealed.
 func (c *Poseidon) SealHash(header *types.Header) common.Hash {
-	return SealHash(header, c.chainConfig.ChainID)
+	return sealHash(header, c.chainConfig.ChainID, c.vrfLength(header.Number))
 }
 
-// Close implements consensus.Engine. It's a noop for clique as there are no background threads.
+// Close implements consensus.Engine, terminating the chain event loop if any.
 func (c *Poseidon) Close() error {
+	c.closeOnce.Do(func() { close(c.quit) })
 	return nil
 }
 
@@ -739,10 +1507,15 @@ func (c *Poseidon) APIs(chain consensus.ChainHeaderReader) []rpc.API {
 	}}
 }
 
-// SealHash returns the hash of a block prior to it being sealed.
-func SealHash(header *types.Header, chainId *big.Int) (hash common.Hash) {
+// SealHash returns the hash of a block prior to it being sealed, with the vrf
+// proof length configured at its height.
+func SealHash(header *types.Header, chainId *big.Int, config *params.PoseidonConfig) (hash common.Hash) {
+	return sealHash(header, chainId, int(config.ParamsAt(header.Number).VrfLength))
+}
+
+func sealHash(header *types.Header, chainId *big.Int, vrfLength int) (hash common.Hash) {
 	hasher := sha3.NewLegacyKeccak256()
-	encodeSigHeader(hasher, header, chainId)
+	encodeSigHeader(hasher, header, chainId, vrfLength)
 	hasher.Sum(hash[:0])
 	return hash
 }
@@ -754,13 +1527,17 @@ func SealHash(header *types.Header, chainId *big.Int) (hash common.Hash) {
 // Note, the method requires the extra data to be at least 65 bytes, otherwise it
 // panics. This is done to avoid accidentally using both forms (signature present
 // or not), which could be abused to produce different hashes for the same header.
-func PoseidonRLP(header *types.Header, chainId *big.Int) []byte {
+func PoseidonRLP(header *types.Header, chainId *big.Int, config *params.PoseidonConfig) []byte {
+	return poseidonRLP(header, chainId, int(config.ParamsAt(header.Number).VrfLength))
+}
+
+func poseidonRLP(header *types.Header, chainId *big.Int, vrfLength int) []byte {
 	b := new(bytes.Buffer)
-	encodeSigHeader(b, header, chainId)
+	encodeSigHeader(b, header, chainId, vrfLength)
 	return b.Bytes()
 }
 
-func encodeSigHeader(w io.Writer, header *types.Header, chainId *big.Int) {
+func encodeSigHeader(w io.Writer, header *types.Header, chainId *big.Int, vrfLength int) {
 	enc := []interface{}{
 		chainId,
 		header.ParentHash,
@@ -775,7 +1552,7 @@ func encodeSigHeader(w io.Writer, header *types.Header, chainId *big.Int) {
 		header.GasLimit,
 		header.GasUsed,
 		header.Time,
-		header.Extra[:len(header.Extra)-crypto.SignatureLength-extraVrf], // Yes, this will panic if extra is too short
+		header.Extra[:len(header.Extra)-crypto.SignatureLength-vrfLength], // Yes, this will panic if extra is too short
 		header.MixDigest,
 		//header.Nonce,
 	}
@@ -787,7 +1564,25 @@ func encodeSigHeader(w io.Writer, header *types.Header, chainId *big.Int) {
 	}
 }
 
+// SetSlashPeers sets whether the heartbeat slashes the committee members that
+// are overdue according to the tracked liveness, rather than the local validator.
+func (c *Poseidon) SetSlashPeers(enabled bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.slashPeers = enabled
+}
+
+// Heartbeat implements consensus.PoSA, slashing the local validator once it's
+// overdue on its heartbeat, or the overdue peers if requested.
 func (c *Poseidon) Heartbeat(number *big.Int) error {
+	c.lock.RLock()
+	slashPeers := c.slashPeers
+	c.lock.RUnlock()
+
+	if slashPeers {
+		return c.slashOverduePeers(number)
+	}
 	currentHeight := number.Uint64()
 
 	if value, ok := c.beatcache.Peek(c.val); ok {
@@ -807,19 +1602,24 @@ func (c *Poseidon) Heartbeat(number *big.Int) error {
 	}
 	lastBlockHeight := info.LastBlockHeight.Uint64()
 
-	if (currentHeight < lastBlockHeight) || (currentHeight-lastBlockHeight) < heartRate {
+	if (currentHeight < lastBlockHeight) || (currentHeight-lastBlockHeight) < c.config.ParamsAt(number).HeartRate {
 		return nil
 	}
+	if err := c.sendSlash(number, c.val); err != nil {
+		return err
+	}
+	c.beatcache.Add(c.val, number)
 
-	// method
-	method := "slash"
+	return nil
+}
 
+// sendSlash sends a slash transaction of the given validator from the local one
+// to the ValidatorHub of the given block.
+func (c *Poseidon) sendSlash(number *big.Int, validator common.Address) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel() // cancel when we are finished consuming integers
 
-	//ctx := context.WithValue(context.Background(), "", "")
-
-	data, err := c.validatorSetABI.Pack(method, c.val)
+	data, err := c.validatorSetABI.Pack("slash", validator)
 	if err != nil {
 		log.Error("Unable to pack tx for slash", "error", err)
 		return err
@@ -827,21 +1627,49 @@ func (c *Poseidon) Heartbeat(number *big.Int) error {
 
 	// call
 	msgData := (hexutil.Bytes)(data)
-	toAddress := common.HexToAddress(systemcontracts.ValidatorHubContract)
+	toAddress := c.hubAt(number)
 	gas := (hexutil.Uint64)(uint64(100000))
 
 	_, err = c.txPoolAPI.SendTransaction(ctx, ethapi.TransactionArgs{From: &c.val, To: &toAddress, Data: &msgData, Gas: &gas})
-	if err != nil {
-		return err
-	}
-
-	c.beatcache.Add(c.val, number)
+	return err
+}
 
+// distributeFees credits the fees of the block to the reward contract of its
+// signer. The transaction tips are paid to the coinbase during execution, which
+// the proposer may set freely, so the owner of the coinbase is checked with an
+// implicit rewardValidator call of the ValidatorHub. If it isn't the signer, the
+// fees are moved over to the signer's reward contract. Every importing node
+// derives the same transfer from the block itself.
+func (c *Poseidon) distributeFees(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, signer common.Address, txs []*types.Transaction, receipts []*types.Receipt) error {
+	fees := TotalFees(header, txs, receipts)
+	if fees.Sign() == 0 {
+		return nil
+	}
+	owner := new(common.Address)
+	if err := c.systemCall(chain, header, state, owner, "rewardValidator", header.Coinbase); err != nil {
+		return fmt.Errorf("failed to resolve coinbase %x owner: %w", header.Coinbase, err)
+	}
+	if *owner == signer {
+		return nil
+	}
+	info := new(ValidatorInfo)
+	if err := c.systemCall(chain, header, state, info, "getValidatorInfo", signer); err != nil {
+		return fmt.Errorf("failed to resolve validator %x reward address: %w", signer, err)
+	}
+	if info.RewardAddr == (common.Address{}) || info.RewardAddr == header.Coinbase {
+		return nil
+	}
+	// The coinbase may have spent its tips within the block already
+	if balance := state.GetBalance(header.Coinbase); balance.Cmp(fees) < 0 {
+		fees = balance
+	}
+	state.SubBalance(header.Coinbase, fees)
+	state.AddBalance(info.RewardAddr, fees)
 	return nil
 }
 
-// totalFees computes total consumed miner fees in ETH. Block transactions and receipts have to have the same order.
-func totalFees(header *types.Header, txs []*types.Transaction, receipts []*types.Receipt) *big.Int {
+// TotalFees computes total consumed miner fees in wei. Block transactions and receipts have to have the same order.
+func TotalFees(header *types.Header, txs []*types.Transaction, receipts []*types.Receipt) *big.Int {
 	feesWei := new(big.Int)
 	for i, tx := range txs {
 		minerFee, _ := tx.EffectiveGasTip(header.BaseFee)
@@ -864,7 +1692,7 @@ func (c chainContext) GetHeader(hash common.Hash, number uint64) *types.Header {
 	return c.Chain.GetHeader(hash, number)
 }
 
-func (p *Poseidon) GetSystemTransaction(signer types.Signer, state *state.StateDB, baseFee *big.Int, totalFee *big.Int) (*types.TransactionsByPriceAndNonce, error) {
+func (p *Poseidon) GetSystemTransaction(signer types.Signer, state *state.StateDB, header *types.Header, totalFee *big.Int) (*types.TransactionsByPriceAndNonce, error) {
 	nonce := state.GetNonce(p.val)
 
 	method := "syncTendermintHeader"
@@ -876,10 +1704,10 @@ func (p *Poseidon) GetSystemTransaction(signer types.Signer, state *state.StateD
 		return nil, err
 	}
 	gasPrice := big.NewInt(0)
-	if baseFee != nil {
-		gasPrice = gasPrice.Set(baseFee)
+	if header.BaseFee != nil {
+		gasPrice = gasPrice.Set(header.BaseFee)
 	}
-	tx := types.NewTransaction(nonce, common.HexToAddress(systemcontracts.ValidatorHubContract), common.Big0, 200000, gasPrice, data)
+	tx := types.NewTransaction(nonce, p.hubAt(header.Number), common.Big0, systemcontracts.SyncHeaderGas, gasPrice, data)
 	//signtx
 	expectedTx, err := p.signTxFn(accounts.Account{Address: p.val}, tx, p.chainConfig.ChainID)
 	if err != nil {
@@ -889,5 +1717,5 @@ func (p *Poseidon) GetSystemTransaction(signer types.Signer, state *state.StateD
 	txs := make(map[common.Address]types.Transactions)
 	txs[p.val] = types.Transactions{expectedTx}
 
-	return types.NewTransactionsByPriceAndNonce(signer, txs, baseFee), nil
+	return types.NewTransactionsByPriceAndNonce(signer, txs, header.BaseFee), nil
 }
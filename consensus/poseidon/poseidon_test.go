@@ -0,0 +1,422 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poseidon
+
+import (
+	"crypto/ecdsa"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/vrf"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// testerChainReader implements consensus.ChainHeaderReader on top of a plain
+// list of headers.
+type testerChainReader struct {
+	config  *params.ChainConfig
+	headers []*types.Header
+}
+
+func (r *testerChainReader) Config() *params.ChainConfig  { return r.config }
+func (r *testerChainReader) CurrentHeader() *types.Header { return r.headers[len(r.headers)-1] }
+
+func (r *testerChainReader) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header := r.GetHeaderByNumber(number); header != nil && header.Hash() == hash {
+		return header
+	}
+	return nil
+}
+
+func (r *testerChainReader) GetHeaderByNumber(number uint64) *types.Header {
+	if number < uint64(len(r.headers)) {
+		return r.headers[number]
+	}
+	return nil
+}
+
+func (r *testerChainReader) GetHeaderByHash(hash common.Hash) *types.Header {
+	for _, header := range r.headers {
+		if header.Hash() == hash {
+			return header
+		}
+	}
+	return nil
+}
+
+// testerValidator is a committee member with a key to seal blocks with.
+type testerValidator struct {
+	key   *ecdsa.PrivateKey
+	addr  common.Address
+	stake *big.Int
+}
+
+func newTesterValidators(n int) []*testerValidator {
+	validators := make([]*testerValidator, n)
+	for i := range validators {
+		key, _ := crypto.GenerateKey()
+		validators[i] = &testerValidator{
+			key:   key,
+			addr:  crypto.PubkeyToAddress(key.PublicKey),
+			stake: new(big.Int).Mul(big.NewInt(20000), ether),
+		}
+	}
+	return validators
+}
+
+// authorize injects the keys of the validator into the engine.
+func (v *testerValidator) authorize(engine *Poseidon) {
+	engine.Authorize(v.addr, func(signer accounts.Account, mimeType string, message []byte) ([]byte, error) {
+		return crypto.Sign(crypto.Keccak256(message), v.key)
+	}, nil, func(signer accounts.Account, alpha []byte) ([]byte, []byte, error) {
+		return vrf.Prove(v.key, alpha)
+	})
+}
+
+// newTesterChain creates a committee checkpointing engine along with a chain
+// reader holding a genesis block that announces the given validators.
+func newTesterChain(validators []*testerValidator, epoch uint64) (*Poseidon, *testerChainReader) {
+	config := *params.TestChainConfig
+	config.LondonBlock = nil
+	config.Ethash = nil
+	config.Poseidon = &params.PoseidonConfig{Period: 1, Epoch: epoch, SlotBlock: common.Big0}
+
+	committee := make([]checkpointValidator, len(validators))
+	for i, validator := range validators {
+		committee[i] = checkpointValidator{Address: validator.addr, Stake: validator.stake}
+	}
+	genesis := &types.Header{
+		Number:     common.Big0,
+		Time:       uint64(time.Now().Add(-24 * time.Hour).Unix()),
+		GasLimit:   params.GenesisGasLimit,
+		Difficulty: common.Big1,
+		UncleHash:  uncleHash,
+		Extra:      append(make([]byte, extraVanity), encodeCheckpoint(committee)...),
+	}
+	engine := New(&config, rawdb.NewMemoryDatabase(), nil, genesis.Hash())
+	return engine, &testerChainReader{config: &config, headers: []*types.Header{genesis}}
+}
+
+// sealNext assembles the next block on top of the chain, searching for the first
+// validator and nonce that wins the sortition, and appends it to the chain.
+func sealNext(t testing.TB, engine *Poseidon, chain *testerChainReader, validators []*testerValidator) *types.Header {
+	parent := chain.CurrentHeader()
+	number := new(big.Int).Add(parent.Number, common.Big1)
+	config := engine.config.ParamsAt(number)
+
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		UncleHash:  uncleHash,
+		Number:     number,
+		GasLimit:   parent.GasLimit,
+		Extra:      make([]byte, extraVanity),
+	}
+	snap, err := engine.snapshot(chain, parent.Number.Uint64(), parent.Hash(), nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve snapshot: %v", err)
+	}
+	if engine.config.IsCheckpoint(header.Number) {
+		committee := make([]checkpointValidator, 0, len(snap.Validators))
+		for addr, validator := range snap.Validators {
+			committee = append(committee, checkpointValidator{Address: addr, Stake: validator.Stake, LastBlockHeight: validator.LastBlockHeight})
+		}
+		header.Extra = append(header.Extra, encodeCheckpoint(committee)...)
+	}
+	header.Extra = append(header.Extra, make([]byte, int(config.VrfLength)+extraSeal)...)
+
+	for nonce := uint64(0); nonce < config.NonceSignSize; nonce++ {
+		header.Nonce = types.EncodeNonce(nonce)
+		header.Time, _ = slotStart(parent.Time, config.Period, nonce)
+		for _, validator := range validators {
+			info := snap.validatorInfo(validator.addr)
+			if info == nil {
+				continue
+			}
+			validator.authorize(engine)
+			sealed, err := engine.sortition(chain, header, info, snap.committeeSupply(), validator.addr, engine.signFn)
+			if err != nil {
+				t.Fatalf("failed to run sortition: %v", err)
+			}
+			if sealed {
+				chain.headers = append(chain.headers, header)
+				return header
+			}
+		}
+	}
+	t.Fatalf("no validator won the sortition of block %d", header.Number)
+	return nil
+}
+
+// Tests that the difficulty packs the nonce, the sealing gap of the signer and
+// the vrf output so that fewer retries always win the fork choice.
+func TestCalcDifficulty(t *testing.T) {
+	beta := common.FromHex("0x0102030405060708")
+
+	tests := []struct {
+		nonce     uint64
+		number    int64
+		lastBlock int64
+		want      *big.Int
+	}{
+		// No retries, no gap: only the nonce and the vrf output count
+		{0, 100, 100, new(big.Int).Or(new(big.Int).Lsh(big.NewInt(255), 88), big.NewInt(0x010203040506))},
+		// Gap of two 256 block windows since the last sealed block
+		{0, 1000, 1000 - 512, new(big.Int).Or(new(big.Int).Or(new(big.Int).Lsh(big.NewInt(255), 88), new(big.Int).Lsh(big.NewInt(2), 48)), big.NewInt(0x010203040506))},
+		// Retries lower the nonce part
+		{10, 100, 100, new(big.Int).Or(new(big.Int).Lsh(big.NewInt(245), 88), big.NewInt(0x010203040506))},
+		// Exhausted retries drop the nonce part altogether
+		{params.DefaultPoseidonNonceSignSize, 100, 100, big.NewInt(0x010203040506)},
+		// Last sealed block ahead of the header (stale stake info) has no gap
+		{0, 100, 200, new(big.Int).Or(new(big.Int).Lsh(big.NewInt(255), 88), big.NewInt(0x010203040506))},
+	}
+	for i, tt := range tests {
+		have := calcDifficulty(types.EncodeNonce(tt.nonce), big.NewInt(tt.number), ether, big.NewInt(tt.lastBlock), beta, params.DefaultPoseidonNonceSignSize)
+		if have.Cmp(tt.want) != 0 {
+			t.Errorf("test %d: difficulty mismatch: have %x, want %x", i, have, tt.want)
+		}
+	}
+	// Whatever the gap and vrf output, a lower nonce must weigh more
+	worst := calcDifficulty(types.EncodeNonce(0), big.NewInt(1), ether, big.NewInt(1), make([]byte, 32), params.DefaultPoseidonNonceSignSize)
+	best := calcDifficulty(types.EncodeNonce(1), big.NewInt(1<<40), ether, big.NewInt(0), common.FromHex("0xffffffffffffffff"), params.DefaultPoseidonNonceSignSize)
+	if worst.Cmp(best) <= 0 {
+		t.Errorf("retried block outweighs first try: %x >= %x", best, worst)
+	}
+}
+
+// Tests that every nonce retry starts one slot after the previous one, and that
+// chains without a period don't wait between retries.
+func TestSlotStart(t *testing.T) {
+	tests := []struct {
+		parent, period, nonce uint64
+		start                 uint64
+		ok                    bool
+	}{
+		{100, 15, 0, 115, true},
+		{100, 15, 1, 123, true},
+		{100, 15, 3, 139, true},
+		{100, 2, 5, 107, true},
+		{100, 1, 5, 106, true},
+		{100, 0, 0, 100, true},
+		{100, 0, 200, 100, true},
+		{100, 15, math.MaxUint64, 0, false},
+	}
+	for i, tt := range tests {
+		start, ok := slotStart(tt.parent, tt.period, tt.nonce)
+		if start != tt.start || ok != tt.ok {
+			t.Errorf("test %d: slot start mismatch: have %d/%v, want %d/%v", i, start, ok, tt.start, tt.ok)
+		}
+	}
+}
+
+// Tests that the sortition fork switches from the floating point sortition to
+// the integer one, which disagree on outputs close to the CDF boundaries, so the
+// blocks sealed before the fork still verify.
+func TestSortitionFork(t *testing.T) {
+	var (
+		engine     = &Poseidon{config: &params.PoseidonConfig{SortitionBlock: big.NewInt(10)}}
+		money      = new(big.Int).Mul(big.NewInt(40000000), ether)
+		totalMoney = new(big.Int).Mul(big.NewInt(100000000), ether)
+		output     = common.FromHex("4d1b10429f3c0c59123093fdaf0c2f3c61f6e600e70a3b2bc5ddae65ea5ec3dc")
+	)
+	if !engine.verifySort(money, totalMoney, big.NewInt(9), output) {
+		t.Errorf("block before the fork not selected")
+	}
+	if engine.verifySort(money, totalMoney, big.NewInt(10), output) {
+		t.Errorf("block at the fork selected")
+	}
+}
+
+// Tests that the seal hash covers the header apart from the fields filled in
+// while sealing: difficulty, nonce, vrf proof and the signature itself.
+func TestSealHash(t *testing.T) {
+	chainId, config := big.NewInt(1157), new(params.PoseidonConfig)
+	header := &types.Header{
+		ParentHash: common.HexToHash("0x01"),
+		Number:     big.NewInt(10),
+		GasLimit:   params.GenesisGasLimit,
+		Time:       1000,
+		Difficulty: big.NewInt(2),
+		Extra:      make([]byte, extraVanity+extraVrf+extraSeal),
+		BaseFee:    big.NewInt(params.InitialBaseFee),
+	}
+	hash := SealHash(header, chainId, config)
+
+	ignored := []func(h *types.Header){
+		func(h *types.Header) { h.Difficulty = big.NewInt(3) },
+		func(h *types.Header) { h.Nonce = types.EncodeNonce(5) },
+		func(h *types.Header) { h.Extra[extraVanity] = 0xff },
+		func(h *types.Header) { h.Extra[len(h.Extra)-1] = 0xff },
+	}
+	for i, tamper := range ignored {
+		cpy := types.CopyHeader(header)
+		tamper(cpy)
+		if have := SealHash(cpy, chainId, config); have != hash {
+			t.Errorf("ignored field %d: seal hash changed", i)
+		}
+	}
+	covered := []func(h *types.Header){
+		func(h *types.Header) { h.ParentHash = common.HexToHash("0x02") },
+		func(h *types.Header) { h.Coinbase = common.HexToAddress("0x01") },
+		func(h *types.Header) { h.Time++ },
+		func(h *types.Header) { h.Extra[0] = 0xff },
+		func(h *types.Header) { h.BaseFee = nil },
+	}
+	for i, tamper := range covered {
+		cpy := types.CopyHeader(header)
+		tamper(cpy)
+		if have := SealHash(cpy, chainId, config); have == hash {
+			t.Errorf("covered field %d: seal hash unchanged", i)
+		}
+	}
+	if SealHash(header, big.NewInt(1), config) == hash {
+		t.Errorf("seal hash not bound to the chain id")
+	}
+	// The vrf proof excluded from the hash is the one configured at the header
+	vrfLength := uint64(extraVrf + 16)
+	config.ForkSchedule = []*params.PoseidonFork{
+		{Block: header.Number, Overrides: params.PoseidonOverrides{VrfLength: &vrfLength}},
+	}
+	if SealHash(header, chainId, config) != sealHash(header, chainId, int(vrfLength)) {
+		t.Errorf("seal hash ignores the scheduled vrf length")
+	}
+}
+
+// Tests that a batch of sealed headers is accepted by the concurrent verifier
+// and that a broken vrf proof is reported at the right position.
+func TestVerifyHeaders(t *testing.T) {
+	validators := newTesterValidators(4)
+	engine, chain := newTesterChain(validators, 16)
+	for i := 0; i < 40; i++ {
+		sealNext(t, engine, chain, validators)
+	}
+	headers := chain.headers[1:]
+	seals := make([]bool, len(headers))
+	for i := range seals {
+		seals[i] = true
+	}
+	// Verify the valid batch with a fresh engine and a chain that only knows the genesis
+	verifier, _ := newTesterChain(nil, 16)
+	reader := &testerChainReader{config: chain.config, headers: chain.headers[:1]}
+
+	_, results := verifier.VerifyHeaders(reader, headers, seals)
+	for i := range headers {
+		if err := <-results; err != nil {
+			t.Fatalf("header %d: verification failed: %v", i, err)
+		}
+	}
+	// Corrupt the vrf proof of a header in the middle and verify again
+	bad := types.CopyHeader(headers[20])
+	bad.Extra[len(bad.Extra)-extraSeal-extraVrf] ^= 0xff
+	broken := append(append(append([]*types.Header{}, headers[:20]...), bad), headers[21:]...)
+
+	verifier, _ = newTesterChain(nil, 16)
+	_, results = verifier.VerifyHeaders(reader, broken, seals)
+	for i := range broken {
+		err := <-results
+		if i < 20 && err != nil {
+			t.Fatalf("header %d: verification failed: %v", i, err)
+		}
+		if i == 20 && err == nil {
+			t.Fatalf("header %d: corrupted vrf proof accepted", i)
+		}
+	}
+}
+
+// Tests that the engine switches to the parameters scheduled by a fork at the
+// fork block, and that headers still following the old ones are rejected.
+func TestForkScheduledParams(t *testing.T) {
+	validators := newTesterValidators(4)
+	engine, chain := newTesterChain(validators, 16)
+
+	period := uint64(5)
+	engine.config.ForkSchedule = []*params.PoseidonFork{
+		{Block: big.NewInt(4), Overrides: params.PoseidonOverrides{Period: &period}},
+	}
+	for i := 0; i < 8; i++ {
+		sealNext(t, engine, chain, validators)
+	}
+	for i, header := range chain.headers[1:] {
+		want := uint64(1)
+		if header.Number.Uint64() >= 4 {
+			want = period
+		}
+		want += header.Nonce.Uint64() * slotLength(want)
+		if have := header.Time - chain.headers[i].Time; have != want {
+			t.Errorf("block %d: period mismatch: have %d, want %d", header.Number, have, want)
+		}
+		if err := engine.verifyHeader(chain, header, chain.headers[1:i+1], true); err != nil {
+			t.Fatalf("block %d: verification failed: %v", header.Number, err)
+		}
+	}
+	// A block at the fork sealed with the old period must be rejected
+	early := types.CopyHeader(chain.headers[4])
+	early.Time = chain.headers[3].Time + 1
+	if err := engine.verifyHeader(chain, early, chain.headers[1:4], false); err != errInvalidTimestamp {
+		t.Fatalf("early block error mismatch: have %v, want %v", err, errInvalidTimestamp)
+	}
+}
+
+// Benchmarks the concurrent batch verifier against verifying the same headers
+// one by one.
+func BenchmarkVerifyHeaders(b *testing.B) {
+	validators := newTesterValidators(4)
+	engine, chain := newTesterChain(validators, 64)
+	for i := 0; i < 256; i++ {
+		sealNext(b, engine, chain, validators)
+	}
+	headers := chain.headers[1:]
+	seals := make([]bool, len(headers))
+	for i := range seals {
+		seals[i] = true
+	}
+	reader := &testerChainReader{config: chain.config, headers: chain.headers[:1]}
+
+	b.Run("serial", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			verifier, _ := newTesterChain(nil, 64)
+			b.StartTimer()
+
+			for j, header := range headers {
+				if err := verifier.verifyHeader(reader, header, headers[:j], true); err != nil {
+					b.Fatalf("header %d: verification failed: %v", j, err)
+				}
+			}
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			verifier, _ := newTesterChain(nil, 64)
+			b.StartTimer()
+
+			_, results := verifier.VerifyHeaders(reader, headers, seals)
+			for j := range headers {
+				if err := <-results; err != nil {
+					b.Fatalf("header %d: verification failed: %v", j, err)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,283 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poseidon
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/vrf"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// staleThreshold is the maximum depth of the sealing work still accepted from
+	// a remote signer.
+	staleThreshold = 7
+)
+
+var (
+	errNoSealingWork       = errors.New("no sealing work available yet")
+	errUnknownSealingWork  = errors.New("unknown or stale sealing work")
+	errPoseidonStopped     = errors.New("poseidon stopped")
+	errInvalidRemoteSigner = errors.New("seal not signed by the remote validator")
+	errInvalidNonce        = errors.New("nonce beyond the retry window")
+)
+
+// NonceEvent is posted when the remote signer selected the nonce it seals the
+// next block with, which has to be reassembled in the slot of the nonce.
+type NonceEvent struct {
+	ParentHash common.Hash
+	Nonce      uint64
+}
+
+// remoteSealer hands the blocks of the local validator over to a remote signer
+// holding its vrf and signing key, and publishes them once the submitted seal
+// passes the same verification as an imported block.
+type remoteSealer struct {
+	works       map[common.Hash]*sealTask
+	currentTask *sealTask
+
+	poseidon     *Poseidon
+	workCh       chan *sealTask   // Notification channel to push new work to the remote sealer
+	fetchWorkCh  chan *sealWork   // Channel used for the remote signer to fetch sealing work
+	submitSealCh chan *sealResult // Channel used for the remote signer to submit its seal
+
+	submitNonceCh chan *nonceResult // Channel used for the remote signer to submit its nonce
+}
+
+// sealTask wraps a block to seal with the context needed to verify and publish
+// its seal.
+type sealTask struct {
+	chain   consensus.ChainHeaderReader
+	block   *types.Block
+	signer  common.Address // Validator the block was assembled for
+	results chan<- *types.Block
+	stop    <-chan struct{}
+}
+
+// sealWork wraps a request for the current sealing work.
+type sealWork struct {
+	errc chan error
+	res  chan *SealingWork
+}
+
+// nonceResult wraps the nonce proof submitted for the block on top of a parent.
+type nonceResult struct {
+	parentHash common.Hash
+	nonce      uint64
+	pubkey     []byte
+	proof      []byte
+
+	errc chan error
+}
+
+// sealResult wraps the vrf proof and signature submitted for a block.
+type sealResult struct {
+	sealHash  common.Hash
+	proof     []byte
+	signature []byte
+
+	errc chan error
+}
+
+func startRemoteSealer(poseidon *Poseidon) *remoteSealer {
+	s := &remoteSealer{
+		poseidon:     poseidon,
+		works:        make(map[common.Hash]*sealTask),
+		workCh:       make(chan *sealTask),
+		fetchWorkCh:  make(chan *sealWork),
+		submitSealCh: make(chan *sealResult),
+
+		submitNonceCh: make(chan *nonceResult),
+	}
+	go s.loop()
+	return s
+}
+
+func (s *remoteSealer) loop() {
+	for {
+		select {
+		case task := <-s.workCh:
+			// Track the new block and drop the ones too old to be accepted
+			number := task.block.NumberU64()
+			for hash, work := range s.works {
+				if work.block.NumberU64()+staleThreshold <= number {
+					delete(s.works, hash)
+				}
+			}
+			s.works[s.poseidon.SealHash(task.block.Header())] = task
+			s.currentTask = task
+
+		case work := <-s.fetchWorkCh:
+			// Return the current sealing work to the remote signer
+			if s.currentTask == nil {
+				work.errc <- errNoSealingWork
+			} else {
+				work.res <- s.makeWork(s.currentTask)
+			}
+
+		case result := <-s.submitSealCh:
+			// Verify the submitted seal against the pending blocks
+			result.errc <- s.submitSeal(result.sealHash, result.proof, result.signature)
+
+		case result := <-s.submitNonceCh:
+			// Verify the submitted nonce against the current block
+			result.errc <- s.submitNonce(result.parentHash, result.nonce, result.pubkey, result.proof)
+
+		case <-s.poseidon.quit:
+			log.Trace("Poseidon remote sealer is exiting")
+			return
+		}
+	}
+}
+
+// makeWork creates the sealing work package of a block for the remote signer.
+func (s *remoteSealer) makeWork(task *sealTask) *SealingWork {
+	header := task.block.Header()
+
+	alphas := make([]hexutil.Bytes, s.poseidon.config.ParamsAt(header.Number).NonceSignSize)
+	for nonce := range alphas {
+		alphas[nonce] = s.poseidon.GetVrfAlpha(header.ParentHash, types.EncodeNonce(uint64(nonce)))
+	}
+	return &SealingWork{
+		SealHash:   s.poseidon.SealHash(header),
+		ParentHash: header.ParentHash,
+		Number:     hexutil.Uint64(header.Number.Uint64()),
+		Nonce:      hexutil.Uint64(header.Nonce.Uint64()),
+		Time:       hexutil.Uint64(header.Time),
+		Signer:     task.signer,
+		Alpha:      s.poseidon.GetVrfAlpha(header.ParentHash, header.Nonce),
+		VrfLength:  hexutil.Uint64(s.poseidon.vrfLength(header.Number)),
+		Alphas:     alphas,
+	}
+}
+
+// submitNonce verifies that the remote signer wins the sortition of the current
+// block with the given nonce, proven with the vrf key of the given public key.
+// The nonce is recorded for preparing the block, which is reassembled in the
+// slot of the nonce.
+func (s *remoteSealer) submitNonce(parentHash common.Hash, nonce uint64, pubkey []byte, proof []byte) error {
+	task := s.currentTask
+	if task == nil || task.block.ParentHash() != parentHash {
+		return errUnknownSealingWork
+	}
+	header := task.block.Header()
+	if nonce >= s.poseidon.config.ParamsAt(header.Number).NonceSignSize {
+		return errInvalidNonce
+	}
+	key, err := crypto.UnmarshalPubkey(pubkey)
+	if err != nil {
+		return err
+	}
+	if signer := crypto.PubkeyToAddress(*key); signer != task.signer {
+		return fmt.Errorf("%w: have %x, want %x", errInvalidRemoteSigner, signer, task.signer)
+	}
+	beta, err := vrf.Verify(key, s.poseidon.GetVrfAlpha(parentHash, types.EncodeNonce(nonce)), proof)
+	if err != nil {
+		return err
+	}
+	info, committeeSupply, err := s.poseidon.proposerInfo(task.chain, header, nil, task.signer)
+	if err != nil {
+		return err
+	}
+	if !s.poseidon.verifySort(info.TotalSupply, committeeSupply, header.Number, beta) {
+		return errUnauthorizedSigner
+	}
+	s.poseidon.nonces.Add(nonceKey{parent: parentHash, signer: task.signer}, nonce)
+
+	log.Debug("Remote nonce accepted", "number", header.Number, "nonce", nonce)
+	if nonce != header.Nonce.Uint64() {
+		go s.poseidon.nonceFeed.Send(NonceEvent{ParentHash: parentHash, Nonce: nonce})
+	}
+	return nil
+}
+
+// submitSeal completes the pending block of the given seal hash with the vrf
+// proof and signature of the remote signer, verifies it the same way as an
+// imported block and publishes it on success.
+func (s *remoteSealer) submitSeal(sealHash common.Hash, proof []byte, signature []byte) error {
+	task := s.works[sealHash]
+	if task == nil {
+		return errUnknownSealingWork
+	}
+	header := task.block.Header()
+	if vrfLength := s.poseidon.vrfLength(header.Number); len(proof) != vrfLength {
+		return fmt.Errorf("invalid vrf proof length: have %d, want %d", len(proof), vrfLength)
+	}
+	if len(signature) != extraSeal {
+		return fmt.Errorf("invalid signature length: have %d, want %d", len(signature), extraSeal)
+	}
+	copy(header.Extra[len(header.Extra)-extraSeal-len(proof):], proof)
+	copy(header.Extra[len(header.Extra)-extraSeal:], signature)
+
+	seal, err := s.poseidon.verifySealProof(header)
+	if err != nil {
+		return err
+	}
+	if seal.signer != task.signer {
+		return fmt.Errorf("%w: have %x, want %x", errInvalidRemoteSigner, seal.signer, task.signer)
+	}
+	// The difficulty derives from the vrf output, fill it in before verifying
+	// the sortition
+	info, _, err := s.poseidon.proposerInfo(task.chain, header, nil, seal.signer)
+	if err != nil {
+		return err
+	}
+	header.Difficulty = s.poseidon.calcDifficulty(header.Nonce, header.Number, info.TotalSupply, info.LastBlockHeight, seal.beta)
+	if err := s.poseidon.verifySealCommittee(task.chain, header, nil, seal); err != nil {
+		return err
+	}
+	delete(s.works, sealHash)
+
+	log.Debug("Remote seal accepted", "number", header.Number, "sealhash", sealHash)
+	s.poseidon.publish(task.block.WithSeal(header), task.results, task.stop, "remote")
+	return nil
+}
+
+// SubscribeNonceEvent registers a subscription of NonceEvent, posted when the
+// remote signer selects a nonce the current block isn't prepared for.
+func (c *Poseidon) SubscribeNonceEvent(ch chan<- NonceEvent) event.Subscription {
+	return c.nonceFeed.Subscribe(ch)
+}
+
+// publish delivers the sealed block to the miner once its timestamp is reached,
+// unless sealing is terminated first.
+func (c *Poseidon) publish(block *types.Block, results chan<- *types.Block, stop <-chan struct{}, mode string) {
+	delay := time.Unix(int64(block.Time()), 0).Sub(time.Now()) // nolint: gosimple
+
+	log.Trace("Waiting for slot to sign and propagate", "delay", common.PrettyDuration(delay))
+	go func() {
+		select {
+		case <-stop:
+			return
+		case <-time.After(delay):
+		}
+		select {
+		case results <- block:
+		default:
+			log.Warn("Sealing result is not read by miner", "mode", mode, "sealhash", c.SealHash(block.Header()))
+		}
+	}()
+}
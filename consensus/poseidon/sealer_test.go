@@ -0,0 +1,233 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poseidon
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/vrf"
+)
+
+// Tests that blocks handed over to a remote signer are only published once the
+// submitted vrf proof and signature pass the seal verification.
+func TestRemoteSeal(t *testing.T) {
+	validators := newTesterValidators(1)
+	engine, chain := newTesterChain(validators, 1024)
+	defer engine.Close()
+
+	validator := validators[0]
+	engine.AuthorizeRemote(validator.addr, nil)
+
+	api := &API{chain: chain, poseidon: engine}
+	if _, err := api.GetSealingWork(); err != errNoSealingWork {
+		t.Fatalf("sealing work error mismatch: have %v, want %v", err, errNoSealingWork)
+	}
+	// Hand the next block over to the remote signer
+	parent := chain.CurrentHeader()
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		UncleHash:  uncleHash,
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:   parent.GasLimit,
+		Difficulty: new(big.Int),
+		Extra:      make([]byte, extraVanity+extraVrf+extraSeal),
+	}
+	header.Time, _ = slotStart(parent.Time, engine.config.Period, 0)
+
+	var (
+		results = make(chan *types.Block, 1)
+		stop    = make(chan struct{})
+	)
+	defer close(stop)
+	if err := engine.Seal(chain, types.NewBlockWithHeader(header), results, stop); err != nil {
+		t.Fatalf("failed to hand over block: %v", err)
+	}
+	work, err := api.GetSealingWork()
+	if err != nil {
+		t.Fatalf("failed to retrieve sealing work: %v", err)
+	}
+	// Select the first nonce the remote signer wins, reassembling the block in
+	// its slot as the miner does on the nonce event
+	nonce := selectRemoteNonce(t, api, engine, work, validator)
+	if nonce != 0 {
+		header.Nonce = types.EncodeNonce(nonce)
+		header.Time, _ = slotStart(parent.Time, engine.config.Period, nonce)
+		if err := engine.Seal(chain, types.NewBlockWithHeader(header), results, stop); err != nil {
+			t.Fatalf("failed to hand over block: %v", err)
+		}
+		if work, err = api.GetSealingWork(); err != nil {
+			t.Fatalf("failed to retrieve sealing work: %v", err)
+		}
+	}
+	if prepared, err := engine.firstWinningNonce(chain, header); err != nil || prepared != nonce {
+		t.Fatalf("prepared nonce mismatch: have %d (%v), want %d", prepared, err, nonce)
+	}
+	if work.SealHash != engine.SealHash(header) {
+		t.Fatalf("seal hash mismatch: have %x, want %x", work.SealHash, engine.SealHash(header))
+	}
+	if alpha := engine.GetVrfAlpha(header.ParentHash, header.Nonce); !bytes.Equal(work.Alpha, alpha) {
+		t.Fatalf("vrf alpha mismatch: have %x, want %x", work.Alpha, alpha)
+	}
+	if work.Signer != validator.addr || uint64(work.Number) != header.Number.Uint64() || int(work.VrfLength) != extraVrf {
+		t.Fatalf("sealing work mismatch: %+v", work)
+	}
+	// Create the seal of the remote signer and an outsider
+	outsider, _ := crypto.GenerateKey()
+	_, proof, err := vrf.Prove(validator.key, work.Alpha)
+	if err != nil {
+		t.Fatalf("failed to prove vrf: %v", err)
+	}
+	signature, err := crypto.Sign(work.SealHash.Bytes(), validator.key)
+	if err != nil {
+		t.Fatalf("failed to sign seal hash: %v", err)
+	}
+	_, outsiderProof, err := vrf.Prove(outsider, work.Alpha)
+	if err != nil {
+		t.Fatalf("failed to prove vrf: %v", err)
+	}
+	outsiderSignature, err := crypto.Sign(work.SealHash.Bytes(), outsider)
+	if err != nil {
+		t.Fatalf("failed to sign seal hash: %v", err)
+	}
+	// Ensure all invalid seals are rejected without publishing the block
+	invalid := []struct {
+		name      string
+		sealHash  common.Hash
+		proof     []byte
+		signature []byte
+		err       error
+	}{
+		{"unknown work", common.Hash{0x01}, proof, signature, errUnknownSealingWork},
+		{"short proof", work.SealHash, proof[1:], signature, nil},
+		{"short signature", work.SealHash, proof, signature[1:], nil},
+		{"foreign proof", work.SealHash, outsiderProof, signature, nil},
+		{"foreign seal", work.SealHash, outsiderProof, outsiderSignature, errInvalidRemoteSigner},
+	}
+	for _, tt := range invalid {
+		err := api.SubmitSeal(tt.sealHash, tt.proof, tt.signature)
+		if err == nil {
+			t.Fatalf("%s: seal accepted", tt.name)
+		}
+		if tt.err != nil && !errors.Is(err, tt.err) {
+			t.Fatalf("%s: error mismatch: have %v, want %v", tt.name, err, tt.err)
+		}
+	}
+	select {
+	case block := <-results:
+		t.Fatalf("block %d published with an invalid seal", block.NumberU64())
+	default:
+	}
+	// Submit the valid seal and ensure the published block verifies
+	if err := api.SubmitSeal(work.SealHash, proof, signature); err != nil {
+		t.Fatalf("failed to submit seal: %v", err)
+	}
+	select {
+	case block := <-results:
+		if err := engine.VerifyHeader(chain, block.Header(), true); err != nil {
+			t.Fatalf("published block invalid: %v", err)
+		}
+		if signer, _ := engine.Author(block.Header()); signer != validator.addr {
+			t.Fatalf("signer mismatch: have %x, want %x", signer, validator.addr)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("sealed block not published")
+	}
+	// Ensure the work can't be submitted twice
+	if err := api.SubmitSeal(work.SealHash, proof, signature); !errors.Is(err, errUnknownSealingWork) {
+		t.Fatalf("resubmission error mismatch: have %v, want %v", err, errUnknownSealingWork)
+	}
+}
+
+// selectRemoteNonce submits the vrf proofs of the sealing work's nonces in order
+// until the node accepts one, as a remote signer would, ensuring that invalid
+// submissions are rejected on the way.
+func selectRemoteNonce(t *testing.T, api *API, engine *Poseidon, work *SealingWork, validator *testerValidator) uint64 {
+	t.Helper()
+
+	events := make(chan NonceEvent, 1)
+	sub := engine.SubscribeNonceEvent(events)
+	defer sub.Unsubscribe()
+
+	outsider, _ := crypto.GenerateKey()
+	pubkey := crypto.FromECDSAPub(&validator.key.PublicKey)
+
+	_, proof, err := vrf.Prove(validator.key, work.Alphas[0])
+	if err != nil {
+		t.Fatalf("failed to prove vrf: %v", err)
+	}
+	_, outsiderProof, err := vrf.Prove(outsider, work.Alphas[0])
+	if err != nil {
+		t.Fatalf("failed to prove vrf: %v", err)
+	}
+	invalid := []struct {
+		name   string
+		parent common.Hash
+		nonce  uint64
+		pubkey []byte
+		proof  []byte
+		err    error
+	}{
+		{"unknown parent", common.Hash{0x01}, 0, pubkey, proof, errUnknownSealingWork},
+		{"nonce beyond window", work.ParentHash, uint64(len(work.Alphas)), pubkey, proof, errInvalidNonce},
+		{"foreign key", work.ParentHash, 0, crypto.FromECDSAPub(&outsider.PublicKey), outsiderProof, errInvalidRemoteSigner},
+		{"foreign proof", work.ParentHash, 0, pubkey, outsiderProof, nil},
+		{"mismatched nonce", work.ParentHash, 1, pubkey, proof, nil},
+	}
+	for _, tt := range invalid {
+		err := api.SubmitNonce(tt.parent, hexutil.Uint64(tt.nonce), tt.pubkey, tt.proof)
+		if err == nil {
+			t.Fatalf("%s: nonce accepted", tt.name)
+		}
+		if tt.err != nil && !errors.Is(err, tt.err) {
+			t.Fatalf("%s: error mismatch: have %v, want %v", tt.name, err, tt.err)
+		}
+	}
+	for nonce, alpha := range work.Alphas {
+		_, proof, err := vrf.Prove(validator.key, alpha)
+		if err != nil {
+			t.Fatalf("failed to prove vrf: %v", err)
+		}
+		err = api.SubmitNonce(work.ParentHash, hexutil.Uint64(nonce), pubkey, proof)
+		if err == errUnauthorizedSigner {
+			continue
+		}
+		if err != nil {
+			t.Fatalf("nonce %d: failed to submit: %v", nonce, err)
+		}
+		if nonce != int(work.Nonce) {
+			select {
+			case ev := <-events:
+				if ev.ParentHash != work.ParentHash || ev.Nonce != uint64(nonce) {
+					t.Fatalf("nonce event mismatch: have %+v, want nonce %d", ev, nonce)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("nonce event not posted")
+			}
+		}
+		return uint64(nonce)
+	}
+	t.Fatalf("no nonce won within the retry window")
+	return 0
+}
@@ -0,0 +1,256 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poseidon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// Validator is the sortition relevant state of a single committee member.
+type Validator struct {
+	Stake           *big.Int `json:"stake"`           // Stake the validator takes part in the sortition with
+	LastBlockHeight uint64   `json:"lastBlockHeight"` // Number of the last block sealed by the validator
+}
+
+// Snapshot is the state of the validator committee at a given point in time.
+type Snapshot struct {
+	config   *params.PoseidonConfig // Consensus engine parameters to fine tune behavior
+	sigcache *lru.ARCCache          // Cache of recent block signatures to speed up ecrecover
+	chainId  *big.Int               // Chain id the seal signatures are bound to
+
+	Number     uint64                        `json:"number"`     // Block number where the snapshot was created
+	Hash       common.Hash                   `json:"hash"`       // Block hash where the snapshot was created
+	Validators map[common.Address]*Validator `json:"validators"` // Set of committee members at this moment
+}
+
+// validatorsAscending implements the sort interface to allow sorting a list of addresses
+type validatorsAscending []common.Address
+
+func (s validatorsAscending) Len() int           { return len(s) }
+func (s validatorsAscending) Less(i, j int) bool { return bytes.Compare(s[i][:], s[j][:]) < 0 }
+func (s validatorsAscending) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// checkpointValidator is a committee member as carried in checkpoint extra-data.
+type checkpointValidator struct {
+	Address         common.Address
+	Stake           *big.Int
+	LastBlockHeight uint64
+}
+
+// newSnapshot creates a new snapshot with the specified startup parameters. This
+// method is only ever used for checkpoint blocks whose committee is trusted.
+func newSnapshot(config *params.PoseidonConfig, sigcache *lru.ARCCache, chainId *big.Int, number uint64, hash common.Hash, validators []checkpointValidator) *Snapshot {
+	snap := &Snapshot{
+		config:     config,
+		sigcache:   sigcache,
+		chainId:    chainId,
+		Number:     number,
+		Hash:       hash,
+		Validators: make(map[common.Address]*Validator),
+	}
+	snap.reset(validators)
+	return snap
+}
+
+// loadSnapshot loads an existing snapshot from the database.
+func loadSnapshot(config *params.PoseidonConfig, sigcache *lru.ARCCache, chainId *big.Int, db ethdb.Database, hash common.Hash) (*Snapshot, error) {
+	blob, err := db.Get(append([]byte("poseidon-"), hash[:]...))
+	if err != nil {
+		return nil, err
+	}
+	snap := new(Snapshot)
+	if err := json.Unmarshal(blob, snap); err != nil {
+		return nil, err
+	}
+	snap.config = config
+	snap.sigcache = sigcache
+	snap.chainId = chainId
+
+	return snap, nil
+}
+
+// store inserts the snapshot into the database.
+func (s *Snapshot) store(db ethdb.Database) error {
+	blob, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return db.Put(append([]byte("poseidon-"), s.Hash[:]...), blob)
+}
+
+// copy creates a deep copy of the snapshot.
+func (s *Snapshot) copy() *Snapshot {
+	cpy := &Snapshot{
+		config:     s.config,
+		sigcache:   s.sigcache,
+		chainId:    s.chainId,
+		Number:     s.Number,
+		Hash:       s.Hash,
+		Validators: make(map[common.Address]*Validator),
+	}
+	for address, validator := range s.Validators {
+		cpy.Validators[address] = &Validator{
+			Stake:           new(big.Int).Set(validator.Stake),
+			LastBlockHeight: validator.LastBlockHeight,
+		}
+	}
+	return cpy
+}
+
+// reset replaces the committee with the one announced by a checkpoint.
+func (s *Snapshot) reset(validators []checkpointValidator) {
+	s.Validators = make(map[common.Address]*Validator)
+	for _, validator := range validators {
+		s.Validators[validator.Address] = &Validator{
+			Stake:           new(big.Int).Set(validator.Stake),
+			LastBlockHeight: validator.LastBlockHeight,
+		}
+	}
+}
+
+// apply creates a new committee snapshot by applying the given headers to
+// the original one.
+func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
+	// Allow passing in no headers for cleaner code
+	if len(headers) == 0 {
+		return s, nil
+	}
+	// Sanity check that the headers can be applied
+	for i := 0; i < len(headers)-1; i++ {
+		if headers[i+1].Number.Uint64() != headers[i].Number.Uint64()+1 {
+			return nil, errInvalidVotingChain
+		}
+	}
+	if headers[0].Number.Uint64() != s.Number+1 {
+		return nil, errInvalidVotingChain
+	}
+	// Iterate through the headers and create a new snapshot
+	snap := s.copy()
+
+	for _, header := range headers {
+		number := header.Number.Uint64()
+
+		// Checkpoint blocks replace the committee with the one they carry
+		if s.config.IsCheckpoint(header.Number) {
+			validators, err := decodeCheckpoint(header, int(s.config.ParamsAt(header.Number).VrfLength))
+			if err != nil {
+				return nil, err
+			}
+			snap.reset(validators)
+		}
+		// Track the last sealed block of the signer, used by the difficulty
+		_, signer, err := ecrecover(header, s.sigcache, s.chainId, int(s.config.ParamsAt(header.Number).VrfLength))
+		if err != nil {
+			return nil, err
+		}
+		if validator, ok := snap.Validators[signer]; ok {
+			validator.LastBlockHeight = number
+		}
+	}
+	snap.Number += uint64(len(headers))
+	snap.Hash = headers[len(headers)-1].Hash()
+
+	return snap, nil
+}
+
+// validators retrieves the list of committee members in ascending order.
+func (s *Snapshot) validators() []common.Address {
+	validators := make([]common.Address, 0, len(s.Validators))
+	for validator := range s.Validators {
+		validators = append(validators, validator)
+	}
+	sort.Sort(validatorsAscending(validators))
+	return validators
+}
+
+// committeeSupply returns the total stake of the committee.
+func (s *Snapshot) committeeSupply() *big.Int {
+	supply := new(big.Int)
+	for _, validator := range s.Validators {
+		supply.Add(supply, validator.Stake)
+	}
+	return supply
+}
+
+// validatorInfo returns the sortition inputs of the given committee member, or
+// nil if the address is not part of the committee.
+func (s *Snapshot) validatorInfo(address common.Address) *ValidatorInfo {
+	validator, ok := s.Validators[address]
+	if !ok {
+		return nil
+	}
+	return &ValidatorInfo{
+		TotalSupply:     new(big.Int).Set(validator.Stake),
+		LastBlockHeight: new(big.Int).SetUint64(validator.LastBlockHeight),
+	}
+}
+
+// encodeCheckpoint serializes the committee into the fixed size checkpoint
+// extra-data format, ordered by ascending address.
+func encodeCheckpoint(validators []checkpointValidator) []byte {
+	sorted := make([]checkpointValidator, len(validators))
+	copy(sorted, validators)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].Address[:], sorted[j].Address[:]) < 0
+	})
+	blob := make([]byte, 0, len(sorted)*validatorBytesLength)
+	for _, validator := range sorted {
+		blob = append(blob, validator.Address[:]...)
+		blob = append(blob, common.BigToHash(validator.Stake).Bytes()...)
+
+		var height [8]byte
+		binary.BigEndian.PutUint64(height[:], validator.LastBlockHeight)
+		blob = append(blob, height[:]...)
+	}
+	return blob
+}
+
+// decodeCheckpoint extracts the committee from the extra-data of a checkpoint
+// header, whose vrf proof has the given length. The genesis header carries no
+// vrf proof nor seal.
+func decodeCheckpoint(header *types.Header, vrfLength int) ([]checkpointValidator, error) {
+	end := len(header.Extra) - vrfLength - extraSeal
+	if header.Number.Sign() == 0 {
+		end = len(header.Extra)
+	}
+	if end < extraVanity {
+		return nil, errInvalidCheckpointSigners
+	}
+	blob := header.Extra[extraVanity:end]
+	if len(blob)%validatorBytesLength != 0 {
+		return nil, errInvalidCheckpointSigners
+	}
+	validators := make([]checkpointValidator, len(blob)/validatorBytesLength)
+	for i := range validators {
+		entry := blob[i*validatorBytesLength : (i+1)*validatorBytesLength]
+
+		validators[i].Address = common.BytesToAddress(entry[:common.AddressLength])
+		validators[i].Stake = new(big.Int).SetBytes(entry[common.AddressLength : common.AddressLength+common.HashLength])
+		validators[i].LastBlockHeight = binary.BigEndian.Uint64(entry[common.AddressLength+common.HashLength:])
+	}
+	return validators, nil
+}
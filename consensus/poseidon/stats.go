@@ -0,0 +1,156 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poseidon
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// BlockSortition is the sortition data a block was sealed with, as reported to
+// the network monitoring services.
+type BlockSortition struct {
+	Signer          common.Address // Validator sealing the block
+	Name            string         // Name the validator registered with, if known
+	Retries         uint64         // Nonce retries the validator needed to win the sortition
+	Beta            []byte         // Output of the vrf proof of the block
+	CommitteeSupply *big.Int       // Stake of the committee the block was proposed in
+}
+
+// ValidatorStatus is the state of a single registered validator.
+type ValidatorStatus struct {
+	Address         common.Address
+	Name            string
+	Stake           *big.Int
+	LastBlockHeight *big.Int
+	SlashHeight     *big.Int
+}
+
+// ValidatorSet is the set of the registered validators at a given block, split
+// into the ones slashed since their last seal, the remaining committee members
+// and the ones not (yet) allowed to propose.
+type ValidatorSet struct {
+	Number   uint64
+	Active   []*ValidatorStatus
+	Slashed  []*ValidatorStatus
+	Inactive []*ValidatorStatus
+}
+
+// StatsEpoch returns the number of blocks the validator set should be reported
+// over at the given block: the committee checkpoint interval, or the heart rate
+// validators are slashed at if the committee lives in the ValidatorHub.
+func (c *Poseidon) StatsEpoch(number *big.Int) uint64 {
+	if c.config.IsCheckpointing(number) {
+		return c.config.Epoch
+	}
+	return c.config.ParamsAt(number).HeartRate
+}
+
+// BlockSortition verifies the seal of the given header and retrieves the
+// sortition data it was accepted with.
+func (c *Poseidon) BlockSortition(chain consensus.ChainHeaderReader, header *types.Header) (*BlockSortition, error) {
+	proof, err := c.verifySealProof(header)
+	if err != nil {
+		return nil, err
+	}
+	info, supply, err := c.proposerInfo(chain, header, nil, proof.signer)
+	if err != nil {
+		return nil, err
+	}
+	// Checkpointed committees carry no metadata, fill it in if the state is around
+	name := info.Name
+	if name == "" {
+		if info, err := c.GetValidatorInfo(proof.signer, header.Number); err == nil {
+			name = info.Name
+		}
+	}
+	return &BlockSortition{
+		Signer:          proof.signer,
+		Name:            name,
+		Retries:         header.Nonce.Uint64(),
+		Beta:            proof.beta,
+		CommitteeSupply: supply,
+	}, nil
+}
+
+// ValidatorSet retrieves the registered validators from the ValidatorHub state
+// the given block is built upon, split by whether they were slashed and whether
+// they are allowed to propose it.
+func (c *Poseidon) ValidatorSet(number *big.Int) (*ValidatorSet, error) {
+	validators, err := c.GetValidators(number)
+	if err != nil {
+		return nil, err
+	}
+	set := &ValidatorSet{
+		Number:   number.Uint64(),
+		Active:   make([]*ValidatorStatus, 0, len(validators)),
+		Slashed:  make([]*ValidatorStatus, 0),
+		Inactive: make([]*ValidatorStatus, 0),
+	}
+	for _, validator := range validators {
+		registered, err := c.IsValidator(validator, number)
+		if err != nil {
+			return nil, err
+		}
+		if !registered {
+			continue
+		}
+		info, err := c.GetValidatorInfo(validator, number)
+		if err != nil {
+			return nil, err
+		}
+		slashHeight, err := c.GetSlashHeight(validator, number)
+		if err != nil {
+			return nil, err
+		}
+		isProposer, err := c.IsProposer(validator, number)
+		if err != nil {
+			return nil, err
+		}
+		status := &ValidatorStatus{
+			Address:         validator,
+			Name:            info.Name,
+			Stake:           info.TotalSupply,
+			LastBlockHeight: info.LastBlockHeight,
+			SlashHeight:     slashHeight,
+		}
+		switch {
+		case isSlashed(info.LastBlockHeight, slashHeight):
+			set.Slashed = append(set.Slashed, status)
+		case isProposer:
+			set.Active = append(set.Active, status)
+		default:
+			set.Inactive = append(set.Inactive, status)
+		}
+	}
+	return set, nil
+}
+
+// isSlashed reports whether a validator was slashed since it last sealed a block.
+// The ValidatorHub stamps the slash height on registration, on the validator's
+// own header syncs and on slashes, while the last block height only moves with
+// the seals. A validator that never sealed can't be told apart from a freshly
+// registered one, so it isn't considered slashed.
+func isSlashed(lastBlockHeight, slashHeight *big.Int) bool {
+	if lastBlockHeight == nil || slashHeight == nil || lastBlockHeight.Sign() == 0 {
+		return false
+	}
+	return slashHeight.Cmp(lastBlockHeight) > 0
+}
@@ -0,0 +1,139 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poseidon
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the sortition data of sealed blocks and the validator set are
+// retrieved for the network monitoring.
+func TestStats(t *testing.T) {
+	h := newTesterHub(t, 3)
+	h.formCommittee(t)
+
+	block := h.seal(t, h.nodes, nil)
+	header := block.Header()
+
+	sortition, err := h.engine.BlockSortition(h.chain, header)
+	if err != nil {
+		t.Fatalf("failed to retrieve block sortition: %v", err)
+	}
+	index := -1
+	for i, node := range h.nodes {
+		if node.addr == sortition.Signer {
+			index = i
+		}
+	}
+	if index < 0 {
+		t.Fatalf("sortition signer %x not a validator", sortition.Signer)
+	}
+	if want := fmt.Sprintf("validator-%d", index); sortition.Name != want {
+		t.Errorf("validator name mismatch: have %q, want %q", sortition.Name, want)
+	}
+	if sortition.Retries != header.Nonce.Uint64() {
+		t.Errorf("retry count mismatch: have %d, want %d", sortition.Retries, header.Nonce.Uint64())
+	}
+	proof, err := h.engine.verifySealProof(header)
+	if err != nil {
+		t.Fatalf("failed to verify seal: %v", err)
+	}
+	if !bytes.Equal(sortition.Beta, proof.beta) {
+		t.Errorf("vrf output mismatch: have %x, want %x", sortition.Beta, proof.beta)
+	}
+	supply, err := h.engine.GetCommitteeSupply(header.Number, common.Address{})
+	if err != nil {
+		t.Fatalf("failed to retrieve committee supply: %v", err)
+	}
+	if sortition.CommitteeSupply.Cmp(supply) != 0 {
+		t.Errorf("committee supply mismatch: have %v, want %v", sortition.CommitteeSupply, supply)
+	}
+	// All registered validators joined the committee, none were slashed
+	set, err := h.engine.ValidatorSet(new(big.Int).Add(header.Number, common.Big1))
+	if err != nil {
+		t.Fatalf("failed to retrieve validator set: %v", err)
+	}
+	if len(set.Active) != len(h.nodes) || len(set.Slashed) != 0 || len(set.Inactive) != 0 {
+		t.Fatalf("validator set mismatch: have %d active, %d slashed and %d inactive, want %d active", len(set.Active), len(set.Slashed), len(set.Inactive), len(h.nodes))
+	}
+	stake := new(big.Int).Mul(big.NewInt(20000), ether)
+	for _, validator := range set.Active {
+		if validator.Stake.Cmp(stake) < 0 {
+			t.Errorf("validator %x stake mismatch: have %v, want at least %v", validator.Address, validator.Stake, stake)
+		}
+	}
+	if epoch := h.engine.StatsEpoch(header.Number); epoch != h.engine.config.ParamsAt(header.Number).HeartRate {
+		t.Errorf("stats epoch mismatch: have %d, want the heart rate", epoch)
+	}
+}
+
+// Tests that the validators slashed since their last seal are reported apart from
+// the committee members, while registered validators outside the committee are
+// reported as inactive.
+func TestStatsSlashed(t *testing.T) {
+	h := newTesterHub(t, 2)
+
+	// Registered validators are inactive until they join the committee
+	var txs []*types.Transaction
+	txs = append(txs, h.callHub(t, h.minter.key, nil, txs, "init"))
+	txs = append(txs, h.callHub(t, h.nodes[0].key, new(big.Int).Mul(big.NewInt(5000), ether), txs, "register", h.nodes[0].addr, "validator-0"))
+	block := h.seal(t, []*testerNode{h.minter}, txs)
+
+	set, err := h.engine.ValidatorSet(new(big.Int).Add(block.Number(), common.Big1))
+	if err != nil {
+		t.Fatalf("failed to retrieve validator set: %v", err)
+	}
+	if len(set.Active) != 0 || len(set.Slashed) != 0 || len(set.Inactive) != 1 {
+		t.Fatalf("validator set mismatch: have %d active, %d slashed and %d inactive, want 1 inactive", len(set.Active), len(set.Slashed), len(set.Inactive))
+	}
+	// Let both validators seal, then only the first one until the second is overdue
+	// and slashes itself, the only slash the hub accepts
+	h = newTesterHub(t, 2)
+	h.formCommittee(t)
+
+	node, peer := h.nodes[0], h.nodes[1]
+	h.seal(t, []*testerNode{peer}, nil)
+	for i := 0; i < params.DefaultPoseidonHeartRate; i++ {
+		h.seal(t, []*testerNode{node}, nil)
+	}
+	block = h.seal(t, []*testerNode{node}, []*types.Transaction{h.callHub(t, peer.key, nil, nil, "slash", peer.addr)})
+
+	set, err = h.engine.ValidatorSet(new(big.Int).Add(block.Number(), common.Big1))
+	if err != nil {
+		t.Fatalf("failed to retrieve validator set: %v", err)
+	}
+	if len(set.Active) != 1 || len(set.Slashed) != 1 || len(set.Inactive) != 0 {
+		t.Fatalf("validator set mismatch: have %d active, %d slashed and %d inactive, want 1 active and 1 slashed", len(set.Active), len(set.Slashed), len(set.Inactive))
+	}
+	if set.Active[0].Address != node.addr {
+		t.Errorf("active validator mismatch: have %x, want %x", set.Active[0].Address, node.addr)
+	}
+	slashed := set.Slashed[0]
+	if slashed.Address != peer.addr {
+		t.Errorf("slashed validator mismatch: have %x, want %x", slashed.Address, peer.addr)
+	}
+	if slashed.SlashHeight.Cmp(block.Number()) != 0 || slashed.LastBlockHeight.Cmp(slashed.SlashHeight) >= 0 {
+		t.Errorf("slash heights mismatch: have last block %v and slash %v, want slash %v", slashed.LastBlockHeight, slashed.SlashHeight, block.Number())
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poseidon
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// VerifySystemTransactions implements consensus.PoSA, checking the ValidatorHub
+// system transactions of a block. From the SystemTx fork on:
+//
+//   - The block must end with exactly one syncTendermintHeader transaction, sent
+//     by the block signer.
+//   - Its fee argument must be the total fees paid by the preceding transactions.
+//     As these are only known after execution, the fee is only checked if the
+//     receipts are given.
+//   - Every slash transaction must target a validator overdue on its heartbeat
+//     according to the hub state of the parent block, at most once per block.
+//
+// From the Evidence fork on, every double-sign evidence must prove that the
+// reported validator sealed two blocks of the same height, at most once per
+// block.
+//
+// Before the SystemTx fork, blocks sealed by the original miners carry the sync
+// header first with a zero fee, or none at all, so only the evidence is checked.
+func (c *Poseidon) VerifySystemTransactions(header *types.Header, txs []*types.Transaction, receipts []*types.Receipt) error {
+	if header.Number.Sign() == 0 {
+		return nil
+	}
+	var (
+		hub      = c.hubAt(header.Number)
+		systemTx = c.config.IsSystemTx(header.Number)
+		last     = len(txs) - 1
+	)
+	for i, tx := range txs {
+		if systemTx && i < last && systemcontracts.IsSyncHeaderTransition(hub, tx.To(), tx.Data()) {
+			return fmt.Errorf("%w: index %d of %d", errMisplacedSyncHeader, i, len(txs))
+		}
+		if systemTx && systemcontracts.IsSlashTransition(hub, tx.To(), tx.Data()) {
+			if err := c.VerifySlash(header, txs[:i], tx); err != nil {
+				return err
+			}
+		}
+		if c.config.IsEvidence(header.Number) && systemcontracts.IsDoubleSignTransition(hub, tx.To(), tx.Data()) {
+			if err := c.VerifyEvidence(header, txs[:i], tx); err != nil {
+				return err
+			}
+		}
+	}
+	if !systemTx {
+		return nil
+	}
+	if len(txs) == 0 {
+		return errMissingSyncHeader
+	}
+	sync := txs[last]
+	if !systemcontracts.IsSyncHeaderTransition(hub, sync.To(), sync.Data()) {
+		return errMissingSyncHeader
+	}
+	signer, err := c.Author(header)
+	if err != nil {
+		return err
+	}
+	sender, err := types.Sender(types.MakeSigner(c.chainConfig, header.Number), sync)
+	if err != nil {
+		return err
+	}
+	if sender != signer {
+		return fmt.Errorf("%w: have %x, want %x", errInvalidSyncHeaderSender, sender, signer)
+	}
+	if receipts == nil {
+		return nil
+	}
+	if len(receipts) != len(txs) {
+		return fmt.Errorf("receipt count mismatch: have %d, want %d", len(receipts), len(txs))
+	}
+	args, err := c.validatorSetABI.Methods["syncTendermintHeader"].Inputs.Unpack(sync.Data()[4:])
+	if err != nil {
+		return err
+	}
+	fee, want := args[0].(*big.Int), TotalFees(header, txs[:last], receipts[:last])
+	if fee.Cmp(want) != 0 {
+		return fmt.Errorf("%w: have %v, want %v", errInvalidSyncHeaderFee, fee, want)
+	}
+	return nil
+}
+
+// VerifySlash implements consensus.PoSA, checking that a slash transaction may
+// be included in the block of the given header after the given transactions. The
+// slashed validator must not have sealed a block for the configured heart rate
+// as of the parent state, and must not be slashed by any of the preceding
+// transactions.
+func (c *Poseidon) VerifySlash(header *types.Header, txs []*types.Transaction, tx *types.Transaction) error {
+	hub := c.hubAt(header.Number)
+	validator, err := slashTarget(hub, tx)
+	if err != nil {
+		return err
+	}
+	for _, prev := range txs {
+		if !systemcontracts.IsSlashTransition(hub, prev.To(), prev.Data()) {
+			continue
+		}
+		if target, err := slashTarget(hub, prev); err == nil && target == validator {
+			return fmt.Errorf("%w: %x", errDuplicateSlash, validator)
+		}
+	}
+	result, err := c.callHubAt("getValidatorInfo", validator, header.Number, rpc.BlockNumberOrHashWithHash(header.ParentHash, false), validator)
+	if err != nil {
+		return err
+	}
+	info := new(ValidatorInfo)
+	if err := c.validatorSetABI.UnpackIntoInterface(info, "getValidatorInfo", result); err != nil {
+		return err
+	}
+	number, lastBlockHeight := header.Number.Uint64(), info.LastBlockHeight.Uint64()
+	if number < lastBlockHeight || number-lastBlockHeight < c.config.ParamsAt(header.Number).HeartRate {
+		return fmt.Errorf("%w: %x last sealed #%d", errInvalidSlash, validator, lastBlockHeight)
+	}
+	return nil
+}
+
+// slashTarget decodes the validator slashed by a slash transaction of the given
+// ValidatorHub.
+func slashTarget(hub common.Address, tx *types.Transaction) (common.Address, error) {
+	if !systemcontracts.IsSlashTransition(hub, tx.To(), tx.Data()) {
+		return common.Address{}, fmt.Errorf("not a slash transaction: %x", tx.Hash())
+	}
+	return common.BytesToAddress(tx.Data()[4:36]), nil
+}
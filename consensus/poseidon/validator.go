@@ -2,12 +2,19 @@ package poseidon
 
 import (
 	"context"
+	"errors"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/ethereum/go-ethereum/core/systemcontracts"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
 	"math/big"
 )
 
@@ -24,24 +31,7 @@ func (p *Poseidon) IsValidator(validator common.Address, blockNumber *big.Int) (
 	// method
 	method := "isValidator"
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel() // cancel when we are finished consuming integers
-
-	data, err := p.validatorSetABI.Pack(method, validator)
-	if err != nil {
-		log.Error("Unable to pack tx for isValidator", "error", err)
-		return false, err
-	}
-	// call
-	msgData := (hexutil.Bytes)(data)
-	toAddress := common.HexToAddress(systemcontracts.ValidatorHubContract)
-	gas := (hexutil.Uint64)(gasCap)
-	result, err := p.ethAPI.Call(ctx, ethapi.TransactionArgs{
-		Gas:  &gas,
-		From: &p.val,
-		To:   &toAddress,
-		Data: &msgData,
-	}, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(blockNumber.Int64()-1)), nil)
+	result, err := p.callHub(method, p.val, blockNumber, validator)
 	if err != nil {
 		return false, err
 	}
@@ -58,24 +48,7 @@ func (p *Poseidon) GetValidatorInfo(validator common.Address, blockNumber *big.I
 	// method
 	method := "getValidatorInfo"
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel() // cancel when we are finished consuming integers
-
-	data, err := p.validatorSetABI.Pack(method, validator)
-	if err != nil {
-		log.Error("Unable to pack tx for getValidatorInfo", "error", err)
-		return nil, err
-	}
-	// call
-	msgData := (hexutil.Bytes)(data)
-	toAddress := common.HexToAddress(systemcontracts.ValidatorHubContract)
-	gas := (hexutil.Uint64)(gasCap)
-	result, err := p.ethAPI.Call(ctx, ethapi.TransactionArgs{
-		Gas:  &gas,
-		From: &p.val,
-		To:   &toAddress,
-		Data: &msgData,
-	}, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(blockNumber.Int64()-1)), nil)
+	result, err := p.callHub(method, p.val, blockNumber, validator)
 	if err != nil {
 		return nil, err
 	}
@@ -92,24 +65,7 @@ func (p *Poseidon) IsProposer(validator common.Address, blockNumber *big.Int) (b
 	// method
 	method := "isProposer"
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel() // cancel when we are finished consuming integers
-
-	data, err := p.validatorSetABI.Pack(method, validator)
-	if err != nil {
-		log.Error("Unable to pack tx for isValidator", "error", err)
-		return false, err
-	}
-	// call
-	msgData := (hexutil.Bytes)(data)
-	toAddress := common.HexToAddress(systemcontracts.ValidatorHubContract)
-	gas := (hexutil.Uint64)(gasCap)
-	result, err := p.ethAPI.Call(ctx, ethapi.TransactionArgs{
-		Gas:  &gas,
-		From: &p.val,
-		To:   &toAddress,
-		Data: &msgData,
-	}, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(blockNumber.Int64()-1)), nil)
+	result, err := p.callHub(method, p.val, blockNumber, validator)
 	if err != nil {
 		return false, err
 	}
@@ -121,28 +77,30 @@ func (p *Poseidon) IsProposer(validator common.Address, blockNumber *big.Int) (b
 	return *out, nil
 }
 
-func (p *Poseidon) GetCommitteeSupply(blockNumber *big.Int, signer common.Address) (*big.Int, error) {
+// GetSlashHeight retrieves the last block the ValidatorHub stamped the validator
+// at: its registration, its own header syncs and its slashes.
+func (p *Poseidon) GetSlashHeight(validator common.Address, blockNumber *big.Int) (*big.Int, error) {
 	// method
-	method := "getCommitteeSupply"
+	method := "getSlashHeight"
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel() // cancel when we are finished consuming integers
-
-	data, err := p.validatorSetABI.Pack(method)
+	result, err := p.callHub(method, p.val, blockNumber, validator)
 	if err != nil {
-		log.Error("Unable to pack tx for getCommitteeSupply", "error", err)
 		return nil, err
 	}
-	// call
-	msgData := (hexutil.Bytes)(data)
-	toAddress := common.HexToAddress(systemcontracts.ValidatorHubContract)
-	gas := (hexutil.Uint64)(gasCap)
-	result, err := p.ethAPI.Call(ctx, ethapi.TransactionArgs{
-		Gas:  &gas,
-		From: &signer,
-		To:   &toAddress,
-		Data: &msgData,
-	}, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(blockNumber.Int64()-1)), nil)
+
+	var out *big.Int
+
+	if err := p.validatorSetABI.UnpackIntoInterface(&out, method, result); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (p *Poseidon) GetCommitteeSupply(blockNumber *big.Int, signer common.Address) (*big.Int, error) {
+	// method
+	method := "getCommitteeSupply"
+
+	result, err := p.callHub(method, signer, blockNumber)
 	if err != nil {
 		return nil, err
 	}
@@ -159,32 +117,187 @@ func (p *Poseidon) GetValidators(blockNumber *big.Int) ([]common.Address, error)
 	// method
 	method := "getValidators"
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel() // cancel when we are finished consuming integers
+	result, err := p.callHub(method, p.val, blockNumber)
+	if err != nil {
+		return nil, err
+	}
 
-	data, err := p.validatorSetABI.Pack(method)
+	out := make([]common.Address, 0)
+
+	if err := p.validatorSetABI.UnpackIntoInterface(&out, method, result); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (p *Poseidon) GetGenesisMinter(blockNumber *big.Int) (common.Address, error) {
+	method := "genesisMinter"
+
+	result, err := p.callHub(method, p.val, blockNumber)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var out common.Address
+
+	if err := p.validatorSetABI.UnpackIntoInterface(&out, method, result); err != nil {
+		return common.Address{}, err
+	}
+	return out, nil
+}
+
+// GetProposers retrieves the validators allowed to propose the given block along
+// with their stake and last sealed block. While the committee is still empty the
+// genesis minter is the only proposer.
+func (p *Poseidon) GetProposers(blockNumber *big.Int) ([]checkpointValidator, error) {
+	return p.proposers(func(out interface{}, method string, args ...interface{}) error {
+		result, err := p.callHub(method, p.val, blockNumber, args...)
+		if err != nil {
+			return err
+		}
+		return p.validatorSetABI.UnpackIntoInterface(out, method, result)
+	})
+}
+
+// hubCaller executes a read-only ValidatorHub method against some fixed state
+// and unpacks the result into out.
+type hubCaller func(out interface{}, method string, args ...interface{}) error
+
+// hubCallerAt returns a hubCaller running against the given state through the
+// blockchain API, using the hub configured for the block with the given number.
+func (p *Poseidon) hubCallerAt(blockNumber *big.Int, blockNrOrHash rpc.BlockNumberOrHash) hubCaller {
+	return func(out interface{}, method string, args ...interface{}) error {
+		result, err := p.callHubAt(method, p.val, blockNumber, blockNrOrHash, args...)
+		if err != nil {
+			return err
+		}
+		return p.validatorSetABI.UnpackIntoInterface(out, method, result)
+	}
+}
+
+// hubCallerState returns a hubCaller running as implicit calls on top of the
+// given state, which the header is built upon.
+func (p *Poseidon) hubCallerState(chain consensus.ChainHeaderReader, header *types.Header, statedb *state.StateDB) hubCaller {
+	return func(out interface{}, method string, args ...interface{}) error {
+		return p.systemCall(chain, header, statedb, out, method, args...)
+	}
+}
+
+// proposers retrieves the proposers elected by the ValidatorHub, see GetProposers.
+func (p *Poseidon) proposers(call hubCaller) ([]checkpointValidator, error) {
+	var (
+		validators []common.Address
+		minter     common.Address
+	)
+	if err := call(&validators, "getValidators"); err != nil {
+		return nil, err
+	}
+	if err := call(&minter, "genesisMinter"); err != nil {
+		return nil, err
+	}
+	candidates := append(validators, minter)
+
+	proposers := make([]checkpointValidator, 0, len(candidates))
+	seen := make(map[common.Address]struct{})
+	for _, candidate := range candidates {
+		if _, ok := seen[candidate]; ok {
+			continue
+		}
+		seen[candidate] = struct{}{}
+
+		isProposer := new(bool)
+		if err := call(isProposer, "isProposer", candidate); err != nil {
+			return nil, err
+		}
+		if !*isProposer {
+			continue
+		}
+		info := new(ValidatorInfo)
+		if err := call(info, "getValidatorInfo", candidate); err != nil {
+			return nil, err
+		}
+		proposers = append(proposers, checkpointValidator{
+			Address:         candidate,
+			Stake:           info.TotalSupply,
+			LastBlockHeight: info.LastBlockHeight.Uint64(),
+		})
+	}
+	return proposers, nil
+}
+
+// isStateUnavailable reports whether a ValidatorHub call failed because the state
+// it runs against isn't available locally, rather than because of the state.
+func isStateUnavailable(err error) bool {
+	var missing *trie.MissingNodeError
+	return errors.Is(err, errNoContractAccess) || errors.As(err, &missing)
+}
+
+// callHub executes a read-only ValidatorHub method against the state the given
+// block is built upon, which is the canonical block preceding it.
+func (p *Poseidon) callHub(method string, from common.Address, blockNumber *big.Int, args ...interface{}) (hexutil.Bytes, error) {
+	parent := rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(blockNumber.Int64() - 1))
+	if blockNumber.Sign() > 0 {
+		if hash := rawdb.ReadCanonicalHash(p.db, blockNumber.Uint64()-1); hash != (common.Hash{}) {
+			parent = rpc.BlockNumberOrHashWithHash(hash, false)
+		}
+	}
+	return p.callHubAt(method, from, blockNumber, parent, args...)
+}
+
+// callHubAt executes a read-only ValidatorHub method against the given state,
+// using the hub and gas allowance configured for the block with the given number.
+func (p *Poseidon) callHubAt(method string, from common.Address, blockNumber *big.Int, blockNrOrHash rpc.BlockNumberOrHash, args ...interface{}) (hexutil.Bytes, error) {
+	data, err := p.validatorSetABI.Pack(method, args...)
 	if err != nil {
-		log.Error("Unable to pack tx for getValidators", "error", err)
+		log.Error("Unable to pack tx for "+method, "error", err)
 		return nil, err
 	}
-	// call
 	msgData := (hexutil.Bytes)(data)
-	toAddress := common.HexToAddress(systemcontracts.ValidatorHubContract)
-	gas := (hexutil.Uint64)(gasCap)
+	toAddress := p.hubAt(blockNumber)
+	gas := (hexutil.Uint64)(p.config.ParamsAt(blockNumber).GasCap)
+
+	// The state of a block is immutable, so calls against a hash can be cached
+	hash, cacheable := blockNrOrHash.Hash()
+	key := hubCallKey{block: hash, from: from, hub: toAddress, input: string(data)}
+	if cacheable {
+		if result, ok := p.hubCache.get(key); ok {
+			return result, nil
+		}
+	}
+	if p.ethAPI == nil {
+		return nil, errNoContractAccess
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	result, err := p.ethAPI.Call(ctx, ethapi.TransactionArgs{
 		Gas:  &gas,
-		From: &p.val,
+		From: &from,
 		To:   &toAddress,
 		Data: &msgData,
-	}, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(blockNumber.Int64()-1)), nil)
-	if err != nil {
-		return nil, err
+	}, blockNrOrHash, nil)
+	if err == nil && cacheable {
+		p.hubCache.add(key, result)
 	}
+	return result, err
+}
 
-	out := make([]common.Address, 0)
-
-	if err := p.validatorSetABI.UnpackIntoInterface(&out, method, result); err != nil {
-		return nil, err
+// systemCall executes a read-only ValidatorHub method as an implicit call on top
+// of the given state and unpacks the result into out. Unlike callHub it doesn't
+// need the blockchain API, so it can run during block processing.
+func (p *Poseidon) systemCall(chain consensus.ChainHeaderReader, header *types.Header, statedb *state.StateDB, out interface{}, method string, args ...interface{}) error {
+	data, err := p.validatorSetABI.Pack(method, args...)
+	if err != nil {
+		log.Error("Unable to pack tx for "+method, "error", err)
+		return err
 	}
-	return out, nil
+	var (
+		toAddress = p.hubAt(header.Number)
+		context   = core.NewEVMBlockContext(header, chainContext{Chain: chain, poseidon: p}, nil)
+		evm       = vm.NewEVM(context, vm.TxContext{Origin: header.Coinbase, GasPrice: common.Big0}, statedb, p.chainConfig, vm.Config{})
+	)
+	result, _, err := evm.Call(vm.AccountRef(header.Coinbase), toAddress, data, p.config.ParamsAt(header.Number).GasCap, common.Big0)
+	if err != nil {
+		return err
+	}
+	return p.validatorSetABI.UnpackIntoInterface(out, method, result)
 }
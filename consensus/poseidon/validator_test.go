@@ -0,0 +1,1593 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poseidon
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/vrf"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	// genesisMinter is the address the ValidatorHub bytecode of the genesis lets
+	// propose blocks until the committee is formed.
+	genesisMinter = common.HexToAddress(systemcontracts.GenesisMinter)
+
+	hubAddress = common.HexToAddress(systemcontracts.ValidatorHubContract)
+
+	testGasPrice = new(big.Int).SetUint64(2 * params.InitialBaseFee)
+)
+
+// testerBackend implements the subset of ethapi.Backend the engine needs to call
+// the ValidatorHub and to submit its heartbeat transactions.
+type testerBackend struct {
+	ethapi.Backend
+
+	chain    *core.BlockChain
+	accounts *accounts.Manager
+
+	lock sync.Mutex
+	txs  []*types.Transaction // Transactions submitted to the pool
+}
+
+func (b *testerBackend) ChainConfig() *params.ChainConfig  { return b.chain.Config() }
+func (b *testerBackend) CurrentHeader() *types.Header      { return b.chain.CurrentHeader() }
+func (b *testerBackend) CurrentBlock() *types.Block        { return b.chain.CurrentBlock() }
+func (b *testerBackend) AccountManager() *accounts.Manager { return b.accounts }
+func (b *testerBackend) RPCGasCap() uint64                 { return params.DefaultPoseidonGasCap }
+func (b *testerBackend) RPCTxFeeCap() float64              { return 1 }
+func (b *testerBackend) UnprotectedAllowed() bool          { return false }
+
+func (b *testerBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(params.GWei), nil
+}
+
+func (b *testerBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	var header *types.Header
+	if number, ok := blockNrOrHash.Number(); ok {
+		if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+			header = b.chain.CurrentHeader()
+		} else {
+			header = b.chain.GetHeaderByNumber(uint64(number))
+		}
+	} else if hash, ok := blockNrOrHash.Hash(); ok {
+		header = b.chain.GetHeaderByHash(hash)
+	}
+	if header == nil {
+		return nil, nil, errUnknownBlock
+	}
+	statedb, err := b.chain.StateAt(header.Root)
+	return statedb, header, err
+}
+
+func (b *testerBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmConfig *vm.Config) (*vm.EVM, func() error, error) {
+	context := core.NewEVMBlockContext(header, b.chain, nil)
+	return vm.NewEVM(context, core.NewEVMTxContext(msg), state, b.chain.Config(), *vmConfig), func() error { return nil }, nil
+}
+
+func (b *testerBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
+	statedb, err := b.chain.State()
+	if err != nil {
+		return 0, err
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	nonce := statedb.GetNonce(addr)
+	for _, tx := range b.txs {
+		if from, _ := types.Sender(types.LatestSigner(b.chain.Config()), tx); from == addr {
+			nonce++
+		}
+	}
+	return nonce, nil
+}
+
+func (b *testerBackend) SendTx(ctx context.Context, tx *types.Transaction) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.txs = append(b.txs, tx)
+	return nil
+}
+
+// pending returns the transactions submitted to the pool so far.
+func (b *testerBackend) pending() []*types.Transaction {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return append([]*types.Transaction{}, b.txs...)
+}
+
+// testerNode is a validator with its key in a keystore and its own engine
+// instance authorized to seal with it.
+type testerNode struct {
+	key    *ecdsa.PrivateKey
+	addr   common.Address
+	engine *Poseidon
+}
+
+// testerHub is a blockchain booted from the ValidatorHub bytecode of the chain
+// genesis, along with a set of validator nodes producing blocks on top of it.
+type testerHub struct {
+	config  *params.ChainConfig
+	chain   *core.BlockChain
+	engine  *Poseidon // Engine verifying the blocks imported into the chain
+	backend *testerBackend
+
+	minter *testerNode   // Proposer allowed to seal while the committee is empty
+	nodes  []*testerNode // Validators joining the committee
+}
+
+// newTesterHub boots a chain from genesis.json with a freshly generated genesis
+// minter and n funded, but not yet registered validators.
+func newTesterHub(t *testing.T, n int) *testerHub {
+	t.Helper()
+
+	blob, err := os.ReadFile("../../genesis.json")
+	if err != nil {
+		t.Fatalf("failed to read genesis: %v", err)
+	}
+	genesis := new(core.Genesis)
+	if err := json.Unmarshal(blob, genesis); err != nil {
+		t.Fatalf("failed to parse genesis: %v", err)
+	}
+	config := *genesis.Config
+	config.Poseidon = &params.PoseidonConfig{Period: 2, SystemTxBlock: common.Big0, SlotBlock: common.Big0}
+	genesis.Config = &config
+	genesis.Timestamp = uint64(time.Now().Add(-time.Hour).Unix())
+
+	// Create the keystore backed validators and fund them
+	ks := keystore.NewKeyStore(t.TempDir(), keystore.LightScryptN, keystore.LightScryptP)
+	newKey := func() *ecdsa.PrivateKey {
+		key, _ := crypto.GenerateKey()
+		account, err := ks.ImportECDSA(key, "")
+		if err != nil {
+			t.Fatalf("failed to import key: %v", err)
+		}
+		if err := ks.Unlock(account, ""); err != nil {
+			t.Fatalf("failed to unlock key: %v", err)
+		}
+		genesis.Alloc[account.Address] = core.GenesisAccount{Balance: new(big.Int).Mul(big.NewInt(100000), ether)}
+		return key
+	}
+	minterKey := newKey()
+	keys := make([]*ecdsa.PrivateKey, n)
+	for i := range keys {
+		keys[i] = newKey()
+	}
+	// The genesis minter is compiled into the hub, swap it for the local key
+	hub := genesis.Alloc[hubAddress]
+	if !bytes.Contains(hub.Code, genesisMinter.Bytes()) {
+		t.Fatalf("genesis minter not found in the ValidatorHub code")
+	}
+	hub.Code = bytes.ReplaceAll(hub.Code, genesisMinter.Bytes(), crypto.PubkeyToAddress(minterKey.PublicKey).Bytes())
+	genesis.Alloc[hubAddress] = hub
+
+	return bootTesterHub(t, genesis, ks, minterKey, keys)
+}
+
+// bootTesterHub boots a chain from the given genesis, with nodes for the minter
+// and the validator keys stored in the keystore.
+func bootTesterHub(t *testing.T, genesis *core.Genesis, ks *keystore.KeyStore, minterKey *ecdsa.PrivateKey, keys []*ecdsa.PrivateKey) *testerHub {
+	t.Helper()
+
+	config := *genesis.Config
+	db := rawdb.NewMemoryDatabase()
+	block := genesis.MustCommit(db)
+
+	engine := New(&config, db, nil, block.Hash())
+	chain, err := core.NewBlockChain(db, nil, &config, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	t.Cleanup(chain.Stop)
+
+	backend := &testerBackend{
+		chain:    chain,
+		accounts: accounts.NewManager(&accounts.Config{InsecureUnlockAllowed: true}, ks),
+	}
+	api, pool := ethapi.NewPublicBlockChainAPI(backend), ethapi.NewPublicTransactionPoolAPI(backend, new(ethapi.AddrLocker))
+	engine.ethAPI = api
+
+	newNode := func(key *ecdsa.PrivateKey) *testerNode {
+		node := &testerNode{
+			key:    key,
+			addr:   crypto.PubkeyToAddress(key.PublicKey),
+			engine: New(&config, db, api, block.Hash()),
+		}
+		wallet, err := backend.accounts.Find(accounts.Account{Address: node.addr})
+		if err != nil {
+			t.Fatalf("failed to find wallet: %v", err)
+		}
+		node.engine.Authorize(node.addr, wallet.SignData, wallet.SignTx, wallet.VrfProve)
+		node.engine.SetTxPoolAPI(pool)
+		return node
+	}
+	h := &testerHub{
+		config:  &config,
+		chain:   chain,
+		engine:  engine,
+		backend: backend,
+		minter:  newNode(minterKey),
+	}
+	for _, key := range keys {
+		h.nodes = append(h.nodes, newNode(key))
+	}
+	return h
+}
+
+// transact creates a transaction from the given key, using the nonce following
+// the head state and the transactions passed as pending.
+func (h *testerHub) transact(t *testing.T, key *ecdsa.PrivateKey, to common.Address, value *big.Int, data []byte, pending []*types.Transaction) *types.Transaction {
+	t.Helper()
+
+	statedb, err := h.chain.State()
+	if err != nil {
+		t.Fatalf("failed to retrieve head state: %v", err)
+	}
+	signer := types.LatestSigner(h.config)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	nonce := statedb.GetNonce(from)
+	for _, tx := range pending {
+		if sender, _ := types.Sender(signer, tx); sender == from {
+			nonce++
+		}
+	}
+	tx, err := types.SignNewTx(key, signer, &types.LegacyTx{
+		Nonce:    nonce,
+		To:       &to,
+		Value:    value,
+		Gas:      3000000,
+		GasPrice: testGasPrice,
+		Data:     data,
+	})
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	return tx
+}
+
+// callHub creates a transaction invoking a ValidatorHub method.
+func (h *testerHub) callHub(t *testing.T, key *ecdsa.PrivateKey, value *big.Int, pending []*types.Transaction, method string, args ...interface{}) *types.Transaction {
+	t.Helper()
+
+	data, err := h.engine.validatorSetABI.Pack(method, args...)
+	if err != nil {
+		t.Fatalf("failed to pack %s: %v", method, err)
+	}
+	return h.transact(t, key, hubAddress, value, data, pending)
+}
+
+// propose assembles an unsealed block of the given node on top of the chain head.
+func (h *testerHub) propose(t *testing.T, node *testerNode, txs []*types.Transaction) *types.Block {
+	t.Helper()
+
+	parent := h.chain.CurrentBlock()
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   parent.GasLimit(),
+	}
+	if h.config.IsLondon(header.Number) {
+		header.BaseFee = misc.CalcBaseFee(h.config, parent.Header())
+	}
+	if err := node.engine.Prepare(h.chain, header); err != nil {
+		t.Fatalf("failed to prepare block: %v", err)
+	}
+	// Keep the chain in the past, the engine would otherwise stamp the wall clock
+	header.Time, _ = slotStart(parent.Time(), h.config.Poseidon.Period, header.Nonce.Uint64())
+
+	statedb, err := h.chain.StateAt(parent.Root())
+	if err != nil {
+		t.Fatalf("failed to retrieve parent state: %v", err)
+	}
+	if err := systemcontracts.UpgradeSystemContracts(h.config, header.Number, statedb); err != nil {
+		t.Fatalf("failed to upgrade system contracts: %v", err)
+	}
+
+	var (
+		gp       = new(core.GasPool).AddGas(header.GasLimit)
+		receipts = make([]*types.Receipt, len(txs))
+	)
+	for i, tx := range txs {
+		statedb.Prepare(tx.Hash(), i)
+		receipt, err := core.ApplyTransaction(h.config, h.chain, &header.Coinbase, gp, statedb, header, tx, &header.GasUsed, vm.Config{})
+		if err != nil {
+			t.Fatalf("failed to apply transaction %d: %v", i, err)
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			t.Fatalf("transaction %d failed", i)
+		}
+		receipts[i] = receipt
+	}
+	// Close the block with the header sync of the node, which reverts for the
+	// genesis minter as it isn't a registered validator
+	signer := types.MakeSigner(h.config, header.Number)
+	syncs, err := node.engine.GetSystemTransaction(signer, statedb, header, TotalFees(header, txs, receipts))
+	if err != nil {
+		t.Fatalf("failed to create header sync: %v", err)
+	}
+	sync := syncs.Peek()
+	statedb.Prepare(sync.Hash(), len(txs))
+	receipt, err := core.ApplyTransaction(h.config, h.chain, &header.Coinbase, gp, statedb, header, sync, &header.GasUsed, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to apply header sync: %v", err)
+	}
+	txs, receipts = append(txs[:len(txs):len(txs)], sync), append(receipts, receipt)
+
+	block, err := node.engine.FinalizeAndAssemble(h.chain, header, statedb, txs, nil, receipts)
+	if err != nil {
+		t.Fatalf("failed to assemble block: %v", err)
+	}
+	return block
+}
+
+// seal lets all the given nodes propose a block and seal it through the engine,
+// imports the first one sealed and returns it.
+func (h *testerHub) seal(t *testing.T, nodes []*testerNode, txs []*types.Transaction) *types.Block {
+	t.Helper()
+
+	block := h.sealBlock(t, nodes, txs)
+	if _, err := h.chain.InsertChain(types.Blocks{block}); err != nil {
+		t.Fatalf("failed to import sealed block %d: %v", block.NumberU64(), err)
+	}
+	return block
+}
+
+// sealBlock lets all the given nodes propose a block and seal it through the
+// engine, returning the first one sealed without importing it.
+func (h *testerHub) sealBlock(t *testing.T, nodes []*testerNode, txs []*types.Transaction) *types.Block {
+	t.Helper()
+
+	return h.sealWith(t, nodes, func(node *testerNode) *types.Block { return h.propose(t, node, txs) })
+}
+
+// sealWith lets all the given nodes seal the block assembled for them by build,
+// returning the first one sealed without importing it.
+func (h *testerHub) sealWith(t *testing.T, nodes []*testerNode, build func(node *testerNode) *types.Block) *types.Block {
+	t.Helper()
+
+	var (
+		results = make(chan *types.Block, len(nodes))
+		stop    = make(chan struct{})
+	)
+	defer close(stop)
+
+	for _, node := range nodes {
+		if err := node.engine.Seal(h.chain, build(node), results, stop); err != nil {
+			t.Fatalf("failed to seal block: %v", err)
+		}
+	}
+	select {
+	case block := <-results:
+		return block
+	case <-time.After(30 * time.Second):
+		t.Fatalf("no block sealed")
+	}
+	return nil
+}
+
+// formCommittee seals the blocks registering all nodes and depositing the stake
+// needed to join the committee.
+func (h *testerHub) formCommittee(t *testing.T) {
+	t.Helper()
+
+	var txs []*types.Transaction
+	txs = append(txs, h.callHub(t, h.minter.key, nil, txs, "init"))
+	for i, node := range h.nodes {
+		register := new(big.Int).Mul(big.NewInt(5000), ether)
+		txs = append(txs, h.callHub(t, node.key, register, txs, "register", node.addr, fmt.Sprintf("validator-%d", i)))
+	}
+	h.seal(t, []*testerNode{h.minter}, txs)
+
+	txs = txs[:0]
+	for _, node := range h.nodes {
+		info, err := h.engine.GetValidatorInfo(node.addr, new(big.Int).Add(h.chain.CurrentBlock().Number(), common.Big1))
+		if err != nil {
+			t.Fatalf("failed to retrieve validator info: %v", err)
+		}
+		deposit := new(big.Int).Mul(big.NewInt(20000), ether)
+		txs = append(txs, h.transact(t, node.key, info.RewardAddr, deposit, common.FromHex("0xd0e30db0"), txs))
+	}
+	h.seal(t, []*testerNode{h.minter}, txs)
+}
+
+// resign replaces the seal of the header with a signature of the given key.
+func resign(t *testing.T, header *types.Header, key *ecdsa.PrivateKey, chainId *big.Int) {
+	t.Helper()
+
+	sig, err := crypto.Sign(sealHash(header, chainId, extraVrf).Bytes(), key)
+	if err != nil {
+		t.Fatalf("failed to sign header: %v", err)
+	}
+	copy(header.Extra[len(header.Extra)-extraSeal:], sig)
+}
+
+// reseal replaces both the vrf proof and the seal of the header with ones made
+// by the given key.
+func reseal(t *testing.T, header *types.Header, key *ecdsa.PrivateKey, chainId *big.Int) {
+	t.Helper()
+
+	alpha := make([]byte, 0, common.HashLength+len(header.Nonce))
+	alpha = append(append(alpha, header.ParentHash[:]...), header.Nonce[:]...)
+	_, pi, err := vrf.Prove(key, alpha)
+	if err != nil {
+		t.Fatalf("failed to prove vrf: %v", err)
+	}
+	copy(header.Extra[len(header.Extra)-extraSeal-extraVrf:], pi)
+	resign(t, header, key, chainId)
+}
+
+// Tests that the genesis minter seals blocks until the committee is formed, at
+// which point the registered validators take over.
+func TestSealCommittee(t *testing.T) {
+	h := newTesterHub(t, 3)
+
+	next := func() *big.Int { return new(big.Int).Add(h.chain.CurrentBlock().Number(), common.Big1) }
+	if ok, err := h.engine.IsProposer(h.minter.addr, next()); err != nil || !ok {
+		t.Fatalf("genesis minter proposer mismatch: have %v, want true (err %v)", ok, err)
+	}
+	h.formCommittee(t)
+
+	if ok, err := h.engine.IsProposer(h.minter.addr, next()); err != nil || ok {
+		t.Fatalf("genesis minter proposer mismatch: have %v, want false (err %v)", ok, err)
+	}
+	supply, err := h.engine.GetCommitteeSupply(next(), common.Address{})
+	if err != nil {
+		t.Fatalf("failed to retrieve committee supply: %v", err)
+	}
+	if want := new(big.Int).Mul(big.NewInt(int64(20000*len(h.nodes))), ether); supply.Cmp(want) < 0 {
+		t.Fatalf("committee supply mismatch: have %v, want at least %v", supply, want)
+	}
+	for _, node := range h.nodes {
+		if ok, err := h.engine.IsProposer(node.addr, next()); err != nil || !ok {
+			t.Fatalf("validator %x proposer mismatch: have %v, want true (err %v)", node.addr, ok, err)
+		}
+	}
+	// Let the committee seal a few blocks and ensure they are all members
+	members := make(map[common.Address]bool)
+	for _, node := range h.nodes {
+		members[node.addr] = true
+	}
+	for i := 0; i < 8; i++ {
+		block := h.seal(t, h.nodes, nil)
+		signer, err := h.engine.Author(block.Header())
+		if err != nil {
+			t.Fatalf("block %d: failed to recover signer: %v", block.NumberU64(), err)
+		}
+		if !members[signer] {
+			t.Fatalf("block %d: sealed by non-member %x", block.NumberU64(), signer)
+		}
+	}
+}
+
+// Tests that the signers API reports the genesis minter for the blocks sealed
+// before the committee is formed, and the committee afterwards.
+func TestGetSigners(t *testing.T) {
+	h := newTesterHub(t, 2)
+	api := &API{chain: h.chain, poseidon: h.engine}
+
+	h.formCommittee(t)
+	first := rpc.BlockNumber(1)
+	signers, err := api.GetSigners(&first)
+	if err != nil {
+		t.Fatalf("failed to retrieve signers: %v", err)
+	}
+	if len(signers) != 1 || signers[0] != h.minter.addr {
+		t.Fatalf("early signers mismatch: have %x, want [%x]", signers, h.minter.addr)
+	}
+	committee, err := api.GetCommittee(&first)
+	if err != nil {
+		t.Fatalf("failed to retrieve committee: %v", err)
+	}
+	if len(committee.Members) != 1 || committee.Members[0].Address != h.minter.addr {
+		t.Fatalf("early committee mismatch: have %d members, want the genesis minter", len(committee.Members))
+	}
+	last := rpc.BlockNumber(h.seal(t, h.nodes, nil).NumberU64())
+	if signers, err = api.GetSigners(&last); err != nil {
+		t.Fatalf("failed to retrieve signers: %v", err)
+	}
+	if len(signers) != len(h.nodes) {
+		t.Fatalf("committee signers mismatch: have %x, want %d validators", signers, len(h.nodes))
+	}
+	members := make(map[common.Address]bool)
+	for _, signer := range signers {
+		members[signer] = true
+	}
+	for i, node := range h.nodes {
+		if !members[node.addr] {
+			t.Fatalf("validator %d missing from the signers %x", i, signers)
+		}
+	}
+}
+
+// Tests that verifySeal accepts blocks sealed by committee members and rejects
+// any tampering with the sortition proof or the proposer.
+func TestVerifySeal(t *testing.T) {
+	h := newTesterHub(t, 3)
+	h.formCommittee(t)
+
+	// Create a validator that's not part of the committee
+	outsider, _ := crypto.GenerateKey()
+
+	sealed := h.sealBlock(t, h.nodes, nil)
+
+	var signer *testerNode
+	for _, node := range h.nodes {
+		if addr, _ := h.engine.Author(sealed.Header()); addr == node.addr {
+			signer = node
+		}
+	}
+	tests := []struct {
+		name   string
+		tamper func(header *types.Header)
+		fail   bool  // Whether verification must fail
+		err    error // Specific error expected, if any
+	}{
+		{
+			name:   "valid",
+			tamper: func(header *types.Header) {},
+		},
+		{
+			name: "bad vrf proof",
+			tamper: func(header *types.Header) {
+				header.Extra[len(header.Extra)-extraSeal-extraVrf/2] ^= 0xff
+			},
+			fail: true,
+		},
+		{
+			name: "vrf proof of another nonce",
+			tamper: func(header *types.Header) {
+				header.Nonce = types.EncodeNonce(header.Nonce.Uint64() + 1)
+			},
+			fail: true,
+		},
+		{
+			name: "wrong difficulty",
+			tamper: func(header *types.Header) {
+				header.Difficulty = new(big.Int).Add(header.Difficulty, common.Big1)
+			},
+			fail: true,
+			err:  errInvalidDifficulty,
+		},
+		{
+			name: "unauthorized proposer",
+			tamper: func(header *types.Header) {
+				reseal(t, header, outsider, h.config.ChainID)
+			},
+			fail: true,
+			err:  errUnauthorizedProposer,
+		},
+		{
+			name: "retired genesis minter",
+			tamper: func(header *types.Header) {
+				reseal(t, header, h.minter.key, h.config.ChainID)
+			},
+			fail: true,
+			err:  errUnauthorizedProposer,
+		},
+	}
+	for _, tt := range tests {
+		header := types.CopyHeader(sealed.Header())
+		tt.tamper(header)
+
+		err := h.engine.verifySeal(h.chain, header, nil)
+		switch {
+		case !tt.fail && err != nil:
+			t.Errorf("%s: verification failed: %v", tt.name, err)
+		case tt.fail && err == nil:
+			t.Errorf("%s: tampered seal accepted", tt.name)
+		case tt.err != nil && err != tt.err:
+			t.Errorf("%s: error mismatch: have %v, want %v", tt.name, err, tt.err)
+		}
+	}
+	// Ensure the seal is bound to the signer and the chain id
+	header := types.CopyHeader(sealed.Header())
+	if err := h.engine.verifySeal(h.chain, header, nil); err != nil {
+		t.Fatalf("failed to verify seal of %x: %v", signer.addr, err)
+	}
+	resign(t, header, signer.key, new(big.Int).Add(h.config.ChainID, common.Big1))
+	if _, addr, _ := ecrecover(header, h.engine.signatures, h.config.ChainID, extraVrf); addr == signer.addr {
+		t.Errorf("seal of another chain id accepted")
+	}
+}
+
+// Tests that headers timestamped before the slot of their nonce, or from the
+// future, are rejected.
+func TestVerifyTimestamp(t *testing.T) {
+	h := newTesterHub(t, 1)
+	h.formCommittee(t)
+
+	parent := h.chain.CurrentBlock()
+	sealed := h.sealBlock(t, h.nodes, nil)
+
+	// Every nonce retry takes one more slot after the period
+	slot := slotLength(h.config.Poseidon.Period)
+	start, _ := slotStart(parent.Time(), h.config.Poseidon.Period, sealed.Nonce())
+
+	tests := []struct {
+		time  uint64
+		nonce uint64
+		err   error
+	}{
+		{start, sealed.Nonce(), nil},
+		{start + slot, sealed.Nonce(), nil},
+		{start - 1, sealed.Nonce(), errInvalidTimestamp},
+		{parent.Time(), sealed.Nonce(), errInvalidTimestamp},
+		{start, sealed.Nonce() + 1, errInvalidTimestamp},
+		{start, math.MaxUint64, errInvalidTimestamp},
+		{uint64(time.Now().Add(time.Hour).Unix()), sealed.Nonce(), consensus.ErrFutureBlock},
+	}
+	for i, tt := range tests {
+		header := types.CopyHeader(sealed.Header())
+		header.Time, header.Nonce = tt.time, types.EncodeNonce(tt.nonce)
+		resign(t, header, h.nodes[0].key, h.config.ChainID)
+
+		if err := h.engine.VerifyHeader(h.chain, header, true); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+	// Ahead of the slot fork, only the period after the parent is enforced
+	h.config.Poseidon.SlotBlock = nil
+	defer func() { h.config.Poseidon.SlotBlock = common.Big0 }()
+
+	legacy := []struct {
+		time  uint64
+		nonce uint64
+		err   error
+	}{
+		{parent.Time() + h.config.Poseidon.Period, sealed.Nonce(), nil},
+		{parent.Time() + h.config.Poseidon.Period - 1, sealed.Nonce(), errInvalidTimestamp},
+	}
+	for i, tt := range legacy {
+		header := types.CopyHeader(sealed.Header())
+		header.Time, header.Nonce = tt.time, types.EncodeNonce(tt.nonce)
+		resign(t, header, h.nodes[0].key, h.config.ChainID)
+
+		if err := h.engine.VerifyHeader(h.chain, header, true); err != tt.err {
+			t.Errorf("legacy test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+// Tests that recommits on top of the same parent reuse the winning nonce instead
+// of proving the retry window again.
+func TestPrepareCachesNonce(t *testing.T) {
+	h := newTesterHub(t, 1)
+	h.formCommittee(t)
+
+	node := h.nodes[0]
+	var proofs int
+	prove := node.engine.vrfFn
+	node.engine.Authorize(node.addr, node.engine.signFn, node.engine.signTxFn, func(signer accounts.Account, alpha []byte) ([]byte, []byte, error) {
+		proofs++
+		return prove(signer, alpha)
+	})
+	parent := h.chain.CurrentBlock()
+	prepare := func() uint64 {
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).Add(parent.Number(), common.Big1),
+			GasLimit:   parent.GasLimit(),
+		}
+		if err := node.engine.Prepare(h.chain, header); err != nil {
+			t.Fatalf("failed to prepare block: %v", err)
+		}
+		return header.Nonce.Uint64()
+	}
+	nonce := prepare()
+	if proofs == 0 || uint64(proofs) != nonce+1 {
+		t.Fatalf("proof count mismatch: have %d, want %d", proofs, nonce+1)
+	}
+	if recommit := prepare(); recommit != nonce {
+		t.Fatalf("recommit nonce mismatch: have %d, want %d", recommit, nonce)
+	}
+	if uint64(proofs) != nonce+1 {
+		t.Fatalf("recommit proved again: have %d proofs, want %d", proofs, nonce+1)
+	}
+}
+
+// Tests that the winning nonce of the next block is searched for as soon as its
+// parent becomes the head, so that preparing the block doesn't prove any nonce.
+func TestPrecomputeNonce(t *testing.T) {
+	h := newTesterHub(t, 1)
+	h.formCommittee(t)
+
+	node := h.nodes[0]
+	var proofs int32
+	prove := node.engine.vrfFn
+	node.engine.Authorize(node.addr, node.engine.signFn, node.engine.signTxFn, func(signer accounts.Account, alpha []byte) ([]byte, []byte, error) {
+		atomic.AddInt32(&proofs, 1)
+		return prove(signer, alpha)
+	})
+	events := &testerChainEvents{ChainHeaderReader: h.chain}
+	node.engine.SubscribeChainEvents(events)
+	defer node.engine.Close()
+
+	parent := h.chain.CurrentBlock()
+	events.headFeed.Send(core.ChainHeadEvent{Block: parent})
+
+	key := nonceKey{parent: parent.Hash(), signer: node.addr}
+	for start := time.Now(); !node.engine.nonces.Contains(key); time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("winning nonce not precomputed")
+		}
+	}
+	precomputed := atomic.LoadInt32(&proofs)
+	if precomputed == 0 {
+		t.Fatalf("winning nonce precomputed without proofs")
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   parent.GasLimit(),
+	}
+	if err := node.engine.Prepare(h.chain, header); err != nil {
+		t.Fatalf("failed to prepare block: %v", err)
+	}
+	if nonce, _ := node.engine.nonces.Get(key); header.Nonce.Uint64() != nonce.(uint64) {
+		t.Fatalf("prepared nonce mismatch: have %d, want %d", header.Nonce.Uint64(), nonce)
+	}
+	if proofs := atomic.LoadInt32(&proofs); proofs != precomputed {
+		t.Fatalf("prepare proved again: have %d proofs, want %d", proofs, precomputed)
+	}
+}
+
+// Tests that ahead of the slot fork the block is prepared after the period
+// without searching the nonce, which is retried every half period when sealing
+// instead, ending up at the first winning nonce.
+func TestSealNonceBeforeSlotFork(t *testing.T) {
+	h := newTesterHub(t, 1)
+	h.formCommittee(t)
+
+	h.config.Poseidon.SlotBlock = nil
+	defer func() { h.config.Poseidon.SlotBlock = common.Big0 }()
+
+	node := h.nodes[0]
+	var proofs int32
+	prove := node.engine.vrfFn
+	node.engine.Authorize(node.addr, node.engine.signFn, node.engine.signTxFn, func(signer accounts.Account, alpha []byte) ([]byte, []byte, error) {
+		atomic.AddInt32(&proofs, 1)
+		return prove(signer, alpha)
+	})
+	parent := h.chain.CurrentBlock()
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   parent.GasLimit(),
+	}
+	if err := node.engine.Prepare(h.chain, header); err != nil {
+		t.Fatalf("failed to prepare block: %v", err)
+	}
+	if proofs := atomic.LoadInt32(&proofs); proofs != 0 {
+		t.Fatalf("prepare proved %d nonces", proofs)
+	}
+	if header.Nonce.Uint64() != 0 || header.Time < parent.Time()+h.config.Poseidon.Period {
+		t.Fatalf("prepared header mismatch: nonce %d, time %d", header.Nonce.Uint64(), header.Time)
+	}
+	block := h.sealBlock(t, []*testerNode{node}, nil)
+	if want, err := node.engine.firstWinningNonce(h.chain, block.Header()); err != nil || block.Nonce() != want {
+		t.Fatalf("sealed nonce mismatch: have %d, want %d (%v)", block.Nonce(), want, err)
+	}
+	if err := h.engine.VerifyHeader(h.chain, block.Header(), true); err != nil {
+		t.Fatalf("failed to verify sealed block: %v", err)
+	}
+}
+
+// Tests that a chain verified against the ValidatorHub state switches over to
+// the committee checkpoints at the checkpoint block, and that checkpoints not
+// matching the hub state are rejected.
+func TestCheckpointFork(t *testing.T) {
+	h := newTesterHub(t, 3)
+	h.formCommittee(t)
+
+	h.config.Poseidon.Epoch = 4
+	h.config.Poseidon.CheckpointBlock = big.NewInt(4)
+	for h.chain.CurrentBlock().NumberU64() < 9 {
+		h.seal(t, h.nodes, nil)
+	}
+	for _, number := range []uint64{4, 8} {
+		header := h.chain.GetHeaderByNumber(number)
+		validators, err := decodeCheckpoint(header, extraVrf)
+		if err != nil {
+			t.Fatalf("block %d: failed to decode checkpoint: %v", number, err)
+		}
+		if len(validators) != len(h.nodes) {
+			t.Fatalf("block %d: checkpoint committee size mismatch: have %d, want %d", number, len(validators), len(h.nodes))
+		}
+		statedb, err := h.chain.StateAt(h.chain.GetHeaderByNumber(number - 1).Root)
+		if err != nil {
+			t.Fatalf("block %d: failed to retrieve parent state: %v", number, err)
+		}
+		if err := h.engine.VerifyCheckpoint(h.chain, header, statedb); err != nil {
+			t.Fatalf("block %d: checkpoint rejected: %v", number, err)
+		}
+		// Inflate the stake of a committee member and check both verifications
+		validators[0].Stake = new(big.Int).Add(validators[0].Stake, ether)
+		tampered := types.CopyHeader(header)
+		tampered.Extra = append(append(append([]byte{}, header.Extra[:extraVanity]...), encodeCheckpoint(validators)...), header.Extra[len(header.Extra)-extraVrf-extraSeal:]...)
+
+		if err := h.engine.VerifyCheckpoint(h.chain, tampered, statedb); err != errMismatchingCheckpointSigners {
+			t.Errorf("block %d: tampered checkpoint error mismatch: have %v, want %v", number, err, errMismatchingCheckpointSigners)
+		}
+		if err := h.engine.verifyCheckpoint(h.chain, tampered, nil); err != errMismatchingCheckpointSigners {
+			t.Errorf("block %d: tampered checkpoint header error mismatch: have %v, want %v", number, err, errMismatchingCheckpointSigners)
+		}
+	}
+	// The blocks before the checkpoint block follow the hub state
+	if _, err := h.engine.snapshot(h.chain, 3, h.chain.GetHeaderByNumber(3).Hash(), nil); err != errNoSnapshots {
+		t.Errorf("pre-checkpoint snapshot error mismatch: have %v, want %v", err, errNoSnapshots)
+	}
+	if _, err := h.engine.snapshot(h.chain, 9, h.chain.GetHeaderByNumber(9).Hash(), nil); err != nil {
+		t.Errorf("failed to retrieve snapshot: %v", err)
+	}
+}
+
+// Tests that a validator which didn't seal a block for longer than the heart
+// rate submits a slash transaction, but only once in a while.
+func TestHeartbeat(t *testing.T) {
+	h := newTesterHub(t, 1)
+
+	node := h.minter
+	for i := 0; i < params.DefaultPoseidonHeartRate; i++ {
+		h.seal(t, []*testerNode{node}, nil)
+	}
+	number := new(big.Int).Add(h.chain.CurrentBlock().Number(), common.Big1)
+	info, err := node.engine.GetValidatorInfo(node.addr, number)
+	if err != nil {
+		t.Fatalf("failed to retrieve validator info: %v", err)
+	}
+	if overdue := number.Uint64()-info.LastBlockHeight.Uint64() >= params.DefaultPoseidonHeartRate; !overdue {
+		t.Fatalf("validator not overdue at block %d, last sealed %d", number, info.LastBlockHeight)
+	}
+	if err := node.engine.Heartbeat(number); err != nil {
+		t.Fatalf("failed to beat: %v", err)
+	}
+	pending := h.backend.pending()
+	if len(pending) != 1 {
+		t.Fatalf("slash transaction count mismatch: have %d, want 1", len(pending))
+	}
+	if to := pending[0].To(); to == nil || *to != hubAddress {
+		t.Fatalf("slash recipient mismatch: have %v, want %x", to, hubAddress)
+	}
+	if !systemcontracts.IsSlashTransition(hubAddress, pending[0].To(), pending[0].Data()) {
+		t.Fatalf("transaction is not a slash: %x", pending[0].Data())
+	}
+	// A beat in the following few blocks must not slash again
+	for i := int64(0); i <= 3; i++ {
+		if err := node.engine.Heartbeat(new(big.Int).Add(number, big.NewInt(i))); err != nil {
+			t.Fatalf("failed to beat: %v", err)
+		}
+	}
+	if pending := h.backend.pending(); len(pending) != 1 {
+		t.Fatalf("slash transaction count mismatch: have %d, want 1", len(pending))
+	}
+}
+
+// Tests that competing blocks of equal height are chosen between by the number
+// of nonce retries and the vrf output, regardless of their difficulty and of
+// the order they are imported in.
+func TestForkChoice(t *testing.T) {
+	h := newTesterHub(t, 3)
+	h.formCommittee(t)
+	h.config.Poseidon.ForkChoiceBlock = common.Big0
+
+	// Let every validator seal its own block on top of the same parent
+	parent := h.chain.CurrentBlock()
+	siblings := make([]*types.Block, len(h.nodes))
+	for i, node := range h.nodes {
+		siblings[i] = h.sealBlock(t, []*testerNode{node}, nil)
+	}
+	best := siblings[0]
+	for _, block := range siblings[1:] {
+		if h.engine.compareSeals(block.Header(), best.Header()) < 0 {
+			best = block
+		}
+	}
+	for i := len(siblings) - 1; i >= 0; i-- {
+		if _, err := h.chain.InsertChain(types.Blocks{siblings[i]}); err != nil {
+			t.Fatalf("failed to import sibling %d: %v", i, err)
+		}
+	}
+	if head := h.chain.CurrentBlock(); head.Hash() != best.Hash() {
+		t.Fatalf("head mismatch: have %x (nonce %d), want %x (nonce %d)", head.Hash(), head.Nonce(), best.Hash(), best.Nonce())
+	}
+	// Heads of different height are still compared by total difficulty
+	var (
+		localTd  = h.chain.GetTd(best.Hash(), best.NumberU64())
+		parentTd = h.chain.GetTd(parent.Hash(), parent.NumberU64())
+	)
+	if h.engine.ReorgNeeded(h.chain, best.Header(), parent.Header(), nil, localTd, parentTd) {
+		t.Fatalf("reorged to a lower total difficulty")
+	}
+	if !h.engine.ReorgNeeded(h.chain, parent.Header(), best.Header(), nil, parentTd, localTd) {
+		t.Fatalf("didn't reorg to a higher total difficulty")
+	}
+}
+
+// Tests that from the fork choice fork on, heads are chosen by height regardless
+// of their total difficulty and never switch away from the finalized block.
+func TestForkChoiceFork(t *testing.T) {
+	h := newTesterHub(t, 2)
+	h.formCommittee(t)
+
+	// Import two siblings, one of them becoming the canonical head
+	siblings := types.Blocks{h.sealBlock(t, h.nodes[:1], nil), h.sealBlock(t, h.nodes[1:], nil)}
+	for _, block := range siblings {
+		if _, err := h.chain.InsertChain(types.Blocks{block}); err != nil {
+			t.Fatalf("failed to import sibling: %v", err)
+		}
+	}
+	head, side := siblings[0].Header(), siblings[1].Header()
+	if h.chain.CurrentBlock().Hash() == side.Hash() {
+		head, side = side, head
+	}
+	child := func(parent *types.Header) *types.Header {
+		header := types.CopyHeader(parent)
+		header.Number = new(big.Int).Add(parent.Number, common.Big1)
+		header.ParentHash = parent.Hash()
+		return header
+	}
+	var (
+		lowTd  = big.NewInt(1)
+		highTd = big.NewInt(2)
+	)
+	// Before the fork, a higher head with a lower difficulty loses and heads of
+	// equal height are told apart by total difficulty, not by their seals
+	if h.engine.ReorgNeeded(h.chain, head, child(side), nil, highTd, lowTd) {
+		t.Fatalf("reorged to a lower total difficulty before the fork")
+	}
+	better, worse := head, side
+	if h.engine.compareSeals(side, head) < 0 {
+		better, worse = side, head
+	}
+	if h.engine.ReorgNeeded(h.chain, worse, better, nil, highTd, lowTd) {
+		t.Fatalf("reorged to a better seal with a lower total difficulty before the fork")
+	}
+	if !h.engine.ReorgNeeded(h.chain, better, worse, nil, lowTd, highTd) {
+		t.Fatalf("didn't reorg to a higher total difficulty before the fork")
+	}
+	h.config.Poseidon.ForkChoiceBlock = common.Big0
+
+	if !h.engine.ReorgNeeded(h.chain, head, child(side), nil, highTd, lowTd) {
+		t.Fatalf("didn't reorg to a higher head")
+	}
+	if h.engine.ReorgNeeded(h.chain, child(head), side, nil, lowTd, highTd) {
+		t.Fatalf("reorged to a lower head")
+	}
+	if !h.engine.ReorgNeeded(h.chain, worse, better, nil, highTd, lowTd) {
+		t.Fatalf("didn't reorg to a better seal at equal height")
+	}
+	// Heads not descending from the finalized block are never chosen
+	if h.engine.ReorgNeeded(h.chain, head, child(side), head, highTd, lowTd) {
+		t.Fatalf("reorged past the finalized block")
+	}
+	if !h.engine.ReorgNeeded(h.chain, side, child(head), head, highTd, lowTd) {
+		t.Fatalf("didn't reorg to a descendant of the finalized block")
+	}
+}
+
+// Tests that blocks become final once votes cover more than 2/3 of the committee
+// supply and that the chain refuses to reorg past them.
+func TestFinality(t *testing.T) {
+	h := newTesterHub(t, 3)
+	h.formCommittee(t)
+
+	// Seal two competing blocks and import the one losing the fork choice, which
+	// goes by total difficulty before the fork choice fork
+	a := h.sealBlock(t, h.nodes[:1], nil)
+	b := h.sealBlock(t, h.nodes[1:2], nil)
+	if a.Difficulty().Cmp(b.Difficulty()) == 0 {
+		t.Fatalf("siblings of equal difficulty %v", a.Difficulty())
+	}
+	worse, better := a, b
+	if a.Difficulty().Cmp(b.Difficulty()) > 0 {
+		worse, better = b, a
+	}
+	if _, err := h.chain.InsertChain(types.Blocks{worse}); err != nil {
+		t.Fatalf("failed to import block: %v", err)
+	}
+	// Votes of the retired genesis minter are not counted
+	sig, err := crypto.Sign(crypto.Keccak256(VoteRLP(worse.NumberU64(), worse.Hash(), h.config.ChainID)), h.minter.key)
+	if err != nil {
+		t.Fatalf("failed to sign vote: %v", err)
+	}
+	if _, err := h.engine.AddVote(h.chain, &types.Vote{Number: worse.NumberU64(), Hash: worse.Hash(), Signature: sig}); err != errUnauthorizedVoter {
+		t.Fatalf("minter vote error mismatch: have %v, want %v", err, errUnauthorizedVoter)
+	}
+	// Equal stakes, so the block is final with the third vote only
+	for i, node := range h.nodes {
+		vote, err := node.engine.SignVote(h.chain, worse.Header())
+		if err != nil || vote == nil {
+			t.Fatalf("node %d: failed to sign vote: %v", i, err)
+		}
+		if again, err := node.engine.SignVote(h.chain, worse.Header()); err != nil || again != nil {
+			t.Fatalf("node %d: voted twice on the same height: %v, %v", i, again, err)
+		}
+		final, err := h.engine.AddVote(h.chain, vote)
+		if err != nil {
+			t.Fatalf("node %d: failed to add vote: %v", i, err)
+		}
+		if want := i == len(h.nodes)-1; final != want {
+			t.Fatalf("node %d: finality mismatch: have %v, want %v", i, final, want)
+		}
+		if _, err := h.engine.AddVote(h.chain, vote); err != errKnownVote {
+			t.Fatalf("node %d: duplicate vote error mismatch: have %v, want %v", i, err, errKnownVote)
+		}
+	}
+	// The last vote survives a restart, so the validator can't attest the sibling
+	node := h.nodes[0]
+	restarted := New(h.config, node.engine.db, nil, h.chain.Genesis().Hash())
+	restarted.Authorize(node.addr, node.engine.signFn, node.engine.signTxFn, node.engine.vrfFn)
+	if vote, err := restarted.SignVote(h.chain, better.Header()); err != nil || vote != nil {
+		t.Fatalf("voted twice on the same height after a restart: %v, %v", vote, err)
+	}
+	if err := h.chain.SetFinalized(better.Header()); err != core.ErrFinalizedNotCanonical {
+		t.Fatalf("non-canonical finalization error mismatch: have %v, want %v", err, core.ErrFinalizedNotCanonical)
+	}
+	if err := h.chain.SetFinalized(worse.Header()); err != nil {
+		t.Fatalf("failed to finalize block: %v", err)
+	}
+	if final := h.chain.CurrentFinalizedBlock(); final == nil || final.Hash() != worse.Hash() {
+		t.Fatalf("finalized block mismatch: have %v, want %x", final, worse.Hash())
+	}
+	// The better sibling would win the fork choice, but must not replace the final block
+	if _, err := h.chain.InsertChain(types.Blocks{better}); !errors.Is(err, core.ErrFinalizedReorg) {
+		t.Fatalf("reorg error mismatch: have %v, want %v", err, core.ErrFinalizedReorg)
+	}
+	if head := h.chain.CurrentBlock(); head.Hash() != worse.Hash() {
+		t.Fatalf("head mismatch: have %x, want %x", head.Hash(), worse.Hash())
+	}
+}
+
+// Tests that finality votes overtaking the block they attest are buffered and
+// tallied once the block is imported.
+func TestQueuedVotes(t *testing.T) {
+	h := newTesterHub(t, 3)
+	h.formCommittee(t)
+
+	block := h.sealBlock(t, h.nodes[:1], nil)
+	sign := func(node *testerNode, number uint64, hash common.Hash) *types.Vote {
+		sig, err := crypto.Sign(crypto.Keccak256(VoteRLP(number, hash, h.config.ChainID)), node.key)
+		if err != nil {
+			t.Fatalf("failed to sign vote: %v", err)
+		}
+		return &types.Vote{Number: number, Hash: hash, Signature: sig}
+	}
+	vote := sign(h.nodes[1], block.NumberU64(), block.Hash())
+	if _, err := h.engine.AddVote(h.chain, vote); err != errQueuedVote {
+		t.Fatalf("early vote error mismatch: have %v, want %v", err, errQueuedVote)
+	}
+	if _, err := h.engine.AddVote(h.chain, vote); err != errKnownVote {
+		t.Fatalf("duplicate early vote error mismatch: have %v, want %v", err, errKnownVote)
+	}
+	// Only a single vote per committee member and height is buffered
+	if _, err := h.engine.AddVote(h.chain, sign(h.nodes[1], block.NumberU64(), common.Hash{0x01})); err != errKnownVote {
+		t.Fatalf("conflicting early vote error mismatch: have %v, want %v", err, errKnownVote)
+	}
+	if _, err := h.engine.AddVote(h.chain, sign(h.minter, block.NumberU64(), block.Hash())); err != errUnexpectedVoter {
+		t.Fatalf("non-member early vote error mismatch: have %v, want %v", err, errUnexpectedVoter)
+	}
+	forged := sign(h.nodes[0], block.NumberU64(), block.Hash())
+	forged.Signature[crypto.RecoveryIDOffset] = 0xff
+	if _, err := h.engine.AddVote(h.chain, forged); !errors.Is(err, consensus.ErrInvalidVote) {
+		t.Fatalf("forged vote error mismatch: have %v, want %v", err, consensus.ErrInvalidVote)
+	}
+	// Votes too far ahead of the head are not buffered
+	future := block.NumberU64() + maxFutureVotes
+	if _, err := h.engine.AddVote(h.chain, sign(h.nodes[1], future, common.Hash{0x01})); err != errUnknownBlock {
+		t.Fatalf("future vote error mismatch: have %v, want %v", err, errUnknownBlock)
+	}
+	if votes := h.engine.QueuedVotes(h.chain); len(votes) != 0 {
+		t.Fatalf("queued votes released before the import: %d", len(votes))
+	}
+	if _, err := h.chain.InsertChain(types.Blocks{block}); err != nil {
+		t.Fatalf("failed to import block: %v", err)
+	}
+	votes := h.engine.QueuedVotes(h.chain)
+	if len(votes) != 1 || votes[0].ID() != vote.ID() {
+		t.Fatalf("queued votes mismatch: have %d, want 1", len(votes))
+	}
+	if _, err := h.engine.AddVote(h.chain, votes[0]); err != nil {
+		t.Fatalf("failed to add queued vote: %v", err)
+	}
+	if votes := h.engine.QueuedVotes(h.chain); len(votes) != 0 {
+		t.Fatalf("queued votes released twice: %d", len(votes))
+	}
+}
+
+// Tests that a validator sealing two different blocks of the same height is
+// detected and reported to the ValidatorHub exactly once from the Evidence fork
+// on, while invalid evidence is rejected.
+func TestDoubleSign(t *testing.T) {
+	// The evidence accepting hub isn't published, run the fork without upgrading
+	defer func(upgrades []*systemcontracts.ContractUpgrade) {
+		systemcontracts.Upgrades["Evidence"] = upgrades
+	}(systemcontracts.Upgrades["Evidence"])
+	systemcontracts.Upgrades["Evidence"] = nil
+
+	h := newTesterHub(t, 2)
+	h.formCommittee(t)
+
+	// Let the first validator seal two different blocks on top of the head
+	var (
+		signer   = h.nodes[0]
+		reporter = h.nodes[1]
+		tx       = h.transact(t, reporter.key, reporter.addr, common.Big1, nil, nil)
+		a        = h.sealBlock(t, []*testerNode{signer}, nil)
+		b        = h.sealBlock(t, []*testerNode{signer}, []*types.Transaction{tx})
+	)
+	api := &API{chain: h.chain, poseidon: reporter.engine}
+	for _, block := range []*types.Block{a, a, b} {
+		for _, node := range []*testerNode{signer, reporter} {
+			if err := node.engine.VerifyHeader(h.chain, block.Header(), true); err != nil {
+				t.Fatalf("failed to verify block %x: %v", block.Hash(), err)
+			}
+		}
+		if block == a && len(api.GetEvidence()) != 0 {
+			t.Fatalf("evidence recorded for a single block")
+		}
+	}
+	evidences := api.GetEvidence()
+	if len(evidences) != 1 {
+		t.Fatalf("evidence count mismatch: have %d, want 1", len(evidences))
+	}
+	evidence := evidences[0]
+	if evidence.Signer != signer.addr || uint64(evidence.Number) != a.NumberU64() {
+		t.Fatalf("evidence mismatch: have %x #%d, want %x #%d", evidence.Signer, evidence.Number, signer.addr, a.NumberU64())
+	}
+	if hashes := []common.Hash{evidence.HeaderA.Hash(), evidence.HeaderB.Hash()}; !(hashes[0] == a.Hash() && hashes[1] == b.Hash()) && !(hashes[0] == b.Hash() && hashes[1] == a.Hash()) {
+		t.Fatalf("evidence headers mismatch: have %x, want %x and %x", hashes, a.Hash(), b.Hash())
+	}
+	if _, err := h.chain.InsertChain(types.Blocks{a}); err != nil {
+		t.Fatalf("failed to import block: %v", err)
+	}
+	// Nothing is reported before the hub accepts evidence
+	number := new(big.Int).Add(a.Number(), common.Big1)
+	if err := reporter.engine.SubmitEvidence(number); err != nil {
+		t.Fatalf("failed to submit evidence: %v", err)
+	}
+	if pending := h.backend.pending(); len(pending) != 0 {
+		t.Fatalf("evidence reported before the fork: %d transactions", len(pending))
+	}
+	// From the fork on, the evidence is reported once, the signer doesn't report itself
+	h.config.Poseidon.EvidenceBlock = number
+
+	if err := signer.engine.SubmitEvidence(number); err != nil {
+		t.Fatalf("failed to submit evidence: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := reporter.engine.SubmitEvidence(number); err != nil {
+			t.Fatalf("failed to submit evidence: %v", err)
+		}
+	}
+	pending := h.backend.pending()
+	if len(pending) != 1 {
+		t.Fatalf("evidence transaction count mismatch: have %d, want 1", len(pending))
+	}
+	report := pending[0]
+	if !systemcontracts.IsDoubleSignTransition(hubAddress, report.To(), report.Data()) {
+		t.Fatalf("transaction is not a double-sign report: %x", report.Data())
+	}
+	if systemcontracts.IsSystemTransitionAt(h.config, a.Number(), report.To(), report.Data()) {
+		t.Fatalf("double-sign report is a system transition before the fork")
+	}
+	if !systemcontracts.IsSystemTransitionAt(h.config, number, report.To(), report.Data()) {
+		t.Fatalf("double-sign report is not a system transition after the fork")
+	}
+	if submitted := api.GetEvidence()[0].Submitted; submitted == nil || *submitted != report.Hash() {
+		t.Fatalf("submission mismatch: have %v, want %x", submitted, report.Hash())
+	}
+	// Only evidence of two different seals of the reported validator is valid
+	headerA, _ := rlp.EncodeToBytes(a.Header())
+	headerB, _ := rlp.EncodeToBytes(b.Header())
+	next := &types.Header{ParentHash: a.Hash(), Number: number}
+	invalid := map[string]*types.Transaction{
+		"same seal":     h.callHub(t, reporter.key, nil, nil, "submitDoubleSignEvidence", signer.addr, headerA, headerA),
+		"other signer":  h.callHub(t, reporter.key, nil, nil, "submitDoubleSignEvidence", reporter.addr, headerA, headerB),
+		"garbage":       h.callHub(t, reporter.key, nil, nil, "submitDoubleSignEvidence", signer.addr, headerA, []byte{0x01}),
+		"future height": h.callHub(t, reporter.key, nil, nil, "submitDoubleSignEvidence", signer.addr, headerA, headerB),
+	}
+	for name, tx := range invalid {
+		header := next
+		if name == "future height" {
+			header = a.Header()
+		}
+		if err := reporter.engine.VerifyEvidence(header, nil, tx); !errors.Is(err, errInvalidEvidence) {
+			t.Errorf("%s: evidence error mismatch: have %v, want %v", name, err, errInvalidEvidence)
+		}
+	}
+	if err := reporter.engine.VerifyEvidence(next, nil, report); err != nil {
+		t.Fatalf("failed to verify evidence: %v", err)
+	}
+	if err := reporter.engine.VerifyEvidence(next, types.Transactions{report}, report); !errors.Is(err, errDuplicateEvidence) {
+		t.Errorf("duplicate evidence error mismatch: have %v, want %v", err, errDuplicateEvidence)
+	}
+}
+
+// Tests that a validator sealing the same height again on a different parent,
+// as it does after a reorg, isn't treated as double-signing.
+func TestDoubleSignReorg(t *testing.T) {
+	h := newTesterHub(t, 2)
+	h.formCommittee(t)
+
+	var (
+		signer   = h.nodes[0]
+		reporter = h.nodes[1]
+		sealed   = h.sealBlock(t, []*testerNode{signer}, nil).Header()
+		sibling  = types.CopyHeader(sealed)
+		reorged  = types.CopyHeader(sealed)
+	)
+	sibling.Time++
+	reseal(t, sibling, signer.key, h.config.ChainID)
+
+	reorged.ParentHash = common.Hash{0x01}
+	reseal(t, reorged, signer.key, h.config.ChainID)
+
+	// Only the sibling on the same parent is recorded as evidence
+	api := &API{chain: h.chain, poseidon: reporter.engine}
+	for _, header := range []*types.Header{sealed, reorged} {
+		reporter.engine.recordSeal(header, signer.addr)
+	}
+	if evidences := api.GetEvidence(); len(evidences) != 0 {
+		t.Fatalf("evidence recorded for a reorged seal: %d", len(evidences))
+	}
+	reporter.engine.recordSeal(sibling, signer.addr)
+	if evidences := api.GetEvidence(); len(evidences) != 1 {
+		t.Fatalf("evidence count mismatch: have %d, want 1", len(evidences))
+	}
+	// Only the sibling on the same parent is valid evidence
+	encoded := make(map[*types.Header][]byte)
+	for _, header := range []*types.Header{sealed, sibling, reorged} {
+		blob, err := rlp.EncodeToBytes(header)
+		if err != nil {
+			t.Fatalf("failed to encode header: %v", err)
+		}
+		encoded[header] = blob
+	}
+	next := &types.Header{ParentHash: sealed.Hash(), Number: new(big.Int).Add(sealed.Number, common.Big1)}
+
+	tx := h.callHub(t, reporter.key, nil, nil, "submitDoubleSignEvidence", signer.addr, encoded[sealed], encoded[sibling])
+	if err := reporter.engine.VerifyEvidence(next, nil, tx); err != nil {
+		t.Fatalf("failed to verify sibling evidence: %v", err)
+	}
+	tx = h.callHub(t, reporter.key, nil, nil, "submitDoubleSignEvidence", signer.addr, encoded[sealed], encoded[reorged])
+	if err := reporter.engine.VerifyEvidence(next, nil, tx); !errors.Is(err, errInvalidEvidence) {
+		t.Fatalf("reorged evidence error mismatch: have %v, want %v", err, errInvalidEvidence)
+	}
+}
+
+// Tests that the fees of a block are credited to the reward contract of its
+// signer, regardless of the coinbase the proposer picked.
+func TestDistributeFees(t *testing.T) {
+	h := newTesterHub(t, 2)
+	h.formCommittee(t)
+
+	var (
+		signer = h.nodes[0]
+		sender = h.nodes[1]
+		number = new(big.Int).Add(h.chain.CurrentBlock().Number(), common.Big1)
+	)
+	info, err := h.engine.GetValidatorInfo(signer.addr, number)
+	if err != nil {
+		t.Fatalf("failed to retrieve validator info: %v", err)
+	}
+	h.config.Poseidon.FeeDistributionBlock = number
+
+	// Blocks paying fees are sealed and imported with the same state
+	tx := h.transact(t, sender.key, sender.addr, common.Big1, nil, nil)
+	block := h.sealBlock(t, []*testerNode{signer}, []*types.Transaction{tx})
+	if _, err := h.chain.InsertChain(types.Blocks{block}); err != nil {
+		t.Fatalf("failed to import block: %v", err)
+	}
+	if block.Coinbase() != info.RewardAddr {
+		t.Fatalf("coinbase mismatch: have %x, want %x", block.Coinbase(), info.RewardAddr)
+	}
+	// Fees paid to any other coinbase are moved to the reward contract
+	statedb, err := h.chain.State()
+	if err != nil {
+		t.Fatalf("failed to retrieve head state: %v", err)
+	}
+	var (
+		header   = types.CopyHeader(h.chain.CurrentHeader())
+		outsider = common.HexToAddress("0xdeadbeef")
+		receipt  = &types.Receipt{GasUsed: params.TxGas}
+		fees     = TotalFees(header, []*types.Transaction{tx}, []*types.Receipt{receipt})
+		before   = statedb.GetBalance(info.RewardAddr)
+	)
+	if fees.Sign() == 0 {
+		t.Fatalf("no fees paid")
+	}
+	owner := new(common.Address)
+	if err := h.engine.systemCall(h.chain, header, statedb, owner, "rewardValidator", info.RewardAddr); err != nil || *owner != signer.addr {
+		t.Fatalf("reward contract owner mismatch: have %x, %v, want %x", *owner, err, signer.addr)
+	}
+	header.Coinbase = outsider
+	statedb.AddBalance(outsider, fees)
+
+	// Before the fork the fees stay with the coinbase
+	early := types.CopyHeader(header)
+	early.Number = new(big.Int).Sub(number, common.Big1)
+	if err := finalizeAs(h, signer, early, statedb, tx, receipt); err != nil {
+		t.Fatalf("failed to finalize pre-fork block: %v", err)
+	}
+	if balance := statedb.GetBalance(outsider); balance.Cmp(fees) != 0 {
+		t.Fatalf("pre-fork coinbase balance mismatch: have %v, want %v", balance, fees)
+	}
+	if err := h.engine.distributeFees(h.chain, header, statedb, signer.addr, []*types.Transaction{tx}, []*types.Receipt{receipt}); err != nil {
+		t.Fatalf("failed to distribute fees: %v", err)
+	}
+	if balance := statedb.GetBalance(outsider); balance.Sign() != 0 {
+		t.Fatalf("coinbase balance mismatch: have %v, want 0", balance)
+	}
+	if balance := statedb.GetBalance(info.RewardAddr); balance.Cmp(new(big.Int).Add(before, fees)) != 0 {
+		t.Fatalf("reward balance mismatch: have %v, want %v", balance, new(big.Int).Add(before, fees))
+	}
+	// Blocks whose fees can't be distributed are rejected
+	statedb.AddBalance(outsider, fees)
+	statedb.SetCode(hubAddress, []byte{byte(vm.PUSH1), 0, byte(vm.DUP1), byte(vm.REVERT)})
+	if err := finalizeAs(h, signer, header, statedb, tx, receipt); err == nil {
+		t.Fatalf("fee distribution succeeded without a ValidatorHub")
+	}
+}
+
+// finalizeAs finalizes the given header on top of the state as if sealed by the
+// given node, paying the fees of a single transaction.
+func finalizeAs(h *testerHub, node *testerNode, header *types.Header, statedb *state.StateDB, tx *types.Transaction, receipt *types.Receipt) error {
+	header = types.CopyHeader(header)
+	h.engine.signatures.Add(header.Hash(), crypto.FromECDSAPub(&node.key.PublicKey))
+	return h.engine.Finalize(h.chain, header, statedb, []*types.Transaction{tx}, nil, []*types.Receipt{receipt})
+}
+
+// Tests that blocks have to end with the header sync of their signer reporting
+// the block fees, and may only slash validators overdue on their heartbeat.
+func TestSystemTransactions(t *testing.T) {
+	h := newTesterHub(t, 3)
+	h.formCommittee(t)
+
+	// Leave a validator out of sealing until it's overdue
+	sealers, overdue := h.nodes[:2], h.nodes[2].addr
+	for i := 0; i < params.DefaultPoseidonHeartRate; i++ {
+		h.seal(t, sealers, nil)
+	}
+	// Seal a block paying fees, which also keeps its signer alive
+	sender := h.minter
+	tx := h.transact(t, sender.key, sender.addr, common.Big1, nil, nil)
+	block := h.seal(t, sealers, []*types.Transaction{tx})
+
+	active, err := h.engine.Author(block.Header())
+	if err != nil {
+		t.Fatalf("failed to recover block signer: %v", err)
+	}
+
+	var (
+		header   = block.Header()
+		txs      = block.Transactions()
+		receipts = h.chain.GetReceiptsByHash(block.Hash())
+	)
+	if err := h.engine.VerifySystemTransactions(header, txs, receipts); err != nil {
+		t.Fatalf("failed to verify sealed block: %v", err)
+	}
+	if fees := TotalFees(header, txs[:1], receipts[:1]); fees.Sign() == 0 {
+		t.Fatalf("no fees paid")
+	}
+	// Missing, misplaced or foreign header syncs must be rejected
+	if err := h.engine.VerifySystemTransactions(header, txs[:1], nil); !errors.Is(err, errMissingSyncHeader) {
+		t.Errorf("missing sync error mismatch: have %v, want %v", err, errMissingSyncHeader)
+	}
+	swapped := types.Transactions{txs[1], txs[0]}
+	if err := h.engine.VerifySystemTransactions(header, swapped, nil); !errors.Is(err, errMisplacedSyncHeader) {
+		t.Errorf("misplaced sync error mismatch: have %v, want %v", err, errMisplacedSyncHeader)
+	}
+	doubled := types.Transactions{txs[1], txs[0], txs[1]}
+	if err := h.engine.VerifySystemTransactions(header, doubled, nil); !errors.Is(err, errMisplacedSyncHeader) {
+		t.Errorf("duplicate sync error mismatch: have %v, want %v", err, errMisplacedSyncHeader)
+	}
+	foreign := types.Transactions{txs[0], h.callHub(t, sender.key, nil, nil, "syncTendermintHeader", common.Big0)}
+	if err := h.engine.VerifySystemTransactions(header, foreign, nil); !errors.Is(err, errInvalidSyncHeaderSender) {
+		t.Errorf("foreign sync error mismatch: have %v, want %v", err, errInvalidSyncHeaderSender)
+	}
+	// The reported fee is checked against the receipts
+	tampered := []*types.Receipt{{GasUsed: receipts[0].GasUsed + 1}, receipts[1]}
+	if err := h.engine.VerifySystemTransactions(header, txs, tampered); !errors.Is(err, errInvalidSyncHeaderFee) {
+		t.Errorf("fee error mismatch: have %v, want %v", err, errInvalidSyncHeaderFee)
+	}
+	// Only overdue validators may be slashed, and only once per block
+	next := &types.Header{ParentHash: block.Hash(), Number: new(big.Int).Add(block.Number(), common.Big1)}
+
+	slash := h.callHub(t, sender.key, nil, nil, "slash", active)
+	if err := h.engine.VerifySlash(next, nil, slash); !errors.Is(err, errInvalidSlash) {
+		t.Errorf("active slash error mismatch: have %v, want %v", err, errInvalidSlash)
+	}
+	slash = h.callHub(t, sender.key, nil, nil, "slash", overdue)
+	if err := h.engine.VerifySlash(next, nil, slash); err != nil {
+		t.Errorf("failed to verify overdue slash: %v", err)
+	}
+	if err := h.engine.VerifySlash(next, types.Transactions{slash}, slash); !errors.Is(err, errDuplicateSlash) {
+		t.Errorf("duplicate slash error mismatch: have %v, want %v", err, errDuplicateSlash)
+	}
+	// Blocks breaking the rules must be rejected on import
+	invalid := h.sealWith(t, sealers, func(node *testerNode) *types.Block {
+		header := h.propose(t, node, nil).Header()
+		return types.NewBlock(header, nil, nil, nil, trie.NewStackTrie(nil))
+	})
+	if _, err := h.chain.InsertChain(types.Blocks{invalid}); !errors.Is(err, errMissingSyncHeader) {
+		t.Errorf("invalid block import error mismatch: have %v, want %v", err, errMissingSyncHeader)
+	}
+}
+
+func TestSystemTransactionsFork(t *testing.T) {
+	h := newTesterHub(t, 1)
+	h.config.Poseidon.SystemTxBlock = nil
+
+	// Assemble blocks the way the miners preceding the fork did, opening them
+	// with a zero fee header sync, if any
+	legacy := func(sync bool, txs []*types.Transaction) *types.Block {
+		return h.sealWith(t, []*testerNode{h.minter}, func(node *testerNode) *types.Block {
+			header := h.propose(t, node, nil).Header()
+			header.GasUsed = 0
+
+			statedb, err := h.chain.StateAt(h.chain.CurrentBlock().Root())
+			if err != nil {
+				t.Fatalf("failed to retrieve parent state: %v", err)
+			}
+			if sync {
+				syncs, err := node.engine.GetSystemTransaction(types.MakeSigner(h.config, header.Number), statedb, header, common.Big0)
+				if err != nil {
+					t.Fatalf("failed to create header sync: %v", err)
+				}
+				txs = append(types.Transactions{syncs.Peek()}, txs...)
+			}
+			var (
+				gp       = new(core.GasPool).AddGas(header.GasLimit)
+				receipts = make([]*types.Receipt, len(txs))
+			)
+			for i, tx := range txs {
+				statedb.Prepare(tx.Hash(), i)
+				if receipts[i], err = core.ApplyTransaction(h.config, h.chain, &header.Coinbase, gp, statedb, header, tx, &header.GasUsed, vm.Config{}); err != nil {
+					t.Fatalf("failed to apply transaction %d: %v", i, err)
+				}
+			}
+			block, err := node.engine.FinalizeAndAssemble(h.chain, header, statedb, txs, nil, receipts)
+			if err != nil {
+				t.Fatalf("failed to assemble block: %v", err)
+			}
+			return block
+		})
+	}
+	sender := h.nodes[0]
+	transfer := func() *types.Transaction {
+		return h.transact(t, sender.key, sender.addr, common.Big1, nil, nil)
+	}
+	// Legacy blocks must be accepted before the fork
+	if _, err := h.chain.InsertChain(types.Blocks{legacy(true, []*types.Transaction{transfer()})}); err != nil {
+		t.Fatalf("failed to import legacy block: %v", err)
+	}
+	if _, err := h.chain.InsertChain(types.Blocks{legacy(false, nil)}); err != nil {
+		t.Fatalf("failed to import empty legacy block: %v", err)
+	}
+	// From the fork on, legacy blocks must be rejected
+	h.config.Poseidon.SystemTxBlock = new(big.Int).Add(h.chain.CurrentBlock().Number(), common.Big1)
+
+	if _, err := h.chain.InsertChain(types.Blocks{legacy(true, []*types.Transaction{transfer()})}); !errors.Is(err, errMisplacedSyncHeader) {
+		t.Errorf("legacy block import error mismatch: have %v, want %v", err, errMisplacedSyncHeader)
+	}
+	if _, err := h.chain.InsertChain(types.Blocks{legacy(false, nil)}); !errors.Is(err, errMissingSyncHeader) {
+		t.Errorf("empty legacy block import error mismatch: have %v, want %v", err, errMissingSyncHeader)
+	}
+	h.seal(t, []*testerNode{h.minter}, []*types.Transaction{transfer()})
+}
+
+// testerChainEvents is a chainEventSource the tests report chain events through.
+type testerChainEvents struct {
+	consensus.ChainHeaderReader
+
+	headFeed event.Feed
+	sideFeed event.Feed
+}
+
+func (c *testerChainEvents) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return c.headFeed.Subscribe(ch)
+}
+
+func (c *testerChainEvents) SubscribeChainSideEvent(ch chan<- core.ChainSideEvent) event.Subscription {
+	return c.sideFeed.Subscribe(ch)
+}
+
+// Tests that the ValidatorHub calls against a block are answered from the cache
+// until the block is reported as side block or reorged out.
+func TestHubCache(t *testing.T) {
+	h := newTesterHub(t, 0)
+	first := h.seal(t, []*testerNode{h.minter}, nil)
+	second := h.seal(t, []*testerNode{h.minter}, nil)
+
+	events := &testerChainEvents{ChainHeaderReader: h.chain}
+	h.engine.SubscribeChainEvents(events)
+	defer h.engine.Close()
+
+	// Cache the results against both blocks, then cut the engine off the state
+	h.engine.hubCache.purge()
+	for _, block := range []*types.Block{first, second} {
+		if _, err := h.engine.GetValidatorInfo(h.minter.addr, new(big.Int).Add(block.Number(), common.Big1)); err != nil {
+			t.Fatalf("failed to retrieve validator info: %v", err)
+		}
+	}
+	if n := h.engine.hubCache.results.Len(); n != 2 {
+		t.Fatalf("cached call count mismatch: have %d, want 2", n)
+	}
+	h.engine.ethAPI = nil
+
+	cached := func(block *types.Block) bool {
+		_, err := h.engine.GetValidatorInfo(h.minter.addr, new(big.Int).Add(block.Number(), common.Big1))
+		return err == nil
+	}
+	waitEvicted := func(block *types.Block) {
+		for start := time.Now(); cached(block); time.Sleep(10 * time.Millisecond) {
+			if time.Since(start) > 5*time.Second {
+				t.Fatalf("results of block %d not evicted", block.NumberU64())
+			}
+		}
+	}
+	if !cached(first) || !cached(second) {
+		t.Fatalf("results not served from the cache")
+	}
+	// Side blocks only evict their own results
+	events.sideFeed.Send(core.ChainSideEvent{Block: second})
+	waitEvicted(second)
+	if !cached(first) {
+		t.Fatalf("results of block %d evicted with a side block", first.NumberU64())
+	}
+	// Heads extending the previous one keep the results, others purge them
+	events.headFeed.Send(core.ChainHeadEvent{Block: first})
+	events.headFeed.Send(core.ChainHeadEvent{Block: second})
+	if !cached(first) {
+		t.Fatalf("results of block %d evicted with an extending head", first.NumberU64())
+	}
+	events.headFeed.Send(core.ChainHeadEvent{Block: first})
+	waitEvicted(first)
+}
@@ -71,6 +71,11 @@ func (v *BlockValidator) ValidateBody(block *types.Block) error {
 		}
 		return consensus.ErrPrunedAncestor
 	}
+	if posa, ok := v.engine.(consensus.PoSA); ok {
+		if err := posa.VerifySystemTransactions(header, block.Transactions(), nil); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -94,6 +99,11 @@ func (v *BlockValidator) ValidateState(block *types.Block, statedb *state.StateD
 	if receiptSha != header.ReceiptHash {
 		return fmt.Errorf("invalid receipt root hash (remote: %x local: %x)", header.ReceiptHash, receiptSha)
 	}
+	if posa, ok := v.engine.(consensus.PoSA); ok {
+		if err := posa.VerifySystemTransactions(header, block.Transactions(), receipts); err != nil {
+			return err
+		}
+	}
 	// Validate the state root against the received state root and throw
 	// an error if they don't match.
 	if root := statedb.IntermediateRoot(v.config.IsEIP158(header.Number)); header.Root != root {
@@ -51,6 +51,7 @@ var (
 	headBlockGauge     = metrics.NewRegisteredGauge("chain/head/block", nil)
 	headHeaderGauge    = metrics.NewRegisteredGauge("chain/head/header", nil)
 	headFastBlockGauge = metrics.NewRegisteredGauge("chain/head/receipt", nil)
+	headFinalizedGauge = metrics.NewRegisteredGauge("chain/head/finalized", nil)
 
 	accountReadTimer   = metrics.NewRegisteredTimer("chain/account/reads", nil)
 	accountHashTimer   = metrics.NewRegisteredTimer("chain/account/hashes", nil)
@@ -468,6 +469,9 @@ func (bc *BlockChain) loadLastState() error {
 	log.Info("Loaded most recent local header", "number", currentHeader.Number, "hash", currentHeader.Hash(), "td", headerTd, "age", common.PrettyAge(time.Unix(int64(currentHeader.Time), 0)))
 	log.Info("Loaded most recent local full block", "number", currentBlock.Number(), "hash", currentBlock.Hash(), "td", blockTd, "age", common.PrettyAge(time.Unix(int64(currentBlock.Time()), 0)))
 	log.Info("Loaded most recent local fast block", "number", currentFastBlock.Number(), "hash", currentFastBlock.Hash(), "td", fastTd, "age", common.PrettyAge(time.Unix(int64(currentFastBlock.Time()), 0)))
+	if finalized := bc.CurrentFinalizedHeader(); finalized != nil {
+		log.Info("Loaded most recent finalized header", "number", finalized.Number, "hash", finalized.Hash(), "age", common.PrettyAge(time.Unix(int64(finalized.Time), 0)))
+	}
 	if pivot := rawdb.ReadLastPivotNumber(bc.db); pivot != nil {
 		log.Info("Loaded last fast-sync pivot marker", "number", *pivot)
 	}
@@ -673,6 +677,32 @@ func (bc *BlockChain) CurrentFastBlock() *types.Block {
 	return bc.currentFastBlock.Load().(*types.Block)
 }
 
+// CurrentFinalizedBlock retrieves the latest block finalized by the consensus
+// engine, or nil if none was finalized yet or its body isn't available.
+func (bc *BlockChain) CurrentFinalizedBlock() *types.Block {
+	if finalized := bc.hc.CurrentFinalizedHeader(); finalized != nil {
+		return bc.GetBlock(finalized.Hash(), finalized.Number.Uint64())
+	}
+	return nil
+}
+
+// CurrentFinalizedHeader retrieves the latest header finalized by the consensus
+// engine, or nil if none was finalized yet.
+func (bc *BlockChain) CurrentFinalizedHeader() *types.Header {
+	return bc.hc.CurrentFinalizedHeader()
+}
+
+// SetFinalized marks the given canonical header as final. The chain will refuse
+// to reorg past it from then on. Finalizing a header below the current finalized
+// one is a noop. Only the header is needed, so that blocks can be finalized
+// while their bodies are still being synced.
+func (bc *BlockChain) SetFinalized(header *types.Header) error {
+	bc.chainmu.Lock()
+	defer bc.chainmu.Unlock()
+
+	return bc.hc.SetFinalized(header)
+}
+
 // Validator returns the current validator.
 func (bc *BlockChain) Validator() Validator {
 	return bc.validator
@@ -1537,7 +1567,10 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	// Please refer to http://www.cs.cornell.edu/~ie53/publications/btcProcFC.pdf
 	reorg := externTd.Cmp(localTd) > 0
 	currentBlock = bc.CurrentBlock()
-	if !reorg && externTd.Cmp(localTd) == 0 {
+	if posa, ok := bc.engine.(consensus.PoSA); ok {
+		// PoSA engines bring their own fork choice rule
+		reorg = posa.ReorgNeeded(bc, currentBlock.Header(), block.Header(), bc.CurrentFinalizedHeader(), localTd, externTd)
+	} else if !reorg && externTd.Cmp(localTd) == 0 {
 		// Split same-difficulty blocks by number, then preferentially select
 		// the block generated by the local miner as the canonical block.
 		if block.NumberU64() < currentBlock.NumberU64() {
@@ -1721,7 +1754,11 @@ func (bc *BlockChain) insertChainRaw(chain types.Blocks, verifySeals bool) (int,
 		)
 		for block != nil && err == ErrKnownBlock {
 			externTd = new(big.Int).Add(externTd, block.Difficulty())
-			if localTd.Cmp(externTd) < 0 {
+			reorg := localTd.Cmp(externTd) < 0
+			if posa, ok := bc.engine.(consensus.PoSA); ok {
+				reorg = posa.ReorgNeeded(bc, current.Header(), block.Header(), bc.CurrentFinalizedHeader(), localTd, externTd)
+			}
+			if reorg {
 				break
 			}
 			log.Debug("Ignoring already known block", "number", block.Number(), "hash", block.Hash())
@@ -2039,7 +2076,11 @@ func (bc *BlockChain) insertSideChain(block *types.Block, it *insertIterator) (i
 	// If the externTd was larger than our local TD, we now need to reimport the previous
 	// blocks to regenerate the required state
 	localTd := bc.GetTd(current.Hash(), current.NumberU64())
-	if localTd.Cmp(externTd) > 0 {
+	reorg := localTd.Cmp(externTd) <= 0
+	if posa, ok := bc.engine.(consensus.PoSA); ok {
+		reorg = posa.ReorgNeeded(bc, current.Header(), it.previous(), bc.CurrentFinalizedHeader(), localTd, externTd)
+	}
+	if !reorg {
 		log.Info("Sidechain written to disk", "start", it.first().NumberU64(), "end", it.previous().Number, "sidetd", externTd, "localtd", localTd)
 		return it.index, err
 	}
@@ -2152,6 +2193,12 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 			return ret
 		}
 	)
+	// Never drop finalized blocks from the canonical chain, be it by rewinding
+	// below them or by replacing them with a sibling
+	finalized := bc.CurrentFinalizedHeader()
+	if finalized != nil && newBlock.NumberU64() < finalized.Number.Uint64() {
+		return fmt.Errorf("%w: #%d [%x]", ErrFinalizedReorg, finalized.Number, finalized.Hash().Bytes()[:4])
+	}
 	// Reduce the longer chain to the same number as the shorter one
 	if oldBlock.NumberU64() > newBlock.NumberU64() {
 		// Old chain is longer, gather all transactions and logs as deleted ones
@@ -2180,6 +2227,9 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 			commonBlock = oldBlock
 			break
 		}
+		if finalized != nil && oldBlock.NumberU64() <= finalized.Number.Uint64() {
+			return fmt.Errorf("%w: #%d [%x]", ErrFinalizedReorg, finalized.Number, finalized.Hash().Bytes()[:4])
+		}
 		// Remove an old block as well as stash away a new block
 		oldChain = append(oldChain, oldBlock)
 		deletedTxs = append(deletedTxs, oldBlock.Transactions()...)
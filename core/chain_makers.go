@@ -24,6 +24,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -217,6 +218,10 @@ func GenerateChain(config *params.ChainConfig, parent *types.Block, engine conse
 		if config.DAOForkSupport && config.DAOForkBlock != nil && config.DAOForkBlock.Cmp(b.header.Number) == 0 {
 			misc.ApplyDAOHardFork(statedb)
 		}
+		if err := systemcontracts.UpgradeSystemContracts(config, b.header.Number, statedb); err != nil {
+			panic(err)
+		}
+
 		// Execute any user modifications to the block
 		if gen != nil {
 			gen(i, b)
@@ -272,7 +277,11 @@ func makeHeader(chain consensus.ChainReader, parent *types.Block, state *state.S
 		Time:     time,
 	}
 	if chain.Config().IsLondon(header.Number) {
-		header.BaseFee = misc.CalcBaseFee(chain.Config(), parent.Header())
+		feeParams, err := misc.GetBaseFeeParams(chain.Config(), engine, chain, parent.Header(), state)
+		if err != nil {
+			panic(fmt.Sprintf("base fee parameters error: %v", err))
+		}
+		header.BaseFee = misc.CalcBaseFeeWithParams(chain.Config(), feeParams, parent.Header())
 		if !chain.Config().IsLondon(parent.Number()) {
 			parentGasLimit := parent.GasLimit() * params.ElasticityMultiplier
 			header.GasLimit = CalcGasLimit(parentGasLimit, parentGasLimit)
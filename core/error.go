@@ -31,6 +31,14 @@ var (
 
 	// ErrNoGenesis is returned when there is no Genesis Block.
 	ErrNoGenesis = errors.New("genesis not found in chain")
+
+	// ErrFinalizedReorg is returned if a block to import would reorg the chain
+	// past the latest finalized block.
+	ErrFinalizedReorg = errors.New("reorg past finalized block")
+
+	// ErrFinalizedNotCanonical is returned if a block to finalize is not part of
+	// the canonical chain.
+	ErrFinalizedNotCanonical = errors.New("finalized block not canonical")
 )
 
 // List of evm-call-message pre-checking errors. All state transition messages will
@@ -57,6 +65,10 @@ var (
 	// have enough funds for transfer(topmost call only).
 	ErrInsufficientFundsForTransfer = errors.New("insufficient funds for transfer")
 
+	// ErrTransferNotAllowed is returned if the transfer policy of the chain forbids
+	// the value transfer of a transaction.
+	ErrTransferNotAllowed = errors.New("value transfer not allowed")
+
 	// ErrInsufficientFunds is returned if the total cost of executing a transaction
 	// is higher than the balance of the user's account.
 	ErrInsufficientFunds = errors.New("insufficient funds for gas * price + value")
@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -210,6 +211,9 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis, override
 	if err := newcfg.CheckConfigForkOrder(); err != nil {
 		return newcfg, common.Hash{}, err
 	}
+	if err := systemcontracts.CheckUpgrades(newcfg); err != nil {
+		return newcfg, common.Hash{}, err
+	}
 	storedcfg := rawdb.ReadChainConfig(db, stored)
 	if storedcfg == nil {
 		log.Warn("Found genesis block without chain config")
@@ -276,6 +280,11 @@ func (g *Genesis) ToBlock(db ethdb.Database) *types.Block {
 			statedb.SetState(addr, key, value)
 		}
 	}
+	// Forks activated at genesis upgrade the system contracts right away
+	if err := systemcontracts.UpgradeSystemContracts(g.Config, new(big.Int).SetUint64(g.Number), statedb); err != nil {
+		panic(err)
+	}
+
 	root := statedb.IntermediateRoot(false)
 	head := &types.Header{
 		Number:     new(big.Int).SetUint64(g.Number),
@@ -324,6 +333,9 @@ func (g *Genesis) Commit(db ethdb.Database) (*types.Block, error) {
 	if err := config.CheckConfigForkOrder(); err != nil {
 		return nil, err
 	}
+	if err := systemcontracts.CheckUpgrades(config); err != nil {
+		return nil, err
+	}
 	rawdb.WriteTd(db, block.Hash(), block.NumberU64(), g.Difficulty)
 	rawdb.WriteBlock(db, block)
 	rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), nil)
@@ -374,6 +386,34 @@ func DefaultGenesisBlock() *Genesis {
 	}
 }
 
+// DefaultPhoenixGenesisBlock returns the Phoenix main network genesis block.
+func DefaultPhoenixGenesisBlock() *Genesis {
+	return &Genesis{
+		Config:     params.PhoenixChainConfig,
+		GasLimit:   15000000,
+		Difficulty: big.NewInt(600000),
+		Alloc:      phoenixGenesisAlloc(),
+	}
+}
+
+// phoenixGenesisAlloc returns the genesis allocation of the Phoenix main network:
+// the premine, the genesis minter and the system contracts.
+func phoenixGenesisAlloc() GenesisAlloc {
+	ether := big.NewInt(params.Ether)
+	return GenesisAlloc{
+		common.HexToAddress("0x44B579774091561E56fC9073fA856a74299f2E97"): {Balance: new(big.Int).Mul(big.NewInt(209999990), ether)},
+		common.HexToAddress(systemcontracts.GenesisMinter):                {Balance: new(big.Int).Mul(big.NewInt(10), ether)},
+		common.HexToAddress(systemcontracts.ValidatorHubContract): {
+			Balance: new(big.Int),
+			Code:    common.FromHex(systemcontracts.ValidatorHubCode),
+		},
+		common.HexToAddress(systemcontracts.ValidatorFactoryContract): {
+			Balance: new(big.Int),
+			Code:    common.FromHex(systemcontracts.ValidatorFactoryCode),
+		},
+	}
+}
+
 // DefaultRopstenGenesisBlock returns the Ropsten network genesis block.
 func DefaultRopstenGenesisBlock() *Genesis {
 	return &Genesis{
@@ -42,6 +42,13 @@ func TestDefaultGenesisBlock(t *testing.T) {
 	}
 }
 
+func TestDefaultPhoenixGenesisBlock(t *testing.T) {
+	block := DefaultPhoenixGenesisBlock().ToBlock(nil)
+	if block.Hash() != params.PhoenixGenesisHash {
+		t.Errorf("wrong phoenix genesis hash, got %v, want %v", block.Hash(), params.PhoenixGenesisHash)
+	}
+}
+
 func TestSetupGenesis(t *testing.T) {
 	var (
 		customghash = common.HexToHash("0x89c99d90b79719238d2645c7642f2c9295246e80775b38cfd162b696817fbd50")
@@ -63,6 +63,7 @@ type HeaderChain struct {
 
 	currentHeader     atomic.Value // Current head of the header chain (may be above the block chain!)
 	currentHeaderHash common.Hash  // Hash of the current head of the header chain (prevent recomputing all the time)
+	currentFinalized  atomic.Value // Latest header finalized by the consensus engine (nil if none)
 
 	headerCache *lru.Cache // Cache for the most recent block headers
 	tdCache     *lru.Cache // Cache for the most recent block total difficulties
@@ -112,6 +113,15 @@ func NewHeaderChain(chainDb ethdb.Database, config *params.ChainConfig, engine c
 	hc.currentHeaderHash = hc.CurrentHeader().Hash()
 	headHeaderGauge.Update(hc.CurrentHeader().Number.Int64())
 
+	var nilHeader *types.Header
+	hc.currentFinalized.Store(nilHeader)
+	if head := rawdb.ReadFinalizedBlockHash(chainDb); head != (common.Hash{}) {
+		if final := hc.GetHeaderByHash(head); final != nil {
+			hc.currentFinalized.Store(final)
+			headFinalizedGauge.Update(final.Number.Int64())
+		}
+	}
+
 	return hc, nil
 }
 
@@ -219,7 +229,10 @@ func (hc *HeaderChain) writeHeaders(headers []*types.Header) (result *headerWrit
 	// Second clause in the if statement reduces the vulnerability to selfish mining.
 	// Please refer to http://www.cs.cornell.edu/~ie53/publications/btcProcFC.pdf
 	reorg := newTD.Cmp(localTD) > 0
-	if !reorg && newTD.Cmp(localTD) == 0 {
+	if posa, ok := hc.engine.(consensus.PoSA); ok {
+		// PoSA engines bring their own fork choice rule
+		reorg = posa.ReorgNeeded(hc, hc.CurrentHeader(), lastHeader, hc.CurrentFinalizedHeader(), localTD, newTD)
+	} else if !reorg && newTD.Cmp(localTD) == 0 {
 		if lastNumber < head {
 			reorg = true
 		} else if lastNumber == head {
@@ -230,6 +243,12 @@ func (hc *HeaderChain) writeHeaders(headers []*types.Header) (result *headerWrit
 	// we don't have to go backwards to delete canon blocks, but
 	// simply pile them onto the existing chain
 	chainAlreadyCanon := headers[0].ParentHash == hc.currentHeaderHash
+	if reorg && !chainAlreadyCanon {
+		// Never drop finalized headers from the canonical chain
+		if finalized := hc.CurrentFinalizedHeader(); finalized != nil && !hc.descendsFrom(lastHash, lastNumber, finalized) {
+			return &headerWriteResult{}, fmt.Errorf("%w: #%d [%x]", ErrFinalizedReorg, finalized.Number, finalized.Hash().Bytes()[:4])
+		}
+	}
 	if reorg {
 		// If the header can be added into canonical chain, adjust the
 		// header chain markers(canonical indexes and head header flag).
@@ -538,6 +557,43 @@ func (hc *HeaderChain) CurrentHeader() *types.Header {
 	return hc.currentHeader.Load().(*types.Header)
 }
 
+// CurrentFinalizedHeader retrieves the latest header finalized by the consensus
+// engine, or nil if none was finalized yet.
+func (hc *HeaderChain) CurrentFinalizedHeader() *types.Header {
+	return hc.currentFinalized.Load().(*types.Header)
+}
+
+// SetFinalized marks the given canonical header as final. The chain will refuse
+// to reorg past it from then on. Finalizing a header below the current finalized
+// one is a noop.
+func (hc *HeaderChain) SetFinalized(header *types.Header) error {
+	number := header.Number.Uint64()
+	if hc.GetCanonicalHash(number) != header.Hash() {
+		return ErrFinalizedNotCanonical
+	}
+	if finalized := hc.CurrentFinalizedHeader(); finalized != nil && finalized.Number.Uint64() >= number {
+		return nil
+	}
+	rawdb.WriteFinalizedBlockHash(hc.chainDb, header.Hash())
+
+	hc.currentFinalized.Store(types.CopyHeader(header))
+	headFinalizedGauge.Update(int64(number))
+
+	log.Debug("Finalized header", "number", number, "hash", header.Hash())
+	return nil
+}
+
+// descendsFrom reports whether the header with the given hash and number is the
+// given canonical ancestor or one of its descendants.
+func (hc *HeaderChain) descendsFrom(hash common.Hash, number uint64, ancestor *types.Header) bool {
+	if number < ancestor.Number.Uint64() {
+		return false
+	}
+	maxNonCanonical := uint64(math.MaxUint64)
+	hash, _ = hc.GetAncestor(hash, number, number-ancestor.Number.Uint64(), &maxNonCanonical)
+	return hash == ancestor.Hash()
+}
+
 // SetCurrentHeader sets the in-memory head header marker of the canonical chan
 // as the given header.
 func (hc *HeaderChain) SetCurrentHeader(head *types.Header) {
@@ -593,6 +649,12 @@ func (hc *HeaderChain) SetHead(head uint64, updateFn UpdateHeadBlocksCallback, d
 		}
 		// Update head header then.
 		rawdb.WriteHeadHeaderHash(markerBatch, parentHash)
+
+		// Rewinding is an explicit user request, degrade the finalized header too
+		finalized := hc.CurrentFinalizedHeader()
+		if finalized != nil && parent.Number.Cmp(finalized.Number) < 0 {
+			rawdb.WriteFinalizedBlockHash(markerBatch, parentHash)
+		}
 		if err := markerBatch.Write(); err != nil {
 			log.Crit("Failed to update chain markers", "error", err)
 		}
@@ -600,6 +662,11 @@ func (hc *HeaderChain) SetHead(head uint64, updateFn UpdateHeadBlocksCallback, d
 		hc.currentHeaderHash = parentHash
 		headHeaderGauge.Update(parent.Number.Int64())
 
+		if finalized != nil && parent.Number.Cmp(finalized.Number) < 0 {
+			hc.currentFinalized.Store(parent)
+			headFinalizedGauge.Update(parent.Number.Int64())
+		}
+
 		// If this is the first iteration, wipe any leftover data upwards too so
 		// we don't end up with dangling daps in the database
 		var nums []uint64
@@ -114,3 +114,49 @@ func TestHeaderInsertion(t *testing.T) {
 	// And B becomes even longer
 	testInsert(t, hc, chainB[107:128], CanonStatTy, nil)
 }
+
+// Tests that the header chain never reorgs past the finalized header, and that
+// the finalized header is persisted and degraded by explicit rewinds.
+func TestHeaderFinality(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		genesis = (&Genesis{BaseFee: big.NewInt(params.InitialBaseFee)}).MustCommit(db)
+	)
+	hc, err := NewHeaderChain(db, params.AllEthashProtocolChanges, ethash.NewFaker(), func() bool { return false })
+	if err != nil {
+		t.Fatal(err)
+	}
+	// chain A: G->A1->A2...A64
+	chainA := makeHeaderChain(genesis.Header(), 64, ethash.NewFaker(), db, 10)
+	// chain B: G->A1->B2...B80
+	chainB := makeHeaderChain(chainA[0], 80, ethash.NewFaker(), db, 10)
+
+	testInsert(t, hc, chainA, CanonStatTy, nil)
+	if err := hc.SetFinalized(chainB[10]); err != ErrFinalizedNotCanonical {
+		t.Fatalf("non-canonical finalization error mismatch: have %v, want %v", err, ErrFinalizedNotCanonical)
+	}
+	if err := hc.SetFinalized(chainA[31]); err != nil {
+		t.Fatalf("failed to finalize header: %v", err)
+	}
+	// The heavier chain B would replace the finalized header
+	testInsert(t, hc, chainB[:32], SideStatTy, nil)
+	testInsert(t, hc, chainB[32:], NonStatTy, ErrFinalizedReorg)
+	if head := hc.CurrentHeader(); head.Hash() != chainA[63].Hash() {
+		t.Fatalf("head mismatch: have #%d, want #%d", head.Number, chainA[63].Number)
+	}
+	// The finalized header is restored on restart
+	hc, err = NewHeaderChain(db, params.AllEthashProtocolChanges, ethash.NewFaker(), func() bool { return false })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if final := hc.CurrentFinalizedHeader(); final == nil || final.Hash() != chainA[31].Hash() {
+		t.Fatalf("finalized header mismatch after restart: have %v, want #%d", final, chainA[31].Number)
+	}
+	// Rewinding below the finalized header degrades it
+	hc.SetCurrentHeader(hc.GetHeaderByHash(rawdb.ReadHeadHeaderHash(db)))
+	hc.SetHead(chainA[15].Number.Uint64(), nil, nil)
+	if final := hc.CurrentFinalizedHeader(); final == nil || final.Hash() != chainA[15].Hash() {
+		t.Fatalf("finalized header mismatch after rewind: have %v, want #%d", final, chainA[15].Number)
+	}
+	testInsert(t, hc, chainA[16:], CanonStatTy, nil)
+}
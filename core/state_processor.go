@@ -24,6 +24,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -66,10 +67,36 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 		allLogs     []*types.Log
 		gp          = new(GasPool).AddGas(block.GasLimit())
 	)
+	// Verify the base fee against the parameters governed on-chain, which header
+	// verification may have skipped without access to the parent state
+	if governance, ok := p.engine.(misc.BaseFeeGovernance); ok && p.config.IsLondon(blockNumber) {
+		parent := p.bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
+		if parent == nil {
+			return nil, nil, 0, consensus.ErrUnknownAncestor
+		}
+		feeParams, err := governance.BaseFeeParams(p.bc, parent, statedb)
+		if err != nil {
+			return nil, nil, 0, fmt.Errorf("could not retrieve base fee parameters: %w", err)
+		}
+		if err := misc.VerifyEip1559HeaderWithParams(p.config, feeParams, parent, header); err != nil {
+			return nil, nil, 0, err
+		}
+	}
+	// Verify the checkpointed committee against the parent state, which header
+	// verification may have skipped as well
+	if posa, ok := p.engine.(consensus.PoSA); ok {
+		if err := posa.VerifyCheckpoint(p.bc, header, statedb); err != nil {
+			return nil, nil, 0, err
+		}
+	}
 	// Mutate the block and state according to any hard-fork specs
 	if p.config.DAOForkSupport && p.config.DAOForkBlock != nil && p.config.DAOForkBlock.Cmp(block.Number()) == 0 {
 		misc.ApplyDAOHardFork(statedb)
 	}
+	if err := systemcontracts.UpgradeSystemContracts(p.config, blockNumber, statedb); err != nil {
+		return nil, nil, 0, err
+	}
+
 	blockContext := NewEVMBlockContext(header, p.bc, nil)
 	vmenv := vm.NewEVM(blockContext, vm.TxContext{}, statedb, p.config, cfg)
 	// Iterate over and process the individual transactions
@@ -87,7 +114,9 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 		allLogs = append(allLogs, receipt.Logs...)
 	}
 	// Finalize the block, applying any consensus engine specific extras (e.g. block rewards)
-	p.engine.Finalize(p.bc, header, statedb, block.Transactions(), block.Uncles())
+	if err := p.engine.Finalize(p.bc, header, statedb, block.Transactions(), block.Uncles(), receipts); err != nil {
+		return nil, nil, 0, err
+	}
 
 	return receipts, allLogs, *usedGas, nil
 }
@@ -17,6 +17,7 @@
 package core
 
 import (
+	"bytes"
 	"math/big"
 	"testing"
 
@@ -25,6 +26,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -286,6 +288,120 @@ func TestStateProcessorErrors(t *testing.T) {
 	}
 }
 
+// Tests that the system contract upgrades of a hard fork are applied at its
+// activation block, with the block producer and importer deriving the same state.
+func TestSystemContractUpgrade(t *testing.T) {
+	var (
+		config = &params.ChainConfig{
+			ChainID:             big.NewInt(1),
+			HomesteadBlock:      big.NewInt(0),
+			EIP150Block:         big.NewInt(0),
+			EIP155Block:         big.NewInt(0),
+			EIP158Block:         big.NewInt(0),
+			ByzantiumBlock:      big.NewInt(0),
+			ConstantinopleBlock: big.NewInt(0),
+			PetersburgBlock:     big.NewInt(0),
+			IstanbulBlock:       big.NewInt(0),
+			MuirGlacierBlock:    big.NewInt(0),
+			BerlinBlock:         big.NewInt(0),
+			LondonBlock:         big.NewInt(0),
+			BigBenBlock:         big.NewInt(2),
+			Ethash:              new(params.EthashConfig),
+		}
+		signer     = types.LatestSigner(config)
+		testKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		testAddr   = crypto.PubkeyToAddress(testKey.PublicKey)
+		hub        = common.HexToAddress(systemcontracts.ValidatorHubContract)
+		code       = common.FromHex("0x602a60025500") // sstore(2, 42)
+	)
+	// Upgrade the hub code and patch a storage slot at BigBen
+	defer func(upgrades []*systemcontracts.ContractUpgrade) {
+		systemcontracts.Upgrades["BigBen"] = upgrades
+	}(systemcontracts.Upgrades["BigBen"])
+
+	systemcontracts.Upgrades["BigBen"] = []*systemcontracts.ContractUpgrade{{
+		Address: hub,
+		Code:    common.Bytes2Hex(code),
+		Storage: map[common.Hash]common.Hash{{0x01}: common.BigToHash(big.NewInt(1))},
+	}}
+	gspec := &Genesis{
+		Config: config,
+		Alloc: GenesisAlloc{
+			testAddr: GenesisAccount{Balance: big.NewInt(1000000000000000000)},
+			hub:      GenesisAccount{Balance: new(big.Int), Code: []byte{0x00}, Storage: map[common.Hash]common.Hash{{}: common.BigToHash(big.NewInt(7))}},
+		},
+	}
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		genesis = gspec.MustCommit(db)
+	)
+	// Generate a chain calling the upgraded hub in the activation block
+	blocks, _ := GenerateChain(config, genesis, ethash.NewFaker(), db, 3, func(i int, gen *BlockGen) {
+		if i == 1 {
+			tx, err := types.SignTx(types.NewTransaction(gen.TxNonce(testAddr), hub, new(big.Int), 50000, gen.BaseFee(), nil), signer, testKey)
+			if err != nil {
+				t.Fatalf("failed to sign transaction: %v", err)
+			}
+			gen.AddTx(tx)
+		}
+	})
+	// Import the chain into a fresh node, the block validation ensures the roots match
+	importdb := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(importdb)
+
+	chain, err := NewBlockChain(importdb, nil, config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import block %d: %v", n, err)
+	}
+	// Ensure the hub is only upgraded from the fork block on
+	for i, block := range blocks {
+		statedb, err := chain.StateAt(block.Root())
+		if err != nil {
+			t.Fatalf("block %d: failed to retrieve state: %v", block.NumberU64(), err)
+		}
+		upgraded := i >= 1
+		if have := statedb.GetCode(hub); bytes.Equal(have, code) != upgraded {
+			t.Errorf("block %d: code mismatch: have %x, upgraded %v", block.NumberU64(), have, upgraded)
+		}
+		if have := statedb.GetState(hub, common.Hash{}); have != common.BigToHash(big.NewInt(7)) {
+			t.Errorf("block %d: untouched slot mismatch: have %x", block.NumberU64(), have)
+		}
+		if have := statedb.GetState(hub, common.Hash{0x01}); (have == common.BigToHash(big.NewInt(1))) != upgraded {
+			t.Errorf("block %d: patched slot mismatch: have %x, upgraded %v", block.NumberU64(), have, upgraded)
+		}
+		if have := statedb.GetState(hub, common.BigToHash(big.NewInt(2))); (have == common.BigToHash(big.NewInt(42))) != upgraded {
+			t.Errorf("block %d: upgraded code not executed: have %x, upgraded %v", block.NumberU64(), have, upgraded)
+		}
+	}
+	// Ensure a block whose upgrade fails is rejected, the config check is bypassed
+	// by committing the genesis beforehand
+	faildb := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(faildb)
+
+	systemcontracts.Upgrades["BigBen"] = []*systemcontracts.ContractUpgrade{{
+		Address: hub,
+		Code:    "0xzz",
+	}}
+	if _, err := gspec.Commit(rawdb.NewMemoryDatabase()); err == nil {
+		t.Fatalf("broken upgrade accepted by the config check")
+	}
+
+	failchain, err := NewBlockChain(faildb, nil, config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer failchain.Stop()
+
+	if n, err := failchain.InsertChain(blocks); err == nil || n != 1 {
+		t.Fatalf("upgrade failure not reported: index %d, err %v", n, err)
+	}
+}
+
 // GenerateBadBlock constructs a "block" which contains the transactions. The transactions are not expected to be
 // valid, and no proper post-state can be made. But from the perspective of the blockchain, the block is sufficiently
 // valid to be considered for import:
@@ -24,7 +24,6 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 	"math"
 	"math/big"
@@ -306,9 +305,12 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	st.gas -= gas
 
 	// Check clause 6
-	if msg.Value().Sign() > 0 && (!st.evm.Context.CanTransfer(st.state, msg.From(), msg.Value()) || !st.tridentCanTransfer(msg.To())) {
+	if msg.Value().Sign() > 0 && !st.evm.Context.CanTransfer(st.state, msg.From(), msg.Value()) {
 		return nil, fmt.Errorf("%w: address %v", ErrInsufficientFundsForTransfer, msg.From().Hex())
 	}
+	if err := CheckTransferPolicy(st.evm.ChainConfig(), st.evm.Context.BlockNumber, st.state, msg.From(), msg.To(), msg.Value()); err != nil {
+		return nil, err
+	}
 
 	// Set up the initial access list.
 	if rules := st.evm.ChainConfig().Rules(st.evm.Context.BlockNumber); rules.IsBerlin {
@@ -346,44 +348,9 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	}, nil
 }
 
-func (st *StateTransition) isContract(address *common.Address) bool {
-	contractHash := st.evm.StateDB.GetCodeHash(*address)
-	if contractHash != (common.Hash{}) && contractHash != emptyCodeHash {
-		return true
-	}
-
-	return false
-}
-
-func (st *StateTransition) tridentCanTransfer(toAddress *common.Address) bool {
-	trident := st.evm.ChainConfig().IsTrident(st.evm.Context.BlockNumber)
-
-	if trident {
-		log.Debug("tridentCheck", "trident fork enabled.")
-		return true
-	}
-
-	if toAddress == nil {
-		log.Debug("tridentCheck", "contract creation is not allowed.")
-		return false
-	}
-
-	if _, ok := systemcontracts.SystemContractAddress[*toAddress]; ok {
-		log.Debug("tridentCheck", "system contract is matched.", *toAddress)
-		return true
-	}
-
-	if st.isContract(toAddress) == true {
-		log.Debug("tridentCheck", "don't allow transfers to contracts", *toAddress)
-		return false
-	}
-
-	return true
-}
-
 func (st *StateTransition) refundGas(refundQuotient uint64) {
 	var refund uint64
-	if systemcontracts.IsSystemTransition(st.msg.To(), st.msg.Data()) {
+	if systemcontracts.IsSystemTransitionAt(st.evm.ChainConfig(), st.evm.Context.BlockNumber, st.msg.To(), st.msg.Data()) {
 		// systemTransition, 0 fee
 		refund = st.gasUsed()
 	} else {
@@ -0,0 +1,12 @@
+package systemcontracts
+
+// GenesisMinter is the address compiled into ValidatorHubCode, the only proposer
+// until the first validators join the committee.
+const GenesisMinter = "0xe60e2f94dE8D2C5D8a1269747d1F60fEA1413A40"
+
+// Runtime bytecode of the genesis contracts, as deployed at ValidatorHubContract
+// and ValidatorFactoryContract.
+const (
+	ValidatorHubCode     = "0x6080604052600436106101f95760003560e01c80638c09fda41161010d578063b7ab4db5116100a0578063e1c7392a1161006f578063e1c7392a14610968578063f88860b51461097d578063facd743b146109a7578063ff7a071b146109da578063ffd8136e146109ef576101f9565b8063b7ab4db5146108f6578063c4e41b221461090b578063c96be4cb14610920578063d7368fd614610953576101f9565b8063a44b47f7116100dc578063a44b47f7146107e1578063a78abc16146107f6578063ab8f6ffe1461080b578063b633891714610820576101f9565b80638c09fda4146106fc5780638e88fb371461078457806395468d26146107b7578063a0730c2d146107cc576101f9565b806335aa2e441161019057806366c368751161015f57806366c36875146105515780636c590d72146105845780636e37d599146105e257806374ec29a0146105f75780638a11d7c91461062a576101f9565b806335aa2e441461046b5780634754857c1461049557806349d60f8e146104c857806352747f7b1461053c576101f9565b806327a50f72116101cc57806327a50f72146103505780632ec2c2461461038357806332434a2e146103b85780633438174914610438576101f9565b806304e75f43146101fe5780631040bd47146102c557806311260a5f1461030a5780631351440a1461031f575b600080fd5b34801561020a57600080fd5b506102b16004803603602081101561022157600080fd5b81019060208101813564010000000081111561023c57600080fd5b82018360208201111561024e57600080fd5b8035906020019184600183028401116401000000008311171561027057600080fd5b91908080601f016020809104026020016040519081016040528093929190818152602001838380828437600092019190915250929550610a19945050505050565b604080519115158252519081900360200190f35b3480156102d157600080fd5b506102f8600480360360208110156102e857600080fd5b50356001600160a01b0316610a39565b60408051918252519081900360200190f35b34801561031657600080fd5b506102f8610a5b565b34801561032b57600080fd5b50610334610a69565b604080516001600160a01b039092168252519081900360200190f35b34801561035c57600080fd5b506103346004803603602081101561037357600080fd5b50356001600160a01b0316610a81565b34801561038f57600080fd5b506103b6600480360360208110156103a657600080fd5b50356001600160a01b0316610aba565b005b6103b6600480360360408110156103ce57600080fd5b6001600160a01b0382351691908101906040810160208201356401000000008111156103f957600080fd5b82018360208201111561040b57600080fd5b8035906020019184600183028401116401000000008311171561042d57600080fd5b509092509050610c39565b34801561044457600080fd5b506102f86004803603602081101561045b57600080fd5b50356001600160a01b0316611118565b34801561047757600080fd5b506103346004803603602081101561048e57600080fd5b503561119f565b3480156104a157600080fd5b50610334600480360360208110156104b857600080fd5b50356001600160a01b03166111c6565b3480156104d457600080fd5b506104fb600480360360208110156104eb57600080fd5b50356001600160a01b03166111e1565b604080519889526020890197909752878701959095526060870193909352608086019190915260a085015260c084015260e083015251908190036101000190f35b34801561054857600080fd5b506102f8611229565b34801561055d57600080fd5b506102f86004803603602081101561057457600080fd5b50356001600160a01b031661122e565b34801561059057600080fd5b506105b7600480360360208110156105a757600080fd5b50356001600160a01b031661124c565b6040805195865260208601949094528484019290925260608401526080830152519081900360a00190f35b3480156105ee57600080fd5b506102f86112f5565b34801561060357600080fd5b506102b16004803603602081101561061a57600080fd5b50356001600160a01b0316611303565b34801561063657600080fd5b5061065d6004803603602081101561064d57600080fd5b50356001600160a01b031661132a565b6040518080602001856001600160a01b03166001600160a01b03168152602001848152602001838152602001828103825286818151815260200191508051906020019080838360005b838110156106be5781810151838201526020016106a6565b50505050905090810190601f1680156106eb5780820380516001836020036101000a031916815260200191505b509550505050505060405180910390f35b34801561070857600080fd5b506107346004803603606081101561071f57600080fd5b5080359060208101359060400135151561145b565b60408051602080825283518183015283519192839290830191858101910280838360005b83811015610770578181015183820152602001610758565b505050509050019250505060405180910390f35b34801561079057600080fd5b50610334600480360360208110156107a757600080fd5b50356001600160a01b03166115e0565b3480156107c357600080fd5b506102f8611610565b3480156107d857600080fd5b506102f861161d565b3480156107ed57600080fd5b506102f86116ba565b34801561080257600080fd5b506102b16116c0565b34801561081757600080fd5b506107346116c9565b34801561082c57600080fd5b506108536004803603602081101561084357600080fd5b50356001600160a01b03166117fc565b604080518515156020808301919091529181018590526001600160a01b0380851660608301528316608082015260a080825287519082015286519091829160c083019189019080838360005b838110156108b757818101518382015260200161089f565b50505050905090810190601f1680156108e45780820380516001836020036101000a031916815260200191505b50965050505050505060405180910390f35b34801561090257600080fd5b506107346118c1565b34801561091757600080fd5b506102f86118da565b34801561092c57600080fd5b506103b66004803603602081101561094357600080fd5b50356001600160a01b03166118e0565b34801561095f57600080fd5b5061033461194d565b34801561097457600080fd5b506103b6611953565b34801561098957600080fd5b506103b6600480360360208110156109a057600080fd5b50356119ba565b3480156109b357600080fd5b506102b1600480360360208110156109ca57600080fd5b50356001600160a01b0316611aed565b3480156109e657600080fd5b506102f8611b0e565b3480156109fb57600080fd5b506103b660048036036020811015610a1257600080fd5b5035611b14565b805160208183018101805160058252928201919093012091525460ff1681565b6001600160a01b0381166000908152600360205260409020600501545b919050565b69021e19e0c9bab240000081565b73e60e2f94de8d2c5d8a1269747d1f60fea1413a4081565b6000610a8c82611c39565b15610a98575080610a56565b506001600160a01b039081166000908152600260205260409020600401541690565b6001600160a01b038116600090815260026020526040902060010154819060ff16610b25576040805162461bcd60e51b81526020600482015260166024820152751d985b1a59185d1bdc881a5cc81b9bdd08195e1a5cdd60521b604482015290519081900360640190fd5b6001600160a01b03828116600090815260026020526040902060030154163314610b8d576040805162461bcd60e51b81526020600482015260146024820152736e6f74207265676973746572206164647265737360601b604482015290519081900360640190fd5b336108fc610bb469010f0cf064dd59200000683635c9adc5dea0000063ffffffff611c8e16565b6040518115909202916000818181858888f19350505050158015610bdc573d6000803e3d6000fd5b506001600160a01b038216600090815260026020908152604091829020600101805460ff19169055815133815291517f0d05b8c95b17143d2a53bebf946b48fb3efb6124a87fe53ba2af8c03b24323c19281900390910190a15050565b6001600160a01b038316600090815260026020526040902060010154839060ff16158015610c8257506001600160a01b0381811660009081526002602052604090206004015416155b610cd3576040805162461bcd60e51b815260206004820152601760248201527f76616c696461746f7220616c7265616479206578697374000000000000000000604482015290519081900360640190fd5b69010f0cf064dd592000003414610d1b5760405162461bcd60e51b8152600401808060200182810382526026815260200180611fe56026913960400191505060405180910390fd5b6040821115610d60576040805162461bcd60e51b815260206004820152600c60248201526b6e616d6520746f206c6f6e6760a01b604482015290519081900360640190fd5b6005838360405180838380828437919091019485525050604051928390036020019092205460ff16159150610dcd9050576040805162461bcd60e51b815260206004820152600c60248201526b1b985b59481a5cc81d5cd95960a21b604482015290519081900360640190fd5b60408051631f34340560e11b81526001600160a01b0386166004820152336024820152905160009161100891633e68680a9160448082019260209290919082900301818787803b158015610e2057600080fd5b505af1158015610e34573d6000803e3d6000fd5b505050506040513d6020811015610e4a57600080fd5b50519050610e56611ee3565b84848080601f0160208091040260200160405190810160405280939291908181526020018383808284376000920191909152505050908252504360408201526001600160a01b038216608082015233606082015260016020820152610eb9611f11565b4360a0820181905260649004600181810160c084015260e08301805190910190526001600160a01b0388166000908152600260209081526040909120845180518693610f09928492910190611f56565b5060208201518160010160006101000a81548160ff0219169083151502179055506040820151816002015560608201518160030160006101000a8154816001600160a01b0302191690836001600160a01b0316021790555060808201518160040160006101000a8154816001600160a01b0302191690836001600160a01b0316021790555090505081600360008a6001600160a01b03166001600160a01b03168152602001908152602001600020600082015181600001556020820151816001015560408201518160020155606082015181600301556080820151816004015560a0820151816005015560c0820151816006015560e082015181600701559050506001889080600181540180825580915050600190039060005260206000200160009091909190916101000a8154816001600160a01b0302191690836001600160a01b031602179055508760046000866001600160a01b03166001600160a01b0316815260200190815260200160002060006101000a8154816001600160a01b0302191690836001600160a01b03160217905550600160058888604051808383808284379190910194855250506040805160209481900385018120805460ff1916961515969096179095556001600160a01b038d168552517f62ed530eedb6ed40f8fba529e2ff38e91835ca48ff1e6d36b7a17ce35cb944ee9481900390930192915050a15050505050505050565b6001600160a01b0380821660009081526002602090815260408083206004908101548251633e8fe5fd60e11b8152925194951693637d1fcbfa9383830193909290829003018186803b15801561116d57600080fd5b505afa158015611181573d6000803e3d6000fd5b505050506040513d602081101561119757600080fd5b505192915050565b600181815481106111ac57fe5b6000918252602090912001546001600160a01b0316905081565b6004602052600090815260409020546001600160a01b031681565b60036020528060005260406000206000915090508060000154908060010154908060020154908060030154908060040154908060050154908060060154908060070154905088565b606481565b6001600160a01b031660009081526003602052604090206007015490565b600080600080600061125c611f11565b506001600160a01b0386166000908152600360208181526040928390208351610100810185528154815260018201549281018390526002820154948101859052928101546060840181905260048201546080850152600582015460a0850152600682015460c085015260079091015460e08401529097509195509093506112e287611118565b92508060e0015191505091939590929450565b69010f0cf064dd5920000081565b600061130e82611c39565b1561131b57506001610a56565b61132482611cd7565b92915050565b6060600080600061133a85611c39565b1561137a57505060408051808201909152600d81526c33b2b732b9b4b9a6b4b73a32b960991b60208201529150829050670de0b6b3a76400006001611454565b6001600160a01b038516600090815260026020818152604092839020805484516001821615610100026000190190911693909304601f81018390048302840183019094528383529192908301828280156114155780601f106113ea57610100808354040283529160200191611415565b820191906000526020600020905b8154815290600101906020018083116113f857829003601f168201915b5050506001600160a01b038089166000908152600260208181526040808420600401546003909252909220805491015495995091169650945091925050505b9193509193565b60608060018054905060405190808252806020026020018201604052801561148d578160200160208202803683370190505b509050600080805b60015481101561155c578683106114ab5761155c565b85151560026000600184815481106114bf57fe5b60009182526020808320909101546001600160a01b0316835282019290925260400190206001015460ff16151514156115545760018201918811611554576001818154811061150a57fe5b600091825260209091200154845160018501946001600160a01b03909216918691811061153357fe5b60200260200101906001600160a01b031690816001600160a01b0316815250505b600101611495565b5081604051908082528060200260200182016040528015611587578160200160208202803683370190505b50935060005b828110156115d5578381815181106115a157fe5b60200260200101518582815181106115b557fe5b6001600160a01b039092166020928302919091019091015260010161158d565b505050509392505050565b6001600160a01b039081166000908152600460209081526040808320548416835260029091529020600301541690565b683635c9adc5dea0000081565b600061162833611c39565b1561163c5750670de0b6b3a76400006116b7565b506000805b6001548110156116b55760006001828154811061165a57fe5b6000918252602090912001546001600160a01b0316905061167a81611cd7565b156116ac576001600160a01b0381166000908152600360205260409020546116a990849063ffffffff611d5316565b92505b50600101611641565b505b90565b60065481565b60005460ff1681565b6060806001805490506040519080825280602002602001820160405280156116fb578160200160208202803683370190505b5090506000805b60015481101561177d5760006001828154811061171b57fe5b6000918252602090912001546001600160a01b0316905061173b81611cd7565b15611774578084848060010195508151811061175357fe5b60200260200101906001600160a01b031690816001600160a01b0316815250505b50600101611702565b50806040519080825280602002602001820160405280156117a8578160200160208202803683370190505b50925060005b818110156117f6578281815181106117c257fe5b60200260200101518482815181106117d657fe5b6001600160a01b03909216602092830291909101909101526001016117ae565b50505090565b600260208181526000928352604092839020805484516001821615610100026000190190911693909304601f810183900483028401830190945283835292839183018282801561188d5780601f106118625761010080835404028352916020019161188d565b820191906000526020600020905b81548152906001019060200180831161187057829003601f168201915b505050600184015460028501546003860154600490960154949560ff909216949093506001600160a01b0391821692501685565b60606118d56000600180549050600161145b565b905090565b60065490565b3360008181526002602052604090206001015460ff16611940576040805162461bcd60e51b81526020600482015260166024820152751d985b1a59185d1bdc881a5cc81b9bdd08195e1a5cdd60521b604482015290519081900360640190fd5b61194982611dad565b5050565b61100881565b60005460ff16156119ab576040805162461bcd60e51b815260206004820152601960248201527f74686520636f6e747261637420616c726561647920696e697400000000000000604482015290519081900360640190fd5b6000805460ff19166001179055565b336000908152600460209081526040808320546001600160a01b031680845260029092529091206001015460ff16611a32576040805162461bcd60e51b81526020600482015260166024820152751d985b1a59185d1bdc881a5cc81b9bdd08195e1a5cdd60521b604482015290519081900360640190fd5b336000908152600460205260409020546001600160a01b031680611a8f576040805162461bcd60e51b815260206004820152600f60248201526e496e76616c6964206164647265737360881b604482015290519081900360640190fd5b6001600160a01b038116600090815260036020526040902054600654611acc918591611ac09163ffffffff611c8e16565b9063ffffffff611d5316565b6006556001600160a01b031660009081526003602052604090209190915550565b6001600160a01b031660009081526002602052604090206001015460ff1690565b60015490565b3360008181526002602052604090206001015460ff16611b74576040805162461bcd60e51b81526020600482015260166024820152751d985b1a59185d1bdc881a5cc81b9bdd08195e1a5cdd60521b604482015290519081900360640190fd5b3360008181526003602090815260408083206002909252909120600401546001600160a01b031641148015611bac5750438160020154105b611bef576040805162461bcd60e51b815260206004820152600f60248201526e496e76616c6964206164647265737360881b604482015290519081900360640190fd5b6001810154611bff574360018201555b43600282015560038101805460010190556004810154611c25908563ffffffff611d5316565b6004820155611c3382611dad565b50505050565b6000611c436116c9565b51158015611c515750606443105b8015611c7957506001600160a01b03821673e60e2f94de8d2c5d8a1269747d1f60fea1413a40145b15611c8657506001610a56565b506000919050565b6000611cd083836040518060400160405280601e81526020017f536166654d6174683a207375627472616374696f6e206f766572666c6f770000815250611e4c565b9392505050565b6001600160a01b03811660009081526003602052604081206006015460644304908111801590611d2957506001600160a01b03831660009081526003602052604090205469021e19e0c9bab240000011155b8015611cd0575050506001600160a01b031660009081526002602052604090206001015460ff1690565b600082820183811015611cd0576040805162461bcd60e51b815260206004820152601b60248201527f536166654d6174683a206164646974696f6e206f766572666c6f770000000000604482015290519081900360640190fd5b6001600160a01b0381163314611dfc576040805162461bcd60e51b815260206004820152600f60248201526e496e76616c6964206164647265737360881b604482015290519081900360640190fd5b6001600160a01b038116600090815260036020526040902043600582018190556006820154606490910490600182011115611e47576001808201600684015560078301805490910190555b505050565b60008184841115611edb5760405162461bcd60e51b81526004018080602001828103825283818151815260200191508051906020019080838360005b83811015611ea0578181015183820152602001611e88565b50505050905090810190601f168015611ecd5780820380516001836020036101000a031916815260200191505b509250505060405180910390fd5b505050900390565b6040805160a08101825260608082526000602083018190529282018390528101829052608081019190915290565b60405180610100016040528060008152602001600081526020016000815260200160008152602001600081526020016000815260200160008152602001600081525090565b828054600181600116156101000203166002900490600052602060002090601f016020900481019282601f10611f9757805160ff1916838001178555611fc4565b82800160010185558215611fc4579182015b82811115611fc4578251825591602001919060010190611fa9565b506116b5926116b79250905b808211156116b55760008155600101611fd056fe72656769737465722076616c7565206973206e6f742065786163746c79207468652073616d65a26469706673582212205cf606771969c417c27ba8bc6c3b332aee72d1f22d4f4aea7d01c88156767b8564736f6c63430006040033"
+	ValidatorFactoryCode = "0x608060405234801561001057600080fd5b50600436106100625760003560e01c8063213c40cf1461006757806327a50f72146100a95780633e68680a146100cf578063a78abc16146100fd578063dbfb867514610119578063e1c7392a14610121575b600080fd5b61008d6004803603602081101561007d57600080fd5b50356001600160a01b031661012b565b604080516001600160a01b039092168252519081900360200190f35b61008d600480360360208110156100bf57600080fd5b50356001600160a01b0316610146565b61008d600480360360408110156100e557600080fd5b506001600160a01b0381358116916020013516610164565b61010561022f565b604080519115158252519081900360200190f35b61008d610238565b61012961023e565b005b6001602052600090815260409020546001600160a01b031681565b6001600160a01b039081166000908152600160205260409020541690565b600033611006146101b4576040805162461bcd60e51b815260206004820152601560248201527436b9b39039b2b73232b91034b9903737ba10343ab160591b604482015290519081900360640190fd5b600083836040516101c4906102a5565b6001600160a01b03928316815291166020820152604080519182900301906000f0801580156101f7573d6000803e3d6000fd5b506001600160a01b03948516600090815260016020526040902080546001600160a01b03191695821695909517909455509192915050565b60005460ff1681565b61100681565b60005460ff1615610296576040805162461bcd60e51b815260206004820152601960248201527f74686520636f6e747261637420616c726561647920696e697400000000000000604482015290519081900360640190fd5b6000805460ff19166001179055565b610f2c806102b38339019056fe6080604052601460005534801561001557600080fd5b50604051610f2c380380610f2c8339818101604052604081101561003857600080fd5b508051602090910151600680546001600160a01b039384166001600160a01b03199182161790915560078054939092169216919091179055610ead8061007f6000396000f3fe6080604052600436106101665760003560e01c80637bf89c85116100d1578063c6ddd5b51161008a578063d246d41111610064578063d246d41114610439578063dbfb86751461044e578063e58e171014610463578063e9fad8ee1461047857610166565b8063c6ddd5b5146103e9578063d086c254146103fe578063d0e30db01461043157610166565b80637bf89c851461034d5780637d1fcbfa14610380578063840e319114610395578063978bbdb9146103aa5780639f35c7e7146103bf578063b69ef8a8146103d457610166565b80632f8f6be2116101235780632f8f6be214610262578063392b66a31461029357806345596e2e146102c65780636375c1e3146102f057806370a082311461030557806377c7b8fc1461033857610166565b806318160ddd146101685780631959a0021461018f5780631df4ccfc146101e85780631f68f20a146101fd578063200cb907146102125780632e1a7d4d14610245575b005b34801561017457600080fd5b5061017d61048d565b60408051918252519081900360200190f35b34801561019b57600080fd5b506101c2600480360360208110156101b257600080fd5b50356001600160a01b0316610493565b604080519485526020850193909352838301919091526060830152519081900360800190f35b3480156101f457600080fd5b5061017d6104ba565b34801561020957600080fd5b5061017d6104c0565b34801561021e57600080fd5b5061017d6004803603602081101561023557600080fd5b50356001600160a01b03166104c5565b6101666004803603602081101561025b57600080fd5b50356104e7565b34801561026e57600080fd5b50610277610873565b604080516001600160a01b039092168252519081900360200190f35b34801561029f57600080fd5b5061017d600480360360208110156102b657600080fd5b50356001600160a01b0316610882565b3480156102d257600080fd5b50610166600480360360208110156102e957600080fd5b503561089d565b3480156102fc57600080fd5b5061017d61093f565b34801561031157600080fd5b5061017d6004803603602081101561032857600080fd5b50356001600160a01b0316610945565b34801561034457600080fd5b5061017d610994565b34801561035957600080fd5b5061017d6004803603602081101561037057600080fd5b50356001600160a01b03166109d5565b34801561038c57600080fd5b5061017d6109f3565b3480156103a157600080fd5b5061017d610a15565b3480156103b657600080fd5b5061017d610a1c565b3480156103cb57600080fd5b50610277610a22565b3480156103e057600080fd5b5061017d610a31565b3480156103f557600080fd5b5061017d610a35565b34801561040a57600080fd5b5061017d6004803603602081101561042157600080fd5b50356001600160a01b0316610a3b565b610166610a81565b34801561044557600080fd5b50610277610bf9565b34801561045a57600080fd5b50610277610bff565b34801561046f57600080fd5b5061017d610c05565b34801561048457600080fd5b50610166610c0a565b60025481565b60046020526000908152604090208054600182015460028301546003909301549192909184565b60015481565b606481565b6001600160a01b0381166000908152600460205260409020600301545b919050565b60008111610530576040805162461bcd60e51b8152602060048201526011602482015270043616e6e6f74207769746864726177203607c1b604482015290519081900360640190fd5b3360009081526004602052604090206001810154821115610591576040805162461bcd60e51b8152602060048201526016602482015275616d6f756e7420657863656564732062616c616e636560501b604482015290519081900360640190fd5b60006105bd6003546105b16105a4610a31565b869063ffffffff610c1d16565b9063ffffffff610c7d16565b905060006105e083600101546105b1856000015487610c1d90919063ffffffff16565b60018401549091506105f8908563ffffffff610cbf16565b6001840155600354610610908563ffffffff610cbf16565b6003558254610625908263ffffffff610cbf16565b835560025461063a908263ffffffff610cbf16565b6002558082111561071e576000610657838363ffffffff610cbf16565b9050600061067560646105b160005485610c1d90919063ffffffff16565b905061069e81610692848860030154610d0190919063ffffffff16565b9063ffffffff610cbf16565b60038601556005546106b6908363ffffffff610d0116565b6005556106c9848263ffffffff610cbf16565b6007546040519195506001600160a01b03169082156108fc029083906000818181858888f19350505050158015610704573d6000803e3d6000fd5b50600154610718908263ffffffff610d0116565b60015550505b62ed4e00610739846002015442610cbf90919063ffffffff16565b1161079d57600061075660646105b185601e63ffffffff610c1d16565b60405190915061dead9082156108fc029083906000818181858888f19350505050158015610788573d6000803e3d6000fd5b50610799838263ffffffff610cbf16565b9250505b604051339083156108fc029084906000818181858888f193505050501580156107ca573d6000803e3d6000fd5b50604080513381526020810184905280820183905290517ff279e6a1f5e320cca91135676d9cb6e44ca8a08c0b88342bcdb1144f6511b5689181900360600190a16110066001600160a01b031663f88860b56002546040518263ffffffff1660e01b815260040180828152602001915050600060405180830381600087803b15801561085557600080fd5b505af1158015610869573d6000803e3d6000fd5b5050505050505050565b6007546001600160a01b031681565b6001600160a01b031660009081526004602052604090205490565b6007546001600160a01b031633146108ee576040805162461bcd60e51b815260206004820152600f60248201526e696e76616c6964206164647265737360881b604482015290519081900360640190fd5b603281111561093a576040805162461bcd60e51b8152602060048201526013602482015272199959481c985d19481d1bdbc81a195a59da1d606a1b604482015290519081900360640190fd5b600055565b60035481565b60006003546000141561095a575060006104e2565b6003546001600160a01b03831660009081526004602052604090206001015461098e91906105b1904763ffffffff610c1d16565b92915050565b6000600354600014156109a9575060006109d2565b6109cf6003546105b1670de0b6b3a76400006109c3610a31565b9063ffffffff610c1d16565b90505b90565b6001600160a01b031660009081526004602052604090206001015490565b60006109cf600554610a09600254610692610a31565b9063ffffffff610d0116565b62ed4e0081565b60005481565b6006546001600160a01b031681565b4790565b60055481565b600080610a4783610945565b90506000610a5484610882565b905080821115610a7757610a6e828263ffffffff610cbf16565b925050506104e2565b5060009392505050565b3480610ac7576040805162461bcd60e51b815260206004820152601060248201526f043616e6e6f74206465706f73697420360841b604482015290519081900360640190fd5b6000610ad582610692610a31565b600354909150600090610ae9575081610b05565b610b02826105b160035486610c1d90919063ffffffff16565b90505b3360009081526004602052604090208054610b26908563ffffffff610d0116565b81556001810154610b3d908363ffffffff610d0116565b6001820155426002820155600354610b5b908363ffffffff610d0116565b600355600254610b71908563ffffffff610d0116565b600255604080513381526020810186905281517fe1fffcc4923d04b559f4d29a8bfc6cda04eb5b0d3c460751c2402c5c5cc9109c929181900390910190a16110066001600160a01b031663f88860b56002546040518263ffffffff1660e01b815260040180828152602001915050600060405180830381600087803b15801561085557600080fd5b61dead81565b61100681565b601e81565b610c1b610c16336109d5565b6104e7565b565b600082610c2c5750600061098e565b82820282848281610c3957fe5b0414610c765760405162461bcd60e51b8152600401808060200182810382526021815260200180610e586021913960400191505060405180910390fd5b9392505050565b6000610c7683836040518060400160405280601a81526020017f536166654d6174683a206469766973696f6e206279207a65726f000000000000815250610d5b565b6000610c7683836040518060400160405280601e81526020017f536166654d6174683a207375627472616374696f6e206f766572666c6f770000815250610dfd565b600082820183811015610c76576040805162461bcd60e51b815260206004820152601b60248201527f536166654d6174683a206164646974696f6e206f766572666c6f770000000000604482015290519081900360640190fd5b60008183610de75760405162461bcd60e51b81526004018080602001828103825283818151815260200191508051906020019080838360005b83811015610dac578181015183820152602001610d94565b50505050905090810190601f168015610dd95780820380516001836020036101000a031916815260200191505b509250505060405180910390fd5b506000838581610df357fe5b0495945050505050565b60008184841115610e4f5760405162461bcd60e51b8152602060048201818152835160248401528351909283926044909101919085019080838360008315610dac578181015183820152602001610d94565b50505090039056fe536166654d6174683a206d756c7469706c69636174696f6e206f766572666c6f77a265627a7a72315820209906ef0b2fa3ea36efd687c549d235766f08a3e0c5e42cba2d56cd6f8b103564736f6c63430005100032a265627a7a72315820c5846ab9ef0d699d4dbe85ef2231c8e3b4ae2daf6e771bc638da17170b2d120f64736f6c63430005100032"
+)
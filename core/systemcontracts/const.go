@@ -2,16 +2,23 @@ package systemcontracts
 
 import (
 	"bytes"
+	"math/big"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 const (
 	// genesis contracts
 	ValidatorHubContract     = "0x0000000000000000000000000000000000001006"
 	ValidatorFactoryContract = "0x0000000000000000000000000000000000001008"
+
+	// SyncHeaderGas is the gas allowance of the syncTendermintHeader transaction
+	// closing every block. Block producers reserve it while packing transactions.
+	SyncHeaderGas uint64 = 200000
 )
 
 var (
@@ -291,19 +298,30 @@ func PrintSystemAddress() {
 	}
 }
 
-func isHubTransition(to *common.Address, data []byte) bool {
+// ValidatorHub returns the address of the ValidatorHub contract in effect at the
+// given block, which is the genesis contract unless the chain's Poseidon config
+// relocates it.
+func ValidatorHub(config *params.ChainConfig, number *big.Int) common.Address {
+	if config != nil && config.Poseidon != nil {
+		if hub := config.Poseidon.ParamsAt(number).ValidatorHub; hub != (common.Address{}) {
+			return hub
+		}
+	}
+	return common.HexToAddress(ValidatorHubContract)
+}
+
+func isHubTransition(hub common.Address, to *common.Address, data []byte) bool {
 	if to == nil || data == nil || len(data) < 4 {
 		return false
 	}
-	systemAddr := common.HexToAddress(ValidatorHubContract)
-	if bytes.Compare(to[:], systemAddr[:]) != 0 {
+	if bytes.Compare(to[:], hub[:]) != 0 {
 		return false
 	}
 	return true
 }
 
-func IsSlashTransition(to *common.Address, data []byte) bool {
-	if isHubTransition(to, data) == false {
+func IsSlashTransition(hub common.Address, to *common.Address, data []byte) bool {
+	if isHubTransition(hub, to, data) == false {
 		return false
 	}
 	if len(data) == 36 && hexutil.Encode(data[:4]) == "0xc96be4cb" { //slash(address)
@@ -312,8 +330,8 @@ func IsSlashTransition(to *common.Address, data []byte) bool {
 	return false
 }
 
-func IsSyncHeaderTransition(to *common.Address, data []byte) bool {
-	if isHubTransition(to, data) == false {
+func IsSyncHeaderTransition(hub common.Address, to *common.Address, data []byte) bool {
+	if isHubTransition(hub, to, data) == false {
 		return false
 	}
 	if len(data) == 36 && hexutil.Encode(data[:4]) == "0xffd8136e" { //syncTendermintHeader(uint256)
@@ -322,6 +340,27 @@ func IsSyncHeaderTransition(to *common.Address, data []byte) bool {
 	return false
 }
 
-func IsSystemTransition(to *common.Address, data []byte) bool {
-	return IsSlashTransition(to, data) || IsSyncHeaderTransition(to, data)
+func IsDoubleSignTransition(hub common.Address, to *common.Address, data []byte) bool {
+	if isHubTransition(hub, to, data) == false {
+		return false
+	}
+	if len(data) >= 164 && hexutil.Encode(data[:4]) == "0x16970aa7" { //submitDoubleSignEvidence(address,bytes,bytes)
+		return true
+	}
+	return false
+}
+
+func IsSystemTransition(hub common.Address, to *common.Address, data []byte) bool {
+	return IsSlashTransition(hub, to, data) || IsSyncHeaderTransition(hub, to, data)
+}
+
+// IsSystemTransitionAt reports whether a call is a system transition of the
+// ValidatorHub in effect at the given block. Double-sign evidence only counts
+// from the Evidence fork on, as the engine doesn't verify it before.
+func IsSystemTransitionAt(config *params.ChainConfig, number *big.Int, to *common.Address, data []byte) bool {
+	hub := ValidatorHub(config, number)
+	if IsSystemTransition(hub, to, data) {
+		return true
+	}
+	return config != nil && config.Poseidon != nil && config.Poseidon.IsEvidence(number) && IsDoubleSignTransition(hub, to, data)
 }
@@ -0,0 +1,14 @@
+package systemcontracts
+
+// ValidatorHubEvidenceCode is the runtime code of the ValidatorHub release adding
+// submitDoubleSignEvidence(address,bytes,bytes), compiled from the hub sources.
+// It's empty until that release is published, so the Evidence fork can't be
+// scheduled before.
+const ValidatorHubEvidenceCode = ""
+
+// evidenceUpgrade is the ValidatorHub upgrade of the Evidence fork, deploying the
+// evidence accepting hub in place of the one in effect at the fork block.
+var evidenceUpgrade = &ContractUpgrade{
+	Target: ValidatorHub,
+	Code:   ValidatorHubEvidenceCode,
+}
@@ -0,0 +1,128 @@
+package systemcontracts
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ContractUpgrade is the in-place upgrade of a system contract at the activation
+// block of a hard fork.
+type ContractUpgrade struct {
+	Address common.Address
+	Code    string                      // Hex encoded runtime bytecode to deploy, empty to keep the current code
+	Storage map[common.Hash]common.Hash // Storage slots to overwrite
+
+	// Target resolves the upgraded contract at the activation block, for system
+	// contracts a chain config may relocate. Address is used if nil.
+	Target func(config *params.ChainConfig, number *big.Int) common.Address
+}
+
+// target returns the address of the contract upgraded at the given block.
+func (u *ContractUpgrade) target(config *params.ChainConfig, number *big.Int) common.Address {
+	if u.Target != nil {
+		return u.Target(config, number)
+	}
+	return u.Address
+}
+
+// upgradeForks are the hard forks able to upgrade the system contracts in
+// activation order, along with their activation block in a chain config.
+var upgradeForks = []struct {
+	name  string
+	block func(config *params.ChainConfig) *big.Int
+}{
+	{"BigBen", func(config *params.ChainConfig) *big.Int { return config.BigBenBlock }},
+	{"Thames", func(config *params.ChainConfig) *big.Int { return config.ThamesBlock }},
+	{"Trident", func(config *params.ChainConfig) *big.Int { return config.TridentBlock }},
+	{"Evidence", func(config *params.ChainConfig) *big.Int {
+		if config.Poseidon == nil {
+			return nil
+		}
+		return config.Poseidon.EvidenceBlock
+	}},
+}
+
+// Upgrades is the registry of the system contract upgrades by hard fork name.
+// Block importers and proposers both apply the upgrades of a fork to the state
+// of its activation block before executing any transaction.
+var Upgrades = map[string][]*ContractUpgrade{
+	// The forks so far only changed the consensus rules
+	"BigBen":  nil,
+	"Thames":  nil,
+	"Trident": nil,
+
+	// The hub accepts double-sign evidence
+	"Evidence": {evidenceUpgrade},
+}
+
+// decodeCode decodes hex encoded contract code, with or without 0x prefix.
+func decodeCode(code string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(code, "0x"))
+}
+
+// CheckUpgrades validates the system contract upgrades of the hard forks the
+// given chain config schedules, so that faulty upgrades, or upgrades whose code
+// isn't published yet, are rejected when the config is loaded instead of at the
+// activation block.
+func CheckUpgrades(config *params.ChainConfig) error {
+	if config == nil {
+		return nil
+	}
+	for _, fork := range upgradeForks {
+		if fork.block(config) == nil {
+			continue
+		}
+		for i, upgrade := range Upgrades[fork.name] {
+			if upgrade.Code == "" && len(upgrade.Storage) == 0 {
+				return fmt.Errorf("%s upgrade %d has no code to deploy", fork.name, i)
+			}
+			if _, err := decodeCode(upgrade.Code); err != nil {
+				return fmt.Errorf("invalid code of %s upgrade %d: %v", fork.name, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// UpgradeSystemContracts applies the registered system contract upgrades of the
+// hard forks activated at the given block. The state is left partially upgraded
+// if an upgrade fails, so the block has to be discarded.
+func UpgradeSystemContracts(config *params.ChainConfig, number *big.Int, statedb *state.StateDB) error {
+	if config == nil || number == nil {
+		return nil
+	}
+	for _, fork := range upgradeForks {
+		if block := fork.block(config); block == nil || block.Cmp(number) != 0 {
+			continue
+		}
+		upgrades := Upgrades[fork.name]
+		if len(upgrades) == 0 {
+			continue
+		}
+		log.Info("Upgrading system contracts", "fork", fork.name, "number", number, "contracts", len(upgrades))
+		for _, upgrade := range upgrades {
+			addr := upgrade.target(config, number)
+			if upgrade.Code == "" && len(upgrade.Storage) == 0 {
+				return fmt.Errorf("%s upgrade of %x has no code to deploy", fork.name, addr)
+			}
+			if upgrade.Code != "" {
+				code, err := decodeCode(upgrade.Code)
+				if err != nil {
+					return fmt.Errorf("invalid code of %s upgrade of %x: %v", fork.name, addr, err)
+				}
+				statedb.SetCode(addr, code)
+			}
+			for key, value := range upgrade.Storage {
+				statedb.SetState(addr, key, value)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package systemcontracts
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the Evidence fork upgrades the ValidatorHub in effect at the fork
+// block, even if the chain relocated it.
+func TestEvidenceUpgradeRelocatedHub(t *testing.T) {
+	defer func(upgrades []*ContractUpgrade) {
+		Upgrades["Evidence"] = upgrades
+	}(Upgrades["Evidence"])
+	Upgrades["Evidence"] = []*ContractUpgrade{{Target: ValidatorHub, Code: "0x602a60025500"}}
+
+	var (
+		genesisHub = common.HexToAddress(ValidatorHubContract)
+		hub        = common.HexToAddress("0x0000000000000000000000000000000000002006")
+		code       = common.FromHex(ValidatorHubCode)
+	)
+	config := &params.ChainConfig{
+		Poseidon: &params.PoseidonConfig{
+			EvidenceBlock: big.NewInt(10),
+			ForkSchedule: []*params.PoseidonFork{
+				{Block: big.NewInt(5), Overrides: params.PoseidonOverrides{ValidatorHub: &hub}},
+			},
+		},
+	}
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetCode(genesisHub, code)
+	statedb.SetCode(hub, code)
+
+	if err := UpgradeSystemContracts(config, big.NewInt(10), statedb); err != nil {
+		t.Fatalf("failed to upgrade system contracts: %v", err)
+	}
+	if !bytes.Equal(statedb.GetCode(genesisHub), code) {
+		t.Errorf("retired hub got upgraded")
+	}
+	if !bytes.Equal(statedb.GetCode(hub), common.FromHex("0x602a60025500")) {
+		t.Errorf("relocated hub not upgraded")
+	}
+}
+
+// Tests that faulty upgrades of the scheduled forks are rejected when the config
+// is loaded.
+func TestCheckUpgrades(t *testing.T) {
+	defer func(upgrades []*ContractUpgrade) {
+		Upgrades["BigBen"] = upgrades
+	}(Upgrades["BigBen"])
+
+	scheduled := &params.ChainConfig{BigBenBlock: big.NewInt(2)}
+	unscheduled := &params.ChainConfig{}
+
+	tests := []struct {
+		upgrade *ContractUpgrade
+		valid   bool
+	}{
+		{&ContractUpgrade{Code: "0x602a60025500"}, true},
+		{&ContractUpgrade{Code: "602a60025500"}, true},
+		{&ContractUpgrade{Storage: map[common.Hash]common.Hash{{}: {0x01}}}, true},
+		{&ContractUpgrade{Code: "0x602a6002550"}, false},
+		{&ContractUpgrade{Code: "0xzz"}, false},
+		{&ContractUpgrade{}, false},
+	}
+	for i, tt := range tests {
+		Upgrades["BigBen"] = []*ContractUpgrade{tt.upgrade}
+		if err := CheckUpgrades(scheduled); (err == nil) != tt.valid {
+			t.Errorf("test %d: validity mismatch: have %v, want %v", i, err, tt.valid)
+		}
+		if err := CheckUpgrades(unscheduled); err != nil {
+			t.Errorf("test %d: unscheduled upgrade rejected: %v", i, err)
+		}
+	}
+	// Upgrades failing at the activation block are reported too
+	Upgrades["BigBen"] = []*ContractUpgrade{{Code: "0xzz"}}
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err := UpgradeSystemContracts(scheduled, big.NewInt(2), statedb); err == nil {
+		t.Errorf("failed upgrade not reported")
+	}
+	// The Evidence fork can't be scheduled until the evidence accepting hub is published
+	evidence := &params.ChainConfig{Poseidon: &params.PoseidonConfig{EvidenceBlock: big.NewInt(10)}}
+	if err := CheckUpgrades(evidence); (err == nil) != (ValidatorHubEvidenceCode != "") {
+		t.Errorf("evidence fork validity mismatch: have %v, want code %q", err, ValidatorHubEvidenceCode)
+	}
+}
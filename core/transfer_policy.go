@@ -0,0 +1,94 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// CheckTransferPolicy returns an error if the transfer policy of the chain in
+// effect at the given block forbids a value transfer from the sender to the
+// recipient (nil for contract creations) on top of the given state.
+//
+// Chains without a configured policy, or ahead of its block, only allow value
+// transfers to externally owned accounts and the compiled-in system contracts
+// until Trident.
+func CheckTransferPolicy(config *params.ChainConfig, number *big.Int, statedb vm.StateDB, from common.Address, to *common.Address, value *big.Int) error {
+	if value == nil || value.Sign() <= 0 || config.IsTrident(number) {
+		return nil
+	}
+	configured := config.TransferPolicy
+	if !config.IsTransferPolicy(number) {
+		configured = nil
+	}
+	policy := configured
+	if policy == nil {
+		policy = &params.TransferPolicyConfig{Mode: params.TransferPolicyCallAllowlist}
+	}
+	switch policy.Mode {
+	case params.TransferPolicyAllowAll:
+		return nil
+
+	case params.TransferPolicyCreationAllowlist:
+		if to == nil && !transferAllowlisted(configured, statedb, from) {
+			return fmt.Errorf("%w: contract creation by %v", ErrTransferNotAllowed, from.Hex())
+		}
+		return nil
+
+	case params.TransferPolicyCallAllowlist:
+		if to == nil {
+			return fmt.Errorf("%w: contract creation by %v", ErrTransferNotAllowed, from.Hex())
+		}
+		if systemcontracts.SystemContracts[*to] || transferAllowlisted(configured, statedb, *to) {
+			return nil
+		}
+		if codeHash := statedb.GetCodeHash(*to); codeHash != (common.Hash{}) && codeHash != emptyCodeHash {
+			return fmt.Errorf("%w: contract %v not allowlisted", ErrTransferNotAllowed, to.Hex())
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("%w: unsupported transfer policy mode %q", ErrTransferNotAllowed, policy.Mode)
+	}
+}
+
+// transferAllowlisted reports whether the address is on the static or on-chain
+// allowlist of the transfer policy, or the compiled-in system contract list if
+// no policy of the chain is in effect.
+func transferAllowlisted(policy *params.TransferPolicyConfig, statedb vm.StateDB, addr common.Address) bool {
+	if policy == nil {
+		return systemcontracts.SystemContractAddress[addr]
+	}
+	for _, allowed := range policy.Allowlist {
+		if allowed == addr {
+			return true
+		}
+	}
+	if policy.Contract == nil {
+		return false
+	}
+	// Solidity stores mapping(address => bool) entries at keccak256(key . slot)
+	slot := crypto.Keccak256Hash(common.LeftPadBytes(addr.Bytes(), 32), common.BigToHash(new(big.Int).SetUint64(policy.Slot)).Bytes())
+	return statedb.GetState(*policy.Contract, slot) != (common.Hash{})
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the transfer policy modes restrict the value transfers involving
+// contracts until Trident.
+func TestTransferPolicy(t *testing.T) {
+	var (
+		sender    = common.HexToAddress("0x0100000000000000000000000000000000000000")
+		creator   = common.HexToAddress("0x0200000000000000000000000000000000000000")
+		account   = common.HexToAddress("0x0300000000000000000000000000000000000000")
+		contract  = common.HexToAddress("0x0400000000000000000000000000000000000000")
+		listed    = common.HexToAddress("0x0500000000000000000000000000000000000000")
+		onchain   = common.HexToAddress("0x0600000000000000000000000000000000000000")
+		allowlist = common.HexToAddress("0x0700000000000000000000000000000000000000")
+		hub       = common.HexToAddress(systemcontracts.ValidatorHubContract)
+		legacy    = common.HexToAddress("0xA020c0a38953C7E8Dafea49e8a3c4607130DDa66")
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	for _, addr := range []common.Address{contract, listed, onchain, allowlist, hub, legacy} {
+		statedb.SetCode(addr, []byte{0x00})
+	}
+	// Allow the on-chain listed contract at slot 3 of the allowlist contract
+	key := crypto.Keccak256Hash(common.LeftPadBytes(onchain.Bytes(), 32), common.BigToHash(big.NewInt(3)).Bytes())
+	statedb.SetState(allowlist, key, common.BigToHash(common.Big1))
+
+	config := func(policy *params.TransferPolicyConfig) *params.ChainConfig {
+		return &params.ChainConfig{TridentBlock: big.NewInt(10), TransferPolicy: policy}
+	}
+	var (
+		callPolicy = &params.TransferPolicyConfig{
+			Mode:      params.TransferPolicyCallAllowlist,
+			Allowlist: []common.Address{listed},
+			Contract:  &allowlist,
+			Slot:      3,
+		}
+		creationPolicy = &params.TransferPolicyConfig{
+			Mode:      params.TransferPolicyCreationAllowlist,
+			Allowlist: []common.Address{creator},
+		}
+		scheduledPolicy = &params.TransferPolicyConfig{
+			Mode:  params.TransferPolicyAllowAll,
+			Block: big.NewInt(5),
+		}
+	)
+	tests := []struct {
+		config  *params.ChainConfig
+		number  int64
+		from    common.Address
+		to      *common.Address
+		value   int64
+		allowed bool
+	}{
+		// Chains without a policy only allow the compiled-in system contracts
+		{config(nil), 1, sender, &account, 1, true},
+		{config(nil), 1, sender, &legacy, 1, true},
+		{config(nil), 1, sender, &contract, 1, false},
+		{config(nil), 1, sender, nil, 1, false},
+		{config(nil), 1, sender, &contract, 0, true},
+		{config(nil), 10, sender, &contract, 1, true},
+		{config(nil), 10, sender, nil, 1, true},
+
+		// Allow-all policies don't restrict anything
+		{config(&params.TransferPolicyConfig{Mode: params.TransferPolicyAllowAll}), 1, sender, &contract, 1, true},
+		{config(&params.TransferPolicyConfig{Mode: params.TransferPolicyAllowAll}), 1, sender, nil, 1, true},
+
+		// Call allowlists allow the static and on-chain listed contracts only
+		{config(callPolicy), 1, sender, &account, 1, true},
+		{config(callPolicy), 1, sender, &hub, 1, true},
+		{config(callPolicy), 1, sender, &listed, 1, true},
+		{config(callPolicy), 1, sender, &onchain, 1, true},
+		{config(callPolicy), 1, sender, &legacy, 1, false},
+		{config(callPolicy), 1, sender, &contract, 1, false},
+		{config(callPolicy), 1, creator, nil, 1, false},
+		{config(callPolicy), 10, sender, &contract, 1, true},
+
+		// Creation allowlists only restrict the creators
+		{config(creationPolicy), 1, creator, nil, 1, true},
+		{config(creationPolicy), 1, sender, nil, 1, false},
+		{config(creationPolicy), 1, sender, &contract, 1, true},
+		{config(creationPolicy), 10, sender, nil, 1, true},
+
+		// Scheduled policies replace the default one from their block
+		{config(scheduledPolicy), 4, sender, &contract, 1, false},
+		{config(scheduledPolicy), 4, sender, &legacy, 1, true},
+		{config(scheduledPolicy), 5, sender, &contract, 1, true},
+		{config(scheduledPolicy), 5, sender, nil, 1, true},
+	}
+	for i, tt := range tests {
+		err := CheckTransferPolicy(tt.config, big.NewInt(tt.number), statedb, tt.from, tt.to, big.NewInt(tt.value))
+		if tt.allowed && err != nil {
+			t.Errorf("test %d: transfer rejected: %v", i, err)
+		}
+		if !tt.allowed && !errors.Is(err, ErrTransferNotAllowed) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, ErrTransferNotAllowed)
+		}
+	}
+}
+
+// Tests that transactions rejected by the transfer policy never enter the pool.
+func TestTransferPolicyTxPool(t *testing.T) {
+	t.Parallel()
+
+	config := *params.TestChainConfig
+	config.TridentBlock = nil
+	config.TransferPolicy = &params.TransferPolicyConfig{Mode: params.TransferPolicyCallAllowlist}
+
+	pool, key := setupTxPoolWithConfig(&config)
+	defer pool.Stop()
+
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+
+	// The test transactions send value to the zero address, make it a contract
+	pool.mu.Lock()
+	pool.currentState.SetCode(common.Address{}, []byte{0x00})
+	pool.mu.Unlock()
+
+	if err := pool.AddRemote(transaction(0, 100000, key)); !errors.Is(err, ErrTransferNotAllowed) {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrTransferNotAllowed)
+	}
+	if pending, queued := pool.Stats(); pending != 0 || queued != 0 {
+		t.Fatalf("rejected transaction pooled: pending %d, queued %d", pending, queued)
+	}
+	// Once the zero address is allowlisted, the transaction is accepted
+	config.TransferPolicy.Allowlist = []common.Address{{}}
+	if err := pool.AddRemote(transaction(0, 100000, key)); err != nil {
+		t.Fatalf("allowlisted transaction rejected: %v", err)
+	}
+}
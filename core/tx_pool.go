@@ -27,6 +27,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/prque"
+	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -51,6 +52,18 @@ const (
 	// more expensive to propagate; larger transactions also take more resources
 	// to validate whether they fit into the pool or not.
 	txMaxSize = 4 * txSlotSize // 128KB
+
+	// maxBundles is the maximum number of transaction bundles the pool keeps for
+	// the upcoming blocks, a DOS protection.
+	maxBundles = 1024
+
+	// maxBundleDistance is the maximum number of blocks ahead of the chain head a
+	// transaction bundle may target.
+	maxBundleDistance = 64
+
+	// maxBundlesPerSender is the maximum number of bundles the transactions of a
+	// single account may be part of, so no account can take up all the slots.
+	maxBundlesPerSender = 16
 )
 
 var (
@@ -85,6 +98,21 @@ var (
 	// than some meaningful limit a user might use. This is not a consensus error
 	// making the transaction invalid, rather a DOS protection.
 	ErrOversizedData = errors.New("oversized data")
+
+	// ErrEmptyBundle is returned if a transaction bundle contains no transactions.
+	ErrEmptyBundle = errors.New("empty bundle")
+
+	// ErrBundleTooLate is returned if a transaction bundle targets a block that
+	// is already part of the chain.
+	ErrBundleTooLate = errors.New("bundle target block already mined")
+
+	// ErrBundleTooEarly is returned if a transaction bundle targets a block too far
+	// ahead of the chain head.
+	ErrBundleTooEarly = errors.New("bundle target block too far ahead")
+
+	// ErrBundleQuotaExceeded is returned if a transaction bundle contains a
+	// transaction of an account already part of too many bundles.
+	ErrBundleQuotaExceeded = errors.New("bundle quota exceeded")
 )
 
 var (
@@ -135,6 +163,7 @@ const (
 // some pre checks in tx pool and event subscribers.
 type blockChain interface {
 	CurrentBlock() *types.Block
+	Engine() consensus.Engine
 	GetBlock(hash common.Hash, number uint64) *types.Block
 	StateAt(root common.Hash) (*state.StateDB, error)
 
@@ -248,6 +277,7 @@ type TxPool struct {
 	beats   map[common.Address]time.Time // Last heartbeat from each known account
 	all     *txLookup                    // All transactions to allow lookups
 	priced  *txPricedList                // All transactions sorted by price
+	bundles []*types.Bundle              // Transaction bundles for the upcoming blocks
 
 	chainHeadCh     chan ChainHeadEvent
 	chainHeadSub    event.Subscription
@@ -417,6 +447,13 @@ func (pool *TxPool) SubscribeNewTxsEvent(ch chan<- NewTxsEvent) event.Subscripti
 	return pool.scope.Track(pool.txFeed.Subscribe(ch))
 }
 
+// validatorHub returns the address of the ValidatorHub contract at the next
+// pending block, whose header sync transactions the pool doesn't accept.
+func (pool *TxPool) validatorHub() common.Address {
+	next := new(big.Int).Add(pool.chain.CurrentBlock().Number(), big.NewInt(1))
+	return systemcontracts.ValidatorHub(pool.chainconfig, next)
+}
+
 // GasPrice returns the current gas price enforced by the transaction pool.
 func (pool *TxPool) GasPrice() *big.Int {
 	pool.mu.RLock()
@@ -543,6 +580,100 @@ func (pool *TxPool) Pending(enforceTips bool) (map[common.Address]types.Transact
 	return pending, nil
 }
 
+// AddBundle enqueues a bundle of transactions for the local miner to include in
+// its target block. The transactions of the bundle are not announced to the
+// network.
+func (pool *TxPool) AddBundle(bundle *types.Bundle) error {
+	if len(bundle.Txs) == 0 {
+		return ErrEmptyBundle
+	}
+	for _, tx := range bundle.Txs {
+		if _, err := types.Sender(pool.signer, tx); err != nil {
+			return ErrInvalidSender
+		}
+	}
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	head := pool.chain.CurrentBlock().Number()
+	if bundle.BlockNumber == nil || bundle.BlockNumber.Cmp(head) <= 0 {
+		return ErrBundleTooLate
+	}
+	if new(big.Int).Sub(bundle.BlockNumber, head).Cmp(big.NewInt(maxBundleDistance)) > 0 {
+		return ErrBundleTooEarly
+	}
+	// Drop the bundles the chain moved past before checking the limits
+	pool.truncateBundles(new(big.Int).Add(head, common.Big1))
+	if len(pool.bundles) >= maxBundles {
+		return ErrTxPoolOverflow
+	}
+	senders := pool.bundleSenders(bundle)
+	for _, queued := range pool.bundles {
+		for sender := range pool.bundleSenders(queued) {
+			if _, ok := senders[sender]; ok {
+				senders[sender]++
+			}
+		}
+	}
+	for sender, count := range senders {
+		if count >= maxBundlesPerSender {
+			log.Trace("Rejecting transaction bundle over quota", "hash", bundle.Hash(), "sender", sender)
+			return ErrBundleQuotaExceeded
+		}
+	}
+	pool.bundles = append(pool.bundles, bundle)
+	return nil
+}
+
+// bundleSenders returns the accounts sending the transactions of the bundle.
+// The senders were cached when the bundle was added, so the lookups are cheap.
+func (pool *TxPool) bundleSenders(bundle *types.Bundle) map[common.Address]int {
+	senders := make(map[common.Address]int)
+	for _, tx := range bundle.Txs {
+		if sender, err := types.Sender(pool.signer, tx); err == nil {
+			senders[sender] = 0
+		}
+	}
+	return senders
+}
+
+// Bundles retrieves the transaction bundles targeting the given block, dropping
+// the ones which targeted the blocks before it.
+func (pool *TxPool) Bundles(number *big.Int) []*types.Bundle {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.truncateBundles(number)
+
+	var bundles []*types.Bundle
+	for _, bundle := range pool.bundles {
+		if bundle.BlockNumber.Cmp(number) == 0 {
+			bundles = append(bundles, bundle)
+		}
+	}
+	return bundles
+}
+
+// truncateBundles drops the transaction bundles targeting the blocks before the
+// given one.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) truncateBundles(number *big.Int) {
+	kept := pool.bundles[:0]
+	for _, bundle := range pool.bundles {
+		if bundle.BlockNumber.Cmp(number) >= 0 {
+			kept = append(kept, bundle)
+		}
+	}
+	for i := len(kept); i < len(pool.bundles); i++ {
+		pool.bundles[i] = nil
+	}
+	if dropped := len(pool.bundles) - len(kept); dropped > 0 {
+		log.Trace("Dropped stale transaction bundles", "count", dropped, "number", number)
+	}
+	pool.bundles = kept
+}
+
 // Locals retrieves the accounts currently considered local by the pool.
 func (pool *TxPool) Locals() []common.Address {
 	pool.mu.Lock()
@@ -620,6 +751,11 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if pool.currentState.GetBalance(from).Cmp(tx.Cost()) < 0 {
 		return ErrInsufficientFunds
 	}
+	// Ensure the transfer policy allows the value transfer in the next block
+	next := new(big.Int).Add(pool.chain.CurrentBlock().Number(), big.NewInt(1))
+	if err := CheckTransferPolicy(pool.chainconfig, next, pool.currentState, from, tx.To(), tx.Value()); err != nil {
+		return err
+	}
 	// Ensure the transaction has more gas than the basic tx fee.
 	intrGas, err := IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil, true, pool.istanbul)
 	if err != nil {
@@ -867,8 +1003,9 @@ func (pool *TxPool) addTxs(txs []*types.Transaction, local, sync bool) []error {
 		errs = make([]error, len(txs))
 		news = make([]*types.Transaction, 0, len(txs))
 	)
+	hub := pool.validatorHub()
 	for i, tx := range txs {
-		if systemcontracts.IsSyncHeaderTransition(tx.To(), tx.Data()) {
+		if systemcontracts.IsSyncHeaderTransition(hub, tx.To(), tx.Data()) {
 			errs[i] = nil
 			continue
 		}
@@ -920,8 +1057,9 @@ func (pool *TxPool) addTxs(txs []*types.Transaction, local, sync bool) []error {
 func (pool *TxPool) addTxsLocked(txs []*types.Transaction, local bool) ([]error, *accountSet) {
 	dirty := newAccountSet(pool.signer)
 	errs := make([]error, len(txs))
+	hub := pool.validatorHub()
 	for i, tx := range txs {
-		if systemcontracts.IsSyncHeaderTransition(tx.To(), tx.Data()) {
+		if systemcontracts.IsSyncHeaderTransition(hub, tx.To(), tx.Data()) {
 			errs[i] = nil
 			continue
 		}
@@ -1159,8 +1297,13 @@ func (pool *TxPool) runReorg(done chan struct{}, reset *txpoolResetRequest, dirt
 	if reset != nil {
 		pool.demoteUnexecutables()
 		if reset.newHead != nil && pool.chainconfig.IsLondon(new(big.Int).Add(reset.newHead.Number, big.NewInt(1))) {
-			pendingBaseFee := misc.CalcBaseFee(pool.chainconfig, reset.newHead)
-			pool.priced.SetBaseFee(pendingBaseFee)
+			feeParams, err := misc.GetBaseFeeParams(pool.chainconfig, pool.chain.Engine(), nil, reset.newHead, nil)
+			if err != nil {
+				log.Error("Failed to retrieve base fee parameters", "number", reset.newHead.Number, "err", err)
+			} else {
+				pendingBaseFee := misc.CalcBaseFeeWithParams(pool.chainconfig, feeParams, reset.newHead)
+				pool.priced.SetBaseFee(pendingBaseFee)
+			}
 		}
 	}
 	// Ensure pool.queue and pool.pending sizes stay within the configured limits.
@@ -1261,6 +1404,9 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	if newHead == nil {
 		newHead = pool.chain.CurrentBlock().Header() // Special case during testing
 	}
+	// Drop the bundles which targeted the blocks up to the new head
+	pool.truncateBundles(new(big.Int).Add(newHead.Number, big.NewInt(1)))
+
 	statedb, err := pool.chain.StateAt(newHead.Root)
 	if err != nil {
 		log.Error("Failed to reset txpool state", "err", err)
@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -76,6 +77,10 @@ func (bc *testBlockChain) StateAt(common.Hash) (*state.StateDB, error) {
 	return bc.statedb, nil
 }
 
+func (bc *testBlockChain) Engine() consensus.Engine {
+	return nil
+}
+
 func (bc *testBlockChain) SubscribeChainHeadEvent(ch chan<- ChainHeadEvent) event.Subscription {
 	return bc.chainHeadFeed.Subscribe(ch)
 }
@@ -2422,6 +2427,114 @@ func TestTransactionSlotCount(t *testing.T) {
 	}
 }
 
+// Tests that transaction bundles are kept until their target block and dropped
+// once it passes.
+func TestTransactionBundles(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	// Empty, unsigned and already mined bundles must be rejected
+	if err := pool.AddBundle(&types.Bundle{BlockNumber: big.NewInt(1)}); err != ErrEmptyBundle {
+		t.Fatalf("empty bundle error mismatch: have %v, want %v", err, ErrEmptyBundle)
+	}
+	unsigned := types.NewTransaction(0, common.Address{}, big.NewInt(100), 100000, big.NewInt(1), nil)
+	if err := pool.AddBundle(&types.Bundle{Txs: types.Transactions{unsigned}, BlockNumber: big.NewInt(1)}); err != ErrInvalidSender {
+		t.Fatalf("unsigned bundle error mismatch: have %v, want %v", err, ErrInvalidSender)
+	}
+	if err := pool.AddBundle(&types.Bundle{Txs: types.Transactions{transaction(0, 100000, key)}, BlockNumber: big.NewInt(0)}); err != ErrBundleTooLate {
+		t.Fatalf("mined bundle error mismatch: have %v, want %v", err, ErrBundleTooLate)
+	}
+	// Queue up bundles for a few upcoming blocks
+	for i := int64(1); i <= 3; i++ {
+		bundle := &types.Bundle{
+			Txs:         types.Transactions{transaction(0, 100000, key), transaction(1, 100000, key)},
+			BlockNumber: big.NewInt(i),
+		}
+		if err := pool.AddBundle(bundle); err != nil {
+			t.Fatalf("failed to add bundle for block %d: %v", i, err)
+		}
+	}
+	if bundles := pool.Bundles(big.NewInt(2)); len(bundles) != 1 || bundles[0].BlockNumber.Int64() != 2 {
+		t.Fatalf("bundles mismatch: have %d, want 1 for block 2", len(bundles))
+	}
+	// The bundle of the first block is dropped, the later one is kept
+	if bundles := pool.Bundles(big.NewInt(1)); len(bundles) != 0 {
+		t.Fatalf("stale bundles returned: %d", len(bundles))
+	}
+	if bundles := pool.Bundles(big.NewInt(3)); len(bundles) != 1 {
+		t.Fatalf("bundles mismatch: have %d, want 1 for block 3", len(bundles))
+	}
+	if pending, queued := pool.Stats(); pending != 0 || queued != 0 {
+		t.Fatalf("bundle transactions pooled: pending %d, queued %d", pending, queued)
+	}
+}
+
+// Tests that transaction bundles may only target the near future, are limited
+// per sender and are dropped once the chain passes their target block.
+func TestTransactionBundleLimits(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	bundle := func(number int64, keys ...*ecdsa.PrivateKey) *types.Bundle {
+		bundle := &types.Bundle{BlockNumber: big.NewInt(number)}
+		for _, key := range keys {
+			bundle.Txs = append(bundle.Txs, transaction(0, 100000, key))
+		}
+		return bundle
+	}
+	// Bundles too far ahead of the chain head must be rejected
+	if err := pool.AddBundle(bundle(maxBundleDistance+1, key)); err != ErrBundleTooEarly {
+		t.Fatalf("future bundle error mismatch: have %v, want %v", err, ErrBundleTooEarly)
+	}
+	if err := pool.AddBundle(bundle(maxBundleDistance, key)); err != nil {
+		t.Fatalf("failed to add bundle at the distance limit: %v", err)
+	}
+	// Fill up the quota of the sender, the bundles of other senders are unaffected
+	for i := 1; i < maxBundlesPerSender; i++ {
+		if err := pool.AddBundle(bundle(1, key)); err != nil {
+			t.Fatalf("failed to add bundle %d: %v", i, err)
+		}
+	}
+	other, _ := crypto.GenerateKey()
+	if err := pool.AddBundle(bundle(1, key)); err != ErrBundleQuotaExceeded {
+		t.Fatalf("over quota bundle error mismatch: have %v, want %v", err, ErrBundleQuotaExceeded)
+	}
+	if err := pool.AddBundle(bundle(1, other, key)); err != ErrBundleQuotaExceeded {
+		t.Fatalf("over quota mixed bundle error mismatch: have %v, want %v", err, ErrBundleQuotaExceeded)
+	}
+	if err := pool.AddBundle(bundle(1, other)); err != nil {
+		t.Fatalf("failed to add bundle of another sender: %v", err)
+	}
+	// A new chain head drops the bundles up to it, freeing up the quota
+	<-pool.requestReset(nil, &types.Header{Number: big.NewInt(1), GasLimit: 1000000, BaseFee: big.NewInt(params.InitialBaseFee)})
+
+	pool.mu.Lock()
+	queued := len(pool.bundles)
+	pool.mu.Unlock()
+
+	if queued != 1 {
+		t.Fatalf("queued bundle count mismatch: have %d, want 1", queued)
+	}
+	if err := pool.AddBundle(bundle(2, key)); err != nil {
+		t.Fatalf("failed to add bundle after reset: %v", err)
+	}
+	// Bundles the chain moved past don't count against the pool limit, even if
+	// no reset dropped them yet
+	pool.mu.Lock()
+	for len(pool.bundles) < maxBundles {
+		pool.bundles = append(pool.bundles, bundle(0, other))
+	}
+	pool.mu.Unlock()
+
+	if err := pool.AddBundle(bundle(2, other)); err != nil {
+		t.Fatalf("failed to add bundle over stale ones: %v", err)
+	}
+}
+
 // Benchmarks the speed of validating the contents of the pending queue of the
 // transaction pool.
 func BenchmarkPendingDemotion100(b *testing.B)   { benchmarkPendingDemotion(b, 100) }
@@ -0,0 +1,53 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Bundle is an ordered set of transactions to be included together at the head
+// of a given block, or not at all.
+type Bundle struct {
+	Txs               Transactions
+	BlockNumber       *big.Int      // Block the bundle may be included in
+	RevertingTxHashes []common.Hash // Transactions allowed to revert without voiding the bundle
+}
+
+// Hash returns the hash identifying the bundle, the keccak256 hash of the
+// concatenated hashes of its transactions.
+func (b *Bundle) Hash() common.Hash {
+	hashes := make([]byte, 0, len(b.Txs)*common.HashLength)
+	for _, tx := range b.Txs {
+		hashes = append(hashes, tx.Hash().Bytes()...)
+	}
+	return crypto.Keccak256Hash(hashes)
+}
+
+// MayRevert reports whether the transaction with the given hash is allowed to
+// revert without voiding the bundle.
+func (b *Bundle) MayRevert(hash common.Hash) bool {
+	for _, reverting := range b.RevertingTxHashes {
+		if reverting == hash {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Vote is a signed attestation of a validator that the block with the given
+// number and hash is part of its canonical chain. Enough votes of the committee
+// make a block final.
+type Vote struct {
+	Number    uint64      // Number of the attested block
+	Hash      common.Hash // Hash of the attested block
+	Signature []byte      // Signature of the voter over the attestation
+}
+
+// ID returns the unique identifier of the vote, covering the signature too.
+func (v *Vote) ID() common.Hash {
+	return rlpHash(v)
+}
@@ -1,27 +1,126 @@
 package vrf
 
 import (
-	"gonum.org/v1/gonum/stat/distuv"
 	"math/big"
+	"sort"
+
+	"gonum.org/v1/gonum/stat/distuv"
 )
 
-func sortitio(n, p, ratio float64, money uint64) uint64 {
-	dist := distuv.Binomial{
-		P: p,
-		N: n,
+// sortitionPrecision is the number of fractional bits of the fixed-point
+// probabilities the sortition is computed with, matching the vrf output size.
+const sortitionPrecision = 256
+
+var (
+	// sortitionOne is 1.0 in sortitionPrecision fixed-point.
+	sortitionOne = new(big.Int).Lsh(big.NewInt(1), sortitionPrecision)
+
+	// vrfOutputMax is the largest vrf output, mapped to a ratio of 1.0.
+	vrfOutputMax = new(big.Int).Sub(sortitionOne, big.NewInt(1))
+)
+
+// Select runs the sortition function and returns the number of time the key was selected
+//
+// The selection is the smallest j for which the vrf output, as a ratio of the
+// largest output, doesn't exceed the CDF of Binomial(money, expectedSize/totalMoney)
+// at j. Only integer arithmetic is used, so the result is the same on every
+// platform, and the CDF is only tabulated up to where its terms vanish, so the
+// number of steps is bounded by the expected selection count and not the stake.
+// Outputs beyond a truncated table select its last entry.
+func SelectSort(money uint64, totalMoney uint64, expectedSize float64, vrfOutput []byte) uint64 {
+	if money == 0 {
+		return 0
 	}
-	for j := uint64(0); j < money; j++ {
-		boundary := dist.CDF(float64(j))
-		if ratio <= boundary {
-			return j
+	if totalMoney == 0 {
+		return money
+	}
+	p := new(big.Rat).SetFloat64(expectedSize)
+	if p == nil || p.Sign() <= 0 {
+		return 0
+	}
+	p.Quo(p, new(big.Rat).SetUint64(totalMoney))
+	if p.Cmp(big.NewRat(1, 1)) >= 0 {
+		return money
+	}
+	cdf := binomialCDF(money, p)
+
+	// ratio <= cdf[j] is compared as output * one <= cdf[j] * max to stay exact
+	output := new(big.Int).SetBytes(vrfOutput)
+	output.Mul(output, sortitionOne)
+
+	bound := new(big.Int)
+	j := sort.Search(len(cdf), func(i int) bool {
+		return output.Cmp(bound.Mul(cdf[i], vrfOutputMax)) <= 0
+	})
+	if j == len(cdf) {
+		// A table covering the whole distribution leaves only the selection of
+		// all the stake, a truncated one leaves the rounding error of its tail
+		if uint64(len(cdf)) == money {
+			return money
 		}
+		return uint64(len(cdf) - 1)
 	}
+	return uint64(j)
+}
 
-	return money
+// binomialCDF tabulates the fixed-point CDF of Binomial(n, p) for 0 < p < 1. The
+// terms are derived from each other as P(j+1) = P(j) * (n-j)/(j+1) * p/(1-p),
+// and the table ends at n-1 or once the terms truncate to zero, as the CDF can't
+// grow any further. The mean n*p has to stay well below the precision for P(0)
+// not to truncate to zero already, which holds for committee sized expectations.
+func binomialCDF(n uint64, p *big.Rat) []*big.Int {
+	var (
+		num   = new(big.Int).Set(p.Num())
+		denom = new(big.Int).Sub(p.Denom(), p.Num()) // p/(1-p) = num/denom
+		step  = new(big.Int)
+	)
+	// P(0) = (1-p)^n
+	q := new(big.Int).Mul(denom, sortitionOne)
+	q.Quo(q, p.Denom())
+	term := fixedPow(q, n)
+
+	var (
+		cdf = make([]*big.Int, 0, 16)
+		sum = new(big.Int)
+	)
+	for j := uint64(0); j < n; j++ {
+		sum.Add(sum, term)
+		cdf = append(cdf, new(big.Int).Set(sum))
+
+		term.Mul(term, step.SetUint64(n-j))
+		term.Mul(term, num)
+		term.Quo(term, step.Mul(step.SetUint64(j+1), denom))
+
+		if term.Sign() == 0 {
+			break
+		}
+	}
+	return cdf
 }
 
-// Select runs the sortition function and returns the number of time the key was selected
-func SelectSort(money uint64, totalMoney uint64, expectedSize float64, vrfOutput []byte) uint64 {
+// fixedPow raises the fixed-point x to the power of n by squaring, truncating
+// every intermediate product.
+func fixedPow(x *big.Int, n uint64) *big.Int {
+	var (
+		result = new(big.Int).Set(sortitionOne)
+		base   = new(big.Int).Set(x)
+	)
+	for ; n > 0; n >>= 1 {
+		if n&1 == 1 {
+			result.Mul(result, base)
+			result.Rsh(result, sortitionPrecision)
+		}
+		base.Mul(base, base)
+		base.Rsh(base, sortitionPrecision)
+	}
+	return result
+}
+
+// SelectSortFloat runs the original floating point sortition, walking the
+// binomial CDF once per unit of stake. Its result may differ across platforms
+// and it is slow for large stakes, it's only kept to verify the blocks sealed
+// before SelectSort took over.
+func SelectSortFloat(money uint64, totalMoney uint64, expectedSize float64, vrfOutput []byte) uint64 {
 	binomialN := float64(money)
 	binomialP := expectedSize / float64(totalMoney)
 
@@ -44,6 +143,21 @@ func SelectSort(money uint64, totalMoney uint64, expectedSize float64, vrfOutput
 	return uint64(sortitio(binomialN, binomialP, cratio, money))
 }
 
+func sortitio(n, p, ratio float64, money uint64) uint64 {
+	dist := distuv.Binomial{
+		P: p,
+		N: n,
+	}
+	for j := uint64(0); j < money; j++ {
+		boundary := dist.CDF(float64(j))
+		if ratio <= boundary {
+			return j
+		}
+	}
+
+	return money
+}
+
 func VerifySort(money uint64, totalMoney uint64, expectedSize float64, vrfOutput []byte) bool {
 	j := SelectSort(money, totalMoney, expectedSize, vrfOutput)
 	if j > 0 {
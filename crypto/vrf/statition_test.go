@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
 	"math/rand"
 	"sort"
 	"testing"
@@ -205,3 +206,155 @@ func TestSortitionMoreNode(t *testing.T) {
 	}
 	fmt.Println(float64(totalNode) / float64(num))
 }
+
+// sortitionVectors are selection counts the sortition must yield on every
+// platform. The selections are integer only, so any change of them is a
+// consensus change.
+var sortitionVectors = []struct {
+	money        uint64
+	totalMoney   uint64
+	expectedSize float64
+	output       string
+	selected     uint64
+}{
+	// The largest output falls behind the truncated CDF table, selecting its last
+	// tabulated count instead of all the stake
+	{100, 200, 10, "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", 76},
+	{100, 200, 10, "79df8aa3e32722461bf9ac2bac04b327389a9def03e9af12577d6de94f6d4e93", 5},
+	{100, 200, 10, "0bf5059875921e668a5bdf2c7fc4844592d2572bcd0668d2d6c52f5054e2d083", 2},
+	{5000, 60000, 3, "ec1c2c9568f2f2b51ca138ce20ac0a1cf65259d32c49e072ec2ec91b3d3f0ce2", 1},
+	{5000, 60000, 3, "29b0223beea5f4f74391f445d15afd4294040374f6924b98cbf8713f8d962d7c", 0},
+	{20000, 60000, 3, "8ea8e4d43965a4f6833cb3794251723628b1a23485462903f3f0546932af8a6a", 1},
+	{20000, 60000, 3, "4c7215a3b539eb1e5849c6077dbb5722f5717a289a266f97647981998ebea89c", 0},
+	{40000000, 100000000, 3, "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", 59},
+	{40000000, 100000000, 3, "2e0396dc446bc65236e90f5620cf2c5919df89ba474b9dbf900569f557a58ef4", 0},
+	{40000000, 100000000, 3, "b04883e56a156a8de563afa467d49dec6a40e9a1d007f033c2823061bdd0eaa5", 2},
+	{123456789, 987654321, 3, "600ed494dc65c236c4b43b3982b24b38776bdc648421e77fe8210f3cd44abfe1", 0},
+	{60000, 60000, 1, "46db2f58a96bb617d57b8fab646a06c1c3ff13875d2880c20b19d5a4fca1464e", 0},
+	{60000, 60000, 1, "ee294b39f32b7c7822ba64f84ab43ca0c6e6b91c1fd3be8990434179d3af4491", 3},
+	{1000, 1000, 3, "060824b7c6c5736e8180b7707c3526a269af146019001a3998e8fea3a1282f57", 0},
+	{1000, 1000, 3, "35d6042c4160f38ee9e2a9f3fb4ffb0019b454d522b5ffa17604193fb8966710", 2},
+	// Committees smaller than the expected size select every unit of stake
+	{1, 1, 1, "908dc76b3f515f19ed0baadc9ec7bdbde2079382d13dfc74292ae3913eb9ba89", 1},
+	{1, 2, 3, "74ad61eb1d1a329fb7cb2355a582e669decf3b0dd5311311fe05bc7309c61074", 1},
+	{2, 1, 3, "5fff332f7576b0620556304a3e3eae14c28d0cea39d2901a52720da85ca1e4b3", 2},
+	// Validators without stake are never selected
+	{0, 10, 3, "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", 0},
+	{0, 10, 3, "97d3bc51371400e0f1306775ec106b0eadacae22ee76897087488f62bccd0264", 0},
+}
+
+func TestSelectSortVectors(t *testing.T) {
+	for i, tt := range sortitionVectors {
+		output, err := hex.DecodeString(tt.output)
+		if err != nil {
+			t.Fatalf("test %d: invalid output: %v", i, err)
+		}
+		if selected := SelectSort(tt.money, tt.totalMoney, tt.expectedSize, output); selected != tt.selected {
+			t.Errorf("test %d: selection mismatch: have %d, want %d", i, selected, tt.selected)
+		}
+	}
+}
+
+// sortitionFloatVectors are selection counts of the floating point sortition the
+// blocks before the sortition fork were sealed with, along with the count of
+// the integer one. The outputs right at the CDF boundaries and the largest one
+// are selected differently, so the fork has to keep verifying the old blocks
+// with the floating point sortition.
+var sortitionFloatVectors = []struct {
+	money        uint64
+	totalMoney   uint64
+	expectedSize float64
+	output       string
+	float        uint64
+	fixed        uint64
+}{
+	{100, 200, 10, "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", 100, 76},
+	{100, 200, 10, "79df8aa3e32722461bf9ac2bac04b327389a9def03e9af12577d6de94f6d4e93", 5, 5},
+	{5000, 60000, 3, "ec1c2c9568f2f2b51ca138ce20ac0a1cf65259d32c49e072ec2ec91b3d3f0ce2", 1, 1},
+	{5000, 60000, 3, "c75f2b4afc285052c8d17633e26cb04860979d1a15bb933c7efd75a298a85b79", 0, 1},
+	{20000, 60000, 3, "4c7215a3b539eb1e5849c6077dbb5722f5717a289a266f97647981998ebea89c", 0, 0},
+	{20000, 60000, 3, "5e2cbe8b1ffb6ceb83a45a60db7a247e65567b145576fcdefa4b15d68b7345e2", 0, 1},
+	{40000000, 100000000, 3, "b04883e56a156a8de563afa467d49dec6a40e9a1d007f033c2823061bdd0eaa5", 2, 2},
+	{40000000, 100000000, 3, "4d1b10429f3c0c59123093fdaf0c2f3c61f6e600e70a3b2bc5ddae65ea5ec3dc", 1, 0},
+	{60000, 60000, 1, "ee294b39f32b7c7822ba64f84ab43ca0c6e6b91c1fd3be8990434179d3af4491", 3, 3},
+	{1000, 1000, 1, "5e21499047b5deb78ec49d8ca0ad430f25dc375d9577e1e4d2f937cdd270085f", 0, 1},
+}
+
+func TestSelectSortFloatVectors(t *testing.T) {
+	for i, tt := range sortitionFloatVectors {
+		output, err := hex.DecodeString(tt.output)
+		if err != nil {
+			t.Fatalf("test %d: invalid output: %v", i, err)
+		}
+		if selected := SelectSortFloat(tt.money, tt.totalMoney, tt.expectedSize, output); selected != tt.float {
+			t.Errorf("test %d: float selection mismatch: have %d, want %d", i, selected, tt.float)
+		}
+		if selected := SelectSort(tt.money, tt.totalMoney, tt.expectedSize, output); selected != tt.fixed {
+			t.Errorf("test %d: fixed selection mismatch: have %d, want %d", i, selected, tt.fixed)
+		}
+	}
+}
+
+// Tests that the integer sortition selects the same as the floating point one
+// it replaced, which may only disagree on outputs right at the CDF boundaries.
+func TestSelectSortFloatCompat(t *testing.T) {
+	var (
+		r      = rand.New(rand.NewSource(1))
+		output [32]byte
+	)
+	for i := 0; i < 2000; i++ {
+		totalMoney := uint64(r.Int63n(100000000)) + 4
+		money := uint64(r.Int63n(int64(totalMoney))) + 1
+		expectedSize := 3.0
+		if money == totalMoney {
+			expectedSize = 1
+		}
+		r.Read(output[:])
+
+		h := new(big.Float).SetInt(new(big.Int).SetBytes(output[:]))
+		ratio, _ := h.Quo(h, new(big.Float).SetInt(vrfOutputMax)).Float64()
+
+		want := sortitio(float64(money), expectedSize/float64(totalMoney), ratio, money)
+		if have := SelectSort(money, totalMoney, expectedSize, output[:]); have != want {
+			t.Errorf("money %d of %d, output %x: selection mismatch: have %d, want %d", money, totalMoney, output, have, want)
+		}
+	}
+}
+
+// benchmarkSortition runs a sortition over a large stake, either with random
+// outputs or with the largest one, which walks the whole floating point CDF.
+func benchmarkSortition(b *testing.B, worst bool, sortition func(money, totalMoney uint64, output []byte) uint64) {
+	const (
+		money      = 100000
+		totalMoney = 300000
+	)
+	var (
+		r      = rand.New(rand.NewSource(1))
+		output = make([]byte, 32)
+	)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if worst {
+			for j := range output {
+				output[j] = 0xff
+			}
+		} else {
+			r.Read(output)
+		}
+		sortition(money, totalMoney, output)
+	}
+}
+
+func selectSort(money, totalMoney uint64, output []byte) uint64 {
+	return SelectSort(money, totalMoney, 3, output)
+}
+
+func selectSortFloat(money, totalMoney uint64, output []byte) uint64 {
+	return SelectSortFloat(money, totalMoney, 3, output)
+}
+
+func BenchmarkSelectSort(b *testing.B)             { benchmarkSortition(b, false, selectSort) }
+func BenchmarkSelectSortWorstCase(b *testing.B)    { benchmarkSortition(b, true, selectSort) }
+func BenchmarkSortitioFloat(b *testing.B)          { benchmarkSortition(b, false, selectSortFloat) }
+func BenchmarkSortitioFloatWorstCase(b *testing.B) { benchmarkSortition(b, true, selectSortFloat) }
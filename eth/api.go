@@ -282,9 +282,12 @@ func (api *PublicDebugAPI) DumpBlock(blockNr rpc.BlockNumber) (state.Dump, error
 		return stateDb.RawDump(opts), nil
 	}
 	var block *types.Block
-	if blockNr == rpc.LatestBlockNumber {
+	switch {
+	case blockNr == rpc.LatestBlockNumber:
 		block = api.eth.blockchain.CurrentBlock()
-	} else {
+	case blockNr == rpc.FinalizedBlockNumber:
+		block = api.eth.blockchain.CurrentFinalizedBlock()
+	case blockNr >= 0:
 		block = api.eth.blockchain.GetBlockByNumber(uint64(blockNr))
 	}
 	if block == nil {
@@ -370,9 +373,12 @@ func (api *PublicDebugAPI) AccountRange(blockNrOrHash rpc.BlockNumberOrHash, sta
 			_, stateDb = api.eth.miner.Pending()
 		} else {
 			var block *types.Block
-			if number == rpc.LatestBlockNumber {
+			switch {
+			case number == rpc.LatestBlockNumber:
 				block = api.eth.blockchain.CurrentBlock()
-			} else {
+			case number == rpc.FinalizedBlockNumber:
+				block = api.eth.blockchain.CurrentFinalizedBlock()
+			case number >= 0:
 				block = api.eth.blockchain.GetBlockByNumber(uint64(number))
 			}
 			if block == nil {
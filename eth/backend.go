@@ -43,6 +43,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/eth/protocols/posa"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
@@ -251,6 +252,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		nonceLock := new(ethapi.AddrLocker)
 		txPoolAPI := ethapi.NewPublicTransactionPoolAPI(eth.APIBackend, nonceLock)
 		engine.SetTxPoolAPI(txPoolAPI)
+		engine.SubscribeChainEvents(bc)
 	}
 
 	// Setup DNS discovery iterators.
@@ -497,7 +499,12 @@ func (s *Ethereum) StartMining(threads int) error {
 				log.Error("Etherbase account unavailable locally", "err", err)
 				return fmt.Errorf("signer missing: %v", err)
 			}
-			poseidon.Authorize(eb, wallet.SignData, wallet.SignTx, wallet.VrfProve)
+			if s.config.Miner.RemoteSeal {
+				poseidon.AuthorizeRemote(eb, wallet.SignTx)
+			} else {
+				poseidon.Authorize(eb, wallet.SignData, wallet.SignTx, wallet.VrfProve)
+			}
+			poseidon.SetSlashPeers(s.config.Miner.SlashPeers)
 		}
 		// If mining is started, we can disable the transaction rejection mechanism
 		// introduced to speed sync times.
@@ -544,6 +551,9 @@ func (s *Ethereum) Protocols() []p2p.Protocol {
 	if s.config.SnapshotCache > 0 {
 		protos = append(protos, snap.MakeProtocols((*snapHandler)(s.handler), s.snapDialCandidates)...)
 	}
+	if _, ok := s.engine.(consensus.PoSA); ok {
+		protos = append(protos, posa.MakeProtocols((*posaHandler)(s.handler))...)
+	}
 	return protos
 }
 
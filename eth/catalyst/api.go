@@ -146,8 +146,12 @@ func (api *consensusAPI) AssembleBlock(params assembleBlockParams) (*executableD
 		Extra:      []byte{},
 		Time:       params.Timestamp,
 	}
-	if config := api.eth.BlockChain().Config(); config.IsLondon(header.Number) {
-		header.BaseFee = misc.CalcBaseFee(config, parent.Header())
+	if config := bc.Config(); config.IsLondon(header.Number) {
+		feeParams, err := misc.GetBaseFeeParams(config, bc.Engine(), bc, parent.Header(), nil)
+		if err != nil {
+			return nil, err
+		}
+		header.BaseFee = misc.CalcBaseFeeWithParams(config, feeParams, parent.Header())
 	}
 	err = api.eth.Engine().Prepare(bc, header)
 	if err != nil {
@@ -250,7 +254,7 @@ func decodeTransactions(enc [][]byte) ([]*types.Transaction, error) {
 	return txs, nil
 }
 
-func insertBlockParamsToBlock(config *chainParams.ChainConfig, parent *types.Header, params executableData) (*types.Block, error) {
+func insertBlockParamsToBlock(chain *core.BlockChain, parent *types.Header, params executableData) (*types.Block, error) {
 	txs, err := decodeTransactions(params.Transactions)
 	if err != nil {
 		return nil, err
@@ -272,8 +276,12 @@ func insertBlockParamsToBlock(config *chainParams.ChainConfig, parent *types.Hea
 		GasUsed:     params.GasUsed,
 		Time:        params.Timestamp,
 	}
-	if config.IsLondon(number) {
-		header.BaseFee = misc.CalcBaseFee(config, parent)
+	if config := chain.Config(); config.IsLondon(number) {
+		feeParams, err := misc.GetBaseFeeParams(config, chain.Engine(), chain, parent, nil)
+		if err != nil {
+			return nil, err
+		}
+		header.BaseFee = misc.CalcBaseFeeWithParams(config, feeParams, parent)
 	}
 	block := types.NewBlockWithHeader(header).WithBody(txs, nil /* uncles */)
 	return block, nil
@@ -287,7 +295,7 @@ func (api *consensusAPI) NewBlock(params executableData) (*newBlockResponse, err
 	if parent == nil {
 		return &newBlockResponse{false}, fmt.Errorf("could not find parent %x", params.ParentHash)
 	}
-	block, err := insertBlockParamsToBlock(api.eth.BlockChain().Config(), parent.Header(), params)
+	block, err := insertBlockParamsToBlock(api.eth.BlockChain(), parent.Header(), params)
 	if err != nil {
 		return nil, err
 	}
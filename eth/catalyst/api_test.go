@@ -206,7 +206,7 @@ func TestEth2NewBlock(t *testing.T) {
 		if err != nil || !success.Valid {
 			t.Fatalf("Failed to insert forked block #%d: %v", i, err)
 		}
-		lastBlock, err = insertBlockParamsToBlock(ethservice.BlockChain().Config(), lastBlock.Header(), p)
+		lastBlock, err = insertBlockParamsToBlock(ethservice.BlockChain(), lastBlock.Header(), p)
 		if err != nil {
 			t.Fatal(err)
 		}
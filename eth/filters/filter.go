@@ -135,18 +135,34 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	}
 	head := header.Number.Uint64()
 
-	if f.begin == -1 {
-		f.begin = int64(head)
+	// Resolve the special block numbers bounding the range
+	resolve := func(number int64) (int64, error) {
+		switch number {
+		case rpc.LatestBlockNumber.Int64():
+			return int64(head), nil
+		case rpc.FinalizedBlockNumber.Int64():
+			header, err := f.backend.HeaderByNumber(ctx, rpc.FinalizedBlockNumber)
+			if err != nil {
+				return 0, err
+			}
+			if header == nil {
+				return 0, errors.New("finalized block not found")
+			}
+			return header.Number.Int64(), nil
+		}
+		return number, nil
 	}
-	end := uint64(f.end)
-	if f.end == -1 {
-		end = head
+	var err error
+	if f.begin, err = resolve(f.begin); err != nil {
+		return nil, err
 	}
+	resolved, err := resolve(f.end)
+	if err != nil {
+		return nil, err
+	}
+	end := uint64(resolved)
 	// Gather all indexed logs, and finish with non indexed ones
-	var (
-		logs []*types.Log
-		err  error
-	)
+	var logs []*types.Log
 	size, sections := f.backend.BloomStatus()
 	if indexed := sections * size; indexed > uint64(f.begin) {
 		if indexed > end {
@@ -199,6 +199,10 @@ func (es *EventSystem) SubscribeLogs(crit ethereum.FilterQuery, logs chan []*typ
 	} else {
 		to = rpc.BlockNumber(crit.ToBlock.Int64())
 	}
+	// new logs are always past the finalized block, which bounds nothing
+	if from == rpc.FinalizedBlockNumber {
+		from = rpc.LatestBlockNumber
+	}
 
 	// only interested in pending logs
 	if from == rpc.PendingBlockNumber && to == rpc.PendingBlockNumber {
@@ -63,14 +63,18 @@ func (b *testBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumbe
 		hash common.Hash
 		num  uint64
 	)
-	if blockNr == rpc.LatestBlockNumber {
+	switch blockNr {
+	case rpc.LatestBlockNumber, rpc.FinalizedBlockNumber:
 		hash = rawdb.ReadHeadBlockHash(b.db)
+		if blockNr == rpc.FinalizedBlockNumber {
+			hash = rawdb.ReadFinalizedBlockHash(b.db)
+		}
 		number := rawdb.ReadHeaderNumber(b.db, hash)
 		if number == nil {
 			return nil, nil
 		}
 		num = *number
-	} else {
+	default:
 		num = uint64(blockNr)
 		hash = rawdb.ReadCanonicalHash(b.db, num)
 	}
@@ -297,6 +301,11 @@ func TestLogFilterCreation(t *testing.T) {
 			{FilterCriteria{FromBlock: big.NewInt(rpc.PendingBlockNumber.Int64()), ToBlock: big.NewInt(100)}, false},
 			// from block "higher" than to block
 			{FilterCriteria{FromBlock: big.NewInt(rpc.PendingBlockNumber.Int64()), ToBlock: big.NewInt(rpc.LatestBlockNumber.Int64())}, false},
+			// finalized block to new mined and pending blocks
+			{FilterCriteria{FromBlock: big.NewInt(rpc.FinalizedBlockNumber.Int64()), ToBlock: big.NewInt(rpc.LatestBlockNumber.Int64())}, true},
+			{FilterCriteria{FromBlock: big.NewInt(rpc.FinalizedBlockNumber.Int64()), ToBlock: big.NewInt(rpc.PendingBlockNumber.Int64())}, true},
+			// new blocks never end at the finalized block
+			{FilterCriteria{FromBlock: big.NewInt(1), ToBlock: big.NewInt(rpc.FinalizedBlockNumber.Int64())}, false},
 		}
 	)
 
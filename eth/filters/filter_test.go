@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 func makeReceipt(addr common.Address) *types.Receipt {
@@ -193,6 +194,19 @@ func TestFilters(t *testing.T) {
 		t.Errorf("expected log[0].Topics[0] to be %x, got %x", hash3, logs[0].Topics[0])
 	}
 
+	rawdb.WriteFinalizedBlockHash(db, chain[994].Hash())
+	filter = NewRangeFilter(backend, 0, rpc.FinalizedBlockNumber.Int64(), []common.Address{addr}, [][]common.Hash{{hash1, hash2, hash3, hash4}})
+	logs, _ = filter.Logs(context.Background())
+	if len(logs) != 2 {
+		t.Error("expected 2 log up to the finalized block, got", len(logs))
+	}
+
+	filter = NewRangeFilter(backend, rpc.FinalizedBlockNumber.Int64(), -1, []common.Address{addr}, [][]common.Hash{{hash1, hash2, hash3, hash4}})
+	logs, _ = filter.Logs(context.Background())
+	if len(logs) != 2 {
+		t.Error("expected 2 log from the finalized block, got", len(logs))
+	}
+
 	filter = NewRangeFilter(backend, 1, 10, nil, [][]common.Hash{{hash1, hash2}})
 
 	logs, _ = filter.Logs(context.Background())
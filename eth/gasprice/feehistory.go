@@ -89,7 +89,12 @@ func (oracle *Oracle) processBlock(bf *blockFees, percentiles []float64) {
 		bf.results.baseFee = new(big.Int)
 	}
 	if chainconfig.IsLondon(big.NewInt(int64(bf.blockNumber + 1))) {
-		bf.results.nextBaseFee = misc.CalcBaseFee(chainconfig, bf.header)
+		feeParams, err := misc.GetBaseFeeParams(chainconfig, oracle.backend.Engine(), nil, bf.header, nil)
+		if err != nil {
+			bf.err = err
+			return
+		}
+		bf.results.nextBaseFee = misc.CalcBaseFeeWithParams(chainconfig, feeParams, bf.header)
 	} else {
 		bf.results.nextBaseFee = new(big.Int)
 	}
@@ -165,9 +170,16 @@ func (oracle *Oracle) resolveBlockRange(ctx context.Context, lastBlock rpc.Block
 			return nil, nil, 0, 0, err
 		}
 	}
-	if lastBlock == rpc.LatestBlockNumber {
+	switch {
+	case lastBlock == rpc.LatestBlockNumber:
 		lastBlock = headBlock
-	} else if pendingBlock == nil && lastBlock > headBlock {
+	case lastBlock == rpc.FinalizedBlockNumber:
+		finalizedHeader, err := oracle.backend.HeaderByNumber(ctx, rpc.FinalizedBlockNumber)
+		if err != nil {
+			return nil, nil, 0, 0, err
+		}
+		lastBlock = rpc.BlockNumber(finalizedHeader.Number.Uint64())
+	case pendingBlock == nil && lastBlock > headBlock:
 		return nil, nil, 0, 0, fmt.Errorf("%w: requested %d, head %d", errRequestBeyondHead, lastBlock, headBlock)
 	}
 	// ensure not trying to retrieve before genesis
@@ -178,8 +190,8 @@ func (oracle *Oracle) resolveBlockRange(ctx context.Context, lastBlock rpc.Block
 }
 
 // FeeHistory returns data relevant for fee estimation based on the specified range of blocks.
-// The range can be specified either with absolute block numbers or ending with the latest
-// or pending block. Backends may or may not support gathering data from the pending block
+// The range can be specified either with absolute block numbers or ending with the latest,
+// finalized or pending block. Backends may or may not support gathering data from the pending block
 // or blocks older than a certain age (specified in maxHistory). The first block of the
 // actually processed range is returned to avoid ambiguity when parts of the requested range
 // are not available or when the head has changed during processing this request.
@@ -50,6 +50,9 @@ func TestFeeHistory(t *testing.T) {
 		{false, 1000, 1000, 2, rpc.PendingBlockNumber, nil, 32, 1, nil},
 		{true, 1000, 1000, 2, rpc.PendingBlockNumber, nil, 32, 2, nil},
 		{true, 1000, 1000, 2, rpc.PendingBlockNumber, []float64{0, 10}, 32, 2, nil},
+		{false, 1000, 1000, 1, rpc.FinalizedBlockNumber, nil, 28, 1, nil},
+		{false, 1000, 1000, 10, rpc.FinalizedBlockNumber, []float64{0, 10}, 19, 10, nil},
+		{true, 1000, 1000, 1000000000, rpc.FinalizedBlockNumber, nil, 0, 29, nil},
 	}
 	for i, c := range cases {
 		config := Config{
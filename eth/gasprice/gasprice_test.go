@@ -23,6 +23,7 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -34,7 +35,10 @@ import (
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
-const testHead = 32
+const (
+	testHead      = 32
+	testFinalized = 28 // Block the test backend reports as finalized
+)
 
 type testBackend struct {
 	chain   *core.BlockChain
@@ -48,6 +52,9 @@ func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber
 	if number == rpc.LatestBlockNumber {
 		number = testHead
 	}
+	if number == rpc.FinalizedBlockNumber {
+		number = testFinalized
+	}
 	if number == rpc.PendingBlockNumber {
 		if b.pending {
 			number = testHead + 1
@@ -65,6 +72,9 @@ func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber)
 	if number == rpc.LatestBlockNumber {
 		number = testHead
 	}
+	if number == rpc.FinalizedBlockNumber {
+		number = testFinalized
+	}
 	if number == rpc.PendingBlockNumber {
 		if b.pending {
 			number = testHead + 1
@@ -91,6 +101,10 @@ func (b *testBackend) ChainConfig() *params.ChainConfig {
 	return b.chain.Config()
 }
 
+func (b *testBackend) Engine() consensus.Engine {
+	return b.chain.Engine()
+}
+
 func (b *testBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
 	return nil
 }
@@ -105,12 +119,12 @@ func newTestBackend(t *testing.T, londonBlock *big.Int, pending bool) *testBacke
 		}
 		signer = types.LatestSigner(gspec.Config)
 	)
-	if londonBlock != nil {
-		gspec.Config.LondonBlock = londonBlock
-		signer = types.LatestSigner(gspec.Config)
-	} else {
-		gspec.Config.LondonBlock = nil
-	}
+	// The Phoenix forks follow London and raise its base fee, leave them out
+	config := *params.TestChainConfig
+	config.LondonBlock, config.BigBenBlock, config.ThamesBlock, config.TridentBlock = londonBlock, nil, nil, nil
+	gspec.Config = &config
+	signer = types.LatestSigner(gspec.Config)
+
 	engine := ethash.NewFaker()
 	db := rawdb.NewMemoryDatabase()
 	genesis, _ := gspec.Commit(db)
@@ -126,7 +140,7 @@ func newTestBackend(t *testing.T, londonBlock *big.Int, pending bool) *testBacke
 				Nonce:     b.TxNonce(addr),
 				To:        &common.Address{},
 				Gas:       30000,
-				GasFeeCap: big.NewInt(100 * params.GWei),
+				GasFeeCap: big.NewInt(1000 * params.GWei),
 				GasTipCap: big.NewInt(int64(i+1) * params.GWei),
 				Data:      []byte{},
 			}
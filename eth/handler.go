@@ -18,6 +18,7 @@ package eth
 
 import (
 	"errors"
+	"fmt"
 	"math"
 	"math/big"
 	"sync"
@@ -25,12 +26,14 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/forkid"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/fetcher"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/eth/protocols/posa"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
@@ -44,6 +47,9 @@ const (
 	// txChanSize is the size of channel listening to NewTxsEvent.
 	// The number is referenced from the size of tx pool.
 	txChanSize = 4096
+
+	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
+	chainHeadChanSize = 10
 )
 
 var (
@@ -113,9 +119,14 @@ type handler struct {
 	txsCh         chan core.NewTxsEvent
 	txsSub        event.Subscription
 	minedBlockSub *event.TypeMuxSubscription
+	chainHeadCh   chan core.ChainHeadEvent
+	chainHeadSub  event.Subscription
 
 	whitelist map[uint64]common.Hash
 
+	pendingFinal map[common.Hash]*types.Header // Blocks voted final that couldn't be finalized yet
+	finalLock    sync.Mutex                    // Mutex protecting the pending finalizations
+
 	// channels for fetcher, syncer, txsyncLoop
 	txsyncCh chan *txsync
 	quitSync chan struct{}
@@ -132,16 +143,17 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		config.EventMux = new(event.TypeMux) // Nicety initialization for tests
 	}
 	h := &handler{
-		networkID:  config.Network,
-		forkFilter: forkid.NewFilter(config.Chain),
-		eventMux:   config.EventMux,
-		database:   config.Database,
-		txpool:     config.TxPool,
-		chain:      config.Chain,
-		peers:      newPeerSet(),
-		whitelist:  config.Whitelist,
-		txsyncCh:   make(chan *txsync),
-		quitSync:   make(chan struct{}),
+		networkID:    config.Network,
+		forkFilter:   forkid.NewFilter(config.Chain),
+		eventMux:     config.EventMux,
+		database:     config.Database,
+		txpool:       config.TxPool,
+		chain:        config.Chain,
+		peers:        newPeerSet(),
+		whitelist:    config.Whitelist,
+		pendingFinal: make(map[common.Hash]*types.Header),
+		txsyncCh:     make(chan *txsync),
+		quitSync:     make(chan struct{}),
 	}
 	if config.Sync == downloader.FullSync {
 		// The database seems empty as the current block is the genesis. Yet the fast
@@ -407,6 +419,14 @@ func (h *handler) Start(maxPeers int) {
 	h.minedBlockSub = h.eventMux.Subscribe(core.NewMinedBlockEvent{})
 	go h.minedBroadcastLoop()
 
+	// sign and broadcast finality votes if the engine supports them
+	if _, ok := h.chain.Engine().(consensus.PoSA); ok {
+		h.wg.Add(1)
+		h.chainHeadCh = make(chan core.ChainHeadEvent, chainHeadChanSize)
+		h.chainHeadSub = h.chain.SubscribeChainHeadEvent(h.chainHeadCh)
+		go h.voteBroadcastLoop()
+	}
+
 	// start sync handlers
 	h.wg.Add(2)
 	go h.chainSync.loop()
@@ -416,6 +436,9 @@ func (h *handler) Start(maxPeers int) {
 func (h *handler) Stop() {
 	h.txsSub.Unsubscribe()        // quits txBroadcastLoop
 	h.minedBlockSub.Unsubscribe() // quits blockBroadcastLoop
+	if h.chainHeadSub != nil {
+		h.chainHeadSub.Unsubscribe() // quits voteBroadcastLoop
+	}
 
 	// Quit chainSync and txsync64.
 	// After this is done, no new peers will be accepted.
@@ -508,6 +531,85 @@ func (h *handler) BroadcastTransactions(txs types.Transactions) {
 		"tx packs", directPeers, "broadcast txs", directCount)
 }
 
+// BroadcastVotes will propagate a batch of finality votes to all `posa` peers
+// which are not known to already have them.
+func (h *handler) BroadcastVotes(votes []*types.Vote) {
+	var (
+		voteset = make(map[*posa.Peer][]*types.Vote) // Set peer->votes to transfer
+		count   int
+	)
+	for _, vote := range votes {
+		for _, peer := range h.peers.peersWithoutVote(vote.ID()) {
+			voteset[peer] = append(voteset[peer], vote)
+		}
+	}
+	for peer, votes := range voteset {
+		count += len(votes)
+		peer.AsyncSendVotes(votes)
+	}
+	log.Trace("Vote broadcast", "votes", len(votes), "peers", len(voteset), "sent", count)
+}
+
+// addVotes tallies a batch of finality votes with the consensus engine and marks
+// the blocks they finalize. It returns the votes that were new and valid, along
+// with an error if any of the votes can never be valid.
+func (h *handler) addVotes(votes []*types.Vote) ([]*types.Vote, error) {
+	engine, ok := h.chain.Engine().(consensus.PoSA)
+	if !ok {
+		return nil, nil
+	}
+	var (
+		fresh   = make([]*types.Vote, 0, len(votes))
+		invalid error
+	)
+	for _, vote := range votes {
+		final, err := engine.AddVote(h.chain, vote)
+		if err != nil {
+			log.Trace("Discarded finality vote", "number", vote.Number, "hash", vote.Hash, "err", err)
+			if invalid == nil && errors.Is(err, consensus.ErrInvalidVote) {
+				invalid = fmt.Errorf("vote #%d [%x]: %w", vote.Number, vote.Hash.Bytes()[:4], err)
+			}
+			continue
+		}
+		fresh = append(fresh, vote)
+		if !final {
+			continue
+		}
+		if header := h.chain.GetHeader(vote.Hash, vote.Number); header != nil {
+			h.finalize(header)
+		}
+	}
+	return fresh, invalid
+}
+
+// finalize marks a block that gathered enough finality votes as final. As the
+// votes are only tallied once, blocks that can't be finalized yet, because they
+// aren't canonical, are retried on every new chain head.
+func (h *handler) finalize(header *types.Header) {
+	h.finalLock.Lock()
+	defer h.finalLock.Unlock()
+
+	h.pendingFinal[header.Hash()] = header
+	h.finalizePending()
+}
+
+// finalizePending retries finalizing the blocks voted final so far, dropping the
+// ones finalized since or superseded by a higher finalized block. The caller must
+// hold finalLock.
+func (h *handler) finalizePending() {
+	for hash, header := range h.pendingFinal {
+		if finalized := h.chain.CurrentFinalizedHeader(); finalized != nil && finalized.Number.Cmp(header.Number) >= 0 {
+			delete(h.pendingFinal, hash)
+			continue
+		}
+		if err := h.chain.SetFinalized(header); err != nil {
+			log.Debug("Failed to finalize block", "number", header.Number, "hash", hash, "err", err)
+			continue
+		}
+		delete(h.pendingFinal, hash)
+	}
+}
+
 // minedBroadcastLoop sends mined blocks to connected peers.
 func (h *handler) minedBroadcastLoop() {
 	defer h.wg.Done()
@@ -532,3 +634,40 @@ func (h *handler) txBroadcastLoop() {
 		}
 	}
 }
+
+// voteBroadcastLoop signs a finality vote for every new chain head if the local
+// node is a committee member and sends it to connected peers, along with the
+// buffered votes of peers whose blocks were imported since. Blocks voted final
+// before they became canonical are finalized on the way.
+func (h *handler) voteBroadcastLoop() {
+	defer h.wg.Done()
+
+	engine := h.chain.Engine().(consensus.PoSA)
+	for {
+		select {
+		case ev := <-h.chainHeadCh:
+			// Finalize the blocks voted final before they became canonical
+			h.finalLock.Lock()
+			h.finalizePending()
+			h.finalLock.Unlock()
+
+			// Tally the votes that overtook the blocks imported meanwhile
+			if votes, _ := h.addVotes(engine.QueuedVotes(h.chain)); len(votes) > 0 {
+				h.BroadcastVotes(votes)
+			}
+			vote, err := engine.SignVote(h.chain, ev.Block.Header())
+			if err != nil {
+				log.Debug("Failed to sign finality vote", "number", ev.Block.Number(), "hash", ev.Block.Hash(), "err", err)
+				continue
+			}
+			if vote == nil {
+				continue
+			}
+			if votes, _ := h.addVotes([]*types.Vote{vote}); len(votes) > 0 {
+				h.BroadcastVotes(votes)
+			}
+		case <-h.chainHeadSub.Err():
+			return
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+
+package eth
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/protocols/posa"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// posaHandler implements the posa.Backend interface to handle the finality votes
+// broadcast by remote peers.
+type posaHandler handler
+
+// RunPeer is invoked when a peer joins on the `posa` protocol.
+func (h *posaHandler) RunPeer(peer *posa.Peer, hand posa.Handler) error {
+	h.peerWG.Add(1)
+	defer h.peerWG.Done()
+
+	if err := h.peers.registerPosaPeer(peer); err != nil {
+		peer.Log().Debug("PoSA peer registration failed", "err", err)
+		return err
+	}
+	defer h.peers.unregisterPosaPeer(peer.ID())
+
+	return hand(peer)
+}
+
+// posaPeerInfo represents a short summary of the `posa` sub-protocol metadata
+// known about a connected peer.
+type posaPeerInfo struct {
+	Version uint `json:"version"` // PoSA protocol version negotiated
+}
+
+// PeerInfo retrieves all known `posa` information about a peer.
+func (h *posaHandler) PeerInfo(id enode.ID) interface{} {
+	h.peers.lock.RLock()
+	defer h.peers.lock.RUnlock()
+
+	if p := h.peers.posaPeers[id.String()]; p != nil {
+		return &posaPeerInfo{Version: p.Version()}
+	}
+	return nil
+}
+
+// Handle is invoked from a peer's message handler when it receives a new remote
+// message that the handler couldn't consume and serve itself.
+func (h *posaHandler) Handle(peer *posa.Peer, packet posa.Packet) error {
+	switch packet := packet.(type) {
+	case *posa.VotesPacket:
+		return h.handleVotes(peer, *packet)
+
+	default:
+		return fmt.Errorf("unexpected posa packet type: %T", packet)
+	}
+}
+
+// handleVotes is invoked from a peer's message handler when it transmits a batch
+// of finality votes for the local node to tally. Peers relaying votes that can
+// never be valid are dropped.
+func (h *posaHandler) handleVotes(peer *posa.Peer, votes []*types.Vote) error {
+	fresh, err := (*handler)(h).addVotes(votes)
+	if len(fresh) > 0 {
+		(*handler)(h).BroadcastVotes(fresh)
+	}
+	return err
+}
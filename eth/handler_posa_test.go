@@ -0,0 +1,62 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+
+package eth
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that blocks voted final before they are canonical are finalized once a
+// reorg makes them canonical, instead of their finality being lost.
+func TestFinalizeRetry(t *testing.T) {
+	handler := newTestHandlerWithBlocks(3)
+	defer handler.close()
+
+	// Import a shorter side chain and finalize its head
+	side, _ := core.GenerateChain(params.TestChainConfig, handler.chain.Genesis(), ethash.NewFaker(), handler.db, 2, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(common.Address{0x01})
+	})
+	if _, err := handler.chain.InsertChain(side); err != nil {
+		t.Fatalf("failed to import side chain: %v", err)
+	}
+	handler.handler.finalize(side[1].Header())
+	if finalized := handler.chain.CurrentFinalizedHeader(); finalized != nil {
+		t.Fatalf("side block finalized: #%d", finalized.Number)
+	}
+	// Reorg to the side chain and ensure its block gets finalized
+	ext, _ := core.GenerateChain(params.TestChainConfig, side[1], ethash.NewFaker(), handler.db, 3, nil)
+	if _, err := handler.chain.InsertChain(ext); err != nil {
+		t.Fatalf("failed to extend side chain: %v", err)
+	}
+	handler.handler.finalLock.Lock()
+	handler.handler.finalizePending()
+	pending := len(handler.handler.pendingFinal)
+	handler.handler.finalLock.Unlock()
+
+	if finalized := handler.chain.CurrentFinalizedHeader(); finalized == nil || finalized.Hash() != side[1].Hash() {
+		t.Fatalf("finalized header mismatch: have %v, want %x", finalized, side[1].Hash())
+	}
+	if pending != 0 {
+		t.Errorf("pending finalizations mismatch: have %d, want 0", pending)
+	}
+}
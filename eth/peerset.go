@@ -23,6 +23,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/eth/protocols/posa"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
 	"github.com/ethereum/go-ethereum/p2p"
 )
@@ -43,6 +44,10 @@ var (
 	// errSnapWithoutEth is returned if a peer attempts to connect only on the
 	// snap protocol without advertizing the eth main protocol.
 	errSnapWithoutEth = errors.New("peer connected on snap without compatible eth support")
+
+	// errPosaWithoutEth is returned if a peer attempts to connect only on the
+	// posa protocol without advertizing the eth main protocol.
+	errPosaWithoutEth = errors.New("peer connected on posa without compatible eth support")
 )
 
 // peerSet represents the collection of active peers currently participating in
@@ -54,6 +59,8 @@ type peerSet struct {
 	snapWait map[string]chan *snap.Peer // Peers connected on `eth` waiting for their snap extension
 	snapPend map[string]*snap.Peer      // Peers connected on the `snap` protocol, but not yet on `eth`
 
+	posaPeers map[string]*posa.Peer // Peers connected on the `posa` protocol for finality votes
+
 	lock   sync.RWMutex
 	closed bool
 }
@@ -61,9 +68,10 @@ type peerSet struct {
 // newPeerSet creates a new peer set to track the active participants.
 func newPeerSet() *peerSet {
 	return &peerSet{
-		peers:    make(map[string]*ethPeer),
-		snapWait: make(map[string]chan *snap.Peer),
-		snapPend: make(map[string]*snap.Peer),
+		peers:     make(map[string]*ethPeer),
+		snapWait:  make(map[string]chan *snap.Peer),
+		snapPend:  make(map[string]*snap.Peer),
+		posaPeers: make(map[string]*posa.Peer),
 	}
 }
 
@@ -131,6 +139,35 @@ func (ps *peerSet) waitSnapExtension(peer *eth.Peer) (*snap.Peer, error) {
 	return <-wait, nil
 }
 
+// registerPosaPeer starts tracking a `posa` peer for finality vote propagation.
+// As votes are only meaningful next to the chain of `eth`, peers not running a
+// compatible `eth` are rejected.
+func (ps *peerSet) registerPosaPeer(peer *posa.Peer) error {
+	if !peer.RunningCap(eth.ProtocolName, eth.ProtocolVersions) {
+		return errPosaWithoutEth
+	}
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	if ps.closed {
+		return errPeerSetClosed
+	}
+	id := peer.ID()
+	if _, ok := ps.posaPeers[id]; ok {
+		return errPeerAlreadyRegistered
+	}
+	ps.posaPeers[id] = peer
+	return nil
+}
+
+// unregisterPosaPeer stops tracking a `posa` peer.
+func (ps *peerSet) unregisterPosaPeer(id string) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	delete(ps.posaPeers, id)
+}
+
 // registerPeer injects a new `eth` peer into the working set, or returns an error
 // if the peer is already known.
 func (ps *peerSet) registerPeer(peer *eth.Peer, ext *snap.Peer) error {
@@ -211,6 +248,21 @@ func (ps *peerSet) peersWithoutTransaction(hash common.Hash) []*ethPeer {
 	return list
 }
 
+// peersWithoutVote retrieves a list of `posa` peers that do not have a given
+// finality vote in their set of known votes.
+func (ps *peerSet) peersWithoutVote(id common.Hash) []*posa.Peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	list := make([]*posa.Peer, 0, len(ps.posaPeers))
+	for _, p := range ps.posaPeers {
+		if !p.KnownVote(id) {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
 // len returns if the current number of `eth` peers in the set. Since the `snap`
 // peers are tied to the existence of an `eth` connection, that will always be a
 // subset of `eth`.
@@ -0,0 +1,122 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package posa
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// testBackend is a mock implementation of the live `posa` backend, collecting
+// the delivered packets.
+type testBackend struct {
+	packets chan Packet
+}
+
+func (b *testBackend) RunPeer(peer *Peer, handler Handler) error { return handler(peer) }
+func (b *testBackend) PeerInfo(enode.ID) interface{}             { return nil }
+
+func (b *testBackend) Handle(peer *Peer, packet Packet) error {
+	b.packets <- packet
+	return nil
+}
+
+// newTestPeer creates a new peer registered at the given backend, returning the
+// local end of its message pipe and the channel its handler error is sent on.
+func newTestPeer(backend Backend) (*Peer, *p2p.MsgPipeRW, <-chan error) {
+	app, net := p2p.MsgPipe()
+
+	var id enode.ID
+	rand.Read(id[:])
+
+	peer := NewPeer(posa1, p2p.NewPeer(id, "peer", nil), net)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- backend.RunPeer(peer, func(peer *Peer) error {
+			return handle(backend, peer)
+		})
+	}()
+	return peer, app, errc
+}
+
+// newTestVotes creates a batch of placeholder finality votes.
+func newTestVotes(n int) []*types.Vote {
+	votes := make([]*types.Vote, n)
+	for i := range votes {
+		votes[i] = &types.Vote{Number: uint64(i + 1), Signature: make([]byte, crypto.SignatureLength)}
+	}
+	return votes
+}
+
+// Tests that received finality votes are delivered to the backend and marked as
+// known by the sending peer.
+func TestVotesDelivery(t *testing.T) {
+	backend := &testBackend{packets: make(chan Packet, 1)}
+
+	peer, app, _ := newTestPeer(backend)
+	defer peer.Close()
+	defer app.Close()
+
+	votes := newTestVotes(3)
+	go p2p.Send(app, VotesMsg, votes)
+
+	select {
+	case packet := <-backend.packets:
+		delivered, ok := packet.(*VotesPacket)
+		if !ok {
+			t.Fatalf("packet type mismatch: have %T, want %T", packet, &VotesPacket{})
+		}
+		if len(*delivered) != len(votes) {
+			t.Fatalf("vote count mismatch: have %d, want %d", len(*delivered), len(votes))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("votes not delivered")
+	}
+	for i, vote := range votes {
+		if !peer.KnownVote(vote.ID()) {
+			t.Errorf("vote %d: not marked as known", i)
+		}
+	}
+}
+
+// Tests that peers sending more finality votes in a packet than allowed are
+// disconnected.
+func TestVotesPacketLimit(t *testing.T) {
+	backend := &testBackend{packets: make(chan Packet, 1)}
+
+	peer, app, errc := newTestPeer(backend)
+	defer peer.Close()
+	defer app.Close()
+
+	go p2p.Send(app, VotesMsg, newTestVotes(maxVotesPacket+1))
+
+	select {
+	case err := <-errc:
+		if !errors.Is(err, errMsgTooLarge) {
+			t.Fatalf("error mismatch: have %v, want %v", err, errMsgTooLarge)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("peer not dropped")
+	}
+}
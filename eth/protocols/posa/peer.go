@@ -0,0 +1,166 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package posa
+
+import (
+	mapset "github.com/deckarep/golang-set"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p"
+)
+
+const (
+	// maxKnownVotes is the maximum vote identifiers to keep in the known list
+	// before starting to randomly evict them.
+	maxKnownVotes = 4096
+
+	// maxQueuedVotes is the maximum number of vote propagations to queue up
+	// before dropping broadcasts.
+	maxQueuedVotes = 64
+)
+
+// max is a helper function which returns the larger of the two given integers.
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Peer is a collection of relevant information we have about a `posa` peer.
+type Peer struct {
+	id string // Unique ID for the peer, cached
+
+	*p2p.Peer                   // The embedded P2P package peer
+	rw        p2p.MsgReadWriter // Input/output streams for posa
+	version   uint              // Protocol version negotiated
+
+	knownVotes  mapset.Set         // Set of vote identifiers known to be known by this peer
+	queuedVotes chan []*types.Vote // Queue of finality votes to broadcast to the peer
+
+	term chan struct{} // Termination channel to stop the broadcaster
+
+	logger log.Logger // Contextual logger with the peer id injected
+}
+
+// NewPeer create a wrapper for a network connection and negotiated  protocol
+// version.
+func NewPeer(version uint, p *p2p.Peer, rw p2p.MsgReadWriter) *Peer {
+	id := p.ID().String()
+	peer := &Peer{
+		id:          id,
+		Peer:        p,
+		rw:          rw,
+		version:     version,
+		knownVotes:  mapset.NewSet(),
+		queuedVotes: make(chan []*types.Vote, maxQueuedVotes),
+		term:        make(chan struct{}),
+		logger:      log.New("peer", id[:8]),
+	}
+	// Start up the vote broadcaster
+	go peer.broadcastVotes()
+
+	return peer
+}
+
+// Close signals the broadcast goroutine to terminate. Only ever call this if
+// you created the peer yourself via NewPeer. Otherwise let whoever created it
+// clean it up!
+func (p *Peer) Close() {
+	close(p.term)
+}
+
+// ID retrieves the peer's unique identifier.
+func (p *Peer) ID() string {
+	return p.id
+}
+
+// Version retrieves the peer's negoatiated `posa` protocol version.
+func (p *Peer) Version() uint {
+	return p.version
+}
+
+// Log overrides the P2P logget with the higher level one containing only the id.
+func (p *Peer) Log() log.Logger {
+	return p.logger
+}
+
+// KnownVote returns whether peer is known to already have a finality vote.
+func (p *Peer) KnownVote(id common.Hash) bool {
+	return p.knownVotes.Contains(id)
+}
+
+// markVote marks a finality vote as known for the peer, ensuring that it will
+// never be propagated to this particular peer.
+func (p *Peer) markVote(id common.Hash) {
+	// If we reached the memory allowance, drop a previously known vote
+	for p.knownVotes.Cardinality() >= maxKnownVotes {
+		p.knownVotes.Pop()
+	}
+	p.knownVotes.Add(id)
+}
+
+// SendVotes propagates a batch of finality votes to a remote peer, split into
+// packets the remote peer accepts.
+func (p *Peer) SendVotes(votes []*types.Vote) error {
+	// Mark all the votes as known, but ensure we don't overflow our limits
+	for p.knownVotes.Cardinality() > max(0, maxKnownVotes-len(votes)) {
+		p.knownVotes.Pop()
+	}
+	for _, vote := range votes {
+		p.knownVotes.Add(vote.ID())
+	}
+	for len(votes) > maxVotesPacket {
+		if err := p2p.Send(p.rw, VotesMsg, votes[:maxVotesPacket]); err != nil {
+			return err
+		}
+		votes = votes[maxVotesPacket:]
+	}
+	return p2p.Send(p.rw, VotesMsg, votes)
+}
+
+// AsyncSendVotes queues a batch of finality votes for propagation to a remote
+// peer. If the peer's broadcast queue is full, the votes are silently dropped.
+func (p *Peer) AsyncSendVotes(votes []*types.Vote) {
+	select {
+	case p.queuedVotes <- votes:
+		// Mark all the votes as known, but ensure we don't overflow our limits
+		for _, vote := range votes {
+			p.markVote(vote.ID())
+		}
+	default:
+		p.Log().Debug("Dropping vote propagation", "count", len(votes))
+	}
+}
+
+// broadcastVotes is a write loop that propagates finality votes to the remote
+// peer. Votes are tiny, so they are sent as they are queued.
+func (p *Peer) broadcastVotes() {
+	for {
+		select {
+		case votes := <-p.queuedVotes:
+			if err := p.SendVotes(votes); err != nil {
+				return
+			}
+			p.Log().Trace("Propagated votes", "count", len(votes))
+
+		case <-p.term:
+			return
+		}
+	}
+}
@@ -31,8 +31,10 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/poseidon"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/eth/downloader"
@@ -43,6 +45,7 @@ import (
 	"github.com/ethereum/go-ethereum/miner"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/gorilla/websocket"
 )
@@ -57,6 +60,10 @@ const (
 	txChanSize = 4096
 	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
 	chainHeadChanSize = 10
+
+	// betaPrefixLength is the number of leading vrf output bytes to report per
+	// block, enough to tell competing blocks apart at a glance.
+	betaPrefixLength = 4
 )
 
 // backend encompasses the bare-minimum functionality needed for ethstats reporting
@@ -80,6 +87,14 @@ type fullNodeBackend interface {
 	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
 }
 
+// chainBackend encompasses the functionality necessary to resolve the headers
+// the consensus engine needs to verify the reported blocks
+type chainBackend interface {
+	backend
+	ChainConfig() *params.ChainConfig
+	HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error)
+}
+
 // Service implements an Ethereum netstats reporting daemon that pushes local
 // chain statistics up to a monitoring server.
 type Service struct {
@@ -307,6 +322,12 @@ func (s *Service) loop(chainHeadCh chan core.ChainHeadEvent, txEventCh chan core
 				errTimer.Reset(0)
 				continue
 			}
+			if err = s.reportValidators(conn, s.backend.CurrentHeader()); err != nil {
+				log.Warn("Initial validator stats report failed", "err", err)
+				conn.Close()
+				errTimer.Reset(0)
+				continue
+			}
 			// Keep sending status updates until the connection breaks
 			fullReport := time.NewTicker(15 * time.Second)
 
@@ -330,6 +351,11 @@ func (s *Service) loop(chainHeadCh chan core.ChainHeadEvent, txEventCh chan core
 					if err = s.reportBlock(conn, head); err != nil {
 						log.Warn("Block stats report failed", "err", err)
 					}
+					if err == nil && s.validatorEpoch(head.Number()) {
+						if err = s.reportValidators(conn, head.Header()); err != nil {
+							log.Warn("Validator stats report failed", "err", err)
+						}
+					}
 					if err = s.reportPending(conn); err != nil {
 						log.Warn("Post-block transaction stats report failed", "err", err)
 					}
@@ -576,6 +602,15 @@ type blockStats struct {
 	TxHash     common.Hash    `json:"transactionsRoot"`
 	Root       common.Hash    `json:"stateRoot"`
 	Uncles     uncleStats     `json:"uncles"`
+	Sortition  *sortStats     `json:"sortition,omitempty"`
+}
+
+// sortStats is the vrf sortition information to report about Poseidon blocks.
+type sortStats struct {
+	Name            string        `json:"validatorName"`
+	Retries         uint64        `json:"retries"`
+	Beta            hexutil.Bytes `json:"beta"`
+	CommitteeSupply string        `json:"committeeSupply"`
 }
 
 // txStats is the information to report about individual transactions.
@@ -651,6 +686,10 @@ func (s *Service) assembleBlockStats(block *types.Block) *blockStats {
 	// Assemble and return the block stats
 	author, _ := s.engine.Author(header)
 
+	var sortition *sortStats
+	if engine, ok := s.engine.(*poseidon.Poseidon); ok && header.Number.Sign() > 0 {
+		sortition = s.assembleSortStats(engine, header)
+	}
 	return &blockStats{
 		Number:     header.Number,
 		Hash:       header.Hash(),
@@ -665,6 +704,31 @@ func (s *Service) assembleBlockStats(block *types.Block) *blockStats {
 		TxHash:     header.TxHash,
 		Root:       header.Root,
 		Uncles:     uncles,
+		Sortition:  sortition,
+	}
+}
+
+// assembleSortStats retrieves the sortition data a Poseidon block was sealed
+// with. Nil is returned if the block or the state of its parent is unavailable.
+func (s *Service) assembleSortStats(engine *poseidon.Poseidon, header *types.Header) *sortStats {
+	backend, ok := s.backend.(chainBackend)
+	if !ok {
+		return nil
+	}
+	sortition, err := engine.BlockSortition(&headerReader{backend}, header)
+	if err != nil {
+		log.Debug("Failed to retrieve block sortition", "number", header.Number, "hash", header.Hash(), "err", err)
+		return nil
+	}
+	beta := sortition.Beta
+	if len(beta) > betaPrefixLength {
+		beta = beta[:betaPrefixLength]
+	}
+	return &sortStats{
+		Name:            sortition.Name,
+		Retries:         sortition.Retries,
+		Beta:            beta,
+		CommitteeSupply: sortition.CommitteeSupply.String(),
 	}
 }
 
@@ -809,3 +873,123 @@ func (s *Service) reportStats(conn *connWrapper) error {
 	}
 	return conn.WriteJSON(report)
 }
+
+// validatorStats is the information to report about a single Poseidon validator.
+type validatorStats struct {
+	Address         common.Address `json:"address"`
+	Name            string         `json:"name"`
+	Stake           string         `json:"stake"`
+	LastBlockHeight uint64         `json:"lastBlockHeight"`
+	SlashHeight     uint64         `json:"slashHeight"`
+}
+
+// validatorSetStats is the information to report about the Poseidon validators.
+type validatorSetStats struct {
+	Number   uint64            `json:"number"`
+	Active   []*validatorStats `json:"active"`
+	Slashed  []*validatorStats `json:"slashed"`
+	Inactive []*validatorStats `json:"inactive"`
+}
+
+// validatorEpoch reports whether the validator set should be reported after the
+// given block was imported.
+func (s *Service) validatorEpoch(number *big.Int) bool {
+	engine, ok := s.engine.(*poseidon.Poseidon)
+	if !ok {
+		return false
+	}
+	epoch := engine.StatsEpoch(number)
+	return epoch > 0 && number.Uint64()%epoch == 0
+}
+
+// reportValidators retrieves the active and slashed validators the block after
+// the given head is proposed by and reports them to the stats server. Nothing is
+// reported if the chain isn't run by Poseidon or the state is unavailable.
+func (s *Service) reportValidators(conn *connWrapper, head *types.Header) error {
+	engine, ok := s.engine.(*poseidon.Poseidon)
+	if !ok {
+		return nil
+	}
+	set, err := engine.ValidatorSet(new(big.Int).Add(head.Number, common.Big1))
+	if err != nil {
+		log.Debug("Failed to retrieve validator set", "number", head.Number, "err", err)
+		return nil
+	}
+	details := &validatorSetStats{
+		Number:   set.Number,
+		Active:   make([]*validatorStats, len(set.Active)),
+		Slashed:  make([]*validatorStats, len(set.Slashed)),
+		Inactive: make([]*validatorStats, len(set.Inactive)),
+	}
+	for i, validator := range set.Active {
+		details.Active[i] = newValidatorStats(validator)
+	}
+	for i, validator := range set.Slashed {
+		details.Slashed[i] = newValidatorStats(validator)
+	}
+	for i, validator := range set.Inactive {
+		details.Inactive[i] = newValidatorStats(validator)
+	}
+	// Assemble the validator report and send it to the server
+	log.Trace("Sending validators to ethstats", "number", details.Number, "active", len(details.Active), "slashed", len(details.Slashed), "inactive", len(details.Inactive))
+
+	stats := map[string]interface{}{
+		"id":         s.node,
+		"validators": details,
+	}
+	report := map[string][]interface{}{
+		"emit": {"validators", stats},
+	}
+	return conn.WriteJSON(report)
+}
+
+// newValidatorStats converts the status of a validator into its reported form.
+func newValidatorStats(validator *poseidon.ValidatorStatus) *validatorStats {
+	stats := &validatorStats{
+		Address: validator.Address,
+		Name:    validator.Name,
+		Stake:   "0",
+	}
+	if validator.Stake != nil {
+		stats.Stake = validator.Stake.String()
+	}
+	if validator.LastBlockHeight != nil {
+		stats.LastBlockHeight = validator.LastBlockHeight.Uint64()
+	}
+	if validator.SlashHeight != nil {
+		stats.SlashHeight = validator.SlashHeight.Uint64()
+	}
+	return stats
+}
+
+// headerReader is a consensus.ChainHeaderReader resolving the headers through
+// the node backend.
+type headerReader struct {
+	backend chainBackend
+}
+
+func (r *headerReader) Config() *params.ChainConfig {
+	return r.backend.ChainConfig()
+}
+
+func (r *headerReader) CurrentHeader() *types.Header {
+	return r.backend.CurrentHeader()
+}
+
+func (r *headerReader) GetHeader(hash common.Hash, number uint64) *types.Header {
+	header := r.GetHeaderByHash(hash)
+	if header == nil || header.Number.Uint64() != number {
+		return nil
+	}
+	return header
+}
+
+func (r *headerReader) GetHeaderByNumber(number uint64) *types.Header {
+	header, _ := r.backend.HeaderByNumber(context.Background(), rpc.BlockNumber(number))
+	return header
+}
+
+func (r *headerReader) GetHeaderByHash(hash common.Hash) *types.Header {
+	header, _ := r.backend.HeaderByHash(context.Background(), hash)
+	return header
+}
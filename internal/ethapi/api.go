@@ -20,7 +20,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"github.com/ethereum/go-ethereum/core/systemcontracts"
 	"math/big"
 	"strings"
 	"time"
@@ -157,12 +156,12 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 		"queued":  make(map[string]map[string]*RPCTransaction),
 	}
 	pending, queue := s.b.TxPoolContent()
-	curHeader := s.b.CurrentHeader()
+	baseFee := pendingBaseFee(s.b)
 	// Flatten the pending transactions
 	for account, txs := range pending {
 		dump := make(map[string]*RPCTransaction)
 		for _, tx := range txs {
-			dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx, curHeader, s.b.ChainConfig())
+			dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx, baseFee)
 		}
 		content["pending"][account.Hex()] = dump
 	}
@@ -170,7 +169,7 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 	for account, txs := range queue {
 		dump := make(map[string]*RPCTransaction)
 		for _, tx := range txs {
-			dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx, curHeader, s.b.ChainConfig())
+			dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx, baseFee)
 		}
 		content["queued"][account.Hex()] = dump
 	}
@@ -181,19 +180,19 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 func (s *PublicTxPoolAPI) ContentFrom(addr common.Address) map[string]map[string]*RPCTransaction {
 	content := make(map[string]map[string]*RPCTransaction, 2)
 	pending, queue := s.b.TxPoolContentFrom(addr)
-	curHeader := s.b.CurrentHeader()
+	baseFee := pendingBaseFee(s.b)
 
 	// Build the pending transactions
 	dump := make(map[string]*RPCTransaction, len(pending))
 	for _, tx := range pending {
-		dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx, curHeader, s.b.ChainConfig())
+		dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx, baseFee)
 	}
 	content["pending"] = dump
 
 	// Build the queued transactions
 	dump = make(map[string]*RPCTransaction, len(queue))
 	for _, tx := range queue {
-		dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx, curHeader, s.b.ChainConfig())
+		dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx, baseFee)
 	}
 	content["queued"] = dump
 
@@ -701,6 +700,38 @@ func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Addre
 	}, state.Error()
 }
 
+// BaseFeeParamsResult is the result of a eth_getBaseFeeParams call.
+type BaseFeeParamsResult struct {
+	MinBaseFee               *hexutil.Big   `json:"minBaseFee"`
+	ElasticityMultiplier     hexutil.Uint64 `json:"elasticityMultiplier"`
+	BaseFeeChangeDenominator hexutil.Uint64 `json:"baseFeeChangeDenominator"`
+	NextBaseFee              *hexutil.Big   `json:"nextBaseFee,omitempty"`
+}
+
+// GetBaseFeeParams returns the base fee parameters in effect on top of the given
+// block, as governed on-chain or scheduled by the chain config, along with the
+// base fee of the block following it.
+func (s *PublicBlockChainAPI) GetBaseFeeParams(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*BaseFeeParamsResult, error) {
+	header, err := s.b.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if header == nil || err != nil {
+		return nil, err
+	}
+	config := s.b.ChainConfig()
+	feeParams, err := misc.GetBaseFeeParams(config, s.b.Engine(), nil, header, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := &BaseFeeParamsResult{
+		MinBaseFee:               (*hexutil.Big)(feeParams.MinBaseFee),
+		ElasticityMultiplier:     hexutil.Uint64(feeParams.ElasticityMultiplier),
+		BaseFeeChangeDenominator: hexutil.Uint64(feeParams.BaseFeeChangeDenominator),
+	}
+	if config.IsLondon(new(big.Int).Add(header.Number, common.Big1)) {
+		result.NextBaseFee = (*hexutil.Big)(misc.CalcBaseFeeWithParams(config, feeParams, header))
+	}
+	return result, nil
+}
+
 // GetHeaderByNumber returns the requested canonical block header.
 // * When blockNr is -1 the chain head is returned.
 // * When blockNr is -2 the pending chain head is returned.
@@ -1344,14 +1375,26 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 }
 
 // newRPCPendingTransaction returns a pending transaction that will serialize to the RPC representation
-func newRPCPendingTransaction(tx *types.Transaction, current *types.Header, config *params.ChainConfig) *RPCTransaction {
-	var baseFee *big.Int
-	if current != nil {
-		baseFee = misc.CalcBaseFee(config, current)
-	}
+func newRPCPendingTransaction(tx *types.Transaction, baseFee *big.Int) *RPCTransaction {
 	return newRPCTransaction(tx, common.Hash{}, 0, 0, baseFee)
 }
 
+// pendingBaseFee returns the base fee of the block on top of the current head,
+// derived from the base fee parameters in effect, or nil if they can't be
+// retrieved.
+func pendingBaseFee(b Backend) *big.Int {
+	current := b.CurrentHeader()
+	if current == nil {
+		return nil
+	}
+	feeParams, err := misc.GetBaseFeeParams(b.ChainConfig(), b.Engine(), nil, current, nil)
+	if err != nil {
+		log.Debug("Failed to retrieve base fee parameters", "number", current.Number, "err", err)
+		return nil
+	}
+	return misc.CalcBaseFeeWithParams(b.ChainConfig(), feeParams, current)
+}
+
 // newRPCTransactionFromBlockIndex returns a transaction that will serialize to the RPC representation.
 func newRPCTransactionFromBlockIndex(b *types.Block, index uint64) *RPCTransaction {
 	txs := b.Transactions()
@@ -1580,7 +1623,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionByHash(ctx context.Context, has
 	}
 	// No finalized transaction, try to retrieve it from the pool
 	if tx := s.b.GetPoolTransaction(hash); tx != nil {
-		return newRPCPendingTransaction(tx, s.b.CurrentHeader(), s.b.ChainConfig()), nil
+		return newRPCPendingTransaction(tx, pendingBaseFee(s.b)), nil
 	}
 
 	// Transaction unknown, return as such
@@ -1685,23 +1728,22 @@ func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (c
 	if err := checkTxFee(tx.GasPrice(), tx.Gas(), b.RPCTxFeeCap()); err != nil {
 		return common.Hash{}, err
 	}
-	if tx.Value().Sign() > 0 && !tridentCanTransfer(ctx, b, tx) {
-		return common.Hash{}, errors.New("the transaction was rejected because of trident")
-	}
 	if !b.UnprotectedAllowed() && !tx.Protected() {
 		// Ensure only eip155 signed transactions are submitted if EIP155Required is set.
 		return common.Hash{}, errors.New("only replay-protected (EIP-155) transactions allowed over RPC")
 	}
-	if err := b.SendTx(ctx, tx); err != nil {
-		return common.Hash{}, err
-	}
-	// Print a log with full tx details for manual investigations and interventions
 	signer := types.MakeSigner(b.ChainConfig(), b.CurrentBlock().Number())
 	from, err := types.Sender(signer, tx)
 	if err != nil {
 		return common.Hash{}, err
 	}
-
+	if err := checkTransferPolicy(ctx, b, from, tx); err != nil {
+		return common.Hash{}, err
+	}
+	if err := b.SendTx(ctx, tx); err != nil {
+		return common.Hash{}, err
+	}
+	// Print a log with full tx details for manual investigations and interventions
 	if tx.To() == nil {
 		addr := crypto.CreateAddress(from, tx.Nonce())
 		log.Info("Submitted contract creation", "hash", tx.Hash().Hex(), "from", from, "nonce", tx.Nonce(), "contract", addr.Hex(), "value", tx.Value())
@@ -1846,12 +1888,12 @@ func (s *PublicTransactionPoolAPI) PendingTransactions() ([]*RPCTransaction, err
 			accounts[account.Address] = struct{}{}
 		}
 	}
-	curHeader := s.b.CurrentHeader()
+	baseFee := pendingBaseFee(s.b)
 	transactions := make([]*RPCTransaction, 0, len(pending))
 	for _, tx := range pending {
 		from, _ := types.Sender(s.signer, tx)
 		if _, exists := accounts[from]; exists {
-			transactions = append(transactions, newRPCPendingTransaction(tx, curHeader, s.b.ChainConfig()))
+			transactions = append(transactions, newRPCPendingTransaction(tx, baseFee))
 		}
 	}
 	return transactions, nil
@@ -2081,32 +2123,13 @@ func toHexSlice(b [][]byte) []string {
 	return r
 }
 
-func tridentCanTransfer(ctx context.Context, b Backend, tx *types.Transaction) bool {
-	blockNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
-	state, header, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+// checkTransferPolicy returns an error if the transfer policy of the chain
+// forbids the value transfer of the transaction on top of the latest state.
+func checkTransferPolicy(ctx context.Context, b Backend, from common.Address, tx *types.Transaction) error {
+	state, header, err := b.StateAndHeaderByNumberOrHash(ctx, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber))
 	if state == nil || err != nil {
-		return true
-	}
-
-	trident := b.ChainConfig().IsTrident(header.Number)
-	if trident {
-		return true
-	}
-
-	if tx.To() == nil || tx.To().String() == "" {
-		return false
-	}
-
-	if _, ok := systemcontracts.SystemContractAddress[*tx.To()]; ok {
-		return true
-	}
-
-	var emptyCodeHash = crypto.Keccak256Hash(nil)
-
-	contractHash := state.GetCodeHash(*tx.To())
-	if contractHash != (common.Hash{}) && contractHash != emptyCodeHash {
-		return false
+		return nil
 	}
-
-	return true
+	next := new(big.Int).Add(header.Number, common.Big1)
+	return core.CheckTransferPolicy(b.ChainConfig(), next, state, from, tx.To(), tx.Value())
 }
@@ -0,0 +1,257 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// callBundleTimeout is the time a bundle simulation may run for before it's
+// aborted.
+const callBundleTimeout = 5 * time.Second
+
+// PublicBundleAPI provides an API to submit and simulate transaction bundles,
+// ordered sets of transactions included at the head of a block together or not
+// at all.
+type PublicBundleAPI struct {
+	b Backend
+}
+
+// NewPublicBundleAPI creates a new transaction bundle API.
+func NewPublicBundleAPI(b Backend) *PublicBundleAPI {
+	return &PublicBundleAPI{b}
+}
+
+// SendBundleArgs represents the arguments to submit a transaction bundle.
+type SendBundleArgs struct {
+	Txs               []hexutil.Bytes `json:"txs"`
+	BlockNumber       rpc.BlockNumber `json:"blockNumber"`
+	RevertingTxHashes []common.Hash   `json:"revertingTxHashes"`
+}
+
+// CallBundleArgs represents the arguments to simulate a transaction bundle.
+type CallBundleArgs struct {
+	Txs              []hexutil.Bytes        `json:"txs"`
+	BlockNumber      rpc.BlockNumber        `json:"blockNumber"`
+	StateBlockNumber *rpc.BlockNumberOrHash `json:"stateBlockNumber"`
+	Timestamp        *hexutil.Uint64        `json:"timestamp"`
+}
+
+// CallBundleTxResult is the outcome of a single simulated bundle transaction.
+type CallBundleTxResult struct {
+	TxHash       common.Hash     `json:"txHash"`
+	From         common.Address  `json:"from"`
+	To           *common.Address `json:"to"`
+	GasUsed      hexutil.Uint64  `json:"gasUsed"`
+	EffectiveTip *hexutil.Big    `json:"effectiveTip"`
+	ReturnValue  hexutil.Bytes   `json:"returnValue,omitempty"`
+	Error        string          `json:"error,omitempty"`
+}
+
+// CallBundleResult is the outcome of a simulated bundle.
+type CallBundleResult struct {
+	BundleHash       common.Hash           `json:"bundleHash"`
+	StateBlockNumber hexutil.Uint64        `json:"stateBlockNumber"`
+	GasUsed          hexutil.Uint64        `json:"gasUsed"`
+	GasFees          *hexutil.Big          `json:"gasFees"`
+	EffectiveTip     *hexutil.Big          `json:"effectiveTip"`
+	Results          []*CallBundleTxResult `json:"results"`
+}
+
+// decodeBundleTxs decodes the raw signed transactions of a bundle.
+func decodeBundleTxs(b Backend, inputs []hexutil.Bytes) (types.Transactions, error) {
+	if len(inputs) == 0 {
+		return nil, core.ErrEmptyBundle
+	}
+	txs := make(types.Transactions, 0, len(inputs))
+	for i, input := range inputs {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(input); err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		if err := checkTxFee(tx.GasPrice(), tx.Gas(), b.RPCTxFeeCap()); err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		if !b.UnprotectedAllowed() && !tx.Protected() {
+			return nil, fmt.Errorf("transaction %d: only replay-protected (EIP-155) transactions allowed over RPC", i)
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+// SendBundle submits a bundle of signed transactions for the local miner to
+// include in the target block, all of them in the given order or none of them.
+// Transactions listed as reverting may fail without voiding the bundle. The
+// hash identifying the bundle is returned.
+func (s *PublicBundleAPI) SendBundle(ctx context.Context, args SendBundleArgs) (common.Hash, error) {
+	if args.BlockNumber <= 0 {
+		return common.Hash{}, errors.New("bundle target block missing")
+	}
+	txs, err := decodeBundleTxs(s.b, args.Txs)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	bundle := &types.Bundle{
+		Txs:               txs,
+		BlockNumber:       big.NewInt(args.BlockNumber.Int64()),
+		RevertingTxHashes: args.RevertingTxHashes,
+	}
+	if err := s.b.SendBundle(ctx, bundle); err != nil {
+		return common.Hash{}, err
+	}
+	log.Info("Submitted transaction bundle", "hash", bundle.Hash(), "txs", len(txs), "number", bundle.BlockNumber)
+	return bundle.Hash(), nil
+}
+
+// CallBundle simulates a bundle of signed transactions as the head of the target
+// block on top of the given state, the latest one if none is specified, and
+// reports the outcome and the tips paid by each of them.
+func (s *PublicBundleAPI) CallBundle(ctx context.Context, args CallBundleArgs) (*CallBundleResult, error) {
+	txs, err := decodeBundleTxs(s.b, args.Txs)
+	if err != nil {
+		return nil, err
+	}
+	stateBlockNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	if args.StateBlockNumber != nil {
+		stateBlockNrOrHash = *args.StateBlockNumber
+	}
+	state, parent, err := s.b.StateAndHeaderByNumberOrHash(ctx, stateBlockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	number := new(big.Int).Add(parent.Number, common.Big1)
+	if args.BlockNumber > 0 {
+		number = big.NewInt(args.BlockNumber.Int64())
+	}
+	if number.Cmp(parent.Number) <= 0 {
+		return nil, fmt.Errorf("bundle target block %d not after state block %d", number, parent.Number)
+	}
+	timestamp := parent.Time + 1
+	if args.Timestamp != nil {
+		timestamp = uint64(*args.Timestamp)
+	}
+	config := s.b.ChainConfig()
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Coinbase:   parent.Coinbase,
+		Difficulty: parent.Difficulty,
+		Number:     number,
+		GasLimit:   parent.GasLimit,
+		Time:       timestamp,
+	}
+	if config.IsLondon(number) {
+		feeParams, err := misc.GetBaseFeeParams(config, s.b.Engine(), nil, parent, nil)
+		if err != nil {
+			return nil, err
+		}
+		header.BaseFee = misc.CalcBaseFeeWithParams(config, feeParams, parent)
+	}
+	ctx, cancel := context.WithTimeout(ctx, callBundleTimeout)
+	defer cancel()
+
+	var (
+		signer = types.MakeSigner(config, number)
+		gp     = new(core.GasPool).AddGas(header.GasLimit)
+		result = &CallBundleResult{
+			BundleHash:       (&types.Bundle{Txs: txs}).Hash(),
+			StateBlockNumber: hexutil.Uint64(parent.Number.Uint64()),
+			Results:          make([]*CallBundleTxResult, 0, len(txs)),
+		}
+		fees = new(big.Int)
+		msgs = make([]types.Message, len(txs))
+	)
+	for i, tx := range txs {
+		if msgs[i], err = tx.AsMessage(signer, header.BaseFee); err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+	}
+	// Run the whole bundle in a single EVM, reset for every transaction
+	evm, vmError, err := s.b.GetEVM(ctx, msgs[0], state, header, &vm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	// Wait for the context to be done and cancel the evm. Even if the
+	// EVM has finished, cancelling may be done (repeatedly)
+	go func() {
+		<-ctx.Done()
+		evm.Cancel()
+	}()
+	for i, tx := range txs {
+		msg := msgs[i]
+
+		state.Prepare(tx.Hash(), i)
+		evm.Reset(core.NewEVMTxContext(msg), state)
+
+		res, err := core.ApplyMessage(evm, msg, gp)
+		if err := vmError(); err != nil {
+			return nil, err
+		}
+		if evm.Cancelled() {
+			return nil, fmt.Errorf("execution aborted (timeout = %v)", callBundleTimeout)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		if config.IsByzantium(number) {
+			state.Finalise(true)
+		} else {
+			state.IntermediateRoot(config.IsEIP158(number))
+		}
+		tip, err := tx.EffectiveGasTip(header.BaseFee)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		txResult := &CallBundleTxResult{
+			TxHash:       tx.Hash(),
+			From:         msg.From(),
+			To:           tx.To(),
+			GasUsed:      hexutil.Uint64(res.UsedGas),
+			EffectiveTip: (*hexutil.Big)(tip),
+		}
+		if res.Failed() {
+			txResult.Error = res.Err.Error()
+			if len(res.Revert()) > 0 {
+				txResult.Error = newRevertError(res).Error()
+			}
+		} else {
+			txResult.ReturnValue = res.Return()
+		}
+		result.Results = append(result.Results, txResult)
+		result.GasUsed += hexutil.Uint64(res.UsedGas)
+		fees.Add(fees, new(big.Int).Mul(tip, new(big.Int).SetUint64(res.UsedGas)))
+	}
+	result.GasFees = (*hexutil.Big)(fees)
+	result.EffectiveTip = (*hexutil.Big)(new(big.Int))
+	if result.GasUsed > 0 {
+		result.EffectiveTip = (*hexutil.Big)(new(big.Int).Div(fees, new(big.Int).SetUint64(uint64(result.GasUsed))))
+	}
+	return result, nil
+}
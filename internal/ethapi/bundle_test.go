@@ -0,0 +1,278 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	bundleKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	bundleAddr    = crypto.PubkeyToAddress(bundleKey.PublicKey)
+	bundleRecv    = common.HexToAddress("0x000000000000000000000000000000000000beef")
+	bundleReverts = common.HexToAddress("0x000000000000000000000000000000000000dead")
+	bundleBaseFee = big.NewInt(params.InitialBaseFee)
+
+	// bundleRevertCode reverts with the standard error "boom".
+	bundleRevertCode = common.FromHex("0x6064600c60003960646000fd" +
+		"08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000004" +
+		"626f6f6d00000000000000000000000000000000000000000000000000000000")
+)
+
+// testBundleBackend is a Backend implementing the methods the bundle API needs,
+// the rest of them panic.
+type testBundleBackend struct {
+	Backend
+
+	parent  *types.Header
+	db      state.Database
+	root    common.Hash
+	bundles []*types.Bundle
+	err     error
+}
+
+func newTestBundleBackend(t *testing.T) *testBundleBackend {
+	db := state.NewDatabase(rawdb.NewMemoryDatabase())
+	statedb, _ := state.New(common.Hash{}, db, nil)
+	statedb.AddBalance(bundleAddr, big.NewInt(params.Ether))
+	statedb.SetCode(bundleReverts, bundleRevertCode)
+
+	root, err := statedb.Commit(true)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := db.TrieDB().Commit(root, false, nil); err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	return &testBundleBackend{
+		parent: &types.Header{
+			Number:   big.NewInt(10),
+			GasLimit: 30_000_000,
+			GasUsed:  15_000_000, // Hit the target, keeping the base fee
+			BaseFee:  bundleBaseFee,
+			Time:     100,
+			Root:     root,
+		},
+		db:   db,
+		root: root,
+	}
+}
+
+func (b *testBundleBackend) RPCTxFeeCap() float64             { return 1 }
+func (b *testBundleBackend) UnprotectedAllowed() bool         { return false }
+func (b *testBundleBackend) ChainConfig() *params.ChainConfig { return params.TestChainConfig }
+func (b *testBundleBackend) Engine() consensus.Engine         { return nil }
+
+func (b *testBundleBackend) SendBundle(ctx context.Context, bundle *types.Bundle) error {
+	if b.err != nil {
+		return b.err
+	}
+	b.bundles = append(b.bundles, bundle)
+	return nil
+}
+
+func (b *testBundleBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	if number, ok := blockNrOrHash.Number(); ok && number != rpc.LatestBlockNumber && number.Int64() != b.parent.Number.Int64() {
+		return nil, nil, errors.New("header not found")
+	}
+	statedb, err := state.New(b.root, b.db, nil)
+	return statedb, b.parent, err
+}
+
+func (b *testBundleBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmConfig *vm.Config) (*vm.EVM, func() error, error) {
+	context := core.NewEVMBlockContext(header, nil, &header.Coinbase)
+	return vm.NewEVM(context, core.NewEVMTxContext(msg), state, params.TestChainConfig, *vmConfig), func() error { return nil }, nil
+}
+
+// signBundleTx signs a legacy transaction of the bundle account and returns its
+// hash and binary encoding.
+func signBundleTx(t *testing.T, signer types.Signer, nonce uint64, to common.Address, gas uint64, gasPrice *big.Int) (common.Hash, hexutil.Bytes) {
+	tx, err := types.SignNewTx(bundleKey, signer, &types.LegacyTx{
+		Nonce:    nonce,
+		To:       &to,
+		Value:    common.Big1,
+		Gas:      gas,
+		GasPrice: gasPrice,
+	})
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	blob, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to encode transaction: %v", err)
+	}
+	return tx.Hash(), blob
+}
+
+// Tests that bundles are decoded, checked against the RPC limits and handed to
+// the backend for their target block.
+func TestSendBundle(t *testing.T) {
+	var (
+		backend  = newTestBundleBackend(t)
+		api      = NewPublicBundleAPI(backend)
+		signer   = types.LatestSigner(params.TestChainConfig)
+		price    = new(big.Int).Mul(bundleBaseFee, common.Big2)
+		hash0, a = signBundleTx(t, signer, 0, bundleRecv, 21000, price)
+		hash1, b = signBundleTx(t, signer, 1, bundleRecv, 21000, price)
+		_, huge  = signBundleTx(t, signer, 0, bundleRecv, 21000, big.NewInt(params.Ether))
+		_, plain = signBundleTx(t, types.HomesteadSigner{}, 0, bundleRecv, 21000, price)
+	)
+	invalid := []struct {
+		name string
+		args SendBundleArgs
+		err  string
+	}{
+		{"empty", SendBundleArgs{BlockNumber: 11}, core.ErrEmptyBundle.Error()},
+		{"garbage", SendBundleArgs{Txs: []hexutil.Bytes{a, {0x01, 0x02}}, BlockNumber: 11}, "transaction 1"},
+		{"no target", SendBundleArgs{Txs: []hexutil.Bytes{a}}, "bundle target block missing"},
+		{"pending target", SendBundleArgs{Txs: []hexutil.Bytes{a}, BlockNumber: rpc.PendingBlockNumber}, "bundle target block missing"},
+		{"fee cap", SendBundleArgs{Txs: []hexutil.Bytes{a, huge}, BlockNumber: 11}, "exceeds the configured cap"},
+		{"unprotected", SendBundleArgs{Txs: []hexutil.Bytes{plain}, BlockNumber: 11}, "only replay-protected"},
+	}
+	for _, tt := range invalid {
+		if _, err := api.SendBundle(context.Background(), tt.args); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error mismatch: have %v, want %q", tt.name, err, tt.err)
+		}
+	}
+	if len(backend.bundles) != 0 {
+		t.Fatalf("invalid bundles submitted: %d", len(backend.bundles))
+	}
+	// Valid bundles are handed over in order, along with the revertible hashes
+	hash, err := api.SendBundle(context.Background(), SendBundleArgs{
+		Txs:               []hexutil.Bytes{a, b},
+		BlockNumber:       12,
+		RevertingTxHashes: []common.Hash{hash1},
+	})
+	if err != nil {
+		t.Fatalf("failed to send bundle: %v", err)
+	}
+	if len(backend.bundles) != 1 {
+		t.Fatalf("submitted bundle count mismatch: have %d, want 1", len(backend.bundles))
+	}
+	bundle := backend.bundles[0]
+	if bundle.Hash() != hash {
+		t.Errorf("bundle hash mismatch: have %x, want %x", hash, bundle.Hash())
+	}
+	if len(bundle.Txs) != 2 || bundle.Txs[0].Hash() != hash0 || bundle.Txs[1].Hash() != hash1 {
+		t.Errorf("bundle transactions mismatch")
+	}
+	if bundle.BlockNumber.Int64() != 12 {
+		t.Errorf("bundle target mismatch: have %v, want 12", bundle.BlockNumber)
+	}
+	if bundle.MayRevert(hash0) || !bundle.MayRevert(hash1) {
+		t.Errorf("bundle reverting transactions mismatch")
+	}
+	// Bundles rejected by the pool, e.g. for a mined target block, are reported
+	backend.err = core.ErrBundleTooLate
+	if _, err := api.SendBundle(context.Background(), SendBundleArgs{Txs: []hexutil.Bytes{a}, BlockNumber: 10}); !errors.Is(err, core.ErrBundleTooLate) {
+		t.Errorf("mined target error mismatch: have %v, want %v", err, core.ErrBundleTooLate)
+	}
+}
+
+// Tests that bundles are simulated on top of the requested state, reporting the
+// reverted transactions without aborting the rest of the bundle.
+func TestCallBundle(t *testing.T) {
+	var (
+		backend = newTestBundleBackend(t)
+		api     = NewPublicBundleAPI(backend)
+		signer  = types.LatestSigner(params.TestChainConfig)
+		tip     = big.NewInt(params.GWei)
+		price   = new(big.Int).Add(bundleBaseFee, tip)
+		_, a    = signBundleTx(t, signer, 0, bundleRecv, 21000, price)
+		_, b    = signBundleTx(t, signer, 1, bundleReverts, 100000, price)
+		_, c    = signBundleTx(t, signer, 2, bundleRecv, 21000, price)
+		_, gap  = signBundleTx(t, signer, 5, bundleRecv, 21000, price)
+		_, huge = signBundleTx(t, signer, 0, bundleRecv, 21000, big.NewInt(params.Ether))
+	)
+	invalid := []struct {
+		name string
+		args CallBundleArgs
+		err  string
+	}{
+		{"empty", CallBundleArgs{}, core.ErrEmptyBundle.Error()},
+		{"garbage", CallBundleArgs{Txs: []hexutil.Bytes{{0xff}}}, "transaction 0"},
+		{"fee cap", CallBundleArgs{Txs: []hexutil.Bytes{huge}}, "exceeds the configured cap"},
+		{"past target", CallBundleArgs{Txs: []hexutil.Bytes{a}, BlockNumber: 10}, "not after state block"},
+		{"nonce gap", CallBundleArgs{Txs: []hexutil.Bytes{a, gap}}, "transaction 1"},
+	}
+	for _, tt := range invalid {
+		if _, err := api.CallBundle(context.Background(), tt.args); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error mismatch: have %v, want %q", tt.name, err, tt.err)
+		}
+	}
+	// A reverting transaction is reported, the rest of the bundle still executes
+	result, err := api.CallBundle(context.Background(), CallBundleArgs{Txs: []hexutil.Bytes{a, b, c}, BlockNumber: 12})
+	if err != nil {
+		t.Fatalf("failed to call bundle: %v", err)
+	}
+	if len(result.Results) != 3 {
+		t.Fatalf("result count mismatch: have %d, want 3", len(result.Results))
+	}
+	if uint64(result.StateBlockNumber) != 10 {
+		t.Errorf("state block mismatch: have %d, want 10", result.StateBlockNumber)
+	}
+	for i, res := range result.Results {
+		if res.From != bundleAddr {
+			t.Errorf("transaction %d: sender mismatch: have %x, want %x", i, res.From, bundleAddr)
+		}
+		if (*big.Int)(res.EffectiveTip).Cmp(tip) != 0 {
+			t.Errorf("transaction %d: tip mismatch: have %v, want %v", i, res.EffectiveTip, tip)
+		}
+	}
+	if result.Results[0].Error != "" || result.Results[2].Error != "" {
+		t.Errorf("transfers failed: %q, %q", result.Results[0].Error, result.Results[2].Error)
+	}
+	if want := "execution reverted: boom"; result.Results[1].Error != want {
+		t.Errorf("revert error mismatch: have %q, want %q", result.Results[1].Error, want)
+	}
+	var gas uint64
+	for _, res := range result.Results {
+		gas += uint64(res.GasUsed)
+	}
+	if uint64(result.GasUsed) != gas {
+		t.Errorf("bundle gas mismatch: have %d, want %d", result.GasUsed, gas)
+	}
+	if fees := new(big.Int).Mul(tip, new(big.Int).SetUint64(gas)); (*big.Int)(result.GasFees).Cmp(fees) != 0 {
+		t.Errorf("bundle fees mismatch: have %v, want %v", result.GasFees, fees)
+	}
+	if (*big.Int)(result.EffectiveTip).Cmp(tip) != 0 {
+		t.Errorf("bundle tip mismatch: have %v, want %v", result.EffectiveTip, tip)
+	}
+	// Simulating on an unknown state fails
+	unknown := rpc.BlockNumberOrHashWithNumber(5)
+	if _, err := api.CallBundle(context.Background(), CallBundleArgs{Txs: []hexutil.Bytes{a}, StateBlockNumber: &unknown}); err == nil {
+		t.Errorf("bundle simulated on unknown state")
+	}
+}
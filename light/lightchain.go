@@ -424,6 +424,21 @@ func (lc *LightChain) CurrentHeader() *types.Header {
 	return lc.hc.CurrentHeader()
 }
 
+// CurrentFinalizedHeader retrieves the latest header finalized by the consensus
+// engine, or nil if none was finalized yet.
+func (lc *LightChain) CurrentFinalizedHeader() *types.Header {
+	return lc.hc.CurrentFinalizedHeader()
+}
+
+// SetFinalized marks the given canonical header as final. The chain will refuse
+// to reorg past it from then on.
+func (lc *LightChain) SetFinalized(header *types.Header) error {
+	lc.chainmu.Lock()
+	defer lc.chainmu.Unlock()
+
+	return lc.hc.SetFinalized(header)
+}
+
 // GetTd retrieves a block's total difficulty in the canonical chain from the
 // database by hash and number, caching it if found.
 func (lc *LightChain) GetTd(hash common.Hash, number uint64) *big.Int {
@@ -51,6 +51,8 @@ type Config struct {
 	GasPrice   *big.Int       // Minimum gas price for mining a transaction
 	Recommit   time.Duration  // The time interval for miner to re-create mining work.
 	Noverify   bool           // Disable remote mining solution verification(only useful in ethash).
+	RemoteSeal bool           // Leave the vrf proof and seal of the blocks to a remote signer (only useful in poseidon).
+	SlashPeers bool           // Slash the overdue committee members instead of the local validator (only useful in poseidon).
 }
 
 // Miner creates blocks and searches for proof-of-work values.
@@ -19,8 +19,10 @@ package miner
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"github.com/ethereum/go-ethereum/consensus/poseidon"
 	"math/big"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -31,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
@@ -141,6 +144,8 @@ type worker struct {
 	chainHeadSub event.Subscription
 	chainSideCh  chan core.ChainSideEvent
 	chainSideSub event.Subscription
+	nonceCh      chan poseidon.NonceEvent
+	nonceSub     event.Subscription
 
 	// Channels
 	newWorkCh          chan *newWorkReq
@@ -222,6 +227,11 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 	// Subscribe events for blockchain
 	worker.chainHeadSub = eth.BlockChain().SubscribeChainHeadEvent(worker.chainHeadCh)
 	worker.chainSideSub = eth.BlockChain().SubscribeChainSideEvent(worker.chainSideCh)
+	// Subscribe the nonces selected by remote poseidon signers
+	if p, ok := engine.(*poseidon.Poseidon); ok {
+		worker.nonceCh = make(chan poseidon.NonceEvent, chainHeadChanSize)
+		worker.nonceSub = p.SubscribeNonceEvent(worker.nonceCh)
+	}
 
 	// Sanitize recommit interval if the user-specified one is too short.
 	recommit := worker.config.Recommit
@@ -414,6 +424,13 @@ func (w *worker) newWorkLoop(recommit time.Duration) {
 				commit(true, commitInterruptResubmit)
 			}
 
+		case ev := <-w.nonceCh:
+			// Reassemble the block in the slot of the nonce selected by the remote
+			// signer, unless the chain moved on already
+			if w.isRunning() && ev.ParentHash == w.chain.CurrentBlock().Hash() {
+				commit(false, commitInterruptResubmit)
+			}
+
 		case interval := <-w.resubmitIntervalCh:
 			// Adjust resubmit interval explicitly by user.
 			if interval < minRecommitInterval {
@@ -455,6 +472,9 @@ func (w *worker) mainLoop() {
 	defer w.txsSub.Unsubscribe()
 	defer w.chainHeadSub.Unsubscribe()
 	defer w.chainSideSub.Unsubscribe()
+	if w.nonceSub != nil {
+		defer w.nonceSub.Unsubscribe()
+	}
 
 	for {
 		select {
@@ -831,6 +851,14 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 			txs.Pop()
 			continue
 		}
+		// Skip the slashes and evidences the consensus engine wouldn't accept in this block
+		if posa, ok := w.engine.(consensus.PoSA); ok {
+			if err := w.verifySystemTransaction(posa, tx); err != nil {
+				log.Trace("Skipping invalid system transaction", "hash", tx.Hash(), "err", err)
+				txs.Pop()
+				continue
+			}
+		}
 		// Start executing the transaction
 		w.current.state.Prepare(tx.Hash(), w.current.tcount)
 
@@ -893,6 +921,159 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 	return false
 }
 
+// simulatedBundle is a transaction bundle along with the result of executing it
+// on top of the pending state.
+type simulatedBundle struct {
+	bundle  *types.Bundle
+	gasUsed uint64   // Gas used by the bundle transactions
+	fees    *big.Int // Tips paid to the block producer by the bundle transactions
+}
+
+// effectiveTipCmp compares the tip per gas unit paid by the two bundles.
+func (b *simulatedBundle) effectiveTipCmp(other *simulatedBundle) int {
+	return new(big.Int).Mul(b.fees, new(big.Int).SetUint64(other.gasUsed)).Cmp(new(big.Int).Mul(other.fees, new(big.Int).SetUint64(b.gasUsed)))
+}
+
+// checkBundle returns an error if the bundle carries transactions the consensus
+// engine wouldn't accept in the current block.
+func (w *worker) checkBundle(bundle *types.Bundle) error {
+	posa, ok := w.engine.(consensus.PoSA)
+	if !ok {
+		return nil
+	}
+	hub := systemcontracts.ValidatorHub(w.chainConfig, w.current.header.Number)
+	for _, tx := range bundle.Txs {
+		if systemcontracts.IsSyncHeaderTransition(hub, tx.To(), tx.Data()) {
+			return errors.New("bundled header sync")
+		}
+		if err := w.verifySystemTransaction(posa, tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifySystemTransaction returns an error if the transaction is a slash or a
+// double-sign evidence the consensus engine wouldn't accept in the current block.
+func (w *worker) verifySystemTransaction(posa consensus.PoSA, tx *types.Transaction) error {
+	header := w.current.header
+	hub := systemcontracts.ValidatorHub(w.chainConfig, header.Number)
+	switch {
+	case systemcontracts.IsSlashTransition(hub, tx.To(), tx.Data()):
+		return posa.VerifySlash(header, w.current.txs, tx)
+	case w.chainConfig.Poseidon != nil && w.chainConfig.Poseidon.IsEvidence(header.Number) && systemcontracts.IsDoubleSignTransition(hub, tx.To(), tx.Data()):
+		return posa.VerifyEvidence(header, w.current.txs, tx)
+	}
+	return nil
+}
+
+// simulateBundle executes the bundle on top of a copy of the pending state and
+// returns the gas it used and the tips it paid, or an error if any of its
+// transactions failed or reverted without being allowed to.
+func (w *worker) simulateBundle(bundle *types.Bundle, coinbase common.Address) (*simulatedBundle, error) {
+	if err := w.checkBundle(bundle); err != nil {
+		return nil, err
+	}
+	var (
+		statedb  = w.current.state.Copy()
+		gasPool  = new(core.GasPool).AddGas(w.current.gasPool.Gas())
+		header   = types.CopyHeader(w.current.header)
+		receipts = make([]*types.Receipt, 0, len(bundle.Txs))
+	)
+	for i, tx := range bundle.Txs {
+		statedb.Prepare(tx.Hash(), w.current.tcount+i)
+
+		receipt, err := core.ApplyTransaction(w.chainConfig, w.chain, &coinbase, gasPool, statedb, header, tx, &header.GasUsed, *w.chain.GetVMConfig())
+		if err != nil {
+			return nil, err
+		}
+		if receipt.Status == types.ReceiptStatusFailed && !bundle.MayRevert(tx.Hash()) {
+			return nil, fmt.Errorf("transaction %v reverted", tx.Hash())
+		}
+		receipts = append(receipts, receipt)
+	}
+	return &simulatedBundle{
+		bundle:  bundle,
+		gasUsed: header.GasUsed - w.current.header.GasUsed,
+		fees:    poseidon.TotalFees(header, bundle.Txs, receipts),
+	}, nil
+}
+
+// commitBundle commits all transactions of the bundle to the pending block, or
+// none of them if any failed or reverted without being allowed to.
+func (w *worker) commitBundle(bundle *types.Bundle, coinbase common.Address) error {
+	if err := w.checkBundle(bundle); err != nil {
+		return err
+	}
+	var (
+		snap     = w.current.state.Snapshot()
+		gas      = w.current.gasPool.Gas()
+		gasUsed  = w.current.header.GasUsed
+		txs      = len(w.current.txs)
+		receipts = len(w.current.receipts)
+		tcount   = w.current.tcount
+	)
+	rollback := func() {
+		w.current.state.RevertToSnapshot(snap)
+		*w.current.gasPool = core.GasPool(gas)
+		w.current.header.GasUsed = gasUsed
+		w.current.txs = w.current.txs[:txs]
+		w.current.receipts = w.current.receipts[:receipts]
+		w.current.tcount = tcount
+	}
+	for _, tx := range bundle.Txs {
+		w.current.state.Prepare(tx.Hash(), w.current.tcount)
+
+		if _, err := w.commitTransaction(tx, coinbase); err != nil {
+			rollback()
+			return err
+		}
+		if receipt := w.current.receipts[len(w.current.receipts)-1]; receipt.Status == types.ReceiptStatusFailed && !bundle.MayRevert(tx.Hash()) {
+			rollback()
+			return fmt.Errorf("transaction %v reverted", tx.Hash())
+		}
+		w.current.tcount++
+	}
+	return nil
+}
+
+// commitBundles simulates the bundles targeting the pending block and commits
+// them ahead of the pool transactions, the ones paying the highest tip per gas
+// unit first. A bundle invalidated by the ones committed before it is skipped.
+func (w *worker) commitBundles(bundles []*types.Bundle, coinbase common.Address, interrupt *int32) bool {
+	// Short circuit if current is nil
+	if w.current == nil {
+		return true
+	}
+	if w.current.gasPool == nil {
+		w.current.gasPool = new(core.GasPool).AddGas(w.current.header.GasLimit)
+	}
+	simulated := make([]*simulatedBundle, 0, len(bundles))
+	for _, bundle := range bundles {
+		sim, err := w.simulateBundle(bundle, coinbase)
+		if err != nil {
+			log.Trace("Skipping failing bundle", "hash", bundle.Hash(), "err", err)
+			continue
+		}
+		simulated = append(simulated, sim)
+	}
+	sort.SliceStable(simulated, func(i, j int) bool {
+		return simulated[i].effectiveTipCmp(simulated[j]) > 0
+	})
+	for _, sim := range simulated {
+		// Bail out on new heads, leave the other interrupts to the pool transactions
+		if interrupt != nil && atomic.LoadInt32(interrupt) == commitInterruptNewHead {
+			return true
+		}
+		if err := w.commitBundle(sim.bundle, coinbase); err != nil {
+			log.Debug("Bundle failed, skipped", "hash", sim.bundle.Hash(), "err", err)
+			continue
+		}
+		log.Debug("Committed transaction bundle", "hash", sim.bundle.Hash(), "txs", len(sim.bundle.Txs), "gas", sim.gasUsed, "fees", sim.fees)
+	}
+	return false
+}
+
 // commitNewWork generates several new sealing tasks based on the parent block.
 func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64) {
 	w.mu.RLock()
@@ -914,9 +1095,14 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 	}
 	// Set baseFee and GasLimit if we are on an EIP-1559 chain
 	if w.chainConfig.IsLondon(header.Number) {
-		header.BaseFee = misc.CalcBaseFee(w.chainConfig, parent.Header())
+		feeParams, err := misc.GetBaseFeeParams(w.chainConfig, w.engine, w.chain, parent.Header(), nil)
+		if err != nil {
+			log.Error("Failed to retrieve base fee parameters", "err", err)
+			return
+		}
+		header.BaseFee = misc.CalcBaseFeeWithParams(w.chainConfig, feeParams, parent.Header())
 		if !w.chainConfig.IsLondon(parent.Number()) {
-			parentGasLimit := parent.GasLimit() * params.ElasticityMultiplier
+			parentGasLimit := parent.GasLimit() * feeParams.ElasticityMultiplier
 			header.GasLimit = core.CalcGasLimit(parentGasLimit, w.config.GasCeil)
 		}
 	}
@@ -933,6 +1119,9 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 		if err := spos.Heartbeat(num); err != nil {
 			log.Warn("Heartbeat failed", "err", err)
 		}
+		if err := spos.SubmitEvidence(num); err != nil {
+			log.Warn("Double-sign evidence submission failed", "err", err)
+		}
 	}
 
 	if err := w.engine.Prepare(w.chain, header); err != nil {
@@ -963,6 +1152,11 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 	if w.chainConfig.DAOForkSupport && w.chainConfig.DAOForkBlock != nil && w.chainConfig.DAOForkBlock.Cmp(header.Number) == 0 {
 		misc.ApplyDAOHardFork(env.state)
 	}
+	if err := systemcontracts.UpgradeSystemContracts(w.chainConfig, header.Number, env.state); err != nil {
+		log.Error("Failed to upgrade system contracts", "err", err)
+		return
+	}
+
 	// Accumulate the uncles for the current block
 	uncles := make([]*types.Header, 0, 2)
 	commitUncles := func(blocks map[common.Hash]*types.Block) {
@@ -988,9 +1182,14 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 	commitUncles(w.localUncles)
 	commitUncles(w.remoteUncles)
 
+	// PoSA blocks have to end with the header sync transaction, there's no
+	// empty block to seal in advance
+	spos, isPoSA := w.engine.(consensus.PoSA)
+	sync := isPoSA && w.isRunning()
+
 	// Create an empty block based on temporary copied state for
 	// sealing in advance without waiting block execution finished.
-	if !noempty && atomic.LoadUint32(&w.noempty) == 0 {
+	if !sync && !noempty && atomic.LoadUint32(&w.noempty) == 0 {
 		w.commit(uncles, nil, false, tstart)
 	}
 
@@ -1000,22 +1199,29 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 		log.Error("Failed to fetch pending transactions", "err", err)
 		return
 	}
+	bundles := w.eth.TxPool().Bundles(header.Number)
+
 	// Short circuit if there is no available pending transactions.
 	// But if we disable empty precommit already, ignore it. Since
 	// empty block is necessary to keep the liveness of the network.
-	if len(pending) == 0 && atomic.LoadUint32(&w.noempty) == 0 {
+	if !sync && len(pending) == 0 && len(bundles) == 0 && atomic.LoadUint32(&w.noempty) == 0 {
 		w.updateSnapshot()
 		return
 	}
 	delete(pending, w.coinbase) //Delete my pending transactions
 
-	spos, isPoSA := w.engine.(consensus.PoSA)
-	if isPoSA && w.isRunning() {
-		txs, err := spos.GetSystemTransaction(w.current.signer, w.current.state, w.current.header.BaseFee, big.NewInt(0))
-		if err != nil {
-			log.Warn("Failed to GetSystemTransaction", "err", err)
+	// Keep the gas of the header sync closing the block out of reach of the
+	// pool transactions
+	if sync {
+		if header.GasLimit < systemcontracts.SyncHeaderGas {
+			log.Error("Gas limit too low for header sync", "limit", header.GasLimit, "want", systemcontracts.SyncHeaderGas)
+			return
 		}
-		if err == nil && w.commitTransactions(txs, w.coinbase, new(int32)) {
+		env.gasPool = new(core.GasPool).AddGas(header.GasLimit - systemcontracts.SyncHeaderGas)
+	}
+	// Commit the transaction bundles targeting this block ahead of the pool
+	if len(bundles) > 0 {
+		if w.commitBundles(bundles, w.coinbase, interrupt) {
 			return
 		}
 	}
@@ -1039,6 +1245,22 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 			return
 		}
 	}
+	if sync {
+		// Report the fees paid by the packed transactions to the hub
+		env.gasPool.AddGas(systemcontracts.SyncHeaderGas)
+		txs, err := spos.GetSystemTransaction(env.signer, env.state, header, poseidon.TotalFees(header, env.txs, env.receipts))
+		if err != nil {
+			log.Error("Failed to create header sync transaction", "err", err)
+			return
+		}
+		if w.commitTransactions(txs, w.coinbase, nil) {
+			return
+		}
+		if n := len(env.txs); n == 0 || !systemcontracts.IsSyncHeaderTransition(systemcontracts.ValidatorHub(w.chainConfig, header.Number), env.txs[n-1].To(), env.txs[n-1].Data()) {
+			log.Error("Failed to include header sync transaction", "number", header.Number)
+			return
+		}
+	}
 	w.commit(uncles, w.fullTaskHook, true, tstart)
 }
 
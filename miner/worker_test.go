@@ -17,6 +17,8 @@
 package miner
 
 import (
+	"bytes"
+	"crypto/ecdsa"
 	"math/big"
 	"math/rand"
 	"sync/atomic"
@@ -28,8 +30,10 @@ import (
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/clique"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -519,3 +523,140 @@ func testAdjustInterval(t *testing.T, chainConfig *params.ChainConfig, engine co
 		t.Error("interval reset timeout")
 	}
 }
+
+// Tests that the system contract upgrades the worker applies at a fork block
+// lead to the same state root as importing the produced block.
+func TestSystemContractUpgrade(t *testing.T) {
+	chainConfig := *params.TestChainConfig
+	chainConfig.Clique = nil
+	chainConfig.BigBenBlock, chainConfig.ThamesBlock, chainConfig.TridentBlock = big.NewInt(2), nil, nil
+
+	var (
+		hub  = common.HexToAddress(systemcontracts.ValidatorHubContract)
+		code = common.FromHex("0x602a60025500") // sstore(2, 42)
+		slot = common.Hash{0x01}
+	)
+	defer func(upgrades []*systemcontracts.ContractUpgrade) {
+		systemcontracts.Upgrades["BigBen"] = upgrades
+	}(systemcontracts.Upgrades["BigBen"])
+
+	systemcontracts.Upgrades["BigBen"] = []*systemcontracts.ContractUpgrade{{
+		Address: hub,
+		Code:    common.Bytes2Hex(code),
+		Storage: map[common.Hash]common.Hash{slot: common.BigToHash(big.NewInt(1))},
+	}}
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	// Let the worker assemble the fork block on top of the first one
+	w, b := newTestWorker(t, &chainConfig, engine, rawdb.NewMemoryDatabase(), 1)
+	defer w.close()
+
+	w.skipSealHook = func(task *task) bool { return true }
+	w.start()
+
+	var block *types.Block
+	for deadline := time.Now().Add(3 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if block = w.pendingBlock(); block != nil && block.NumberU64() == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("fork block not assembled")
+		}
+	}
+	// Import the block, the block validation ensures the roots match
+	if _, err := b.chain.InsertChain(types.Blocks{block}); err != nil {
+		t.Fatalf("failed to import fork block: %v", err)
+	}
+	statedb, err := b.chain.State()
+	if err != nil {
+		t.Fatalf("failed to retrieve state: %v", err)
+	}
+	if have := statedb.GetCode(hub); !bytes.Equal(have, code) {
+		t.Errorf("code mismatch: have %x, want %x", have, code)
+	}
+	if have, want := statedb.GetState(hub, slot), common.BigToHash(big.NewInt(1)); have != want {
+		t.Errorf("storage mismatch: have %x, want %x", have, want)
+	}
+}
+
+// Tests that the worker commits the bundles ahead of the pool transactions, the
+// highest paying one first, and drops the bundles failing on top of the state
+// or reverting without being allowed to as a whole.
+func TestCommitBundles(t *testing.T) {
+	chainConfig := *params.TestChainConfig
+	chainConfig.Clique = nil
+
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, b := newTestWorker(t, &chainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	parent := b.chain.CurrentBlock()
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   parent.GasLimit(),
+		Time:       parent.Time() + 1,
+		Difficulty: big.NewInt(1),
+		BaseFee:    misc.CalcBaseFee(&chainConfig, parent.Header()),
+	}
+	if err := w.makeCurrent(parent, header); err != nil {
+		t.Fatalf("failed to create mining context: %v", err)
+	}
+	w.current.state.AddBalance(testUserAddress, testBankFunds)
+
+	signer := types.LatestSigner(&chainConfig)
+	newTx := func(key *ecdsa.PrivateKey, nonce uint64, tip int64, creation bool) *types.Transaction {
+		var (
+			to   = &testUserAddress
+			gas  = params.TxGas
+			data []byte
+		)
+		if creation {
+			to, gas, data = nil, 100000, common.FromHex("0x60006000fd") // revert(0, 0)
+		}
+		return types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   chainConfig.ChainID,
+			Nonce:     nonce,
+			GasTipCap: big.NewInt(tip * params.GWei),
+			GasFeeCap: new(big.Int).Add(header.BaseFee, big.NewInt(tip*params.GWei)),
+			Gas:       gas,
+			To:        to,
+			Data:      data,
+		})
+	}
+	var (
+		low      = &types.Bundle{Txs: types.Transactions{newTx(testBankKey, 0, 1, false), newTx(testBankKey, 1, 1, false)}}
+		high     = &types.Bundle{Txs: types.Transactions{newTx(testBankKey, 0, 3, false), newTx(testBankKey, 1, 3, false)}}
+		reverted = &types.Bundle{Txs: types.Transactions{newTx(testUserKey, 0, 5, false), newTx(testUserKey, 1, 5, true)}}
+		allowed  = &types.Bundle{Txs: types.Transactions{newTx(testUserKey, 0, 2, false), newTx(testUserKey, 1, 2, true)}}
+	)
+	allowed.RevertingTxHashes = []common.Hash{allowed.Txs[1].Hash()}
+
+	if w.commitBundles([]*types.Bundle{low, reverted, allowed, high}, testBankAddress, nil) {
+		t.Fatalf("bundle commit interrupted")
+	}
+	want := append(append(types.Transactions{}, high.Txs...), allowed.Txs...)
+	if len(w.current.txs) != len(want) {
+		t.Fatalf("committed transaction count mismatch: have %d, want %d", len(w.current.txs), len(want))
+	}
+	for i, tx := range w.current.txs {
+		if tx.Hash() != want[i].Hash() {
+			t.Errorf("transaction %d mismatch: have %x, want %x", i, tx.Hash(), want[i].Hash())
+		}
+	}
+	if w.current.tcount != len(want) || len(w.current.receipts) != len(want) {
+		t.Errorf("bookkeeping mismatch: %d transactions counted, %d receipts", w.current.tcount, len(w.current.receipts))
+	}
+	if status := w.current.receipts[len(want)-1].Status; status != types.ReceiptStatusFailed {
+		t.Errorf("allowed revert status mismatch: have %d, want %d", status, types.ReceiptStatusFailed)
+	}
+	if nonce := w.current.state.GetNonce(testBankAddress); nonce != 2 {
+		t.Errorf("bank nonce mismatch: have %d, want 2", nonce)
+	}
+	if used := w.current.header.GasUsed; used != w.current.receipts[len(want)-1].CumulativeGasUsed {
+		t.Errorf("gas used mismatch: have %d, want %d", used, w.current.receipts[len(want)-1].CumulativeGasUsed)
+	}
+}
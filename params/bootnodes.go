@@ -18,13 +18,22 @@ package params
 
 import "github.com/ethereum/go-ethereum/common"
 
-// MainnetBootnodes are the enode URLs of the P2P bootstrap nodes running on
-// the main Ethereum network.
-var MainnetBootnodes = []string{
+// PhoenixBootnodes are the enode URLs of the P2P bootstrap nodes running on
+// the main Phoenix network.
+var PhoenixBootnodes = []string{
 	"enode://c93e898a5fe79fde54354aaadf85da460c7b7e2caef906e8cb8f7638b025e68507551d740535a0cb034737d757e3066042bcf6f449908580064d9eb3de17b896@172.96.161.252:20217",
 	"enode://50f6b79fedf4ed1c2e4babaa817feccd035cfd5ba0e04613c4a86296d913b1e8cb98626aa8f9e225f0bca7f7d95f1601629c8df411c37d5552bc128f271fd4d4@45.32.140.239:20217",
 }
 
+// PhoenixTestBootnodes are the enode URLs of the P2P bootstrap nodes running on
+// the Phoenix test network. None are published yet, test network nodes have to
+// be given theirs with --bootnodes.
+var PhoenixTestBootnodes []string
+
+// MainnetBootnodes are the enode URLs of the P2P bootstrap nodes running on
+// the main network, which --mainnet selects the Phoenix one for.
+var MainnetBootnodes = PhoenixBootnodes
+
 // RopstenBootnodes are the enode URLs of the P2P bootstrap nodes running on the
 // Ropsten test network.
 var RopstenBootnodes = []string{
@@ -20,6 +20,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math/big"
+	"sort"
 
 	"github.com/ethereum/go-ethereum/common"
 	"golang.org/x/crypto/sha3"
@@ -43,6 +44,9 @@ var TrustedCheckpoints = map[common.Hash]*TrustedCheckpoint{
 	RopstenGenesisHash: RopstenTrustedCheckpoint,
 	RinkebyGenesisHash: RinkebyTrustedCheckpoint,
 	GoerliGenesisHash:  GoerliTrustedCheckpoint,
+
+	PhoenixGenesisHash:     PhoenixTrustedCheckpoint,
+	PhoenixTestGenesisHash: PhoenixTestTrustedCheckpoint,
 }
 
 // CheckpointOracles associates each known checkpoint oracles with the genesis hash of
@@ -52,6 +56,9 @@ var CheckpointOracles = map[common.Hash]*CheckpointOracleConfig{
 	RopstenGenesisHash: RopstenCheckpointOracle,
 	RinkebyGenesisHash: RinkebyCheckpointOracle,
 	GoerliGenesisHash:  GoerliCheckpointOracle,
+
+	PhoenixGenesisHash:     PhoenixCheckpointOracle,
+	PhoenixTestGenesisHash: PhoenixTestCheckpointOracle,
 }
 
 var (
@@ -102,6 +109,22 @@ var (
 		},
 	}
 
+	// PhoenixTrustedCheckpoint contains the light client trusted checkpoint for the
+	// main Phoenix network, nil until the first section is signed.
+	PhoenixTrustedCheckpoint *TrustedCheckpoint
+
+	// PhoenixCheckpointOracle contains a set of configs for the main Phoenix network
+	// oracle, nil until the oracle contract is deployed.
+	PhoenixCheckpointOracle *CheckpointOracleConfig
+
+	// PhoenixTestTrustedCheckpoint contains the light client trusted checkpoint for
+	// the Phoenix test network, nil until the first section is signed.
+	PhoenixTestTrustedCheckpoint *TrustedCheckpoint
+
+	// PhoenixTestCheckpointOracle contains a set of configs for the Phoenix test
+	// network oracle, nil until the oracle contract is deployed.
+	PhoenixTestCheckpointOracle *CheckpointOracleConfig
+
 	// MainnetChainConfig is the chain parameters to run a node on the main network.
 	MainnetChainConfig = &ChainConfig{
 		ChainID:             big.NewInt(1),
@@ -368,6 +391,8 @@ type ChainConfig struct {
 
 	CatalystBlock *big.Int `json:"catalystBlock,omitempty"` // Catalyst switch block (nil = no fork, 0 = already on catalyst)
 
+	TransferPolicy *TransferPolicyConfig `json:"transferPolicy,omitempty"` // Value transfer restrictions until Trident (nil = system contracts only)
+
 	// Various consensus engines
 	Ethash   *EthashConfig   `json:"ethash,omitempty"`
 	Clique   *CliqueConfig   `json:"clique,omitempty"`
@@ -393,9 +418,82 @@ func (c *CliqueConfig) String() string {
 	return "clique"
 }
 
+// Default Poseidon engine parameters, used for the values a chain config leaves unset.
+const (
+	DefaultPoseidonExpectedSize  = 3.0      // Expected number of validators selected by the sortition per block
+	DefaultPoseidonHeartRate     = 100      // Number of blocks a validator may go without sealing before it's slashable
+	DefaultPoseidonNonceSignSize = 255      // Number of nonces a validator may retry the sortition with per block
+	DefaultPoseidonVrfLength     = 81       // Length of the vrf proof in the header extra-data
+	DefaultPoseidonGasCap        = 50000000 // Gas allowance of the engine's calls into the ValidatorHub
+)
+
 // PoseidonConfig is the consensus engine configs for proof-of-staked-authority based sealing.
 type PoseidonConfig struct {
-	Period uint64 `json:"period"` // Number of seconds between blocks to enforce
+	Period uint64 `json:"period"`          // Number of seconds between blocks to enforce
+	Epoch  uint64 `json:"epoch,omitempty"` // Epoch length to checkpoint the committee (0 = verify against the ValidatorHub state)
+
+	CheckpointBlock *big.Int `json:"checkpointBlock,omitempty"` // Block from which the committee is checkpointed every epoch (nil = genesis)
+	ForkChoiceBlock *big.Int `json:"forkChoiceBlock,omitempty"` // Block from which forks are chosen by finality and height first (nil = no fork)
+	EvidenceBlock   *big.Int `json:"evidenceBlock,omitempty"`   // Block from which double-sign evidence is verified and slashed (nil = no fork)
+
+	FeeDistributionBlock *big.Int `json:"feeDistributionBlock,omitempty"` // Block from which the fees are credited to the signer's reward contract (nil = no fork)
+	SystemTxBlock        *big.Int `json:"systemTxBlock,omitempty"`        // Block from which the sync header and slash transactions are verified (nil = no fork)
+	SlotBlock            *big.Int `json:"slotBlock,omitempty"`            // Block from which the timestamps are verified against the slot of the nonce (nil = no fork)
+	SortitionBlock       *big.Int `json:"sortitionBlock,omitempty"`       // Block from which the sortition is computed with integer arithmetic (nil = no fork)
+
+	ExpectedSize  float64         `json:"expectedSize,omitempty"`  // Expected committee size of the sortition (0 = default)
+	HeartRate     uint64          `json:"heartRate,omitempty"`     // Blocks without a seal after which a validator is slashable (0 = default)
+	NonceSignSize uint64          `json:"nonceSignSize,omitempty"` // Nonces a validator may retry the sortition with (0 = default)
+	VrfLength     uint64          `json:"vrfLength,omitempty"`     // Length of the vrf proof in the extra-data (0 = default)
+	GasCap        uint64          `json:"gasCap,omitempty"`        // Gas allowance of the ValidatorHub calls (0 = default)
+	ValidatorHub  *common.Address `json:"validatorHub,omitempty"`  // Address of the ValidatorHub contract (nil = genesis system contract)
+
+	MinBaseFee               uint64          `json:"minBaseFee,omitempty"`               // Floor of the EIP-1559 base fee in wei (0 = fork default)
+	ElasticityMultiplier     uint64          `json:"elasticityMultiplier,omitempty"`     // EIP-1559 gas target divisor (0 = protocol default)
+	BaseFeeChangeDenominator uint64          `json:"baseFeeChangeDenominator,omitempty"` // EIP-1559 base fee change bound (0 = protocol default)
+	FeeGovernance            *common.Address `json:"feeGovernance,omitempty"`            // Contract overriding the base fee parameters (nil = config only)
+
+	ForkSchedule []*PoseidonFork `json:"forkSchedule,omitempty"` // Parameter changes scheduled by hard forks, in block order
+}
+
+// PoseidonFork is a hard fork changing some of the Poseidon parameters from the
+// given block onwards.
+type PoseidonFork struct {
+	Block     *big.Int          `json:"block"`
+	Overrides PoseidonOverrides `json:"overrides"`
+}
+
+// PoseidonOverrides are the Poseidon parameters changed by a fork, nil fields
+// retaining their previous value.
+type PoseidonOverrides struct {
+	Period        *uint64         `json:"period,omitempty"`
+	ExpectedSize  *float64        `json:"expectedSize,omitempty"`
+	HeartRate     *uint64         `json:"heartRate,omitempty"`
+	NonceSignSize *uint64         `json:"nonceSignSize,omitempty"`
+	VrfLength     *uint64         `json:"vrfLength,omitempty"`
+	GasCap        *uint64         `json:"gasCap,omitempty"`
+	ValidatorHub  *common.Address `json:"validatorHub,omitempty"`
+
+	MinBaseFee               *uint64         `json:"minBaseFee,omitempty"`
+	ElasticityMultiplier     *uint64         `json:"elasticityMultiplier,omitempty"`
+	BaseFeeChangeDenominator *uint64         `json:"baseFeeChangeDenominator,omitempty"`
+	FeeGovernance            *common.Address `json:"feeGovernance,omitempty"`
+}
+
+// PoseidonParams are the Poseidon parameters in effect at a given block.
+type PoseidonParams struct {
+	Period        uint64
+	ExpectedSize  float64
+	HeartRate     uint64
+	NonceSignSize uint64
+	VrfLength     uint64
+	GasCap        uint64
+	ValidatorHub  common.Address // Zero if the genesis system contract is used
+
+	MinBaseFee               uint64         // Zero if the fork default floor is used
+	ElasticityMultiplier     uint64         // Zero if the protocol default is used
+	BaseFeeChangeDenominator uint64         // Zero if the protocol default is used
+	FeeGovernance            common.Address // Zero if the base fee isn't governed on-chain
 }
 
 // String implements the stringer interface, returning the consensus engine details.
@@ -403,6 +501,295 @@ func (b *PoseidonConfig) String() string {
 	return "poseidon"
 }
 
+// ParamsAt returns the Poseidon parameters in effect at the given block, applying
+// the overrides of every fork scheduled up to it and filling in the defaults.
+func (b *PoseidonConfig) ParamsAt(num *big.Int) PoseidonParams {
+	p := PoseidonParams{
+		Period:        b.Period,
+		ExpectedSize:  b.ExpectedSize,
+		HeartRate:     b.HeartRate,
+		NonceSignSize: b.NonceSignSize,
+		VrfLength:     b.VrfLength,
+		GasCap:        b.GasCap,
+
+		MinBaseFee:               b.MinBaseFee,
+		ElasticityMultiplier:     b.ElasticityMultiplier,
+		BaseFeeChangeDenominator: b.BaseFeeChangeDenominator,
+	}
+	if b.ValidatorHub != nil {
+		p.ValidatorHub = *b.ValidatorHub
+	}
+	if b.FeeGovernance != nil {
+		p.FeeGovernance = *b.FeeGovernance
+	}
+	for _, fork := range b.ForkSchedule {
+		if !isForked(fork.Block, num) {
+			break
+		}
+		o := fork.Overrides
+		if o.Period != nil {
+			p.Period = *o.Period
+		}
+		if o.ExpectedSize != nil {
+			p.ExpectedSize = *o.ExpectedSize
+		}
+		if o.HeartRate != nil {
+			p.HeartRate = *o.HeartRate
+		}
+		if o.NonceSignSize != nil {
+			p.NonceSignSize = *o.NonceSignSize
+		}
+		if o.VrfLength != nil {
+			p.VrfLength = *o.VrfLength
+		}
+		if o.GasCap != nil {
+			p.GasCap = *o.GasCap
+		}
+		if o.ValidatorHub != nil {
+			p.ValidatorHub = *o.ValidatorHub
+		}
+		if o.MinBaseFee != nil {
+			p.MinBaseFee = *o.MinBaseFee
+		}
+		if o.ElasticityMultiplier != nil {
+			p.ElasticityMultiplier = *o.ElasticityMultiplier
+		}
+		if o.BaseFeeChangeDenominator != nil {
+			p.BaseFeeChangeDenominator = *o.BaseFeeChangeDenominator
+		}
+		if o.FeeGovernance != nil {
+			p.FeeGovernance = *o.FeeGovernance
+		}
+	}
+	if p.ExpectedSize == 0 {
+		p.ExpectedSize = DefaultPoseidonExpectedSize
+	}
+	if p.HeartRate == 0 {
+		p.HeartRate = DefaultPoseidonHeartRate
+	}
+	if p.NonceSignSize == 0 {
+		p.NonceSignSize = DefaultPoseidonNonceSignSize
+	}
+	if p.VrfLength == 0 {
+		p.VrfLength = DefaultPoseidonVrfLength
+	}
+	if p.GasCap == 0 {
+		p.GasCap = DefaultPoseidonGasCap
+	}
+	return p
+}
+
+// IsCheckpointing returns whether the committee is checkpointed in the extra-data
+// of every epoch block at the given block.
+func (b *PoseidonConfig) IsCheckpointing(num *big.Int) bool {
+	return b.Epoch > 0 && (b.CheckpointBlock == nil || isForked(b.CheckpointBlock, num))
+}
+
+// IsCheckpoint returns whether the given block carries a committee checkpoint.
+func (b *PoseidonConfig) IsCheckpoint(num *big.Int) bool {
+	return b.IsCheckpointing(num) && new(big.Int).Mod(num, new(big.Int).SetUint64(b.Epoch)).Sign() == 0
+}
+
+// CheckpointStart returns the first checkpoint block, whose committee is trusted
+// as the starting point of the snapshots.
+func (b *PoseidonConfig) CheckpointStart() uint64 {
+	if b.CheckpointBlock == nil {
+		return 0
+	}
+	return b.CheckpointBlock.Uint64()
+}
+
+// IsForkChoice returns whether forks are chosen by finality and height before
+// the seals and the total difficulty at the given block.
+func (b *PoseidonConfig) IsForkChoice(num *big.Int) bool {
+	return isForked(b.ForkChoiceBlock, num)
+}
+
+// IsEvidence returns whether the ValidatorHub accepts double-sign evidence as a
+// verified system transaction at the given block.
+func (b *PoseidonConfig) IsEvidence(num *big.Int) bool {
+	return isForked(b.EvidenceBlock, num)
+}
+
+// IsFeeDistribution returns whether the fees of the block at the given height
+// are credited to the reward contract of its signer.
+func (b *PoseidonConfig) IsFeeDistribution(num *big.Int) bool {
+	return isForked(b.FeeDistributionBlock, num)
+}
+
+// IsSystemTx returns whether the block at the given height has to end with the
+// signer's syncTendermintHeader transaction and may only slash overdue validators.
+func (b *PoseidonConfig) IsSystemTx(num *big.Int) bool {
+	return isForked(b.SystemTxBlock, num)
+}
+
+// IsSlot returns whether the block at the given height may not be sealed before
+// the time slot of its nonce.
+func (b *PoseidonConfig) IsSlot(num *big.Int) bool {
+	return isForked(b.SlotBlock, num)
+}
+
+// IsSortition returns whether the sortition of the block at the given height is
+// computed with integer arithmetic instead of floating point.
+func (b *PoseidonConfig) IsSortition(num *big.Int) bool {
+	return isForked(b.SortitionBlock, num)
+}
+
+// checkpointBlock returns the block the checkpointing starts at, nil if it's
+// disabled.
+func (b *PoseidonConfig) checkpointBlock() *big.Int {
+	if b.Epoch == 0 {
+		return nil
+	}
+	if b.CheckpointBlock == nil {
+		return common.Big0
+	}
+	return b.CheckpointBlock
+}
+
+// validate returns an error if the checkpointing can't be enabled as configured
+// or if the forks aren't scheduled at strictly increasing blocks.
+func (b *PoseidonConfig) validate() error {
+	if b.CheckpointBlock != nil {
+		if b.Epoch == 0 {
+			return fmt.Errorf("poseidon checkpoint block %v without an epoch", b.CheckpointBlock)
+		}
+		if new(big.Int).Mod(b.CheckpointBlock, new(big.Int).SetUint64(b.Epoch)).Sign() != 0 {
+			return fmt.Errorf("poseidon checkpoint block %v not an epoch block (epoch %d)", b.CheckpointBlock, b.Epoch)
+		}
+	}
+	return b.checkScheduleOrder()
+}
+
+// checkScheduleOrder returns an error if the forks aren't scheduled at strictly
+// increasing blocks.
+func (b *PoseidonConfig) checkScheduleOrder() error {
+	var last *big.Int
+	for i, fork := range b.ForkSchedule {
+		if fork == nil || fork.Block == nil {
+			return fmt.Errorf("poseidon fork %d has no block", i)
+		}
+		if last != nil && last.Cmp(fork.Block) >= 0 {
+			return fmt.Errorf("unsupported poseidon fork ordering: fork %d at %v, but fork %d at %v", i-1, last, i, fork.Block)
+		}
+		last = fork.Block
+	}
+	return nil
+}
+
+// checkCompatible returns an error if the parameters in effect at any block up
+// to head differ between the two configs, reporting the earliest such block.
+func (b *PoseidonConfig) checkCompatible(newcfg *PoseidonConfig, head *big.Int) *ConfigCompatError {
+	if isForkIncompatible(b.checkpointBlock(), newcfg.checkpointBlock(), head) {
+		return newCompatError("Poseidon checkpoint block", b.checkpointBlock(), newcfg.checkpointBlock())
+	}
+	if b.Epoch != newcfg.Epoch && isForked(b.checkpointBlock(), head) {
+		return newCompatError("Poseidon epoch", b.checkpointBlock(), newcfg.checkpointBlock())
+	}
+	if isForkIncompatible(b.ForkChoiceBlock, newcfg.ForkChoiceBlock, head) {
+		return newCompatError("Poseidon fork choice block", b.ForkChoiceBlock, newcfg.ForkChoiceBlock)
+	}
+	if isForkIncompatible(b.EvidenceBlock, newcfg.EvidenceBlock, head) {
+		return newCompatError("Poseidon evidence block", b.EvidenceBlock, newcfg.EvidenceBlock)
+	}
+	if isForkIncompatible(b.FeeDistributionBlock, newcfg.FeeDistributionBlock, head) {
+		return newCompatError("Poseidon fee distribution block", b.FeeDistributionBlock, newcfg.FeeDistributionBlock)
+	}
+	if isForkIncompatible(b.SystemTxBlock, newcfg.SystemTxBlock, head) {
+		return newCompatError("Poseidon system transaction block", b.SystemTxBlock, newcfg.SystemTxBlock)
+	}
+	if isForkIncompatible(b.SlotBlock, newcfg.SlotBlock, head) {
+		return newCompatError("Poseidon slot block", b.SlotBlock, newcfg.SlotBlock)
+	}
+	if isForkIncompatible(b.SortitionBlock, newcfg.SortitionBlock, head) {
+		return newCompatError("Poseidon sortition block", b.SortitionBlock, newcfg.SortitionBlock)
+	}
+	blocks := []*big.Int{common.Big0}
+	for _, fork := range b.ForkSchedule {
+		blocks = append(blocks, fork.Block)
+	}
+	for _, fork := range newcfg.ForkSchedule {
+		blocks = append(blocks, fork.Block)
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].Cmp(blocks[j]) < 0 })
+
+	for _, block := range blocks {
+		if !isForked(block, head) {
+			break
+		}
+		if b.ParamsAt(block) != newcfg.ParamsAt(block) {
+			return newCompatError("Poseidon parameter schedule", block, block)
+		}
+	}
+	return nil
+}
+
+// Modes of the transfer policy, restricting the value transfers involving
+// contracts until the Trident fork.
+const (
+	TransferPolicyAllowAll          = "allowAll"          // Value transfers are unrestricted
+	TransferPolicyCreationAllowlist = "creationAllowlist" // Only allowlisted senders may create contracts with value
+	TransferPolicyCallAllowlist     = "callAllowlist"     // Value may only be sent to allowlisted contracts, never to new ones
+)
+
+// TransferPolicyConfig is the permissioning of the value transfers involving
+// contracts, lifted by the Trident fork.
+type TransferPolicyConfig struct {
+	Mode      string           `json:"mode"`                // Restriction mode of the value transfers
+	Allowlist []common.Address `json:"allowlist,omitempty"` // Addresses allowed by the mode
+	Contract  *common.Address  `json:"contract,omitempty"`  // Contract keeping an on-chain allowlist (nil = static allowlist only)
+	Slot      uint64           `json:"slot,omitempty"`      // Storage slot of the contract's mapping(address => bool) allowlist
+	Block     *big.Int         `json:"block,omitempty"`     // Block from which the policy replaces the default one (nil = genesis)
+}
+
+// String implements the stringer interface, returning the transfer policy mode.
+func (p *TransferPolicyConfig) String() string {
+	return p.Mode
+}
+
+// block returns the block the transfer policy takes effect at, nil if there is
+// no policy to take effect.
+func (p *TransferPolicyConfig) block() *big.Int {
+	switch {
+	case p == nil:
+		return nil
+	case p.Block == nil:
+		return common.Big0
+	default:
+		return p.Block
+	}
+}
+
+// sameRules reports whether both transfer policies restrict the same transfers,
+// regardless of when they take effect.
+func (p *TransferPolicyConfig) sameRules(q *TransferPolicyConfig) bool {
+	if p == nil || q == nil {
+		return p == q
+	}
+	if p.Mode != q.Mode || p.Slot != q.Slot || len(p.Allowlist) != len(q.Allowlist) {
+		return false
+	}
+	for i := range p.Allowlist {
+		if p.Allowlist[i] != q.Allowlist[i] {
+			return false
+		}
+	}
+	if p.Contract == nil || q.Contract == nil {
+		return p.Contract == q.Contract
+	}
+	return *p.Contract == *q.Contract
+}
+
+// validate returns an error if the transfer policy mode is unknown.
+func (p *TransferPolicyConfig) validate() error {
+	switch p.Mode {
+	case TransferPolicyAllowAll, TransferPolicyCreationAllowlist, TransferPolicyCallAllowlist:
+		return nil
+	default:
+		return fmt.Errorf("unsupported transfer policy mode %q", p.Mode)
+	}
+}
+
 // String implements the fmt.Stringer interface.
 func (c *ChainConfig) String() string {
 	var engine interface{}
@@ -514,6 +901,12 @@ func (c *ChainConfig) IsTrident(num *big.Int) bool {
 	return isForked(c.TridentBlock, num)
 }
 
+// IsTransferPolicy returns whether num is either equal to the block the configured
+// transfer policy takes effect at or greater.
+func (c *ChainConfig) IsTransferPolicy(num *big.Int) bool {
+	return isForked(c.TransferPolicy.block(), num)
+}
+
 // IsCatalyst returns whether num is either equal to the Merge fork block or greater.
 func (c *ChainConfig) IsCatalyst(num *big.Int) bool {
 	return isForked(c.CatalystBlock, num)
@@ -581,6 +974,14 @@ func (c *ChainConfig) CheckConfigForkOrder() error {
 			lastFork = cur
 		}
 	}
+	if c.TransferPolicy != nil {
+		if err := c.TransferPolicy.validate(); err != nil {
+			return err
+		}
+	}
+	if c.Poseidon != nil {
+		return c.Poseidon.validate()
+	}
 	return nil
 }
 
@@ -640,6 +1041,17 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.TridentBlock, newcfg.TridentBlock, head) {
 		return newCompatError("Trident fork block", c.TridentBlock, newcfg.TridentBlock)
 	}
+	if isForkIncompatible(c.TransferPolicy.block(), newcfg.TransferPolicy.block(), head) {
+		return newCompatError("Transfer policy block", c.TransferPolicy.block(), newcfg.TransferPolicy.block())
+	}
+	if !c.TransferPolicy.sameRules(newcfg.TransferPolicy) && isForked(c.TransferPolicy.block(), head) {
+		return newCompatError("Transfer policy", c.TransferPolicy.block(), newcfg.TransferPolicy.block())
+	}
+	if c.Poseidon != nil && newcfg.Poseidon != nil {
+		if err := c.Poseidon.checkCompatible(newcfg.Poseidon, head); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
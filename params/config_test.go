@@ -20,6 +20,8 @@ import (
 	"math/big"
 	"reflect"
 	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
 )
 
 func TestCheckCompatible(t *testing.T) {
@@ -87,6 +89,186 @@ func TestCheckCompatible(t *testing.T) {
 				RewindTo:     30,
 			},
 		},
+		{
+			stored:  &ChainConfig{Poseidon: &PoseidonConfig{Period: 3}},
+			new:     &ChainConfig{Poseidon: &PoseidonConfig{Period: 3, HeartRate: DefaultPoseidonHeartRate}},
+			head:    40,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{Poseidon: &PoseidonConfig{Period: 3}},
+			new:    &ChainConfig{Poseidon: &PoseidonConfig{Period: 3, HeartRate: 50}},
+			head:   40,
+			wantErr: &ConfigCompatError{
+				What:         "Poseidon parameter schedule",
+				StoredConfig: big.NewInt(0),
+				NewConfig:    big.NewInt(0),
+				RewindTo:     0,
+			},
+		},
+		{
+			stored:  &ChainConfig{Poseidon: &PoseidonConfig{Period: 3}},
+			new:     &ChainConfig{Poseidon: &PoseidonConfig{Period: 3, ForkSchedule: []*PoseidonFork{{Block: big.NewInt(50), Overrides: PoseidonOverrides{Period: newUint64(5)}}}}},
+			head:    40,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{Poseidon: &PoseidonConfig{Period: 3}},
+			new:    &ChainConfig{Poseidon: &PoseidonConfig{Period: 3, ForkSchedule: []*PoseidonFork{{Block: big.NewInt(30), Overrides: PoseidonOverrides{Period: newUint64(5)}}}}},
+			head:   40,
+			wantErr: &ConfigCompatError{
+				What:         "Poseidon parameter schedule",
+				StoredConfig: big.NewInt(30),
+				NewConfig:    big.NewInt(30),
+				RewindTo:     29,
+			},
+		},
+		{
+			stored: &ChainConfig{Poseidon: &PoseidonConfig{Period: 3, ForkSchedule: []*PoseidonFork{{Block: big.NewInt(20), Overrides: PoseidonOverrides{ExpectedSize: newFloat64(5)}}}}},
+			new:    &ChainConfig{Poseidon: &PoseidonConfig{Period: 3, ForkSchedule: []*PoseidonFork{{Block: big.NewInt(30), Overrides: PoseidonOverrides{ExpectedSize: newFloat64(5)}}}}},
+			head:   25,
+			wantErr: &ConfigCompatError{
+				What:         "Poseidon parameter schedule",
+				StoredConfig: big.NewInt(20),
+				NewConfig:    big.NewInt(20),
+				RewindTo:     19,
+			},
+		},
+		{
+			stored: &ChainConfig{Poseidon: &PoseidonConfig{Period: 3, Epoch: 10}},
+			new:    &ChainConfig{Poseidon: &PoseidonConfig{Period: 3, Epoch: 10, CheckpointBlock: big.NewInt(50)}},
+			head:   40,
+			wantErr: &ConfigCompatError{
+				What:         "Poseidon checkpoint block",
+				StoredConfig: big.NewInt(0),
+				NewConfig:    big.NewInt(50),
+				RewindTo:     0,
+			},
+		},
+		{
+			stored: &ChainConfig{Poseidon: &PoseidonConfig{Period: 3, Epoch: 10, CheckpointBlock: big.NewInt(30)}},
+			new:    &ChainConfig{Poseidon: &PoseidonConfig{Period: 3, Epoch: 20, CheckpointBlock: big.NewInt(30)}},
+			head:   40,
+			wantErr: &ConfigCompatError{
+				What:         "Poseidon epoch",
+				StoredConfig: big.NewInt(30),
+				NewConfig:    big.NewInt(30),
+				RewindTo:     29,
+			},
+		},
+		{
+			stored:  &ChainConfig{Poseidon: &PoseidonConfig{Period: 3, Epoch: 10, CheckpointBlock: big.NewInt(60)}},
+			new:     &ChainConfig{Poseidon: &PoseidonConfig{Period: 3, Epoch: 20, CheckpointBlock: big.NewInt(60)}},
+			head:    40,
+			wantErr: nil,
+		},
+		{
+			stored:  &ChainConfig{Poseidon: &PoseidonConfig{Period: 3, Epoch: 10, CheckpointBlock: big.NewInt(60)}},
+			new:     &ChainConfig{Poseidon: &PoseidonConfig{Period: 3, Epoch: 10, CheckpointBlock: big.NewInt(50)}},
+			head:    40,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{Poseidon: &PoseidonConfig{Period: 3, ForkChoiceBlock: big.NewInt(30)}},
+			new:    &ChainConfig{Poseidon: &PoseidonConfig{Period: 3}},
+			head:   40,
+			wantErr: &ConfigCompatError{
+				What:         "Poseidon fork choice block",
+				StoredConfig: big.NewInt(30),
+				NewConfig:    nil,
+				RewindTo:     29,
+			},
+		},
+		{
+			stored: &ChainConfig{Poseidon: &PoseidonConfig{Period: 3}},
+			new:    &ChainConfig{Poseidon: &PoseidonConfig{Period: 3, EvidenceBlock: big.NewInt(20)}},
+			head:   40,
+			wantErr: &ConfigCompatError{
+				What:         "Poseidon evidence block",
+				StoredConfig: nil,
+				NewConfig:    big.NewInt(20),
+				RewindTo:     19,
+			},
+		},
+		{
+			stored:  &ChainConfig{Poseidon: &PoseidonConfig{Period: 3, FeeDistributionBlock: big.NewInt(50)}},
+			new:     &ChainConfig{Poseidon: &PoseidonConfig{Period: 3, FeeDistributionBlock: big.NewInt(60)}},
+			head:    40,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{Poseidon: &PoseidonConfig{Period: 3, SystemTxBlock: big.NewInt(30)}},
+			new:    &ChainConfig{Poseidon: &PoseidonConfig{Period: 3, SystemTxBlock: big.NewInt(20)}},
+			head:   40,
+			wantErr: &ConfigCompatError{
+				What:         "Poseidon system transaction block",
+				StoredConfig: big.NewInt(30),
+				NewConfig:    big.NewInt(20),
+				RewindTo:     19,
+			},
+		},
+		{
+			stored: &ChainConfig{Poseidon: &PoseidonConfig{Period: 3}},
+			new:    &ChainConfig{Poseidon: &PoseidonConfig{Period: 3, SlotBlock: big.NewInt(20)}},
+			head:   40,
+			wantErr: &ConfigCompatError{
+				What:         "Poseidon slot block",
+				StoredConfig: nil,
+				NewConfig:    big.NewInt(20),
+				RewindTo:     19,
+			},
+		},
+		{
+			stored: &ChainConfig{Poseidon: &PoseidonConfig{Period: 3}},
+			new:    &ChainConfig{Poseidon: &PoseidonConfig{Period: 3, SortitionBlock: big.NewInt(20)}},
+			head:   40,
+			wantErr: &ConfigCompatError{
+				What:         "Poseidon sortition block",
+				StoredConfig: nil,
+				NewConfig:    big.NewInt(20),
+				RewindTo:     19,
+			},
+		},
+		{
+			stored:  &ChainConfig{},
+			new:     &ChainConfig{TransferPolicy: &TransferPolicyConfig{Mode: TransferPolicyAllowAll, Block: big.NewInt(30)}},
+			head:    20,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{},
+			new:    &ChainConfig{TransferPolicy: &TransferPolicyConfig{Mode: TransferPolicyAllowAll, Block: big.NewInt(10)}},
+			head:   20,
+			wantErr: &ConfigCompatError{
+				What:         "Transfer policy block",
+				StoredConfig: nil,
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored:  &ChainConfig{TransferPolicy: &TransferPolicyConfig{Mode: TransferPolicyCallAllowlist, Block: big.NewInt(10)}},
+			new:     &ChainConfig{TransferPolicy: &TransferPolicyConfig{Mode: TransferPolicyAllowAll, Block: big.NewInt(10)}},
+			head:    5,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{TransferPolicy: &TransferPolicyConfig{Mode: TransferPolicyCallAllowlist, Block: big.NewInt(10)}},
+			new:    &ChainConfig{TransferPolicy: &TransferPolicyConfig{Mode: TransferPolicyAllowAll, Block: big.NewInt(10)}},
+			head:   20,
+			wantErr: &ConfigCompatError{
+				What:         "Transfer policy",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored:  &ChainConfig{TransferPolicy: &TransferPolicyConfig{Mode: TransferPolicyCallAllowlist, Allowlist: []common.Address{{0x01}}}},
+			new:     &ChainConfig{TransferPolicy: &TransferPolicyConfig{Mode: TransferPolicyCallAllowlist, Allowlist: []common.Address{{0x01}}}},
+			head:    20,
+			wantErr: nil,
+		},
 	}
 
 	for _, test := range tests {
@@ -96,3 +278,99 @@ func TestCheckCompatible(t *testing.T) {
 		}
 	}
 }
+
+func TestPoseidonCheckpointing(t *testing.T) {
+	config := &PoseidonConfig{Period: 3, Epoch: 10, CheckpointBlock: big.NewInt(20)}
+	if err := config.validate(); err != nil {
+		t.Fatalf("valid config rejected: %v", err)
+	}
+	tests := []struct {
+		number        int64
+		checkpointing bool
+		checkpoint    bool
+	}{
+		{0, false, false},
+		{10, false, false},
+		{19, false, false},
+		{20, true, true},
+		{25, true, false},
+		{30, true, true},
+	}
+	for _, tt := range tests {
+		number := big.NewInt(tt.number)
+		if have := config.IsCheckpointing(number); have != tt.checkpointing {
+			t.Errorf("block %d: checkpointing mismatch: have %v, want %v", tt.number, have, tt.checkpointing)
+		}
+		if have := config.IsCheckpoint(number); have != tt.checkpoint {
+			t.Errorf("block %d: checkpoint mismatch: have %v, want %v", tt.number, have, tt.checkpoint)
+		}
+	}
+	// Checkpoints can't start without an epoch or off an epoch block
+	if err := (&PoseidonConfig{CheckpointBlock: big.NewInt(20)}).validate(); err == nil {
+		t.Errorf("checkpoint block without epoch accepted")
+	}
+	if err := (&PoseidonConfig{Epoch: 10, CheckpointBlock: big.NewInt(25)}).validate(); err == nil {
+		t.Errorf("checkpoint block off an epoch block accepted")
+	}
+	// Contract verified chains never checkpoint
+	if (&PoseidonConfig{}).IsCheckpoint(common.Big0) {
+		t.Errorf("zero epoch config checkpoints the genesis")
+	}
+}
+
+func TestPoseidonParamsAt(t *testing.T) {
+	hub := common.HexToAddress("0x0000000000000000000000000000000000002006")
+	config := &PoseidonConfig{
+		Period:    3,
+		HeartRate: 50,
+		ForkSchedule: []*PoseidonFork{
+			{Block: big.NewInt(10), Overrides: PoseidonOverrides{Period: newUint64(5), ExpectedSize: newFloat64(7)}},
+			{Block: big.NewInt(20), Overrides: PoseidonOverrides{HeartRate: newUint64(200), ValidatorHub: &hub}},
+		},
+	}
+	defaults := PoseidonParams{
+		Period:        3,
+		ExpectedSize:  DefaultPoseidonExpectedSize,
+		HeartRate:     50,
+		NonceSignSize: DefaultPoseidonNonceSignSize,
+		VrfLength:     DefaultPoseidonVrfLength,
+		GasCap:        DefaultPoseidonGasCap,
+	}
+	first, second := defaults, defaults
+	first.Period, first.ExpectedSize = 5, 7
+	second.Period, second.ExpectedSize, second.HeartRate, second.ValidatorHub = 5, 7, 200, hub
+
+	tests := []struct {
+		number uint64
+		want   PoseidonParams
+	}{
+		{0, defaults},
+		{9, defaults},
+		{10, first},
+		{19, first},
+		{20, second},
+		{1000, second},
+	}
+	for _, tt := range tests {
+		if have := config.ParamsAt(new(big.Int).SetUint64(tt.number)); have != tt.want {
+			t.Errorf("block %d: params mismatch: have %+v, want %+v", tt.number, have, tt.want)
+		}
+	}
+}
+
+func TestPoseidonScheduleOrder(t *testing.T) {
+	config := &ChainConfig{Poseidon: &PoseidonConfig{
+		ForkSchedule: []*PoseidonFork{{Block: big.NewInt(20)}, {Block: big.NewInt(10)}},
+	}}
+	if err := config.CheckConfigForkOrder(); err == nil {
+		t.Fatal("unordered poseidon fork schedule accepted")
+	}
+	config.Poseidon.ForkSchedule[1].Block = big.NewInt(30)
+	if err := config.CheckConfigForkOrder(); err != nil {
+		t.Fatalf("ordered poseidon fork schedule rejected: %v", err)
+	}
+}
+
+func newUint64(val uint64) *uint64 { return &val }
+
+func newFloat64(val float64) *float64 { return &val }
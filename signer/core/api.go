@@ -31,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/usbwallet"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/signer/core/apitypes"
@@ -41,9 +42,9 @@ const (
 	// numberOfAccountsToDerive For hardware wallets, the number of accounts to derive
 	numberOfAccountsToDerive = 10
 	// ExternalAPIVersion -- see extapi_changelog.md
-	ExternalAPIVersion = "6.1.0"
+	ExternalAPIVersion = "6.2.0"
 	// InternalAPIVersion -- see intapi_changelog.md
-	InternalAPIVersion = "7.0.1"
+	InternalAPIVersion = "7.1.0"
 )
 
 // ExternalAPI defines the external API through which signing requests are made.
@@ -64,6 +65,8 @@ type ExternalAPI interface {
 	Version(ctx context.Context) (string, error)
 	// SignGnosisSafeTransaction signs/confirms a gnosis-safe multisig transaction
 	SignGnosisSafeTx(ctx context.Context, signerAddress common.MixedcaseAddress, gnosisTx GnosisSafeTx, methodSelector *string) (*GnosisSafeTx, error)
+	// VrfProve - request to prove the vrf output of the given alpha
+	VrfProve(ctx context.Context, addr common.MixedcaseAddress, alpha hexutil.Bytes) (*VrfProveResult, error)
 }
 
 // UIClientAPI specifies what method a UI needs to implement to be able to be used as a
@@ -73,6 +76,8 @@ type UIClientAPI interface {
 	ApproveTx(request *SignTxRequest) (SignTxResponse, error)
 	// ApproveSignData prompt the user for confirmation to request to sign data
 	ApproveSignData(request *SignDataRequest) (SignDataResponse, error)
+	// ApproveVrfProve prompt the user for confirmation to request to prove a vrf output
+	ApproveVrfProve(request *VrfProveRequest) (VrfProveResponse, error)
 	// ApproveListing prompt the user for confirmation to list accounts
 	// the list of accounts to list can be modified by the UI
 	ApproveListing(request *ListRequest) (ListResponse, error)
@@ -243,6 +248,21 @@ type (
 	SignDataResponse struct {
 		Approved bool `json:"approved"`
 	}
+	VrfProveRequest struct {
+		Address common.MixedcaseAddress `json:"address"`
+		Alpha   hexutil.Bytes           `json:"alpha"`
+		Meta    Metadata                `json:"meta"`
+	}
+	VrfProveResponse struct {
+		Approved bool `json:"approved"`
+	}
+	// VrfProveResult is the vrf output and its proof, as returned to the caller
+	// along with the public key verifying the proof
+	VrfProveResult struct {
+		Beta      hexutil.Bytes `json:"beta"`
+		Proof     hexutil.Bytes `json:"proof"`
+		PublicKey hexutil.Bytes `json:"publicKey"`
+	}
 	NewAccountRequest struct {
 		Meta Metadata `json:"meta"`
 	}
@@ -640,6 +660,55 @@ func (api *SignerAPI) SignGnosisSafeTx(ctx context.Context, signerAddress common
 	return &gnosisTx, nil
 }
 
+// VrfProve proves the vrf output of the given alpha with the account, allowing
+// Poseidon validators to seal without unlocking their key in geth.
+func (api *SignerAPI) VrfProve(ctx context.Context, addr common.MixedcaseAddress, alpha hexutil.Bytes) (*VrfProveResult, error) {
+	req := &VrfProveRequest{Address: addr, Alpha: alpha, Meta: MetadataFromContext(ctx)}
+
+	// We make the request prior to looking up if we actually have the account, to prevent
+	// account-enumeration via the API
+	res, err := api.UI.ApproveVrfProve(req)
+	if err != nil {
+		return nil, err
+	}
+	if !res.Approved {
+		api.UI.ShowError(ErrRequestDenied.Error())
+		return nil, ErrRequestDenied
+	}
+	// Look up the wallet containing the requested prover
+	account := accounts.Account{Address: addr.Address()}
+	wallet, err := api.am.Find(account)
+	if err != nil {
+		return nil, err
+	}
+	pw, err := api.lookupOrQueryPassword(account.Address,
+		"Password for vrf proving",
+		fmt.Sprintf("Please enter password for proving vrf output with account %s", account.Address.Hex()))
+	if err != nil {
+		return nil, err
+	}
+	beta, pi, err := wallet.VrfProveWithPassphrase(account, pw, alpha)
+	if err != nil {
+		api.UI.ShowError(err.Error())
+		return nil, err
+	}
+	// Wallets don't expose public keys, recover it from a signature over the proof
+	// which never leaves clef
+	sig, err := wallet.SignTextWithPassphrase(account, pw, pi)
+	if err != nil {
+		api.UI.ShowError(err.Error())
+		return nil, err
+	}
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	pubkey, err := crypto.Ecrecover(accounts.TextHash(pi), sig)
+	if err != nil {
+		return nil, err
+	}
+	return &VrfProveResult{Beta: beta, Proof: pi, PublicKey: pubkey}, nil
+}
+
 // Returns the external api version. This method does not require user acceptance. Available methods are
 // available via enumeration anyway, and this info does not contain user-specific data
 func (api *SignerAPI) Version(ctx context.Context) (string, error) {
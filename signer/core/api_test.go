@@ -32,6 +32,8 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/vrf"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/signer/core"
@@ -76,6 +78,11 @@ func (ui *headlessUi) ApproveSignData(request *core.SignDataRequest) (core.SignD
 	return core.SignDataResponse{approved}, nil
 }
 
+func (ui *headlessUi) ApproveVrfProve(request *core.VrfProveRequest) (core.VrfProveResponse, error) {
+	approved := (<-ui.approveCh == "Y")
+	return core.VrfProveResponse{approved}, nil
+}
+
 func (ui *headlessUi) ApproveListing(request *core.ListRequest) (core.ListResponse, error) {
 	approval := <-ui.approveCh
 	//fmt.Printf("approval %s\n", approval)
@@ -324,3 +331,42 @@ func TestSignTx(t *testing.T) {
 	}
 
 }
+
+func TestVrfProve(t *testing.T) {
+	api, control := setup(t)
+	createAccount(control, api, t)
+	createAccount(control, api, t)
+	control.approveCh <- "1"
+	list, err := api.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := common.NewMixedcaseAddress(list[0])
+	alpha := hexutil.Bytes(common.Hex2Bytes("7f9b0a4e3c1d8a5f2e6b9c0d1a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d0000000000000003"))
+
+	control.approveCh <- "No way"
+	if _, err := api.VrfProve(context.Background(), a, alpha); err != core.ErrRequestDenied {
+		t.Errorf("Expected ErrRequestDenied! '%v'", err)
+	}
+	control.approveCh <- "Y"
+	control.inputCh <- "a_long_password"
+	res, err := api.VrfProve(context.Background(), a, alpha)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The returned public key has to belong to the account and verify the proof
+	pubkey, err := crypto.UnmarshalPubkey(res.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr := crypto.PubkeyToAddress(*pubkey); addr != list[0] {
+		t.Errorf("Public key mismatch: have %x, want %x", addr, list[0])
+	}
+	beta, err := vrf.Verify(pubkey, alpha, res.Proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(beta, res.Beta) {
+		t.Errorf("Vrf output mismatch: have %x, want %x", res.Beta, beta)
+	}
+}
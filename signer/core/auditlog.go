@@ -97,6 +97,18 @@ func (l *AuditLogger) SignTypedData(ctx context.Context, addr common.MixedcaseAd
 	return b, e
 }
 
+func (l *AuditLogger) VrfProve(ctx context.Context, addr common.MixedcaseAddress, alpha hexutil.Bytes) (*VrfProveResult, error) {
+	l.log.Info("VrfProve", "type", "request", "metadata", MetadataFromContext(ctx).String(),
+		"addr", addr.String(), "alpha", common.Bytes2Hex(alpha))
+	res, e := l.api.VrfProve(ctx, addr, alpha)
+	if res != nil {
+		l.log.Info("VrfProve", "type", "response", "beta", common.Bytes2Hex(res.Beta), "proof", common.Bytes2Hex(res.Proof), "error", e)
+	} else {
+		l.log.Info("VrfProve", "type", "response", "data", res, "error", e)
+	}
+	return res, e
+}
+
 func (l *AuditLogger) EcRecover(ctx context.Context, data hexutil.Bytes, sig hexutil.Bytes) (common.Address, error) {
 	l.log.Info("EcRecover", "type", "request", "metadata", MetadataFromContext(ctx).String(),
 		"data", common.Bytes2Hex(data), "sig", common.Bytes2Hex(sig))
@@ -186,6 +186,22 @@ func (ui *CommandlineUI) ApproveSignData(request *SignDataRequest) (SignDataResp
 	return SignDataResponse{true}, nil
 }
 
+// ApproveVrfProve prompt the user for confirmation to request to prove a vrf output
+func (ui *CommandlineUI) ApproveVrfProve(request *VrfProveRequest) (VrfProveResponse, error) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	fmt.Printf("-------- Vrf prove request--------------\n")
+	fmt.Printf("Account:  %s\n", request.Address.String())
+	fmt.Printf("alpha:  %v\n", request.Alpha)
+	fmt.Printf("-------------------------------------------\n")
+	showMetadata(request.Meta)
+	if !ui.confirm() {
+		return VrfProveResponse{false}, nil
+	}
+	return VrfProveResponse{true}, nil
+}
+
 // ApproveListing prompt the user for confirmation to list accounts
 // the list of accounts to list can be modified by the UI
 func (ui *CommandlineUI) ApproveListing(request *ListRequest) (ListResponse, error) {
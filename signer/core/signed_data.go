@@ -38,6 +38,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/clique"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 )
@@ -64,6 +65,10 @@ var (
 		accounts.MimetypePoseidon,
 		0x03,
 	}
+	ApplicationPoseidonVote = SigFormat{
+		accounts.MimetypePoseidonVote,
+		0x04,
+	}
 	TextPlain = SigFormat{
 		accounts.MimetypeTextPlain,
 		0x45,
@@ -277,10 +282,15 @@ func (api *SignerAPI) determineSignatureFormat(ctx context.Context, contentType
 		if err := rlp.DecodeBytes(poseidonData, header); err != nil {
 			return nil, useEthereumV, err
 		}
+		config, err := poseidonConfig(api.chainID)
+		if err != nil {
+			return nil, useEthereumV, err
+		}
 		// The incoming poseidon header is already truncated, sent to us with a extradata already shortened
-		if len(header.Extra) < 65+81 {
+		vrfLength := int(config.ParamsAt(header.Number).VrfLength)
+		if len(header.Extra) < 65+vrfLength {
 			// Need to add it back, to get a suitable length for hashing
-			newExtra := make([]byte, len(header.Extra)+65+81)
+			newExtra := make([]byte, len(header.Extra)+65+vrfLength)
 			copy(newExtra, header.Extra)
 			header.Extra = newExtra
 		}
@@ -299,6 +309,36 @@ func (api *SignerAPI) determineSignatureFormat(ctx context.Context, contentType
 		// Poseidon uses V on the form 0 or 1
 		useEthereumV = false
 		req = &SignDataRequest{ContentType: mediaType, Rawdata: poseidonRlp, Messages: messages, Hash: sighash}
+	case ApplicationPoseidonVote.Mime:
+		stringData, ok := data.(string)
+		if !ok {
+			return nil, useEthereumV, fmt.Errorf("input for %v must be an hex-encoded string", ApplicationPoseidonVote.Mime)
+		}
+		voteRlp, err := hexutil.Decode(stringData)
+		if err != nil {
+			return nil, useEthereumV, err
+		}
+		var vote struct {
+			ChainId *big.Int
+			Number  uint64
+			Hash    common.Hash
+		}
+		if err := rlp.DecodeBytes(voteRlp, &vote); err != nil {
+			return nil, useEthereumV, err
+		}
+		if vote.ChainId.Cmp(api.chainID) != 0 {
+			return nil, useEthereumV, fmt.Errorf("poseidon vote for chain %v, expected %v", vote.ChainId, api.chainID)
+		}
+		messages := []*NameValueType{
+			{
+				Name:  "Poseidon vote",
+				Typ:   "poseidon",
+				Value: fmt.Sprintf("poseidon finality vote %d [0x%x]", vote.Number, vote.Hash),
+			},
+		}
+		// Poseidon uses V on the form 0 or 1
+		useEthereumV = false
+		req = &SignDataRequest{ContentType: mediaType, Rawdata: voteRlp, Messages: messages, Hash: crypto.Keccak256(voteRlp)}
 	default: // also case TextPlain.Mime:
 		// Calculates an Ethereum ECDSA signature for:
 		// hash = keccak256("\x19${byteVersion}Ethereum Signed Message:\n${message length}${message}")
@@ -352,15 +392,30 @@ func cliqueHeaderHashAndRlp(header *types.Header) (hash, rlp []byte, err error)
 }
 
 func poseidonHeaderHashAndRlp(header *types.Header, chainId *big.Int) (hash, rlp []byte, err error) {
-	if len(header.Extra) < 65+81 {
-		err = fmt.Errorf("poseidon header extradata too short, %d < 65+81", len(header.Extra))
+	config, err := poseidonConfig(chainId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if vrfLength := int(config.ParamsAt(header.Number).VrfLength); len(header.Extra) < 65+vrfLength {
+		err = fmt.Errorf("poseidon header extradata too short, %d < 65+%d", len(header.Extra), vrfLength)
 		return
 	}
-	rlp = poseidon.PoseidonRLP(header, chainId)
-	hash = poseidon.SealHash(header, chainId).Bytes()
+	rlp = poseidon.PoseidonRLP(header, chainId, config)
+	hash = poseidon.SealHash(header, chainId, config).Bytes()
 	return hash, rlp, err
 }
 
+// poseidonConfig returns the consensus config of the known poseidon network with
+// the given chain id. Clef only knows the seal layout of these networks.
+func poseidonConfig(chainId *big.Int) (*params.PoseidonConfig, error) {
+	for _, config := range []*params.ChainConfig{params.PhoenixChainConfig, params.PhoenixTestChainConfig} {
+		if config.ChainID.Cmp(chainId) == 0 {
+			return config.Poseidon, nil
+		}
+	}
+	return nil, fmt.Errorf("no poseidon config for chain %v", chainId)
+}
+
 // SignTypedData signs EIP-712 conformant typed data
 // hash = keccak256("\x19${byteVersion}${domainSeparator}${hashStruct(message)}")
 // It returns
@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"path"
 	"strings"
 	"testing"
@@ -30,7 +31,9 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/signer/core"
 )
 
@@ -229,6 +232,15 @@ func TestSignData(t *testing.T) {
 	if signature == nil || len(signature) != 65 {
 		t.Errorf("Expected 65 byte signature (got %d bytes)", len(signature))
 	}
+	// application/x-poseidon-header on a chain without poseidon config
+	header, _ := rlp.EncodeToBytes(&types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1)})
+	signature, err = api.SignData(context.Background(), core.ApplicationPoseidon.Mime, a, hexutil.Encode(header))
+	if signature != nil {
+		t.Errorf("Expected nil-data, got %x", signature)
+	}
+	if err == nil {
+		t.Errorf("Expected error for unknown poseidon chain")
+	}
 }
 
 func TestDomainChainId(t *testing.T) {
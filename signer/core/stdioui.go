@@ -72,6 +72,12 @@ func (ui *StdIOUI) ApproveSignData(request *SignDataRequest) (SignDataResponse,
 	return result, err
 }
 
+func (ui *StdIOUI) ApproveVrfProve(request *VrfProveRequest) (VrfProveResponse, error) {
+	var result VrfProveResponse
+	err := ui.dispatch("ui_approveVrfProve", request, &result)
+	return result, err
+}
+
 func (ui *StdIOUI) ApproveListing(request *ListRequest) (ListResponse, error) {
 	var result ListResponse
 	err := ui.dispatch("ui_approveListing", request, &result)
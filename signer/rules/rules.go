@@ -183,6 +183,19 @@ func (r *rulesetUI) ApproveSignData(request *core.SignDataRequest) (core.SignDat
 	return core.SignDataResponse{Approved: false}, err
 }
 
+func (r *rulesetUI) ApproveVrfProve(request *core.VrfProveRequest) (core.VrfProveResponse, error) {
+	jsonreq, err := json.Marshal(request)
+	approved, err := r.checkApproval("ApproveVrfProve", jsonreq, err)
+	if err != nil {
+		log.Info("Rule-based approval error, going to manual", "error", err)
+		return r.next.ApproveVrfProve(request)
+	}
+	if approved {
+		return core.VrfProveResponse{Approved: true}, nil
+	}
+	return core.VrfProveResponse{Approved: false}, err
+}
+
 // OnInputRequired not handled by rules
 func (r *rulesetUI) OnInputRequired(info core.UserInputRequest) (core.UserInputResponse, error) {
 	return r.next.OnInputRequired(info)
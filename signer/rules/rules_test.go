@@ -92,6 +92,10 @@ func (alwaysDenyUI) ApproveSignData(request *core.SignDataRequest) (core.SignDat
 	return core.SignDataResponse{Approved: false}, nil
 }
 
+func (alwaysDenyUI) ApproveVrfProve(request *core.VrfProveRequest) (core.VrfProveResponse, error) {
+	return core.VrfProveResponse{Approved: false}, nil
+}
+
 func (alwaysDenyUI) ApproveListing(request *core.ListRequest) (core.ListResponse, error) {
 	return core.ListResponse{Accounts: nil}, nil
 }
@@ -218,6 +222,11 @@ func (d *dummyUI) ApproveSignData(request *core.SignDataRequest) (core.SignDataR
 	return core.SignDataResponse{}, core.ErrRequestDenied
 }
 
+func (d *dummyUI) ApproveVrfProve(request *core.VrfProveRequest) (core.VrfProveResponse, error) {
+	d.calls = append(d.calls, "ApproveVrfProve")
+	return core.VrfProveResponse{}, core.ErrRequestDenied
+}
+
 func (d *dummyUI) ApproveListing(request *core.ListRequest) (core.ListResponse, error) {
 	d.calls = append(d.calls, "ApproveListing")
 	return core.ListResponse{}, core.ErrRequestDenied
@@ -260,13 +269,14 @@ func TestForwarding(t *testing.T) {
 	r.ApproveTx(nil)
 	r.ApproveNewAccount(nil)
 	r.ApproveListing(nil)
+	r.ApproveVrfProve(nil)
 	r.ShowError("test")
 	r.ShowInfo("test")
 
 	//This one is not forwarded
 	r.OnApprovedTx(ethapi.SignTransactionResult{})
 
-	expCalls := 6
+	expCalls := 7
 	if len(ui.calls) != expCalls {
 
 		t.Errorf("Expected %d forwarded calls, got %d: %s", expCalls, len(ui.calls), strings.Join(ui.calls, ","))
@@ -522,6 +532,11 @@ func (d *dontCallMe) ApproveSignData(request *core.SignDataRequest) (core.SignDa
 	return core.SignDataResponse{}, core.ErrRequestDenied
 }
 
+func (d *dontCallMe) ApproveVrfProve(request *core.VrfProveRequest) (core.VrfProveResponse, error) {
+	d.t.Fatalf("Did not expect next-handler to be called")
+	return core.VrfProveResponse{}, core.ErrRequestDenied
+}
+
 func (d *dontCallMe) ApproveListing(request *core.ListRequest) (core.ListResponse, error) {
 	d.t.Fatalf("Did not expect next-handler to be called")
 	return core.ListResponse{}, core.ErrRequestDenied
@@ -626,3 +641,44 @@ function ApproveSignData(r){
 		t.Fatalf("Expected approved")
 	}
 }
+
+func TestVrfProve(t *testing.T) {
+
+	js := `function ApproveVrfProve(r){
+    if( r.address.toLowerCase() == "0x694267f14675d7e1b9494fd8d72fefe1755710fa")
+    {
+        return "Approve"
+    }
+    return "Reject"
+}`
+	r, err := initRuleEngine(js)
+	if err != nil {
+		t.Errorf("Couldn't create evaluator %v", err)
+		return
+	}
+	validator, _ := mixAddr("0x694267f14675d7e1b9494fd8d72fefe1755710fa")
+	other, _ := mixAddr("0x000000000000000000000000000000000000dead")
+
+	resp, err := r.ApproveVrfProve(&core.VrfProveRequest{
+		Address: *validator,
+		Alpha:   []byte{0x01, 0x02},
+		Meta:    core.Metadata{Remote: "remoteip", Local: "localip", Scheme: "inproc"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if !resp.Approved {
+		t.Fatalf("Expected approved")
+	}
+	resp, err = r.ApproveVrfProve(&core.VrfProveRequest{
+		Address: *other,
+		Alpha:   []byte{0x01, 0x02},
+		Meta:    core.Metadata{Remote: "remoteip", Local: "localip", Scheme: "inproc"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if resp.Approved {
+		t.Fatalf("Expected rejected")
+	}
+}